@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxAnchorExpansionDepth guards against alias cycles and alias bombs.
+const maxAnchorExpansionDepth = 64
+
+// isMergeKey reports whether a mapping key is the "<<" merge key.
+func isMergeKey(key *yaml.Node) bool {
+	return key.Kind == yaml.ScalarNode && key.Tag == "!!merge"
+}
+
+// expandNode returns a copy of n with every alias replaced by the node it refers to and
+// every merge key replaced by the merged keys, keeping explicit keys and key order.
+func expandNode(n *yaml.Node, depth int) (*yaml.Node, error) {
+	if depth > maxAnchorExpansionDepth {
+		return nil, errors.New("anchors nested too deeply to expand")
+	}
+	if n.Kind == yaml.AliasNode {
+		expanded, err := expandNode(n.Alias, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		expanded.HeadComment, expanded.LineComment = n.HeadComment, n.LineComment
+		return expanded, nil
+	}
+
+	out := *n
+	out.Anchor = ""
+	out.Content = nil
+	if n.Kind != yaml.MappingNode {
+		for _, child := range n.Content {
+			expanded, err := expandNode(child, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			out.Content = append(out.Content, expanded)
+		}
+		return &out, nil
+	}
+
+	explicit := make(map[string]bool)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if !isMergeKey(n.Content[i]) {
+			explicit[n.Content[i].Value] = true
+		}
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		if !isMergeKey(key) {
+			expandedKey, err := expandNode(key, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			expandedValue, err := expandNode(value, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			out.Content = append(out.Content, expandedKey, expandedValue)
+			continue
+		}
+		// "<<: *a" or "<<: [*a, *b]"; earlier sources win, and explicit keys win over all
+		sources := []*yaml.Node{value}
+		if value.Kind == yaml.SequenceNode {
+			sources = value.Content
+		}
+		for _, source := range sources {
+			merged, err := expandNode(source, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			if merged.Kind != yaml.MappingNode {
+				return nil, errors.New("merge key value is not a mapping")
+			}
+			for j := 0; j+1 < len(merged.Content); j += 2 {
+				name := merged.Content[j].Value
+				if explicit[name] {
+					continue
+				}
+				explicit[name] = true
+				out.Content = append(out.Content, merged.Content[j], merged.Content[j+1])
+			}
+		}
+	}
+	return &out, nil
+}
+
+// expandYAMLAnchors rewrites content with anchors, aliases, and merge keys expanded into
+// the effective configuration, so the LLM describes what applies rather than the anchor.
+func expandYAMLAnchors(content []byte) ([]byte, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	for {
+		var doc yaml.Node
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		expanded, err := expandNode(&doc, 0)
+		if err != nil {
+			return nil, err
+		}
+		if err := encoder.Encode(expanded); err != nil {
+			return nil, err
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SummaryCommentPrefix starts the comment --annotate-files writes at the top of each file.
+const SummaryCommentPrefix = "# Summary: "
+
+// summaryCommentContinuation starts each wrapped line of a summary comment.
+const summaryCommentContinuation = "#   "
+
+// summaryCommentWidth is the line length summary comments are wrapped to.
+const summaryCommentWidth = 100
+
+// summaryComment renders summary as a comment block, wrapped at summaryCommentWidth and
+// ending with newline.
+func summaryComment(summary, newline string) string {
+	var b strings.Builder
+	line := SummaryCommentPrefix
+	empty := true
+	for _, word := range strings.Fields(summary) {
+		if !empty && len(line)+1+len(word) > summaryCommentWidth {
+			b.WriteString(line + newline)
+			line = summaryCommentContinuation
+			empty = true
+		}
+		if !empty {
+			line += " "
+		}
+		line += word
+		empty = false
+	}
+	b.WriteString(line + newline)
+	return b.String()
+}
+
+// splitSummaryComment splits content into the summary comment block at its top, if any,
+// and the rest of the file.
+func splitSummaryComment(content string) (string, string) {
+	if !strings.HasPrefix(content, SummaryCommentPrefix) {
+		return "", content
+	}
+	end := 0
+	for first := true; end < len(content); first = false {
+		next := strings.IndexByte(content[end:], '\n')
+		if next < 0 {
+			next = len(content) - end - 1
+		}
+		line := content[end : end+next+1]
+		if !first && !strings.HasPrefix(line, summaryCommentContinuation) {
+			break
+		}
+		end += len(line)
+	}
+	return content[:end], content[end:]
+}
+
+// stripSummaryComment removes the summary comment written by --annotate-files, so a file
+// is summarized from its own content rather than a previous summary.
+func stripSummaryComment(content []byte) []byte {
+	_, rest := splitSummaryComment(string(content))
+	return []byte(rest)
+}
+
+// annotateFile inserts or updates the summary comment at the top of file, leaving the
+// rest of the file byte for byte as it was. It reports whether the file changed.
+func annotateFile(file, summary string) (bool, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return false, err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return false, err
+	}
+	content := string(data)
+	newline := "\n"
+	if strings.Contains(content, "\r\n") {
+		newline = "\r\n"
+	}
+	old, rest := splitSummaryComment(content)
+	comment := summaryComment(summary, newline)
+	if old == comment {
+		return false, nil
+	}
+	if err := os.WriteFile(file, []byte(comment+rest), info.Mode().Perm()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// annotateFiles writes each summary into its file and returns how many files changed.
+// Files that cannot be written are logged and skipped.
+func annotateFiles(dir string, summaries map[string]string) int {
+	files := make([]string, 0, len(summaries))
+	for file, summary := range summaries {
+		// A packaged chart is an archive, not a file a comment can be written into
+		if summary != "" && !isChartArchive(file) {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+	changed := 0
+	for _, file := range files {
+		ok, err := annotateFile(file, summaries[file])
+		if err != nil {
+			slog.Warn("failed to annotate file", "file", file, "error", err)
+			continue
+		}
+		if ok {
+			changed++
+			rel, _ := filepath.Rel(dir, file)
+			porcelainf("annotated", "path", filepath.ToSlash(rel))
+		}
+	}
+	return changed
+}
+
+// validateAnnotate checks that --annotate-files is not combined with --no-write, which
+// promises the source tree is left untouched.
+func validateAnnotate() error {
+	if annotateFilesEnabled && noWrite {
+		return fmt.Errorf("--annotate-files writes into the source tree and cannot be combined with --no-write")
+	}
+	return nil
+}
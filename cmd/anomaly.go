@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Kinds of suspicious provider responses.
+const (
+	anomalyEmpty     = "empty"
+	anomalyRefusal   = "refusal"
+	anomalyTruncated = "truncated"
+)
+
+// anomalySeverity ranks each anomaly kind: high when the response is unusable, medium
+// when it is likely incomplete.
+var anomalySeverity = map[string]string{
+	anomalyEmpty:     "high",
+	anomalyRefusal:   "high",
+	anomalyTruncated: "medium",
+}
+
+// refusalPattern matches the opening of a response declining the request.
+var refusalPattern = regexp.MustCompile(`(?i)^(?:i'?m sorry|i am sorry|sorry,|i cannot|i can'?t|i can not|i'?m unable|i am unable|i'?m not able|i am not able|i won'?t|as an ai)\b`)
+
+// minTruncatedWords is how many words a response needs before a missing final
+// punctuation mark counts as truncation, so one-word answers like NONE do not.
+const minTruncatedWords = 5
+
+// detectAnomaly returns the kind of anomaly a provider response shows, or an empty
+// string if it looks normal. Truncation is inferred from a response of several words
+// that stops without closing punctuation.
+func detectAnomaly(response string) string {
+	trimmed := strings.TrimSpace(response)
+	switch {
+	case trimmed == "":
+		return anomalyEmpty
+	case refusalPattern.MatchString(trimmed):
+		return anomalyRefusal
+	case len(strings.Fields(trimmed)) >= minTruncatedWords:
+		last := []rune(trimmed)[len([]rune(trimmed))-1]
+		if unicode.IsLetter(last) || unicode.IsDigit(last) || last == ',' || last == ':' || last == ';' || last == '-' {
+			return anomalyTruncated
+		}
+	}
+	return ""
+}
+
+// providerAnomaly is one suspicious response recorded during a run.
+type providerAnomaly struct {
+	// Path is the file the request was about, relative to the documented directory.
+	Path string
+	Kind string
+}
+
+// anomalyProvider wraps an LLMProvider and logs and records suspicious responses.
+type anomalyProvider struct {
+	LLMProvider
+	mu        sync.Mutex
+	anomalies []providerAnomaly
+}
+
+// newAnomalyProvider wraps llm so its suspicious responses are logged and recorded.
+func newAnomalyProvider(llm LLMProvider) *anomalyProvider {
+	return &anomalyProvider{LLMProvider: llm}
+}
+
+// Summarize sends the request to the wrapped provider and checks the response.
+func (a *anomalyProvider) Summarize(ctx context.Context, content string, prompt string) (string, error) {
+	answer, err := a.LLMProvider.Summarize(ctx, content, prompt)
+	if err != nil {
+		return answer, err
+	}
+	if kind := detectAnomaly(answer); kind != "" {
+		file, _ := ctx.Value(auditFileKey{}).(string)
+		slog.Warn("provider anomaly", "file", file, "anomaly", kind, "severity", anomalySeverity[kind],
+			"provider", a.Name(), "model", providerModel(a.LLMProvider))
+		a.mu.Lock()
+		a.anomalies = append(a.anomalies, providerAnomaly{Path: file, Kind: kind})
+		a.mu.Unlock()
+	}
+	return answer, nil
+}
+
+// WarmUp implements Warmer if the wrapped provider does.
+func (a *anomalyProvider) WarmUp(ctx context.Context) error {
+	if w, ok := a.LLMProvider.(Warmer); ok {
+		return w.WarmUp(ctx)
+	}
+	return nil
+}
+
+// Model returns the model of the wrapped provider.
+func (a *anomalyProvider) Model() string {
+	return providerModel(a.LLMProvider)
+}
+
+// recorded returns the anomalies recorded so far, with paths relative to dir, sorted by
+// path.
+func (a *anomalyProvider) recorded(dir string) []providerAnomaly {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	anomalies := make([]providerAnomaly, 0, len(a.anomalies))
+	for _, an := range a.anomalies {
+		if rel, err := filepath.Rel(dir, an.Path); err == nil && an.Path != "" {
+			an.Path = filepath.ToSlash(rel)
+		}
+		anomalies = append(anomalies, an)
+	}
+	sort.SliceStable(anomalies, func(i, j int) bool { return anomalies[i].Path < anomalies[j].Path })
+	return anomalies
+}
+
+// anomalyBreakdown describes anomalies by kind, e.g. "2 refusal, 1 truncated".
+func anomalyBreakdown(anomalies []providerAnomaly) string {
+	counts := make(map[string]int)
+	for _, an := range anomalies {
+		counts[an.Kind]++
+	}
+	var parts []string
+	for _, kind := range []string{anomalyEmpty, anomalyRefusal, anomalyTruncated} {
+		if counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[kind], kind))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// docAppendix is an extra section rendered after the directory sections of a document,
+// listing files with a short note each rather than an LLM summary.
+type docAppendix struct {
+	Title   string          `json:"title"`
+	Intro   string          `json:"intro,omitempty"`
+	Entries []appendixEntry `json:"entries"`
+	// Link renders entry paths as links to the files where the format supports it.
+	Link bool `json:"-"`
+	// Leading renders the section before the directory sections, as a highlight.
+	Leading bool `json:"leading,omitempty"`
+}
+
+// splitAppendices separates the appendices rendered before the directory sections from
+// those rendered after them.
+func splitAppendices(appendices []docAppendix) ([]docAppendix, []docAppendix) {
+	var leading, trailing []docAppendix
+	for _, a := range appendices {
+		if a.Leading {
+			leading = append(leading, a)
+		} else {
+			trailing = append(trailing, a)
+		}
+	}
+	return leading, trailing
+}
+
+// appendixEntry is one file listed in an appendix.
+type appendixEntry struct {
+	Path string `json:"path"`
+	Note string `json:"note"`
+	// Anchor, if set, is an id rendered with the entry so it can be linked to.
+	Anchor string `json:"-"`
+}
+
+// writeMarkdownAppendices renders appendices as markdown sections. Entries use an em
+// dash rather than ": " so they are never parsed back as summaries. fileLink renders
+// entry paths for appendices with Link set.
+func writeMarkdownAppendices(w io.Writer, appendices []docAppendix, fileLink func(path string) string) error {
+	for _, a := range appendices {
+		if len(a.Entries) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "\n## %s\n", a.Title); err != nil {
+			return err
+		}
+		if a.Intro != "" {
+			if _, err := fmt.Fprintf(w, "\n%s\n\n", a.Intro); err != nil {
+				return err
+			}
+		}
+		for _, e := range a.Entries {
+			ref := "`" + e.Path + "`"
+			if a.Link {
+				ref = fileLink(e.Path)
+			}
+			if e.Anchor != "" {
+				ref = fmt.Sprintf("<a id=\"%s\"></a>%s", e.Anchor, ref)
+			}
+			if _, err := fmt.Fprintf(w, "- %s — %s\n", ref, e.Note); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// appendixEntryPattern matches an appendix entry as writeMarkdownAppendices renders it,
+// capturing its anchor, its path as inline code, link, or wiki link, and its note.
+var appendixEntryPattern = regexp.MustCompile("^- (?:<a id=\"([^\"]+)\"></a>)?(?:`([^`]+)`|\\[`([^`]+)`\\]\\([^)]*\\)|\\[\\[[^|\\]]+\\|([^\\]]+)\\]\\]) — (.*)$")
+
+// readDocAppendices recovers the appendices of the existing document at docPath, so
+// commands that rewrite it from its entries keep the sections only a full run
+// computes. Appendices of HTML and AsciiDoc documents are not recovered.
+func readDocAppendices(docPath string) []docAppendix {
+	data, err := os.ReadFile(docPath)
+	if err != nil {
+		return nil
+	}
+	switch detectDocKind(data) {
+	case docKindJSON:
+		var output JSONOutput
+		if json.Unmarshal(data, &output) != nil {
+			return nil
+		}
+		return output.Appendices
+	case docKindMarkdown:
+		return parseMarkdownAppendices(existingDocumentLines(docPath))
+	}
+	return nil
+}
+
+// parseMarkdownAppendices returns the sections of a markdown or GitHub wiki document
+// made of appendix entries. Sections before the first summary entry are leading.
+func parseMarkdownAppendices(lines []string) []docAppendix {
+	var appendices []docAppendix
+	var current *docAppendix
+	var intro []string
+	var currentDir string
+	seenDir := false
+	finish := func() {
+		if current != nil && len(current.Entries) > 0 {
+			current.Leading = !seenDir
+			appendices = append(appendices, *current)
+		}
+		current = nil
+	}
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if _, _, ok := parseSummaryLine(line, &currentDir); ok {
+			seenDir = true
+		}
+		switch {
+		case strings.HasPrefix(line, "## "):
+			finish()
+			current = &docAppendix{Title: strings.TrimPrefix(line, "## ")}
+			intro = nil
+		case current == nil:
+		case strings.HasPrefix(line, "<!--"):
+			finish()
+		case strings.HasPrefix(line, "- "):
+			m := appendixEntryPattern.FindStringSubmatch(line)
+			if m == nil {
+				// A directory section or the overview, not an appendix
+				current = nil
+				continue
+			}
+			path := m[2]
+			if m[3] != "" || m[4] != "" {
+				current.Link = true
+				path = firstNonEmpty(m[3], strings.TrimPrefix(m[4], strings.TrimSuffix(wikiBaseURL, "/")+"/"))
+			}
+			current.Entries = append(current.Entries, appendixEntry{Path: path, Note: m[5], Anchor: m[1]})
+		case line != "" && len(current.Entries) == 0:
+			intro = append(intro, line)
+			current.Intro = strings.Join(intro, "\n")
+		}
+	}
+	finish()
+	return appendices
+}
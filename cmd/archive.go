@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxArchiveEntrySize caps how much is extracted from a single archive entry, guarding
+// against decompression bombs.
+const maxArchiveEntrySize = 64 << 20
+
+// maxArchiveSize caps how much is extracted from an archive in total.
+const maxArchiveSize = 512 << 20
+
+// maxArchiveEntries caps how many entries an archive may have, extracted or not.
+const maxArchiveEntries = 100000
+
+// extractionBudget tracks how many more entries an archive extraction may read and how
+// many more bytes it may write.
+type extractionBudget struct {
+	entries int
+	bytes   int64
+}
+
+// newExtractionBudget returns the budget of one archive extraction.
+func newExtractionBudget() *extractionBudget {
+	return &extractionBudget{entries: maxArchiveEntries, bytes: maxArchiveSize}
+}
+
+// entry counts one archive entry against the budget.
+func (b *extractionBudget) entry() error {
+	if b.entries == 0 {
+		return fmt.Errorf("archive has more than %d entries", maxArchiveEntries)
+	}
+	b.entries--
+	return nil
+}
+
+// isExtractedEntry reports whether an archive entry is extracted: files that are
+// documented and the .gitignore files that decide which are. Everything else is
+// skipped without being written.
+func isExtractedEntry(name string) bool {
+	return isYAMLName(name) || path.Base(name) == ".gitignore"
+}
+
+// archiveSuffixes are the input archive formats accepted in place of a directory.
+var archiveSuffixes = []string{".tar.gz", ".tgz", ".zip"}
+
+// isArchive reports whether path names an archive file rather than a directory.
+func isArchive(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	return archiveStem(path) != filepath.Base(path)
+}
+
+// archiveStem returns the archive file name without its archive suffix.
+func archiveStem(path string) string {
+	base := filepath.Base(path)
+	for _, suffix := range archiveSuffixes {
+		if strings.HasSuffix(strings.ToLower(base), suffix) {
+			return base[:len(base)-len(suffix)]
+		}
+	}
+	return base
+}
+
+// archiveDocPath returns where the document for an archive is written: next to the
+// archive (or in --write-dir), prefixed with the archive's name.
+func archiveDocPath(archivePath string) string {
+	return filepath.Join(outputDir(filepath.Dir(archivePath)), archiveStem(archivePath)+"_"+markdownFileName)
+}
+
+// safeJoin joins an archive entry name to dest, rejecting names that escape dest.
+func safeJoin(dest, name string) (string, error) {
+	target := filepath.Join(dest, filepath.FromSlash(name))
+	if target != dest && !strings.HasPrefix(target, dest+string(os.PathSeparator)) {
+		return "", fmt.Errorf("archive entry %q escapes the extraction directory", name)
+	}
+	return target, nil
+}
+
+// writeArchiveEntry copies one regular file out of an archive, within budget.
+func writeArchiveEntry(target string, r io.Reader, budget *extractionBudget) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	limit := min(maxArchiveEntrySize, budget.bytes)
+	n, copyErr := io.CopyN(f, r, limit+1)
+	closeErr := f.Close()
+	if copyErr != nil && !errors.Is(copyErr, io.EOF) {
+		return copyErr
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+	switch {
+	case n > maxArchiveEntrySize:
+		return fmt.Errorf("archive entry %s is larger than %d bytes", filepath.Base(target), maxArchiveEntrySize)
+	case n > limit:
+		return fmt.Errorf("archive extracts to more than %d bytes", maxArchiveSize)
+	}
+	budget.bytes -= n
+	return nil
+}
+
+// extractTarGz extracts the documented regular files of a gzipped tarball into dest.
+// Links and special files are skipped.
+func extractTarGz(archivePath, dest string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", archivePath, err)
+	}
+	tr := tar.NewReader(gz)
+	budget := newExtractionBudget()
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", archivePath, err)
+		}
+		if err := budget.entry(); err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg || !isExtractedEntry(hdr.Name) {
+			continue
+		}
+		target, err := safeJoin(dest, hdr.Name)
+		if err != nil {
+			return err
+		}
+		if err := writeArchiveEntry(target, tr, budget); err != nil {
+			return err
+		}
+	}
+}
+
+// extractZip extracts the documented regular files of a zip archive into dest.
+func extractZip(archivePath, dest string) error {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", archivePath, err)
+	}
+	defer func() {
+		_ = zr.Close()
+	}()
+	budget := newExtractionBudget()
+	for _, zf := range zr.File {
+		if err := budget.entry(); err != nil {
+			return err
+		}
+		if !zf.Mode().IsRegular() || !isExtractedEntry(zf.Name) {
+			continue
+		}
+		target, err := safeJoin(dest, zf.Name)
+		if err != nil {
+			return err
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return err
+		}
+		err = writeArchiveEntry(target, rc, budget)
+		_ = rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractArchive extracts an archive into a new temporary directory and returns it. The
+// caller must remove the directory.
+func extractArchive(archivePath string) (string, error) {
+	dest, err := os.MkdirTemp("", "yaml-to-readme-archive-*")
+	if err != nil {
+		return "", err
+	}
+	if strings.HasSuffix(strings.ToLower(archivePath), ".zip") {
+		err = extractZip(archivePath, dest)
+	} else {
+		err = extractTarGz(archivePath, dest)
+	}
+	if err != nil {
+		_ = os.RemoveAll(dest)
+		return "", err
+	}
+	return dest, nil
+}
+
+// copyFile copies src to dst.
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0o644)
+}
+
+// summarizeStagedTree summarizes a temporary directory of YAML files and publishes the
+// document to docPath. Summaries from an existing document at docPath are reused.
+func summarizeStagedTree(dir, docPath string, llm LLMProvider) (*runReport, error) {
+	if _, err := os.Stat(docPath); err == nil {
+		if err := copyFile(docPath, docPathFor(dir)); err != nil {
+			return nil, err
+		}
+	}
+	report, err := summarizeDirectory(dir, llm)
+	if err != nil {
+		return nil, err
+	}
+	if err := copyFile(report.OutputPath, docPath); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", docPath, err)
+	}
+	report.OutputPath = docPath
+	return report, nil
+}
+
+// summarizeArchive extracts an archive, summarizes its YAML files, and writes the
+// document next to the archive.
+func summarizeArchive(archivePath string, llm LLMProvider) (*runReport, error) {
+	dir, err := extractArchive(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	report, err := summarizeStagedTree(dir, archiveDocPath(archivePath), llm)
+	if err != nil {
+		return nil, err
+	}
+	report.Dir = archivePath
+	return report, nil
+}
+
+// runArchive is the main logic for summarizing an archive given as the input argument.
+func runArchive(archivePath string) error {
+	if dryRun {
+		dir, err := extractArchive(archivePath)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = os.RemoveAll(dir)
+		}()
+		return runDryRun(dir)
+	}
+
+	llm, err := createProvider()
+	if err != nil {
+		return fmt.Errorf("failed to create %s provider: %w", provider, err)
+	}
+	report, err := summarizeArchive(archivePath, llm)
+	if err != nil {
+		return err
+	}
+	printRunReport(report)
+	return nil
+}
@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// DefaultAskTopK is how many files ask retrieves to answer a question.
+const DefaultAskTopK = 5
+
+// DefaultAskFileTokens is how much of each file, in the model's tokens, is searched and
+// sent along with its summary.
+const DefaultAskFileTokens = 1000
+
+// AskPrompt is the instruction sent to the LLM with the question and retrieved files.
+const AskPrompt = "Answer the question using only the numbered YAML files below. Cite every file you rely on by its number in square brackets, like [1]. If the files do not answer the question, say so. Do not use markdown headings.\n\n"
+
+var askTopK int
+var embedModel string
+
+// askPassage is one file of the inventory considered when answering a question.
+type askPassage struct {
+	// Rel is the file path relative to the documented directory.
+	Rel     string
+	Summary string
+	// Content is the first DefaultAskFileTokens of the file.
+	Content string
+	score   float64
+}
+
+// text returns what is embedded or matched for the passage.
+func (p askPassage) text() string {
+	return p.Rel + ": " + p.Summary + "\n" + p.Content
+}
+
+// loadInventory returns the summaries in the document for dir, with the start of each
+// file's content, secrets redacted, sorted by path.
+func loadInventory(dir string) ([]askPassage, error) {
+	docPath := docPathFor(dir)
+	summaries := parseExistingSummaries(docPath)
+	if len(summaries) == 0 {
+		return nil, fmt.Errorf("no summaries found in %s; run summarize-yaml on %s first", docPath, dir)
+	}
+	tok := activeTokenizer()
+	passages := make([]askPassage, 0, len(summaries))
+	for rel, summary := range summaries {
+		p := askPassage{Rel: filepath.ToSlash(rel), Summary: summary}
+		file := filepath.Join(dir, rel)
+		if content, err := os.ReadFile(file); err == nil {
+			// Passages are sent to the provider and its embedding endpoint
+			p.Content = tok.Truncate(promptContent(file, string(content)), DefaultAskFileTokens)
+		}
+		passages = append(passages, p)
+	}
+	sort.Slice(passages, func(i, j int) bool { return passages[i].Rel < passages[j].Rel })
+	return passages, nil
+}
+
+// askIndex retrieves the passages relevant to a question. The inventory is embedded
+// once, so a chat session only embeds each new question.
+type askIndex struct {
+	passages []askPassage
+	embedder Embedder
+	model    string
+	// vectors holds the passage embeddings, or nil to match words instead.
+	vectors [][]float32
+}
+
+// newAskIndex indexes passages of files under dir. Providers that implement Embedder
+// rank by embedding similarity; others, or a failed embedding request, fall back to
+// matching words.
+func newAskIndex(ctx context.Context, llm LLMProvider, dir string, passages []askPassage) *askIndex {
+	index := &askIndex{passages: passages}
+	e, ok := llm.(Embedder)
+	if !ok {
+		return index
+	}
+	index.embedder, index.model = e, embedModel
+	if index.model == "" {
+		index.model = e.DefaultEmbedModel()
+	}
+	texts := make([]string, len(passages))
+	files := make([]string, len(passages))
+	for i, p := range passages {
+		texts[i] = p.text()
+		files[i] = filepath.Join(dir, filepath.FromSlash(p.Rel))
+	}
+	vectors, err := e.Embed(withAuditFiles(ctx, files), index.model, texts)
+	if err != nil {
+		slog.Warn("embedding failed; matching words instead", "provider", llm.Name(), "model", index.model, "error", err)
+		return index
+	}
+	index.vectors = vectors
+	return index
+}
+
+// retrieve returns the k passages most relevant to query, most relevant first.
+func (x *askIndex) retrieve(ctx context.Context, query string, k int) []askPassage {
+	passages := append([]askPassage(nil), x.passages...)
+	ranked := false
+	if x.vectors != nil {
+		if q, err := x.embedder.Embed(ctx, x.model, []string{query}); err != nil {
+			slog.Warn("embedding failed; matching words instead", "model", x.model, "error", err)
+		} else {
+			for i := range passages {
+				passages[i].score = cosineSimilarity(q[0], x.vectors[i])
+			}
+			ranked = true
+		}
+	}
+	if !ranked {
+		lexicalScores(query, passages)
+	}
+	sort.SliceStable(passages, func(i, j int) bool { return passages[i].score > passages[j].score })
+	return passages[:min(k, len(passages))]
+}
+
+// cosineSimilarity returns the cosine of the angle between a and b, or 0 if either is
+// empty or they differ in length.
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// askWordPattern splits text into the words lexicalScores compares.
+var askWordPattern = regexp.MustCompile(`[a-z0-9]+`)
+
+// lexicalScores scores every passage by how many of the query's words it contains.
+// Words of two letters or fewer are ignored.
+func lexicalScores(query string, passages []askPassage) {
+	terms := make(map[string]bool)
+	for _, word := range askWordPattern.FindAllString(strings.ToLower(query), -1) {
+		if len(word) > 2 {
+			terms[word] = true
+		}
+	}
+	for i := range passages {
+		words := make(map[string]bool)
+		for _, word := range askWordPattern.FindAllString(strings.ToLower(passages[i].text()), -1) {
+			words[word] = true
+		}
+		passages[i].score = 0
+		for term := range terms {
+			if words[term] {
+				passages[i].score++
+			}
+		}
+	}
+}
+
+// askContent renders the question and the retrieved files, numbered for citation, with
+// the start of each file's content.
+func askContent(question string, passages []askPassage) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Question: %s\n", question)
+	for i, p := range passages {
+		fmt.Fprintf(&sb, "\n[%d] %s\nSummary: %s\n", i+1, p.Rel, p.Summary)
+		if p.Content != "" {
+			fmt.Fprintf(&sb, "Content:\n%s\n", p.Content)
+		}
+	}
+	return sb.String()
+}
+
+// askCitationPattern matches a numbered citation such as [2].
+var askCitationPattern = regexp.MustCompile(`\[(\d+)\]`)
+
+// citedPassages returns the passages cited in answer, in citation number order. If the
+// answer cites none, every retrieved passage is returned.
+func citedPassages(answer string, passages []askPassage) ([]int, []askPassage) {
+	seen := make(map[int]bool)
+	for _, m := range askCitationPattern.FindAllStringSubmatch(answer, -1) {
+		if n, err := strconv.Atoi(m[1]); err == nil && n >= 1 && n <= len(passages) {
+			seen[n] = true
+		}
+	}
+	var numbers []int
+	var cited []askPassage
+	for i, p := range passages {
+		if len(seen) == 0 || seen[i+1] {
+			numbers = append(numbers, i+1)
+			cited = append(cited, p)
+		}
+	}
+	return numbers, cited
+}
+
+// runAsk is the main logic for the ask command.
+func runAsk(dir, question string) error {
+	setupLogging()
+	llm, err := createProvider()
+	if err != nil {
+		return fmt.Errorf("failed to create %s provider: %w", provider, err)
+	}
+	return runAskWithProvider(os.Stdout, dir, question, llm)
+}
+
+// runAskWithProvider answers question from the inventory of dir, writing the answer and
+// the cited files to w.
+func runAskWithProvider(w io.Writer, dir, question string, llm LLMProvider) error {
+	passages, err := loadInventory(dir)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	var answer string
+	var retrieved []askPassage
+	if err := auditRun(dir, llm, func(llm LLMProvider) error {
+		retrieved = newAskIndex(ctx, llm, dir, passages).retrieve(ctx, question, max(askTopK, 1))
+		slog.Debug("retrieved files", "question", question, "count", len(retrieved))
+		answer, err = llm.Summarize(ctx, askContent(question, retrieved), localizedPrompt(AskPrompt))
+		return err
+	}); err != nil {
+		return fmt.Errorf("%s error: %w", llm.Name(), err)
+	}
+	return writeAnswer(w, dir, answer, retrieved)
+}
+
+// writeAnswer prints answer followed by the paths of the files it cites.
+func writeAnswer(w io.Writer, dir, answer string, retrieved []askPassage) error {
+	if _, err := fmt.Fprintf(w, "%s\n\nSources:\n", strings.TrimSpace(answer)); err != nil {
+		return err
+	}
+	numbers, cited := citedPassages(answer, retrieved)
+	for i, p := range cited {
+		if _, err := fmt.Fprintf(w, "[%d] %s\n", numbers[i], filepath.Join(dir, filepath.FromSlash(p.Rel))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// askCmd answers a question about the YAML files from their generated summaries.
+var askCmd = &cobra.Command{
+	Use:   "ask <question> [directory]",
+	Short: "Answer a question about the YAML files, citing the files used",
+	Long: `Answer a question from the summaries in the generated document for the directory
+(default: the current directory). The summaries most relevant to the question are
+retrieved with embeddings, or by matching words when the provider has no embedding
+API, and the LLM answers from them and the files' content, citing the files it used.`,
+	Args:         cobra.RangeArgs(1, 2),
+	SilenceUsage: true,
+	// The config file is looked up in the directory, not the question
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return loadConfig(cmd, args[1:])
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := "."
+		if len(args) == 2 {
+			dir = args[1]
+		}
+		return runAsk(dir, args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(askCmd)
+	askCmd.Flags().IntVar(&askTopK, "top-k", DefaultAskTopK, "Number of files retrieved to answer the question")
+	askCmd.Flags().StringVar(&embedModel, "embed-model", "", "Embedding model used to retrieve files (default: nomic-embed-text for ollama, text-embedding-3-small for openai)")
+}
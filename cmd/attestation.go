@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+)
+
+const (
+	// inTotoStatementType is the in-toto Statement v1 type of --attestation.
+	inTotoStatementType = "https://in-toto.io/Statement/v1"
+	// slsaProvenanceType is the predicate type of --attestation.
+	slsaProvenanceType = "https://slsa.dev/provenance/v1"
+	// attestationBuildType identifies how a document was produced in its provenance.
+	attestationBuildType = "https://github.com/sebrandon1/yaml-to-readme/summarize/v1"
+	// attestationBuilderID identifies the tool in its provenance.
+	attestationBuilderID = "https://github.com/sebrandon1/yaml-to-readme"
+)
+
+// resourceDescriptor is an in-toto resource descriptor: a named artifact and its digest.
+type resourceDescriptor struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// inTotoStatement is an in-toto Statement carrying SLSA provenance for a document.
+type inTotoStatement struct {
+	Type          string               `json:"_type"`
+	Subject       []resourceDescriptor `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     slsaProvenance       `json:"predicate"`
+}
+
+// slsaProvenance is a SLSA v1 provenance predicate.
+type slsaProvenance struct {
+	BuildDefinition struct {
+		BuildType            string               `json:"buildType"`
+		ExternalParameters   map[string]any       `json:"externalParameters"`
+		ResolvedDependencies []resourceDescriptor `json:"resolvedDependencies"`
+	} `json:"buildDefinition"`
+	RunDetails struct {
+		Builder struct {
+			ID      string            `json:"id"`
+			Version map[string]string `json:"version"`
+		} `json:"builder"`
+		Metadata struct {
+			StartedOn  string `json:"startedOn"`
+			FinishedOn string `json:"finishedOn"`
+		} `json:"metadata"`
+	} `json:"runDetails"`
+}
+
+// toolVersion returns the version of this binary from its build info.
+func toolVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" && info.Main.Version == "(devel)" {
+			return s.Value
+		}
+	}
+	return info.Main.Version
+}
+
+// fileSHA256 returns the hex SHA-256 digest of a file's content.
+func fileSHA256(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// buildAttestation returns the provenance statement of the document at docPath,
+// generated from files under dir by a run that started at start.
+func buildAttestation(dir, docPath string, files []string, providerName string, start time.Time) (*inTotoStatement, error) {
+	docDigest, err := fileSHA256(docPath)
+	if err != nil {
+		return nil, err
+	}
+	st := &inTotoStatement{
+		Type:          inTotoStatementType,
+		Subject:       []resourceDescriptor{{Name: filepath.Base(docPath), Digest: map[string]string{"sha256": docDigest}}},
+		PredicateType: slsaProvenanceType,
+	}
+	def := &st.Predicate.BuildDefinition
+	def.BuildType = attestationBuildType
+	def.ExternalParameters = map[string]any{
+		"provider": providerName,
+		"model":    ModelName,
+		"format":   outputFormat,
+		"lang":     lang,
+	}
+	def.ResolvedDependencies = []resourceDescriptor{}
+	for _, file := range files {
+		digest, err := fileSHA256(file)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			rel = file
+		}
+		def.ResolvedDependencies = append(def.ResolvedDependencies, resourceDescriptor{Name: filepath.ToSlash(rel), Digest: map[string]string{"sha256": digest}})
+	}
+	run := &st.Predicate.RunDetails
+	run.Builder.ID = attestationBuilderID
+	run.Builder.Version = map[string]string{"yaml-to-readme": toolVersion()}
+	run.Metadata.StartedOn = start.UTC().Format(time.RFC3339)
+	run.Metadata.FinishedOn = time.Now().UTC().Format(time.RFC3339)
+	return st, nil
+}
+
+// writeAttestation writes the --attestation statement for a run's document.
+func writeAttestation(path, dir, docPath string, files []string, providerName string, start time.Time) error {
+	st, err := buildAttestation(dir, docPath, files, providerName, start)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// audiencePromptRules ends every audience prompt with the same output rules as
+// SummarizePrompt, so summaries stay short, plain-text sentences.
+const audiencePromptRules = "Do not include any lists, breakdowns, explanations, advice, notes, or formatting. Do not use markdown. No newlines. No code sections. Only output a single, concise summary, and nothing else. Stop after two sentences. If you cannot summarize in two sentences, summarize in one: \n"
+
+// audiencePrompts are the prompt presets selectable with --audience, from most to least
+// technical.
+var audiencePrompts = map[string]string{
+	"dev": "Summarize this YAML file for a developer in no more than two short sentences: name the resources or settings it defines and how they fit together, using exact kind, key, and image names where they matter. " +
+		audiencePromptRules,
+	"ops": "Summarize this YAML file for an operations engineer in no more than two short sentences: what it runs or configures and what matters when operating it, such as exposed ports, replicas, resource limits, storage, schedules, and external dependencies. " +
+		audiencePromptRules,
+	"exec": "Summarize the business purpose of this YAML file for a non-technical reader in no more than two short, plain-language sentences: what capability it provides or supports. Avoid jargon, product names of infrastructure tools, resource kinds, and configuration keys. " +
+		audiencePromptRules,
+}
+
+// audiences are the --audience values. The first selects the prompt of the document
+// written to --output; each further audience writes its own document.
+var audiences []string
+
+// activeAudience is the audience summaries are currently written for, or an empty string
+// for the default prompt.
+var activeAudience string
+
+// supportedAudiences returns the --audience values in order of technical depth.
+func supportedAudiences() []string {
+	return []string{"dev", "ops", "exec"}
+}
+
+// validateAudiences checks the --audience values and selects the first one.
+func validateAudiences() error {
+	seen := make(map[string]bool)
+	for _, a := range audiences {
+		if _, ok := audiencePrompts[a]; !ok {
+			return fmt.Errorf("unsupported --audience %q, expected one of: %s", a, strings.Join(supportedAudiences(), ", "))
+		}
+		if seen[a] {
+			return fmt.Errorf("--audience %s is given twice", a)
+		}
+		seen[a] = true
+	}
+	if len(audiences) > 0 && promptTemplate != nil {
+		return fmt.Errorf("--audience cannot be combined with --prompt-file, which replaces the prompt")
+	}
+	if len(audiences) > 1 && (writesToStdout() || injectPath != "") {
+		return fmt.Errorf("several --audience values write one document each and cannot be combined with -o - or --inject")
+	}
+	activeAudience = ""
+	if len(audiences) > 0 {
+		activeAudience = audiences[0]
+	}
+	return nil
+}
+
+// audiencePrompt returns the prompt preset of the active audience, or SummarizePrompt.
+func audiencePrompt() string {
+	if prompt, ok := audiencePrompts[activeAudience]; ok {
+		return prompt
+	}
+	return SummarizePrompt
+}
+
+// audienceDocName returns the document name of an additional audience: name with the
+// audience before its extension, such as yaml_details.exec.md.
+func audienceDocName(name, audience string) string {
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + audience + ext
+}
+
+// forEachAudience runs fn once per --audience value, with that audience active and,
+// after the first, --output renamed for it. Without --audience fn runs once with the
+// default prompt.
+func forEachAudience(fn func() error) error {
+	if len(audiences) <= 1 {
+		return fn()
+	}
+	origName, origAudience := markdownFileName, activeAudience
+	defer func() {
+		markdownFileName, activeAudience = origName, origAudience
+	}()
+	for i, a := range audiences {
+		activeAudience = a
+		if i > 0 {
+			markdownFileName = audienceDocName(origName, a)
+		}
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
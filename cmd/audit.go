@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// auditFileKey is the context key naming the file whose content a provider request
+// carries, recorded in the --audit-log.
+type auditFileKey struct{}
+
+// withAuditFile returns ctx annotated with the file a provider request is about.
+func withAuditFile(ctx context.Context, file string) context.Context {
+	return context.WithValue(ctx, auditFileKey{}, file)
+}
+
+// auditFilesKey is the context key naming the file of each text of an embedding
+// request, recorded in the --audit-log.
+type auditFilesKey struct{}
+
+// withAuditFiles returns ctx annotated with the files the texts of an embedding request
+// are about, in the same order.
+func withAuditFiles(ctx context.Context, files []string) context.Context {
+	return context.WithValue(ctx, auditFilesKey{}, files)
+}
+
+// auditSend is one provider request recorded in the audit log.
+type auditSend struct {
+	// Path is the file the request was about, relative to the documented directory.
+	Path string `json:"path"`
+	// SHA256 is the hash of the exact content sent.
+	SHA256 string `json:"sha256"`
+	// Model is the model the request was sent to.
+	Model string `json:"model"`
+}
+
+// auditRecord is one line of the --audit-log: a single summarization run.
+type auditRecord struct {
+	Time     string      `json:"time"`
+	User     string      `json:"user"`
+	Host     string      `json:"host"`
+	Dir      string      `json:"dir"`
+	Provider string      `json:"provider"`
+	Model    string      `json:"model"`
+	Endpoint string      `json:"endpoint,omitempty"`
+	Remote   bool        `json:"remote"`
+	Sent     []auditSend `json:"sent"`
+}
+
+// auditTrail collects the requests of one run sent through the providers it wraps. It
+// is nil without --audit-log, and a nil trail wraps nothing.
+type auditTrail struct {
+	mu   sync.Mutex
+	sent []auditSend
+}
+
+// newAuditTrail returns a trail for a run, or nil without --audit-log.
+func newAuditTrail() *auditTrail {
+	if auditLogPath == "" {
+		return nil
+	}
+	return &auditTrail{}
+}
+
+// wrap returns llm wrapped so its requests are recorded in the trail, keeping its
+// embedding support.
+func (t *auditTrail) wrap(llm LLMProvider) LLMProvider {
+	if t == nil {
+		return llm
+	}
+	a := &auditProvider{LLMProvider: llm, trail: t}
+	if e, ok := llm.(Embedder); ok {
+		return &auditEmbedder{auditProvider: a, embedder: e}
+	}
+	return a
+}
+
+// add records content sent to model about file.
+func (t *auditTrail) add(file, content, model string) {
+	sum := sha256.Sum256([]byte(content))
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sent = append(t.sent, auditSend{Path: file, SHA256: hex.EncodeToString(sum[:]), Model: model})
+}
+
+// record returns the audit record of a run over dir with llm.
+func (t *auditTrail) record(dir string, llm LLMProvider) auditRecord {
+	rec := auditRecord{
+		Time:     time.Now().UTC().Format(time.RFC3339),
+		Dir:      dir,
+		Provider: llm.Name(),
+		Model:    providerModel(llm),
+		Endpoint: providerEndpoint(llm.Name()),
+		Sent:     []auditSend{},
+	}
+	rec.Remote = isRemoteEndpoint(rec.Endpoint)
+	if u, err := user.Current(); err == nil {
+		rec.User = u.Username
+	} else {
+		rec.User = os.Getenv("USER")
+	}
+	rec.Host, _ = os.Hostname()
+	if abs, err := filepath.Abs(dir); err == nil {
+		rec.Dir = abs
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, s := range t.sent {
+		if rel, err := filepath.Rel(dir, s.Path); err == nil && s.Path != "" {
+			s.Path = filepath.ToSlash(rel)
+		}
+		rec.Sent = append(rec.Sent, s)
+	}
+	return rec
+}
+
+// write appends the record of the run over dir with llm to the --audit-log.
+func (t *auditTrail) write(dir string, llm LLMProvider) error {
+	if t == nil {
+		return nil
+	}
+	return appendAuditRecord(auditLogPath, t.record(dir, llm))
+}
+
+// auditRun runs fn with llm wrapped for the --audit-log and appends the record of the
+// run over dir once fn returns, whether or not it failed. Commands that send content
+// outside summarizeDirectory run through it.
+func auditRun(dir string, llm LLMProvider, fn func(llm LLMProvider) error) error {
+	trail := newAuditTrail()
+	err := fn(trail.wrap(llm))
+	if writeErr := trail.write(dir, llm); err == nil {
+		err = writeErr
+	}
+	return err
+}
+
+// auditProvider wraps an LLMProvider and records every request it sends in its trail.
+type auditProvider struct {
+	LLMProvider
+	trail *auditTrail
+}
+
+// Summarize records the request, then sends it to the wrapped provider.
+func (a *auditProvider) Summarize(ctx context.Context, content string, prompt string) (string, error) {
+	file, _ := ctx.Value(auditFileKey{}).(string)
+	a.trail.add(file, content, providerModel(a.LLMProvider))
+	return a.LLMProvider.Summarize(ctx, content, prompt)
+}
+
+// WarmUp implements Warmer if the wrapped provider does.
+func (a *auditProvider) WarmUp(ctx context.Context) error {
+	if w, ok := a.LLMProvider.(Warmer); ok {
+		return w.WarmUp(ctx)
+	}
+	return nil
+}
+
+// Model returns the model of the wrapped provider.
+func (a *auditProvider) Model() string {
+	return providerModel(a.LLMProvider)
+}
+
+// auditEmbedder is an auditProvider for a provider that implements Embedder, recording
+// every embedded text as well.
+type auditEmbedder struct {
+	*auditProvider
+	embedder Embedder
+}
+
+// Embed records each text, then sends the request to the wrapped provider.
+func (a *auditEmbedder) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	files, _ := ctx.Value(auditFilesKey{}).([]string)
+	for i, text := range texts {
+		file := ""
+		if i < len(files) {
+			file = files[i]
+		}
+		a.trail.add(file, text, model)
+	}
+	return a.embedder.Embed(ctx, model, texts)
+}
+
+// DefaultEmbedModel implements Embedder.
+func (a *auditEmbedder) DefaultEmbedModel() string {
+	return a.embedder.DefaultEmbedModel()
+}
+
+// appendAuditRecord appends rec as one JSON line to the audit log at path. The file is
+// only ever appended to, never rewritten.
+func appendAuditRecord(path string, rec auditRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log %s: %w", path, err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write audit log %s: %w", path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// renovateConfigNames are the Renovate config files documented alongside YAML files.
+var renovateConfigNames = []string{"renovate.json", "renovate.json5", ".renovaterc", ".renovaterc.json", ".renovaterc.json5"}
+
+// documentedExtensions returns the extra file name suffixes found in addition to the
+// default YAML extensions: --match-extensions, the Renovate config names, and with
+// --chart-archives packaged charts.
+func documentedExtensions() []string {
+	extensions := append(slices.Clone(matchExtensions), renovateConfigNames...)
+	if chartArchives {
+		extensions = append(extensions, chartArchiveSuffix)
+	}
+	return extensions
+}
+
+// stripJSONComments removes // and /* */ comments outside of strings, so JSON5-style
+// configs parse as YAML flow mappings.
+func stripJSONComments(data []byte) []byte {
+	var out []byte
+	var quote byte
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case quote != 0:
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := strings.Index(string(data[i+2:]), "*/")
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// countLabel formats n with the singular or plural format string of a label.
+func countLabel(n int, one, many string) string {
+	if n == 1 {
+		return fmt.Sprintf(one, n)
+	}
+	return fmt.Sprintf(many, n)
+}
+
+// dependabotSummary describes the update rules of a dependabot.yml.
+func dependabotSummary(doc map[string]any) string {
+	l := currentLabels()
+	updates := mapSlice(doc, "updates")
+	var rules []string
+	var ignored []string
+	for _, u := range updates {
+		schedule, _ := u["schedule"].(map[string]any)
+		when := stringField(schedule, "interval")
+		if day := stringField(schedule, "day"); day != "" {
+			when = fmt.Sprintf(l.DependabotSchedule, when, day)
+		}
+		rule := fmt.Sprintf(l.DependabotRule, stringField(u, "package-ecosystem"), stringField(u, "directory"))
+		if when != "" {
+			rule += " " + when
+		}
+		rules = append(rules, rule)
+		for _, ignore := range mapSlice(u, "ignore") {
+			if name := stringField(ignore, "dependency-name"); name != "" && !slices.Contains(ignored, name) {
+				ignored = append(ignored, name)
+			}
+		}
+	}
+	summary := fmt.Sprintf(l.DependabotSummary, countLabel(len(rules), l.DependabotEcosystem, l.DependabotEcosystems), strings.Join(rules, ", "))
+	if len(ignored) > 0 {
+		summary += fmt.Sprintf(l.DependabotIgnores, strings.Join(ignored, ", "))
+	}
+	return summary
+}
+
+// renovateSummary describes the presets, managers, schedule, and package rules of a
+// Renovate config.
+func renovateSummary(doc map[string]any) string {
+	l := currentLabels()
+	var extending, schedule string
+	if presets := scalarStrings(doc["extends"]); len(presets) > 0 {
+		extending = fmt.Sprintf(l.RenovateExtending, strings.Join(presets, ", "))
+	}
+	managers := l.RenovateAllManagers
+	if enabled := scalarStrings(doc["enabledManagers"]); len(enabled) > 0 {
+		managers = fmt.Sprintf(l.RenovateManagers, strings.Join(enabled, ", "))
+	}
+	if times := scalarStrings(doc["schedule"]); len(times) > 0 {
+		schedule = fmt.Sprintf(l.RenovateSchedule, "\""+strings.Join(times, "\", \"")+"\"")
+	}
+	summary := fmt.Sprintf(l.RenovateSummary, extending, managers, schedule)
+	rules := mapSlice(doc, "packageRules")
+	if len(rules) > 0 {
+		automerged := 0
+		for _, rule := range rules {
+			if rule["automerge"] == true {
+				automerged++
+			}
+		}
+		var automerge string
+		if automerged > 0 {
+			automerge = fmt.Sprintf(l.RenovateAutomergeRules, automerged)
+		}
+		summary += fmt.Sprintf(l.RenovateDefines, countLabel(len(rules), l.RenovatePackageRule, l.RenovatePackageRules), automerge)
+	} else if doc["automerge"] == true {
+		summary += l.RenovateAutomerged
+	}
+	return summary
+}
+
+// automationSummary returns a deterministic summary for Dependabot and Renovate
+// configs, which the generic prompt describes poorly.
+func automationSummary(file string, content []byte) (string, bool) {
+	name := filepath.Base(file)
+	switch {
+	case name == "dependabot.yml" || name == "dependabot.yaml":
+		var doc map[string]any
+		if err := yaml.Unmarshal(content, &doc); err != nil || len(mapSlice(doc, "updates")) == 0 {
+			return "", false
+		}
+		return dependabotSummary(doc), true
+	case slices.Contains(renovateConfigNames, name):
+		var doc map[string]any
+		if err := yaml.Unmarshal(stripJSONComments(content), &doc); err != nil || doc == nil {
+			return "", false
+		}
+		return renovateSummary(doc), true
+	}
+	return "", false
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const DefaultMaxAutoConcurrency = 16
+
+// concurrencyValue is the pflag.Value behind --concurrency. It accepts a positive
+// worker count or "auto", which enables latency-based autotuning.
+type concurrencyValue struct {
+	workers *int
+	auto    *bool
+}
+
+// String implements pflag.Value.
+func (c concurrencyValue) String() string {
+	if c.auto != nil && *c.auto {
+		return "auto"
+	}
+	if c.workers == nil {
+		return "1"
+	}
+	return strconv.Itoa(*c.workers)
+}
+
+// Set implements pflag.Value.
+func (c concurrencyValue) Set(value string) error {
+	if value == "auto" {
+		*c.auto = true
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return fmt.Errorf("must be a positive integer or \"auto\"")
+	}
+	*c.workers = n
+	*c.auto = false
+	return nil
+}
+
+// Type implements pflag.Value.
+func (c concurrencyValue) Type() string {
+	return "int|auto"
+}
+
+// concurrencyLimiter bounds the number of in-flight provider requests. In adaptive mode
+// it starts with a single request and tunes the limit from observed latency and errors
+// using additive increase / multiplicative decrease: the limit grows by one after a full
+// window of healthy requests, shrinks by one when latency exceeds twice the best latency
+// seen, and halves on errors, which usually signal rate limiting.
+type concurrencyLimiter struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	adaptive  bool
+	limit     int
+	maxLimit  int
+	inFlight  int
+	successes int
+	baseline  time.Duration
+}
+
+// newConcurrencyLimiter creates a limiter for pending requests based on the
+// --concurrency and --max-concurrency flags.
+func newConcurrencyLimiter(pending int) *concurrencyLimiter {
+	l := &concurrencyLimiter{adaptive: autoConcurrency}
+	if l.adaptive {
+		l.limit = 1
+		l.maxLimit = max(maxAutoConcurrency, 1)
+	} else {
+		l.limit = max(concurrency, 1)
+		if l.limit > pending && pending > 0 {
+			l.limit = pending
+		}
+		l.maxLimit = l.limit
+	}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// Acquire blocks until a request slot is available.
+func (l *concurrencyLimiter) Acquire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.inFlight >= l.limit {
+		l.cond.Wait()
+	}
+	l.inFlight++
+}
+
+// Release frees a request slot and, in adaptive mode, adjusts the limit from the
+// request's latency and outcome.
+func (l *concurrencyLimiter) Release(latency time.Duration, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+	if l.adaptive {
+		l.adjust(latency, err)
+	}
+	l.cond.Broadcast()
+}
+
+// Cancel frees a request slot that was acquired but not used, without adjusting the
+// limit.
+func (l *concurrencyLimiter) Cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+	l.cond.Broadcast()
+}
+
+// adjust applies one AIMD step. The caller must hold l.mu.
+func (l *concurrencyLimiter) adjust(latency time.Duration, err error) {
+	prev := l.limit
+	switch {
+	case err != nil:
+		l.limit = max(l.limit/2, 1)
+		l.successes = 0
+	case l.baseline > 0 && latency > 2*l.baseline:
+		l.limit = max(l.limit-1, 1)
+		l.successes = 0
+	default:
+		if l.baseline == 0 || latency < l.baseline {
+			l.baseline = latency
+		}
+		l.successes++
+		if l.successes >= l.limit && l.limit < l.maxLimit {
+			l.limit++
+			l.successes = 0
+		}
+	}
+	if l.limit != prev {
+		slog.Debug("adjusted concurrency", "from", prev, "to", l.limit, "latency", latency, "baseline", l.baseline, "error", err)
+	}
+}
+
+// providerCallsKey is the context key of the counter of provider requests made on
+// behalf of a file, so files summarized without the provider do not feed the limiter.
+type providerCallsKey struct{}
+
+// withProviderCalls returns ctx carrying a new provider request counter.
+func withProviderCalls(ctx context.Context) (context.Context, *atomic.Int32) {
+	calls := new(atomic.Int32)
+	return context.WithValue(ctx, providerCallsKey{}, calls), calls
+}
+
+// countProviderCall records a provider request in the counter carried by ctx, if any.
+func countProviderCall(ctx context.Context) {
+	if calls, ok := ctx.Value(providerCallsKey{}).(*atomic.Int32); ok {
+		calls.Add(1)
+	}
+}
+
+// Limit returns the current request limit.
+func (l *concurrencyLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
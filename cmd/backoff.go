@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	ollama "github.com/ollama/ollama/api"
+)
+
+// providerRetries is how many times a failed provider request is retried before its
+// file is given up on.
+var providerRetries int
+
+// retryBackoff is the delay before the first retry; it doubles with every attempt.
+var retryBackoff time.Duration
+
+// retryJitter is the fraction by which each retry delay is randomly varied, so workers
+// that failed together do not retry together.
+var retryJitter float64
+
+// failOnError makes a run exit non-zero when any file could not be summarized.
+var failOnError bool
+
+// validateRetries checks the --retries and --retry-jitter values.
+func validateRetries() error {
+	if providerRetries < 0 {
+		return fmt.Errorf("--retries must not be negative, got %d", providerRetries)
+	}
+	if retryJitter < 0 || retryJitter > 1 {
+		return fmt.Errorf("--retry-jitter must be between 0 and 1, got %g", retryJitter)
+	}
+	return nil
+}
+
+// retryDelay returns how long to wait before retry number attempt, counting from zero.
+func retryDelay(attempt int) time.Duration {
+	delay := float64(retryBackoff) * float64(int64(1)<<min(attempt, 30))
+	if retryJitter > 0 {
+		delay *= 1 + retryJitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(max(delay, 0))
+}
+
+// retryable reports whether a provider error is likely transient: a network failure,
+// a timeout, a dropped connection, or an HTTP 408, 429, or 5xx response. Other errors,
+// such as a rejected API key, fail the same way every time.
+func retryable(err error) bool {
+	status := 0
+	var se *statusError
+	var ose ollama.StatusError
+	switch {
+	case errors.As(err, &se):
+		status = se.StatusCode
+	case errors.As(err, &ose):
+		status = ose.StatusCode
+	default:
+		var netErr net.Error
+		return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, context.DeadlineExceeded)
+	}
+	return status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= 500
+}
+
+// providerFailure is a file whose provider request still failed after every retry.
+type providerFailure struct {
+	// Path is the file the request was about, relative to the documented directory.
+	Path  string
+	Error string
+}
+
+// retryProvider wraps an LLMProvider and retries requests that fail with a retryable
+// error, with exponential backoff, recording the files that fail permanently.
+type retryProvider struct {
+	LLMProvider
+	mu       sync.Mutex
+	failures map[string]error
+	answered map[string]requestStats
+}
+
+// requestStats counts the successful requests about one file and the retries they took.
+type requestStats struct {
+	Requests int
+	Retries  int
+}
+
+// newRetryProvider wraps llm so its failed requests are retried.
+func newRetryProvider(llm LLMProvider) *retryProvider {
+	return &retryProvider{LLMProvider: llm, failures: make(map[string]error), answered: make(map[string]requestStats)}
+}
+
+// Summarize sends the request to the wrapped provider, retrying transient failures up
+// to --retries times.
+func (r *retryProvider) Summarize(ctx context.Context, content string, prompt string) (string, error) {
+	file, _ := ctx.Value(auditFileKey{}).(string)
+	for attempt := 0; ; attempt++ {
+		answer, err := r.LLMProvider.Summarize(ctx, content, prompt)
+		if err == nil {
+			r.record(file, nil)
+			r.count(file, attempt)
+			return answer, nil
+		}
+		if attempt >= providerRetries || ctx.Err() != nil || !retryable(err) {
+			r.record(file, err)
+			return answer, err
+		}
+		delay := retryDelay(attempt)
+		slog.Warn("provider request failed; retrying", "file", file, "attempt", attempt+1, "retries", providerRetries,
+			"delay", delay.Round(time.Millisecond), "error", err)
+		select {
+		case <-ctx.Done():
+			r.record(file, err)
+			return answer, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// record remembers the outcome of the last request about file.
+func (r *retryProvider) record(file string, err error) {
+	if file == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err == nil {
+		delete(r.failures, file)
+	} else {
+		r.failures[file] = err
+	}
+}
+
+// count adds a successful request about file that took retries retries.
+func (r *retryProvider) count(file string, retries int) {
+	if file == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := r.answered[file]
+	stats.Requests++
+	stats.Retries += retries
+	r.answered[file] = stats
+}
+
+// answeredFiles returns the successful requests made so far about each file.
+func (r *retryProvider) answeredFiles() map[string]requestStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return maps.Clone(r.answered)
+}
+
+// WarmUp implements Warmer if the wrapped provider does.
+func (r *retryProvider) WarmUp(ctx context.Context) error {
+	if w, ok := r.LLMProvider.(Warmer); ok {
+		return w.WarmUp(ctx)
+	}
+	return nil
+}
+
+// Model returns the model of the wrapped provider.
+func (r *retryProvider) Model() string {
+	return providerModel(r.LLMProvider)
+}
+
+// recorded returns the files whose last request failed, with paths relative to dir,
+// sorted by path.
+func (r *retryProvider) recorded(dir string) []providerFailure {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	failures := make([]providerFailure, 0, len(r.failures))
+	for file, err := range r.failures {
+		path := file
+		if rel, err := filepath.Rel(dir, file); err == nil {
+			path = filepath.ToSlash(rel)
+		}
+		failures = append(failures, providerFailure{Path: path, Error: err.Error()})
+	}
+	sort.Slice(failures, func(i, j int) bool { return failures[i].Path < failures[j].Path })
+	return failures
+}
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TechDocsRefAnnotation tells Backstage where the TechDocs of a catalog entity live.
+const TechDocsRefAnnotation = "backstage.io/techdocs-ref"
+
+// techdocsDefaultDocsDir is the docs_dir of a generated mkdocs.yml.
+const techdocsDefaultDocsDir = "techdocs"
+
+// techdocsPageName is the inventory page written into an existing MkDocs site.
+const techdocsPageName = "yaml-inventory.md"
+
+// backstageNamePattern matches the characters not allowed in a Backstage entity name.
+var backstageNamePattern = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// techdocsRenderer renders the inventory as a TechDocs page. Files outside the MkDocs
+// docs directory are not part of the site, so they are linked through --wiki-base-url
+// when it is set and shown as inline code otherwise. The page is a copy, so it carries
+// no schema marker or checksum footer.
+type techdocsRenderer struct{}
+
+// Render implements Renderer.
+func (techdocsRenderer) Render(w io.Writer, baseDir string, grouped map[string][][2]string, sources map[string]string, appendices []docAppendix) error {
+	if _, err := io.WriteString(w, markdownHeader()+markdownStats(baseDir, grouped)); err != nil {
+		return err
+	}
+	leading, trailing := splitAppendices(appendices)
+	if err := writeMarkdownAppendices(w, leading, techdocsFileLink); err != nil {
+		return err
+	}
+
+	dirs, sorted := sortedDirs(grouped)
+
+	for _, dir := range dirs {
+		if _, err := fmt.Fprintf(w, "\n## %s\n", dirHeading(dir)); err != nil {
+			return err
+		}
+		for _, entry := range sorted[dir] {
+			if _, err := fmt.Fprintf(w, "- %s: %s\n", techdocsFileLink(filepath.ToSlash(filepath.Join(dir, entry[0]))), entry[1]); err != nil {
+				return err
+			}
+		}
+	}
+	return writeMarkdownAppendices(w, trailing, techdocsFileLink)
+}
+
+// techdocsFileLink renders a file reference on the TechDocs page, given its path relative
+// to the base directory.
+func techdocsFileLink(relPath string) string {
+	if wikiBaseURL == "" {
+		return "`" + relPath + "`"
+	}
+	return fmt.Sprintf("[`%s`](%s/%s)", relPath, strings.TrimSuffix(wikiBaseURL, "/"), relPath)
+}
+
+// backstageName turns a directory name into a valid Backstage entity name.
+func backstageName(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		abs = dir
+	}
+	name := strings.Trim(backstageNamePattern.ReplaceAllString(filepath.Base(abs), "-"), "-_.")
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-_.")
+	}
+	if name == "" {
+		return "yaml-inventory"
+	}
+	return name
+}
+
+// writeIfMissing writes content to path unless the file already exists, and reports
+// whether it was written.
+func writeIfMissing(path, content string) (bool, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if _, err := io.WriteString(f, content); err != nil {
+		_ = f.Close()
+		return false, err
+	}
+	return true, f.Close()
+}
+
+// techdocsPagePath returns where the inventory page goes. A generated mkdocs.yml gets a
+// dedicated docs directory with the inventory as its index page; an existing site gets
+// the inventory as an extra page in its docs_dir.
+func techdocsPagePath(outDir, name string) (string, error) {
+	mkdocsPath := filepath.Join(outDir, "mkdocs.yml")
+	mkdocs := fmt.Sprintf(`# Generated by yaml-to-readme --backstage. Edit freely; it is never overwritten.
+site_name: %s YAML inventory
+docs_dir: %s
+nav:
+  - YAML Inventory: index.md
+plugins:
+  - techdocs-core
+`, name, techdocsDefaultDocsDir)
+	created, err := writeIfMissing(mkdocsPath, mkdocs)
+	if err != nil {
+		return "", err
+	}
+	if created {
+		return filepath.Join(outDir, techdocsDefaultDocsDir, "index.md"), nil
+	}
+
+	data, err := os.ReadFile(mkdocsPath)
+	if err != nil {
+		return "", err
+	}
+	var site struct {
+		DocsDir string `yaml:"docs_dir"`
+		Nav     any    `yaml:"nav"`
+	}
+	if err := yaml.Unmarshal(data, &site); err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", mkdocsPath, err)
+	}
+	if site.DocsDir == techdocsDefaultDocsDir {
+		// A site generated on an earlier run
+		return filepath.Join(outDir, techdocsDefaultDocsDir, "index.md"), nil
+	}
+	if site.DocsDir == "" {
+		site.DocsDir = "docs"
+	}
+	if site.Nav != nil && !strings.Contains(string(data), techdocsPageName) {
+		slog.Warn("mkdocs.yml has an explicit nav; add the inventory page to it", "mkdocs", mkdocsPath, "page", techdocsPageName)
+	}
+	return filepath.Join(outDir, site.DocsDir, techdocsPageName), nil
+}
+
+// writeCatalogInfo writes a catalog-info.yaml registering the directory's TechDocs, or
+// warns if an existing one lacks the TechDocs annotation. Existing files are not edited,
+// since they are usually maintained by hand.
+func writeCatalogInfo(outDir, name string) error {
+	path := filepath.Join(outDir, "catalog-info.yaml")
+	catalog := fmt.Sprintf(`# Generated by yaml-to-readme --backstage. Set the owner and edit freely; it is never overwritten.
+apiVersion: backstage.io/v1alpha1
+kind: Component
+metadata:
+  name: %s
+  description: YAML configuration inventory generated by yaml-to-readme
+  annotations:
+    %s: dir:.
+spec:
+  type: documentation
+  lifecycle: production
+  owner: unknown
+`, name, TechDocsRefAnnotation)
+	created, err := writeIfMissing(path, catalog)
+	if err != nil || created {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(string(data), TechDocsRefAnnotation) {
+		slog.Warn("catalog-info.yaml has no TechDocs annotation; add it so Backstage shows the inventory",
+			"catalog", path, "annotation", TechDocsRefAnnotation+": dir:.")
+	}
+	return nil
+}
+
+// writeBackstage writes the TechDocs site and catalog entity for --backstage next to the
+// document: mkdocs.yml and catalog-info.yaml when missing, and the inventory page on
+// every run.
+func writeBackstage(dir string, grouped map[string][][2]string, appendices []docAppendix) error {
+	outDir := outputDir(dir)
+	name := backstageName(dir)
+	if err := writeCatalogInfo(outDir, name); err != nil {
+		return err
+	}
+	page, err := techdocsPagePath(outDir, name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(page), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(page)
+	if err != nil {
+		return err
+	}
+	if err := (techdocsRenderer{}).Render(f, dir, grouped, nil, appendices); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,417 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sebrandon1/yaml-to-readme/summarizer"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DefaultBatchIndexFileName = "repos_index.md"
+	DefaultBatchWorkDirName   = ".yaml_to_readme_repos"
+)
+
+// BatchManifest describes a set of repositories to summarize in one batch run.
+type BatchManifest struct {
+	Repos []BatchRepo `yaml:"repos"`
+}
+
+// BatchRepo is a single repository entry in a batch manifest. Exactly one of
+// Path (a local directory) or URL (a git remote to clone) must be set.
+type BatchRepo struct {
+	Name string `yaml:"name"`
+	Path string `yaml:"path"`
+	URL  string `yaml:"url"`
+	Ref  string `yaml:"ref"`
+	// authHeader is an HTTP header git sends when cloning URL, such as the GITHUB_TOKEN
+	// credentials of an org crawl. It is never part of the manifest.
+	authHeader string
+}
+
+// batchResult holds the outcome of summarizing one repository in a batch.
+type batchResult struct {
+	Repo   BatchRepo
+	Report *runReport
+	Err    error
+}
+
+// loadBatchManifest reads and validates a batch manifest file.
+func loadBatchManifest(manifestPath string) (*BatchManifest, error) {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %w", manifestPath, err)
+	}
+	var manifest BatchManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", manifestPath, err)
+	}
+	if len(manifest.Repos) == 0 {
+		return nil, fmt.Errorf("manifest %s does not list any repos", manifestPath)
+	}
+
+	baseDir := filepath.Dir(manifestPath)
+	seen := make(map[string]bool)
+	for i := range manifest.Repos {
+		repo := &manifest.Repos[i]
+		if (repo.Path == "") == (repo.URL == "") {
+			return nil, fmt.Errorf("repo entry %d must set exactly one of path or url", i+1)
+		}
+		if repo.Path != "" && !filepath.IsAbs(repo.Path) {
+			repo.Path = filepath.Join(baseDir, repo.Path)
+		}
+		if repo.Name == "" {
+			repo.Name = defaultRepoName(repo.Path, repo.URL)
+		}
+		if seen[repo.Name] {
+			return nil, fmt.Errorf("duplicate repo name %q in manifest", repo.Name)
+		}
+		seen[repo.Name] = true
+	}
+	return &manifest, nil
+}
+
+// defaultRepoName derives a repository name from its local path or git URL.
+func defaultRepoName(path, url string) string {
+	if url != "" {
+		name := url[strings.LastIndexAny(url, "/:")+1:]
+		return strings.TrimSuffix(name, ".git")
+	}
+	return filepath.Base(filepath.Clean(path))
+}
+
+// validateBatchRepo checks the values of a repo entry that reach the file system and
+// git: the name, which names its clone under the work directory, and the URL and ref,
+// which must not be taken for git options.
+func validateBatchRepo(repo BatchRepo) error {
+	if repo.Name == "" || repo.Name == "." || repo.Name == ".." || strings.ContainsAny(repo.Name, `/\`) || strings.Contains(repo.Name, "..") {
+		return fmt.Errorf("invalid repo name %q: it must not be empty or contain path separators or \"..\"", repo.Name)
+	}
+	if strings.HasPrefix(repo.URL, "-") {
+		return fmt.Errorf("invalid url %q for repo %s: it must not start with \"-\"", repo.URL, repo.Name)
+	}
+	if strings.HasPrefix(repo.Ref, "-") {
+		return fmt.Errorf("invalid ref %q for repo %s: it must not start with \"-\"", repo.Ref, repo.Name)
+	}
+	return nil
+}
+
+// validateBatchOutput checks that every repository of a batch gets its own document
+// and failed-file list: with --write-dir, -o -, or an absolute --output or --inject,
+// they would all write the same file.
+func validateBatchOutput() error {
+	switch {
+	case writeDir != "":
+		return fmt.Errorf("--write-dir cannot be used with multiple repositories, which would all write to it")
+	case writesToStdout():
+		return fmt.Errorf("-o - cannot be used with multiple repositories")
+	case sharedDocPath() && injectPath != "":
+		return fmt.Errorf("an absolute --inject file cannot be used with multiple repositories, which would all write to it")
+	case sharedDocPath():
+		return fmt.Errorf("an absolute --output cannot be used with multiple repositories, which would all write to it")
+	}
+	return nil
+}
+
+// gitCommand returns a git command for the clone of repo. Its auth header is passed
+// through the environment as http.<url>.extraHeader config, scoped to the repo URL, so
+// it appears neither in the arguments nor in the clone's saved remote URL or config.
+func gitCommand(repo BatchRepo, args ...string) *exec.Cmd {
+	cmd := exec.Command("git", args...)
+	if repo.authHeader != "" {
+		cmd.Env = append(os.Environ(),
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http."+repo.URL+".extraHeader",
+			"GIT_CONFIG_VALUE_0="+repo.authHeader,
+		)
+	}
+	return cmd
+}
+
+// cloneRepo shallow-clones the URL of repo into its path, or fetches the latest
+// revision if the path already contains a clone. Options end before the URL, ref, and
+// destination, so none of them is parsed as one.
+func cloneRepo(repo BatchRepo) error {
+	dest := repo.Path
+	var args []string
+	if _, err := os.Stat(filepath.Join(dest, ".git")); err == nil {
+		fetchRef := repo.Ref
+		if fetchRef == "" {
+			fetchRef = "HEAD"
+		}
+		if out, err := gitCommand(repo, "-C", dest, "fetch", "--depth", "1", "--end-of-options", "origin", fetchRef).CombinedOutput(); err != nil {
+			return fmt.Errorf("git fetch failed for %s: %w: %s", repo.URL, err, strings.TrimSpace(string(out)))
+		}
+		args = []string{"-C", dest, "checkout", "--force", "FETCH_HEAD", "--"}
+	} else {
+		args = []string{"clone", "--depth", "1"}
+		if repo.Ref != "" {
+			args = append(args, "--branch", repo.Ref)
+		}
+		args = append(args, "--", repo.URL, dest)
+	}
+	if out, err := gitCommand(repo, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s failed for %s: %w: %s", args[0], repo.URL, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// runBatch is the main logic for the batch command.
+func runBatch(manifestPath string) error {
+	setupLogging()
+	if err := validateRunFlags(filepath.Dir(manifestPath)); err != nil {
+		return err
+	}
+	llm, err := createProvider()
+	if err != nil {
+		return fmt.Errorf("failed to create %s provider: %w", provider, err)
+	}
+	return runBatchWithProvider(manifestPath, llm)
+}
+
+// runBatchWithProvider summarizes every repository listed in the manifest and writes
+// a combined cross-repo index next to the manifest.
+func runBatchWithProvider(manifestPath string, llm LLMProvider) error {
+	manifest, err := loadBatchManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+	return runBatchRepos(manifest.Repos, filepath.Dir(manifestPath), batchIndexFileName, llm)
+}
+
+// runBatchRepos summarizes the given repositories, cloning any git URLs into the work
+// directory under baseDir, and writes the combined index into baseDir.
+func runBatchRepos(repos []BatchRepo, baseDir, indexFileName string, llm LLMProvider) error {
+	workDir := batchWorkDir
+	if !filepath.IsAbs(workDir) {
+		workDir = filepath.Join(baseDir, workDir)
+	}
+
+	if err := validateBatchOutput(); err != nil {
+		return err
+	}
+	for _, repo := range repos {
+		if err := validateBatchRepo(repo); err != nil {
+			return err
+		}
+	}
+
+	results := make([]batchResult, len(repos))
+	sem := make(chan struct{}, max(batchConcurrency, 1))
+	var wg sync.WaitGroup
+	for i, repo := range repos {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, repo BatchRepo) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			results[i] = batchResult{Repo: repo}
+			if repo.URL != "" {
+				repo.Path = filepath.Join(workDir, repo.Name)
+				results[i].Repo.Path = repo.Path
+				if err := cloneRepo(repo); err != nil {
+					results[i].Err = err
+					return
+				}
+			}
+			slog.Debug("summarizing repo", "repo", repo.Name, "path", repo.Path)
+			results[i].Report, results[i].Err = summarizeDirectory(repo.Path, llm)
+		}(i, repo)
+	}
+	wg.Wait()
+
+	indexPath := filepath.Join(baseDir, indexFileName)
+	if err := writeBatchIndex(indexPath, results); err != nil {
+		return fmt.Errorf("failed to write batch index: %w", err)
+	}
+
+	failed := 0
+	for _, res := range results {
+		if res.Err != nil {
+			failed++
+			porcelainf("repo", "name", res.Repo.Name, "status", "failed", "error", res.Err)
+			statusf("%s: FAILED: %v\n", res.Repo.Name, res.Err)
+			continue
+		}
+		porcelainf("repo", "name", res.Repo.Name, "status", "ok", "output", res.Report.OutputPath,
+			"processed", res.Report.Processed, "skipped", res.Report.Skipped, "failed", len(res.Report.Failed))
+		statusf("%s: %d processed, %d skipped -> %s\n", res.Repo.Name, res.Report.Processed, res.Report.Skipped, res.Report.OutputPath)
+	}
+	porcelainf("index", "path", indexPath)
+	statusf("\nCombined index written to %s\n", indexPath)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d repos failed", failed, len(results))
+	}
+	return nil
+}
+
+// indexAnchor returns a stable HTML anchor id for a section of the combined index, so
+// links keep working regardless of how a renderer slugifies headings.
+func indexAnchor(prefix, name string) string {
+	var sb strings.Builder
+	sb.WriteString(prefix + "-")
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteRune('-')
+		}
+	}
+	return sb.String()
+}
+
+// indexEntry is one file listed in the combined index.
+type indexEntry struct {
+	Repo    string
+	Rel     string
+	Link    string
+	Summary string
+	Kind    string
+}
+
+// collectIndexEntries lists the summarized files of every successful result, sorted by
+// repo and path, with links relative to indexDir.
+func collectIndexEntries(indexDir string, results []batchResult) []indexEntry {
+	var entries []indexEntry
+	for _, res := range results {
+		if res.Err != nil {
+			continue
+		}
+		for _, file := range res.Report.YAMLFiles {
+			rel, _ := filepath.Rel(res.Report.Dir, file)
+			link, err := filepath.Rel(indexDir, file)
+			if err != nil {
+				link = file
+			}
+			kind := ""
+			if content, err := os.ReadFile(file); err == nil {
+				kind = summarizer.DetectKind(content)
+			}
+			entries = append(entries, indexEntry{
+				Repo:    res.Repo.Name,
+				Rel:     filepath.ToSlash(rel),
+				Link:    filepath.ToSlash(link),
+				Summary: res.Report.Summaries[file],
+				Kind:    kind,
+			})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Repo != entries[j].Repo {
+			return entries[i].Repo < entries[j].Repo
+		}
+		return entries[i].Rel < entries[j].Rel
+	})
+	return entries
+}
+
+// writeBatchIndex writes a markdown index with a section per repository and a section
+// per resource kind across all repositories, each with a stable anchor and links
+// relative to the index file.
+func writeBatchIndex(indexPath string, results []batchResult) error {
+	const otherKind = "Other"
+	indexDir := filepath.Dir(indexPath)
+	entries := collectIndexEntries(indexDir, results)
+
+	byKind := make(map[string][]indexEntry)
+	for _, e := range entries {
+		kind := e.Kind
+		if kind == "" {
+			kind = otherKind
+		}
+		byKind[kind] = append(byKind[kind], e)
+	}
+	kinds := make([]string, 0, len(byKind))
+	for kind := range byKind {
+		if kind != otherKind {
+			kinds = append(kinds, kind)
+		}
+	}
+	sort.Strings(kinds)
+	if _, ok := byKind[otherKind]; ok {
+		kinds = append(kinds, otherKind)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# YAML File Details Across Repositories\n\n")
+	sb.WriteString("This index combines the YAML summaries of every repository in the batch, grouped by repository and by resource kind.\n")
+
+	sb.WriteString("\n## Contents\n")
+	sb.WriteString("- [By Repository](#by-repository)\n")
+	for _, res := range results {
+		fmt.Fprintf(&sb, "  - [%s](#%s)\n", res.Repo.Name, indexAnchor("repo", res.Repo.Name))
+	}
+	sb.WriteString("- [By Kind](#by-kind)\n")
+	for _, kind := range kinds {
+		fmt.Fprintf(&sb, "  - [%s](#%s) (%d)\n", kind, indexAnchor("kind", kind), len(byKind[kind]))
+	}
+
+	sb.WriteString("\n<a id=\"by-repository\"></a>\n\n## By Repository\n")
+	for _, res := range results {
+		fmt.Fprintf(&sb, "\n<a id=\"%s\"></a>\n\n### %s\n", indexAnchor("repo", res.Repo.Name), res.Repo.Name)
+		if res.Err != nil {
+			fmt.Fprintf(&sb, "_Summarization failed: %v_\n", res.Err)
+			continue
+		}
+		docLink, err := filepath.Rel(indexDir, res.Report.OutputPath)
+		if err != nil {
+			docLink = res.Report.OutputPath
+		}
+		fmt.Fprintf(&sb, "Full document: [%s](%s)\n\n", filepath.Base(res.Report.OutputPath), filepath.ToSlash(docLink))
+		for _, e := range entries {
+			if e.Repo == res.Repo.Name {
+				fmt.Fprintf(&sb, "- [%s](%s): %s\n", e.Rel, e.Link, e.Summary)
+			}
+		}
+	}
+
+	sb.WriteString("\n<a id=\"by-kind\"></a>\n\n## By Kind\n")
+	for _, kind := range kinds {
+		fmt.Fprintf(&sb, "\n<a id=\"%s\"></a>\n\n### %s\n\n", indexAnchor("kind", kind), kind)
+		for _, e := range byKind[kind] {
+			fmt.Fprintf(&sb, "- [%s](#%s) [%s](%s): %s\n", e.Repo, indexAnchor("repo", e.Repo), e.Rel, e.Link, e.Summary)
+		}
+	}
+	return os.WriteFile(indexPath, []byte(sb.String()), 0o644)
+}
+
+// batchCmd summarizes multiple repositories listed in a manifest file.
+var batchCmd = &cobra.Command{
+	Use:   "batch [manifest]",
+	Short: "Summarize every repository listed in a repos.yaml manifest",
+	Long: `Summarize every repository listed in a repos.yaml manifest. Each entry sets either a
+local path or a git URL (optionally with a ref) to clone. Per-repo documents are written
+into each repository and a combined cross-repo index is written next to the manifest.
+
+Example manifest:
+
+  repos:
+    - path: ./platform-manifests
+    - name: networking
+      url: https://github.com/my-org/networking.git
+      ref: main`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBatch(args[0])
+	},
+}
+
+var batchConcurrency int
+var batchIndexFileName string
+var batchWorkDir string
+
+func init() {
+	rootCmd.AddCommand(batchCmd)
+	batchCmd.Flags().IntVar(&batchConcurrency, "repo-concurrency", 1, "Number of repositories to summarize concurrently")
+	batchCmd.Flags().StringVar(&batchIndexFileName, "index", DefaultBatchIndexFileName, "Combined index filename, written next to the manifest")
+	batchCmd.Flags().StringVar(&batchWorkDir, "workdir", DefaultBatchWorkDirName, "Directory where git URLs are cloned, relative to the manifest")
+}
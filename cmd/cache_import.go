@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// cacheEntryPath returns the --localcache entry of rel, a path relative to the documented
+// directory, under repoRoot.
+func cacheEntryPath(repoRoot, rel string) string {
+	return filepath.Join(repoRoot, cacheDirName, strings.ReplaceAll(rel, string(os.PathSeparator), "_")+".md")
+}
+
+// docEntries reads the summaries of a markdown, GitHub wiki, or JSON document, keyed by
+// path relative to the documented directory, and the content hashes the document
+// recorded for them: JSON entry hashes, or --stable-entries keys.
+func docEntries(docPath string) (map[string]string, map[string]string, error) {
+	data, err := os.ReadFile(docPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	summaries := make(map[string]string)
+	hashes := make(map[string]string)
+	switch detectDocKind(data) {
+	case docKindHTML, docKindAsciiDoc:
+		return nil, nil, fmt.Errorf("%s is an HTML or AsciiDoc document; only markdown, GitHub wiki, and JSON documents can be imported", docPath)
+	case docKindJSON:
+		var output JSONOutput
+		if err := json.Unmarshal(data, &output); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse JSON document: %w", err)
+		}
+		for _, entries := range output.Directories {
+			for _, entry := range entries {
+				rel := filepath.Clean(entry.Path)
+				summaries[rel] = entry.Summary
+				if digest, ok := strings.CutPrefix(entry.Hash, "sha256:"); ok {
+					hashes[rel] = digest
+				}
+			}
+		}
+		return summaries, hashes, nil
+	}
+	lines := strings.Split(string(data), "\n")
+	parseSummaryLines(lines, summaries)
+	for _, line := range lines {
+		if m := entryKeyPattern.FindStringSubmatch(strings.TrimRight(line, "\r")); m != nil {
+			hashes[filepath.FromSlash(m[2])] = m[1]
+		}
+	}
+	return summaries, hashes, nil
+}
+
+// runCacheImport backfills the --localcache entries of dir from the summaries in the
+// document at docPath, without calling the LLM. Entries of files that no longer exist,
+// or whose content changed since the document recorded their hash, are left out, and
+// existing cache entries are kept.
+func runCacheImport(docPath, dir string) error {
+	setupLogging()
+	summaries, hashes, err := docEntries(docPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", docPath, err)
+	}
+	repoRoot := cacheRoot()
+	var imported, cached, missing, stale int
+	for _, rel := range slices.Sorted(maps.Keys(summaries)) {
+		file := filepath.Join(dir, rel)
+		if _, err := os.Stat(file); err != nil {
+			missing++
+			continue
+		}
+		if recorded := hashes[rel]; recorded != "" {
+			if digest, err := fileSHA256(file); err != nil || digest != recorded {
+				stale++
+				continue
+			}
+		}
+		if _, err := os.Stat(cacheEntryPath(repoRoot, rel)); err == nil {
+			cached++
+			continue
+		}
+		imported++
+		if dryRun {
+			statusf("Would import %s\n", filepath.ToSlash(rel))
+			continue
+		}
+		if err := writeIndividualSummary(repoRoot, dir, file, summaries[rel]); err != nil {
+			return fmt.Errorf("failed to write cache entry for %s: %w", rel, err)
+		}
+	}
+	porcelainf("imported", "document", docPath, "imported", imported, "cached", cached, "missing", missing, "stale", stale, "dry_run", dryRun)
+	verb := "Imported"
+	if dryRun {
+		verb = "Would import"
+	}
+	statusf("%s %d summaries from %s into %s\n", verb, imported, docPath, filepath.Join(repoRoot, cacheDirName))
+	statusf("Already cached: %d, file missing: %d, file changed: %d\n", cached, missing, stale)
+	return nil
+}
+
+// cacheCmd groups the --localcache subcommands.
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Work with the --localcache summary cache",
+}
+
+// cacheImportCmd backfills the cache from an existing document.
+var cacheImportCmd = &cobra.Command{
+	Use:   "import-from-doc <document> [directory]",
+	Short: "Backfill the summary cache from an existing document without calling the LLM",
+	Long: `Write a --localcache entry for every summary in a markdown, GitHub wiki, or JSON
+document, so an existing document primes the cache without summarizing anything again.
+The directory the document describes defaults to the document's directory. Entries of
+files that no longer exist are skipped, and so are files whose content changed since
+the document was written, when it recorded hashes (JSON documents and documents written
+with --stable-entries). Existing cache entries are kept. With --dry-run, only report
+what would be imported.`,
+	Args:         cobra.RangeArgs(1, 2),
+	SilenceUsage: true,
+	// The config file is looked up in the directory, not the document
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return loadConfig(cmd, args[1:])
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := filepath.Dir(args[0])
+		if len(args) == 2 {
+			dir = args[1]
+		}
+		return runCacheImport(args[0], dir)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheImportCmd)
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// changelogRuns is how many runs the --changelog section of the document keeps, newest
+// first. 0 leaves the section out.
+var changelogRuns int
+
+// maxChangelogFiles is how many files a change log entry names per kind of change
+// before counting the rest.
+const maxChangelogFiles = 5
+
+// validateChangelog checks --changelog, whose history is read back from the document.
+func validateChangelog() error {
+	if changelogRuns < 0 {
+		return fmt.Errorf("--changelog must not be negative, got %d", changelogRuns)
+	}
+	if changelogRuns > 0 && !markdownSummariesSupported() {
+		return fmt.Errorf("--changelog requires --format markdown or github-wiki")
+	}
+	return nil
+}
+
+// parseChangelog reads the change log entries of an existing document, newest first.
+func parseChangelog(lines []string) []appendixEntry {
+	heading := "## " + currentLabels().ChangeLog
+	var entries []appendixEntry
+	inSection := false
+	for _, line := range lines {
+		switch {
+		case line == heading:
+			inSection = true
+		case strings.HasPrefix(line, "## ") || strings.HasPrefix(line, "<!--"):
+			inSection = false
+		case inSection && strings.HasPrefix(line, "- `"):
+			run, note, ok := strings.Cut(strings.TrimPrefix(line, "- `"), "` — ")
+			if ok && note != "" {
+				entries = append(entries, appendixEntry{Path: run, Note: note})
+			}
+		}
+	}
+	return entries
+}
+
+// changelogFiles lists the files of one kind of change as code spans, naming at most
+// maxChangelogFiles and counting the rest.
+func changelogFiles(label string, files []string) string {
+	var refs []string
+	for i, file := range files {
+		if i == maxChangelogFiles {
+			refs[len(refs)-1] += fmt.Sprintf(" +%d", len(files)-maxChangelogFiles)
+			break
+		}
+		refs = append(refs, "`"+file+"`")
+	}
+	return fmt.Sprintf("%s: %s", label, strings.Join(refs, ", "))
+}
+
+// changelogNote describes the entries a run added, updated, and removed, or returns an
+// empty string if it changed nothing.
+func changelogNote(added, updated, removed []string) string {
+	l := currentLabels()
+	var parts []string
+	if len(added) > 0 {
+		parts = append(parts, changelogFiles(l.ChangeLogAdded, added))
+	}
+	if len(updated) > 0 {
+		parts = append(parts, changelogFiles(l.ChangeLogUpdated, updated))
+	}
+	if len(removed) > 0 {
+		parts = append(parts, changelogFiles(l.ChangeLogRemoved, removed))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// removedEntries returns the slash-separated paths of entries in the existing document
+// whose files are no longer documented, sorted.
+func removedEntries(dir string, yamlFiles []string, existing map[string]string) []string {
+	current := make(map[string]bool, len(yamlFiles))
+	for _, file := range yamlFiles {
+		if rel, err := filepath.Rel(dir, file); err == nil {
+			current[rel] = true
+		}
+	}
+	var removed []string
+	for rel := range existing {
+		if !current[rel] {
+			removed = append(removed, filepath.ToSlash(rel))
+		}
+	}
+	slices.Sort(removed)
+	return removed
+}
+
+// changelogAppendix renders the --changelog section: this run's changes, if any,
+// followed by the entries of earlier runs in the document at mdPath, keeping
+// changelogRuns entries.
+func changelogAppendix(mdPath, dir string, yamlFiles []string, existing, summaries map[string]string) []docAppendix {
+	entries := parseChangelog(existingDocumentLines(mdPath))
+
+	added, changed := summaryChanges(dir, yamlFiles, existing, summaries)
+	var addedPaths, updatedPaths []string
+	for _, c := range added {
+		addedPaths = append(addedPaths, c.Rel)
+	}
+	for _, c := range changed {
+		updatedPaths = append(updatedPaths, c.Rel)
+	}
+	if note := changelogNote(addedPaths, updatedPaths, removedEntries(dir, yamlFiles, existing)); note != "" {
+		entries = append([]appendixEntry{{Path: generatedAt(), Note: note}}, entries...)
+	}
+	if len(entries) > changelogRuns {
+		entries = entries[:changelogRuns]
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	l := currentLabels()
+	return []docAppendix{{Title: l.ChangeLog, Intro: l.ChangeLogIntro, Entries: entries}}
+}
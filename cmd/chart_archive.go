@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// chartArchives documents packaged Helm charts vendored as .tgz files under charts/
+// directories, from their Chart.yaml rather than their templates.
+var chartArchives bool
+
+// chartArchiveSuffix is the file suffix of a packaged Helm chart.
+const chartArchiveSuffix = ".tgz"
+
+// maxChartYAMLBytes bounds how much of a Chart.yaml is read from an archive.
+const maxChartYAMLBytes = 1 << 20
+
+// isChartArchive reports whether file is a packaged chart in a charts/ directory, where
+// Helm keeps a chart's vendored dependencies.
+func isChartArchive(file string) bool {
+	return strings.HasSuffix(strings.ToLower(file), chartArchiveSuffix) && filepath.Base(filepath.Dir(file)) == "charts"
+}
+
+// chartArchiveMetadata returns what is summarized for a packaged chart: its Chart.yaml,
+// followed by a comment listing the template file names, so the chart is described
+// without sending every template.
+func chartArchiveMetadata(file string) ([]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chart archive %s: %w", file, err)
+	}
+	tr := tar.NewReader(gz)
+	var chartYAML []byte
+	var templates []string
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chart archive %s: %w", file, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		// Entries are <chart>/...; files of nested dependencies are left out
+		name := path.Clean(hdr.Name)
+		chartDir, rest, ok := strings.Cut(name, "/")
+		if !ok || chartDir == "" {
+			continue
+		}
+		switch {
+		case rest == "Chart.yaml":
+			if chartYAML, err = io.ReadAll(io.LimitReader(tr, maxChartYAMLBytes)); err != nil {
+				return nil, fmt.Errorf("failed to read chart archive %s: %w", file, err)
+			}
+		case strings.HasPrefix(rest, "templates/"):
+			templates = append(templates, strings.TrimPrefix(rest, "templates/"))
+		}
+	}
+	if chartYAML == nil {
+		return nil, fmt.Errorf("no Chart.yaml found in %s", file)
+	}
+	out := fmt.Sprintf("# Chart.yaml of the packaged Helm chart %s\n%s", filepath.Base(file), chartYAML)
+	if !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	if len(templates) > 0 {
+		out += "# Templates: " + strings.Join(templates, ", ") + "\n"
+	}
+	return []byte(out), nil
+}
+
+// readSummarySource returns the content summarized for file: the file itself, or the
+// metadata of a packaged chart.
+func readSummarySource(file string) ([]byte, error) {
+	if isChartArchive(file) {
+		return chartArchiveMetadata(file)
+	}
+	return os.ReadFile(file)
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// DefaultChatHistory is how many previous questions and answers are sent with each new
+// question in a chat session.
+const DefaultChatHistory = 6
+
+// ChatPrompt is the instruction sent to the LLM with each chat question, the
+// conversation so far, and the retrieved files.
+const ChatPrompt = "You are helping someone explore a repository of YAML manifests. Answer the latest question using the conversation so far and only the numbered YAML files below. Cite every file you rely on by its number in square brackets, like [1]. If the files do not answer the question, say so. Do not use markdown headings.\n\n"
+
+var chatHistory int
+
+// chatTurn is one question and answer of a chat session.
+type chatTurn struct {
+	Question string
+	Answer   string
+}
+
+// chatSession holds the conversation and the index questions are answered from.
+type chatSession struct {
+	dir   string
+	llm   LLMProvider
+	index *askIndex
+	turns []chatTurn
+}
+
+// retrievalQuery returns the text used to retrieve files for question. The previous
+// question is included so follow-ups such as "and its replicas?" find the same files.
+func (s *chatSession) retrievalQuery(question string) string {
+	if len(s.turns) == 0 {
+		return question
+	}
+	return s.turns[len(s.turns)-1].Question + " " + question
+}
+
+// chatContent renders the recent conversation followed by the question and the
+// retrieved files.
+func (s *chatSession) chatContent(question string, retrieved []askPassage) string {
+	var sb strings.Builder
+	if history := s.turns[max(len(s.turns)-chatHistory, 0):]; chatHistory > 0 && len(history) > 0 {
+		sb.WriteString("Conversation so far:\n")
+		for _, turn := range history {
+			fmt.Fprintf(&sb, "Q: %s\nA: %s\n", turn.Question, turn.Answer)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(askContent(question, retrieved))
+	return sb.String()
+}
+
+// ask answers question, writing the answer and its sources to w, and records the turn.
+func (s *chatSession) ask(ctx context.Context, w io.Writer, question string) error {
+	retrieved := s.index.retrieve(ctx, s.retrievalQuery(question), max(askTopK, 1))
+	slog.Debug("retrieved files", "question", question, "count", len(retrieved))
+	answer, err := s.llm.Summarize(ctx, s.chatContent(question, retrieved), localizedPrompt(ChatPrompt))
+	if err != nil {
+		return fmt.Errorf("%s error: %w", s.llm.Name(), err)
+	}
+	s.turns = append(s.turns, chatTurn{Question: question, Answer: strings.TrimSpace(answer)})
+	return writeAnswer(w, s.dir, answer, retrieved)
+}
+
+// runChat is the main logic for the chat command.
+func runChat(dir string) error {
+	setupLogging()
+	llm, err := createProvider()
+	if err != nil {
+		return fmt.Errorf("failed to create %s provider: %w", provider, err)
+	}
+	return runChatWithProvider(os.Stdin, os.Stdout, dir, llm)
+}
+
+// runChatWithProvider runs a chat session, recorded in the --audit-log as one run.
+func runChatWithProvider(in io.Reader, out io.Writer, dir string, llm LLMProvider) error {
+	return auditRun(dir, llm, func(llm LLMProvider) error {
+		return runChatSession(in, out, dir, llm)
+	})
+}
+
+// runChatSession reads questions from in, one per line, and answers each on out until
+// in ends or the user types exit or quit. /reset forgets the conversation. A failed
+// question is reported and the session continues.
+func runChatSession(in io.Reader, out io.Writer, dir string, llm LLMProvider) error {
+	passages, err := loadInventory(dir)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	session := &chatSession{dir: dir, llm: llm, index: newAskIndex(ctx, llm, dir, passages)}
+	if _, err := fmt.Fprintf(out, "Ask about the %d files documented in %s. Type /reset to start over, exit to quit.\n", len(passages), docPathFor(dir)); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(in)
+	for {
+		if _, err := io.WriteString(out, "> "); err != nil {
+			return err
+		}
+		if !scanner.Scan() {
+			_, err := io.WriteString(out, "\n")
+			if err == nil {
+				err = scanner.Err()
+			}
+			return err
+		}
+		question := strings.TrimSpace(scanner.Text())
+		switch question {
+		case "":
+			continue
+		case "exit", "quit":
+			return nil
+		case "/reset":
+			session.turns = nil
+			if _, err := io.WriteString(out, "Conversation cleared.\n"); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := session.ask(ctx, out, question); err != nil {
+			if _, err := fmt.Fprintf(out, "Error: %v\n", err); err != nil {
+				return err
+			}
+		}
+		if _, err := io.WriteString(out, "\n"); err != nil {
+			return err
+		}
+	}
+}
+
+// chatCmd starts an interactive question-and-answer session over the YAML files.
+var chatCmd = &cobra.Command{
+	Use:   "chat [directory]",
+	Short: "Explore the YAML files in an interactive question-and-answer session",
+	Long: `Start an interactive session that answers questions about the directory (default:
+the current directory) from its generated document, like ask. Earlier questions and
+answers are kept as context, so follow-up questions work. Type /reset to forget the
+conversation and exit or quit (or Ctrl-D) to leave.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := "."
+		if len(args) == 1 {
+			dir = args[0]
+		}
+		return runChat(dir)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(chatCmd)
+	chatCmd.Flags().IntVar(&askTopK, "top-k", DefaultAskTopK, "Number of files retrieved to answer each question")
+	chatCmd.Flags().StringVar(&embedModel, "embed-model", "", "Embedding model used to retrieve files (default: nomic-embed-text for ollama, text-embedding-3-small for openai)")
+	chatCmd.Flags().IntVar(&chatHistory, "history", DefaultChatHistory, "Number of previous questions and answers sent with each question")
+}
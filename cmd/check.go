@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// docStaleness lists the YAML files whose document entries are out of date, relative to
+// the base directory.
+type docStaleness struct {
+	// New files have no summary in the document.
+	New []string
+	// Changed files were modified after the document was written.
+	Changed []string
+	// Removed files have a summary but no longer exist.
+	Removed []string
+	// ContentChanged is set when the inputs checksum differs although no file could be
+	// attributed, such as when the document was generated from uncommitted changes.
+	ContentChanged bool
+}
+
+// stale reports whether the document needs to be regenerated.
+func (s *docStaleness) stale() bool {
+	return len(s.New) > 0 || len(s.Changed) > 0 || len(s.Removed) > 0 || s.ContentChanged
+}
+
+// gitModifiedSince returns the files under dir that differ from the commit that last
+// touched docPath, relative to dir. It reports false when the document is not committed
+// or has uncommitted changes, so the commit does not describe what it was generated from.
+func gitModifiedSince(dir, docPath string) (map[string]bool, bool) {
+	absDoc, err := filepath.Abs(docPath)
+	if err != nil {
+		return nil, false
+	}
+	git := func(args ...string) (string, error) {
+		out, err := exec.Command("git", append([]string{"-C", dir, "-c", "core.quotepath=off"}, args...)...).Output()
+		return strings.TrimSpace(string(out)), err
+	}
+	commit, err := git("log", "-1", "--format=%H", "--", absDoc)
+	if err != nil || commit == "" {
+		return nil, false
+	}
+	if dirty, err := git("status", "--porcelain", "--", absDoc); err != nil || dirty != "" {
+		return nil, false
+	}
+	out, err := git("diff", "--name-only", "--relative", commit, "--")
+	if err != nil {
+		return nil, false
+	}
+	modified := make(map[string]bool)
+	for _, line := range strings.Split(out, "\n") {
+		if line != "" {
+			modified[line] = true
+		}
+	}
+	return modified, true
+}
+
+// checkDocStaleness compares the YAML files under dir with the entries of its document,
+// without calling the LLM. Changed files are those whose content differs from the one
+// their summary was generated from, by the hashes the document records. For entries
+// without one, they are found with git when the document is committed, since a checkout
+// gives every file the same modification time, and by modification time otherwise.
+func checkDocStaleness(dir string) (*docStaleness, error) {
+	yamlFiles, err := findYAMLFiles(dir, includeHidden)
+	if err != nil {
+		return nil, err
+	}
+	yamlFiles, _ = partitionGenerated(dir, yamlFiles)
+	docPath := docPathFor(dir)
+	existing := parseExistingSummaries(docPath)
+	sources := readDocSources(docPath)
+
+	gitModified, useGit := gitModifiedSince(dir, docPath)
+	var docInfo os.FileInfo
+	if !useGit {
+		docInfo, _ = os.Stat(docPath)
+	}
+
+	s := &docStaleness{}
+	rels := make([]string, 0, len(yamlFiles))
+	found := make(map[string]bool, len(yamlFiles))
+	for _, file := range yamlFiles {
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return nil, err
+		}
+		rels = append(rels, rel)
+		rel = filepath.ToSlash(rel)
+		found[rel] = true
+		switch {
+		case existing[rel] == "":
+			s.New = append(s.New, rel)
+		case sources[rel] != "":
+			// The recorded hash is exact, so it overrides git and modification times
+			if sources[rel] != currentDigest(file) {
+				s.Changed = append(s.Changed, rel)
+			}
+		case useGit:
+			if gitModified[rel] {
+				s.Changed = append(s.Changed, rel)
+			}
+		case docInfo != nil:
+			if info, err := os.Stat(file); err == nil && info.ModTime().After(docInfo.ModTime()) {
+				s.Changed = append(s.Changed, rel)
+			}
+		}
+	}
+	for rel := range existing {
+		if !found[rel] {
+			s.Removed = append(s.Removed, rel)
+		}
+	}
+	sort.Strings(s.New)
+	sort.Strings(s.Changed)
+	sort.Strings(s.Removed)
+
+	// Documents without per-entry hashes, such as HTML and AsciiDoc, still record the
+	// inputs checksum, which catches edits no other check attributed to a file
+	if recorded, err := readInputsChecksum(docPath); err == nil && recorded != "" && !s.stale() {
+		s.ContentChanged = inputsChecksum(dir, rels) != recorded
+	}
+	return s, nil
+}
+
+// runCheck is the main logic for the check command.
+func runCheck(w io.Writer, dir string) error {
+	setupLogging()
+	docPath := docPathFor(dir)
+	if _, err := os.Stat(docPath); err != nil {
+		return fmt.Errorf("%s does not exist; generate it first", docPath)
+	}
+	s, err := checkDocStaleness(dir)
+	if err != nil {
+		return err
+	}
+	if !s.stale() {
+		porcelainf("check", "status", "up-to-date")
+		if !porcelain {
+			_, _ = fmt.Fprintf(w, "%s is up to date\n", docPath)
+		}
+		return nil
+	}
+
+	if !porcelain {
+		_, _ = fmt.Fprintf(w, "%s is out of date:\n", docPath)
+	}
+	for _, group := range []struct {
+		status string
+		files  []string
+	}{{"new", s.New}, {"changed", s.Changed}, {"removed", s.Removed}} {
+		for _, rel := range group.files {
+			porcelainf("stale", "path", rel, "status", group.status)
+			if !porcelain {
+				_, _ = fmt.Fprintf(w, "  %-8s %s\n", group.status, rel)
+			}
+		}
+	}
+	if s.ContentChanged && !porcelain {
+		_, _ = fmt.Fprintln(w, "  YAML file contents changed since it was generated")
+	}
+	porcelainf("check", "status", "stale", "new", len(s.New), "changed", len(s.Changed), "removed", len(s.Removed))
+	return fmt.Errorf("%s is out of date: %d new, %d changed, %d removed; regenerate it with summarize-yaml %s",
+		docPath, len(s.New), len(s.Changed), len(s.Removed), dir)
+}
+
+// checkCmd reports which files a document is missing or out of date for.
+var checkCmd = &cobra.Command{
+	Use:   "check [directory]",
+	Short: "Report new, changed, and removed YAML files and fail if the document is stale",
+	Long: `List the YAML files that have no summary in the document, were changed after it
+was written, or were removed since, and exit non-zero if there are any. No LLM calls are
+made, so this can gate pull requests on an up-to-date document. When the document is
+committed, changed files are those that differ from the commit that last touched it.`,
+	Args: cobra.ExactArgs(1),
+	// A stale document is reported by runCheck; the usage text adds nothing.
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCheck(cmd.OutOrStdout(), args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+}
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// maxOutlineItems caps how many stages, jobs, or triggers are listed in the prompt.
+const maxOutlineItems = 20
+
+// ciOutline is the structure extracted from a CI pipeline file.
+type ciOutline struct {
+	Stages   []string
+	Jobs     []string
+	Triggers []string
+}
+
+// ciProfile recognizes one CI system's pipeline files and extracts their outline.
+type ciProfile struct {
+	Name    string
+	Match   func(file string) bool
+	Extract func(doc map[string]any) ciOutline
+}
+
+// ciProfiles are checked in order against every file.
+var ciProfiles = []ciProfile{
+	{
+		Name: "GitLab CI",
+		Match: func(file string) bool {
+			name := filepath.Base(file)
+			return name == ".gitlab-ci.yml" || name == ".gitlab-ci.yaml"
+		},
+		Extract: gitlabOutline,
+	},
+	{
+		Name: "CircleCI",
+		Match: func(file string) bool {
+			name := filepath.Base(file)
+			return filepath.Base(filepath.Dir(file)) == ".circleci" && (name == "config.yml" || name == "config.yaml")
+		},
+		Extract: circleCIOutline,
+	},
+	{
+		Name: "Azure Pipelines",
+		Match: func(file string) bool {
+			return strings.HasPrefix(filepath.Base(file), "azure-pipelines")
+		},
+		Extract: azureOutline,
+	},
+}
+
+// gitlabReservedKeys are top-level .gitlab-ci.yml keys that are not jobs.
+var gitlabReservedKeys = map[string]bool{
+	"stages": true, "variables": true, "default": true, "include": true, "workflow": true,
+	"image": true, "services": true, "before_script": true, "after_script": true, "cache": true,
+}
+
+// scalarStrings returns the string items of a list, or the value itself if it is a string.
+func scalarStrings(v any) []string {
+	switch val := v.(type) {
+	case string:
+		return []string{val}
+	case []any:
+		var items []string
+		for _, item := range val {
+			if s, ok := item.(string); ok {
+				items = append(items, s)
+			}
+		}
+		return items
+	}
+	return nil
+}
+
+// gitlabOutline extracts stages, jobs, and workflow rules from a .gitlab-ci.yml.
+func gitlabOutline(doc map[string]any) ciOutline {
+	outline := ciOutline{Stages: scalarStrings(doc["stages"])}
+	for key := range doc {
+		if !gitlabReservedKeys[key] && !strings.HasPrefix(key, ".") {
+			outline.Jobs = append(outline.Jobs, key)
+		}
+	}
+	slices.Sort(outline.Jobs)
+	workflow, _ := doc["workflow"].(map[string]any)
+	for _, rule := range mapSlice(workflow, "rules") {
+		if cond := stringField(rule, "if"); cond != "" {
+			outline.Triggers = append(outline.Triggers, cond)
+		}
+	}
+	return outline
+}
+
+// circleCIOutline extracts workflows, jobs, and scheduled or filtered triggers from a
+// .circleci/config.yml. Workflows are reported as stages.
+func circleCIOutline(doc map[string]any) ciOutline {
+	var outline ciOutline
+	if jobs, ok := doc["jobs"].(map[string]any); ok {
+		for name := range jobs {
+			outline.Jobs = append(outline.Jobs, name)
+		}
+		slices.Sort(outline.Jobs)
+	}
+	workflows, _ := doc["workflows"].(map[string]any)
+	for name, wf := range workflows {
+		w, ok := wf.(map[string]any)
+		if !ok {
+			continue
+		}
+		outline.Stages = append(outline.Stages, name)
+		for _, trigger := range mapSlice(w, "triggers") {
+			schedule, _ := trigger["schedule"].(map[string]any)
+			if cron := stringField(schedule, "cron"); cron != "" {
+				outline.Triggers = append(outline.Triggers, fmt.Sprintf("%s on schedule %q", name, cron))
+			}
+		}
+	}
+	slices.Sort(outline.Stages)
+	slices.Sort(outline.Triggers)
+	return outline
+}
+
+// azureBranches returns the branches of an Azure trigger or pr value, which is "none", a
+// list of branches, or a mapping with branches.include.
+func azureBranches(v any) []string {
+	if m, ok := v.(map[string]any); ok {
+		branches, _ := m["branches"].(map[string]any)
+		return scalarStrings(branches["include"])
+	}
+	return scalarStrings(v)
+}
+
+// azureOutline extracts stages, jobs, and triggers from an azure-pipelines.yml.
+func azureOutline(doc map[string]any) ciOutline {
+	var outline ciOutline
+	addJobs := func(parent map[string]any) {
+		for _, job := range mapSlice(parent, "jobs") {
+			name := stringField(job, "job")
+			if name == "" {
+				name = stringField(job, "deployment")
+			}
+			if name != "" {
+				outline.Jobs = append(outline.Jobs, name)
+			}
+		}
+	}
+	for _, stage := range mapSlice(doc, "stages") {
+		if name := stringField(stage, "stage"); name != "" {
+			outline.Stages = append(outline.Stages, name)
+		}
+		addJobs(stage)
+	}
+	addJobs(doc)
+	if branches := azureBranches(doc["trigger"]); len(branches) > 0 {
+		outline.Triggers = append(outline.Triggers, "push to "+strings.Join(branches, ", "))
+	}
+	if branches := azureBranches(doc["pr"]); len(branches) > 0 {
+		outline.Triggers = append(outline.Triggers, "pull requests to "+strings.Join(branches, ", "))
+	}
+	for _, schedule := range mapSlice(doc, "schedules") {
+		if cron := stringField(schedule, "cron"); cron != "" {
+			outline.Triggers = append(outline.Triggers, fmt.Sprintf("schedule %q", cron))
+		}
+	}
+	return outline
+}
+
+// outlineList renders up to maxOutlineItems items.
+func outlineList(items []string) string {
+	if len(items) > maxOutlineItems {
+		return strings.Join(items[:maxOutlineItems], ", ") + fmt.Sprintf(" and %d more", len(items)-maxOutlineItems)
+	}
+	return strings.Join(items, ", ")
+}
+
+// ciContext tells the LLM that a file is a CI pipeline and gives it the extracted
+// stages, jobs, and triggers, so it explains what the pipeline does and when it runs.
+// It returns an empty string for files that are not recognized pipeline files.
+func ciContext(file string) string {
+	for _, profile := range ciProfiles {
+		if !profile.Match(file) {
+			continue
+		}
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "This file is a CI pipeline for %s.", profile.Name)
+		if data, err := os.ReadFile(file); err == nil {
+			if docs, _ := decodeYAMLDocuments(data); len(docs) > 0 {
+				outline := profile.Extract(docs[0])
+				for _, part := range []struct {
+					label string
+					items []string
+				}{{"Stages", outline.Stages}, {"Jobs", outline.Jobs}, {"Triggers", outline.Triggers}} {
+					if len(part.items) > 0 {
+						fmt.Fprintf(&sb, " %s: %s.", part.label, outlineList(part.items))
+					}
+				}
+			}
+		}
+		sb.WriteString(" Summarize when the pipeline runs and what its stages accomplish instead of listing its keys.\n")
+		return sb.String()
+	}
+	return ""
+}
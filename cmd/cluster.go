@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/sebrandon1/yaml-to-readme/summarizer"
+	"github.com/spf13/cobra"
+)
+
+// kubectlCommand is the kubectl binary used to read from the cluster. Shelling out to
+// kubectl rather than linking client-go picks up the user's kubeconfig, contexts, and
+// credential plugins exactly as kubectl does, and keeps the Kubernetes client libraries
+// out of the binary and the summarizer package.
+var kubectlCommand = "kubectl"
+
+// requireKubectl checks that kubectl can be run before anything is read from the
+// cluster.
+func requireKubectl() error {
+	if _, err := exec.LookPath(kubectlCommand); err != nil {
+		return fmt.Errorf("the cluster command reads resources with kubectl, which must be on PATH and configured for the cluster: %w", err)
+	}
+	return nil
+}
+
+// DefaultClusterKinds are the resource kinds inventoried when --kind is not set. Secrets
+// are left out by default; if requested, their data is never sent to the LLM.
+var DefaultClusterKinds = []string{"deployments", "statefulsets", "daemonsets", "cronjobs", "services", "ingresses", "configmaps"}
+
+// runKubectl runs kubectl against kubeContext, or the current context if it is empty,
+// and returns its stdout, including stderr in the error.
+func runKubectl(kubeContext string, args ...string) ([]byte, error) {
+	cmdArgs := args
+	if kubeContext != "" {
+		cmdArgs = append([]string{"--context", kubeContext}, args...)
+	}
+	cmd := exec.Command(kubectlCommand, cmdArgs...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("kubectl %s failed: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// resourceIdentity returns the kind, namespace, and name of a resource.
+func resourceIdentity(obj map[string]any) (string, string, string) {
+	kind, _ := obj["kind"].(string)
+	meta, _ := obj["metadata"].(map[string]any)
+	namespace, _ := meta["namespace"].(string)
+	name, _ := meta["name"].(string)
+	return kind, namespace, name
+}
+
+// stageClusterResources reads every requested kind and namespace from the cluster into
+// dir and returns how many resources were staged.
+func stageClusterResources(dir string) (int, error) {
+	scanner := summarizer.KubernetesScanner{
+		Context:    clusterContext,
+		Namespaces: clusterNamespaces,
+		Kinds:      clusterKinds,
+		Command:    kubectlCommand,
+	}
+	files, err := scanner.Scan(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	for i, file := range files {
+		data, err := file.Read()
+		if err != nil {
+			return i, err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(file.Path))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return i, err
+		}
+		if err := os.WriteFile(target, data, 0o644); err != nil {
+			return i, err
+		}
+	}
+	return len(files), nil
+}
+
+// inventoryDocPath returns where a cluster inventory document is written, named after
+// the inventory kind and kubeconfig context.
+func inventoryDocPath(prefix string) string {
+	name := clusterContext
+	if name == "" {
+		name = "current"
+	}
+	return filepath.Join(clusterOutputDir, prefix+"_"+name+"_"+markdownFileName)
+}
+
+// runCluster is the main logic for the cluster command.
+func runCluster() error {
+	setupLogging()
+	if err := validateLang(); err != nil {
+		return err
+	}
+	llm, err := createProvider()
+	if err != nil {
+		return fmt.Errorf("failed to create %s provider: %w", provider, err)
+	}
+	return runClusterWithProvider(llm)
+}
+
+// runClusterWithProvider inventories the live cluster and summarizes it with the given provider.
+func runClusterWithProvider(llm LLMProvider) error {
+	if err := requireKubectl(); err != nil {
+		return err
+	}
+	dir, err := os.MkdirTemp("", "yaml-to-readme-cluster-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	staged, err := stageClusterResources(dir)
+	if err != nil {
+		return err
+	}
+	statusf("Read %d resources from the cluster\n", staged)
+	report, err := summarizeStagedTree(dir, inventoryDocPath("cluster"), llm)
+	if err != nil {
+		return err
+	}
+	printRunReport(report)
+	return nil
+}
+
+// clusterCmd documents what is actually running in a live cluster.
+var clusterCmd = &cobra.Command{
+	Use:   "cluster",
+	Short: "Summarize the resources running in a live Kubernetes cluster",
+	Long: `Read resources from a live cluster with kubectl, filtered by namespace and kind, strip
+server-populated fields such as status and managed fields, and summarize them into the
+same style of document, grouped by namespace and kind. Secret values are never read into
+the document or sent to the LLM.
+
+kubectl must be on PATH and configured for the cluster. It is used instead of the
+client-go library so that the kubeconfig, contexts, and credential plugins (such as
+cloud provider exec plugins) work exactly as they do for kubectl, without linking the
+Kubernetes client libraries into this tool.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCluster()
+	},
+}
+
+var clusterContext string
+var clusterNamespaces []string
+var clusterKinds []string
+var clusterOutputDir string
+
+func init() {
+	rootCmd.AddCommand(clusterCmd)
+	clusterCmd.Flags().StringVar(&clusterContext, "context", "", "kubeconfig context to read from (default: current context)")
+	clusterCmd.Flags().StringSliceVarP(&clusterNamespaces, "namespace", "n", nil, "Namespaces to read (default: all namespaces)")
+	clusterCmd.Flags().StringSliceVar(&clusterKinds, "kind", DefaultClusterKinds, "Resource kinds to read")
+	clusterCmd.Flags().StringVar(&clusterOutputDir, "output-dir", ".", "Directory to write the cluster document to")
+}
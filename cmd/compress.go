@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultContextTokens is the context window, in tokens, files are compressed to fit.
+const DefaultContextTokens = 8192
+
+// contextResponseTokens is kept free in the context window for the model's answer.
+const contextResponseTokens = 512
+
+// compressKeepItems is how many items of a run of same-shaped list items are kept.
+const compressKeepItems = 3
+
+// blockScalarPattern matches a line that opens a literal or folded block scalar.
+var blockScalarPattern = regexp.MustCompile(`(^|:\s|^\s*-\s)\s*[|>][-+0-9]*\s*(#.*)?$`)
+
+// base64RunPattern matches a base64 value long enough to be worth eliding.
+var base64RunPattern = regexp.MustCompile(`^[A-Za-z0-9+/]{64,}={0,2}$`)
+
+// base64LinePattern matches one line of a wrapped base64 value, such as a PEM body.
+var base64LinePattern = regexp.MustCompile(`^[A-Za-z0-9+/]{40,}={0,2}$`)
+
+// compressLine is one line of a YAML file being compressed.
+type compressLine struct {
+	text   string
+	indent int
+	// block is set for the content lines of a block scalar, which are not YAML structure.
+	block bool
+}
+
+// compressStep is one way of shrinking a file, in the order they are tried.
+type compressStep struct {
+	name  string
+	apply func([]compressLine) []compressLine
+}
+
+// compressSteps are applied cumulatively, cheapest and least lossy first.
+var compressSteps = []compressStep{
+	{"comments", dropComments},
+	{"base64", elideBase64},
+	{"repeated-items", collapseRepeatedItems},
+}
+
+// compressToFit shrinks a YAML file until it fits in budget tokens, applying
+// compressSteps in order and stopping as soon as it fits. It returns the content and the
+// steps applied; content that already fits is returned unchanged. Content that does not
+// fit even after every step is returned fully compressed.
+func compressToFit(content string, budget int, tok Tokenizer) (string, []string) {
+	if tok.Count(content) <= budget {
+		return content, nil
+	}
+	lines := splitCompressLines(content)
+	var applied []string
+	for _, step := range compressSteps {
+		next := step.apply(lines)
+		if len(next) == len(lines) && joinCompressLines(next) == joinCompressLines(lines) {
+			continue
+		}
+		lines = next
+		applied = append(applied, step.name)
+		if tok.Count(joinCompressLines(lines)) <= budget {
+			break
+		}
+	}
+	if len(applied) == 0 {
+		return content, nil
+	}
+	return joinCompressLines(lines), applied
+}
+
+// splitCompressLines splits content into lines, marking the content of block scalars.
+func splitCompressLines(content string) []compressLine {
+	raw := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	lines := make([]compressLine, len(raw))
+	blockIndent := -1
+	for i, text := range raw {
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+		blank := strings.TrimSpace(text) == ""
+		if blockIndent >= 0 && (blank || indent > blockIndent) {
+			lines[i] = compressLine{text: text, indent: indent, block: true}
+			continue
+		}
+		blockIndent = -1
+		lines[i] = compressLine{text: text, indent: indent}
+		if !strings.HasPrefix(strings.TrimSpace(text), "#") && blockScalarPattern.MatchString(text) {
+			blockIndent = indent
+		}
+	}
+	return lines
+}
+
+// joinCompressLines joins lines back into file content.
+func joinCompressLines(lines []compressLine) string {
+	texts := make([]string, len(lines))
+	for i, l := range lines {
+		texts[i] = l.text
+	}
+	return strings.Join(texts, "\n") + "\n"
+}
+
+// dropComments removes blank lines and full-line comments outside block scalars.
+func dropComments(lines []compressLine) []compressLine {
+	var out []compressLine
+	for _, l := range lines {
+		trimmed := strings.TrimSpace(l.text)
+		if !l.block && (trimmed == "" || strings.HasPrefix(trimmed, "#")) {
+			continue
+		}
+		out = append(out, l)
+	}
+	return out
+}
+
+// base64Placeholder replaces an elided value of n bytes.
+func base64Placeholder(n int) string {
+	return fmt.Sprintf("<base64, %d bytes elided>", n)
+}
+
+// elideBase64 replaces long base64 values, inline or wrapped in a block scalar, with a
+// placeholder giving their size.
+func elideBase64(lines []compressLine) []compressLine {
+	var out []compressLine
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+		if l.block {
+			// Collapse a run of wrapped base64 lines into one placeholder
+			j, size := i, 0
+			for j < len(lines) && lines[j].block && base64LinePattern.MatchString(strings.TrimSpace(lines[j].text)) {
+				size += len(strings.TrimSpace(lines[j].text))
+				j++
+			}
+			if size >= 64 {
+				out = append(out, compressLine{text: strings.Repeat(" ", l.indent) + base64Placeholder(size), indent: l.indent, block: true})
+				i = j - 1
+				continue
+			}
+			out = append(out, l)
+			continue
+		}
+		if key, value, ok := strings.Cut(l.text, ": "); ok {
+			unquoted := strings.Trim(strings.TrimSpace(value), `"'`)
+			if base64RunPattern.MatchString(unquoted) {
+				l.text = key + ": " + base64Placeholder(len(unquoted))
+			}
+		}
+		out = append(out, l)
+	}
+	return out
+}
+
+// listItem is the span of one sequence item.
+type listItem struct {
+	start, end int
+	shape      string
+}
+
+// itemShape describes the keys of an item's lines, so items that differ only in their
+// values compare equal.
+func itemShape(lines []compressLine) string {
+	var keys []string
+	for i, l := range lines {
+		if l.block {
+			continue
+		}
+		text := strings.TrimSpace(l.text)
+		if i == 0 {
+			text = strings.TrimSpace(strings.TrimPrefix(text, "-"))
+		}
+		key, _, ok := strings.Cut(text, ":")
+		if !ok {
+			key = ""
+		}
+		keys = append(keys, fmt.Sprintf("%d/%s", l.indent-lines[0].indent, key))
+	}
+	return strings.Join(keys, ",")
+}
+
+// isItemStart reports whether a line starts a sequence item.
+func isItemStart(l compressLine) bool {
+	trimmed := strings.TrimSpace(l.text)
+	return !l.block && (trimmed == "-" || strings.HasPrefix(trimmed, "- "))
+}
+
+// itemEnd returns the index after the sequence item starting at lines[start].
+func itemEnd(lines []compressLine, start int) int {
+	end := start + 1
+	for end < len(lines) && (lines[end].block || lines[end].indent > lines[start].indent) {
+		end++
+	}
+	return end
+}
+
+// collapseRepeatedItems keeps the first compressKeepItems of every run of sibling list
+// items with the same shape and replaces the rest with a comment counting them.
+func collapseRepeatedItems(lines []compressLine) []compressLine {
+	var out []compressLine
+	for i := 0; i < len(lines); {
+		if !isItemStart(lines[i]) {
+			out = append(out, lines[i])
+			i++
+			continue
+		}
+		var run []listItem
+		for j := i; j < len(lines) && isItemStart(lines[j]) && lines[j].indent == lines[i].indent; {
+			end := itemEnd(lines, j)
+			item := listItem{start: j, end: end, shape: itemShape(lines[j:end])}
+			if len(run) > 0 && item.shape != run[0].shape {
+				break
+			}
+			run = append(run, item)
+			j = end
+		}
+		last := run[len(run)-1].end
+		if len(run) <= compressKeepItems {
+			// Emit only the first item: nested runs inside it, or a differently shaped run
+			// starting at the next item, are handled on later iterations
+			out = append(out, lines[i])
+			i++
+			continue
+		}
+		kept := run[compressKeepItems-1].end
+		out = append(out, collapseRepeatedItems(lines[i:kept])...)
+		indent := strings.Repeat(" ", lines[i].indent)
+		out = append(out, compressLine{text: fmt.Sprintf("%s# ... %d more similar items", indent, len(run)-compressKeepItems), indent: lines[i].indent})
+		i = last
+	}
+	return out
+}
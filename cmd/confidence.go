@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"strings"
+)
+
+// confidenceNotes marks entries whose summary only succeeded after provider retries or
+// a second guardrail attempt.
+var confidenceNotes bool
+
+// confidenceNoteMarker starts the low-confidence note appended to an entry.
+const confidenceNoteMarker = " 🔍 "
+
+// confidenceReasons explains why a summary deserves review, or returns nil if its
+// requests went through on the first try.
+func confidenceReasons(stats requestStats) []string {
+	var reasons []string
+	switch {
+	case stats.Retries == 1:
+		reasons = append(reasons, "needed 1 retry")
+	case stats.Retries > 1:
+		reasons = append(reasons, fmt.Sprintf("needed %d retries", stats.Retries))
+	}
+	if stats.Requests > 1 {
+		// summarizeYAMLFile only asks about a file again when the first answer failed
+		// the guardrail check
+		reasons = append(reasons, "rewritten after a guardrail check")
+	}
+	return reasons
+}
+
+// parseConfidenceNotes reads the low-confidence notes of an existing document, keyed by
+// file path relative to the base directory, so notes of reused summaries are kept.
+func parseConfidenceNotes(lines []string) map[string]string {
+	notes := make(map[string]string)
+	var currentDir string
+	for _, line := range lines {
+		key, _, ok := parseSummaryLine(line, &currentDir)
+		if !ok {
+			continue
+		}
+		// The note is appended after every other note, so it runs to the entry key
+		if i := strings.Index(line, confidenceNoteMarker); i >= 0 {
+			note, _ := cutEntryKey(line[i+len(confidenceNoteMarker):])
+			notes[key] = strings.TrimSpace(note)
+		}
+	}
+	return notes
+}
+
+// lowConfidenceNotes returns the note of every file whose summary deserves review:
+// files summarized in this run by their request stats, and files whose summary was
+// reused from the document by the note they had there.
+func lowConfidenceNotes(dir string, yamlFiles []string, stats map[string]requestStats, previous map[string]string) map[string]string {
+	notes := make(map[string]string)
+	for _, file := range yamlFiles {
+		if s, ok := stats[file]; ok {
+			if reasons := confidenceReasons(s); len(reasons) > 0 {
+				notes[file] = "low confidence: " + strings.Join(reasons, ", ")
+			}
+			continue
+		}
+		if rel, err := filepath.Rel(dir, file); err == nil && previous[rel] != "" {
+			notes[file] = previous[rel]
+		}
+	}
+	return notes
+}
+
+// withConfidenceNotes returns a copy of summaries with the note of every summarized file
+// that has one appended.
+func withConfidenceNotes(summaries map[string]string, notes map[string]string) map[string]string {
+	annotated := maps.Clone(summaries)
+	for file, note := range notes {
+		if summary := annotated[file]; summary != "" {
+			annotated[file] = summary + confidenceNoteMarker + note
+		}
+	}
+	return annotated
+}
@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigFileName is the config file looked up in the target directory when
+// --config is not set.
+const DefaultConfigFileName = ".yaml-to-readme.yaml"
+
+// fileConfig is the on-disk configuration. Every setting mirrors a flag; flags given on
+// the command line take precedence over the file.
+type fileConfig struct {
+	// MatchExtensions lists extra file name suffixes treated as YAML, like --match-extensions.
+	MatchExtensions []string `yaml:"match_extensions"`
+	// Exclude lists globs of files and directories to skip, like --exclude.
+	Exclude []string `yaml:"exclude"`
+	// Include lists globs of the only files to document, like --include.
+	Include []string `yaml:"include"`
+	// Gitignore toggles .gitignore handling, like --no-gitignore.
+	Gitignore *bool `yaml:"gitignore"`
+	// SkipGenerated toggles generated-file detection, like --skip-generated.
+	SkipGenerated *bool `yaml:"skip_generated"`
+	// StableEntries toggles keyed entries, like --stable-entries. Set it here so everyone
+	// regenerating the document writes the same layout.
+	StableEntries *bool `yaml:"stable_entries"`
+	// Redact toggles secrets redaction, like --no-redact.
+	Redact *bool `yaml:"redact"`
+	// MaxFileSizeKB skips larger files, like --max-file-size.
+	MaxFileSizeKB int `yaml:"max_file_size_kb"`
+	// MaxFileTokens skips files with more tokens, like --max-file-tokens.
+	MaxFileTokens int `yaml:"max_file_tokens"`
+	// Tokenizer selects how tokens are counted, like --tokenizer.
+	Tokenizer string `yaml:"tokenizer"`
+	// RequiredLabels lists label keys every resource must set, like --required-labels.
+	RequiredLabels []string `yaml:"required_labels"`
+	// TrivialLines sets the trivial-file threshold, like --trivial-lines.
+	TrivialLines *int `yaml:"trivial_lines"`
+	// KnowledgeBase is a kind description file, like --knowledge-base. A relative path
+	// is resolved against the config file's directory.
+	KnowledgeBase string `yaml:"knowledge_base"`
+	// PromptFile is a summarization prompt template, like --prompt-file. A relative path
+	// is resolved against the config file's directory.
+	PromptFile string `yaml:"prompt_file"`
+	// AuditLog is the run audit log, like --audit-log. A relative path is resolved
+	// against the config file's directory.
+	AuditLog string `yaml:"audit_log"`
+	// ProviderEndpoints restricts which provider endpoints may be used. It has no flag,
+	// so it cannot be loosened from the command line.
+	ProviderEndpoints endpointRules `yaml:"provider_endpoints"`
+	// KeyFiles selects the files highlighted at the top of the document, like --key-files.
+	KeyFiles []string `yaml:"key_files"`
+	// ModelAliases maps alias names to a model, or to a model per provider, so one
+	// committed config works with different backends.
+	ModelAliases map[string]modelAlias `yaml:"model_aliases"`
+	// ModelAlias selects an alias, like --model-alias.
+	ModelAlias string `yaml:"model_alias"`
+	// DirAliases maps directories to the display names used in their section headings.
+	// It has no flag.
+	DirAliases map[string]string `yaml:"dir_aliases"`
+}
+
+// findConfigFile returns the config file to load: --config if set, otherwise
+// DefaultConfigFileName in the directory named by the first argument (or the directory
+// containing it, if it is a file), or in the current directory. It returns an empty
+// string if there is none.
+func findConfigFile(args []string) string {
+	if configPath != "" {
+		return configPath
+	}
+	var candidates []string
+	if len(args) > 0 {
+		if info, err := os.Stat(args[0]); err == nil {
+			dir := args[0]
+			if !info.IsDir() {
+				dir = filepath.Dir(dir)
+			}
+			candidates = append(candidates, filepath.Join(dir, DefaultConfigFileName))
+		}
+	}
+	candidates = append(candidates, DefaultConfigFileName)
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// loadConfigFile reads and parses a config file.
+func loadConfigFile(path string) (*fileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+	var cfg fileConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	if cfg.KnowledgeBase != "" && !filepath.IsAbs(cfg.KnowledgeBase) {
+		cfg.KnowledgeBase = filepath.Join(filepath.Dir(path), cfg.KnowledgeBase)
+	}
+	if cfg.PromptFile != "" && !filepath.IsAbs(cfg.PromptFile) {
+		cfg.PromptFile = filepath.Join(filepath.Dir(path), cfg.PromptFile)
+	}
+	if cfg.AuditLog != "" && !filepath.IsAbs(cfg.AuditLog) {
+		cfg.AuditLog = filepath.Join(filepath.Dir(path), cfg.AuditLog)
+	}
+	return &cfg, nil
+}
+
+// applyConfig copies settings from cfg into the flag variables, skipping any flag that
+// was set on the command line.
+func applyConfig(cmd *cobra.Command, cfg *fileConfig) {
+	flags := cmd.Flags()
+	if len(cfg.MatchExtensions) > 0 && !flags.Changed("match-extensions") {
+		matchExtensions = cfg.MatchExtensions
+	}
+	if len(cfg.Exclude) > 0 && !flags.Changed("exclude") {
+		excludePatterns = cfg.Exclude
+	}
+	if len(cfg.Include) > 0 && !flags.Changed("include") {
+		includePatterns = cfg.Include
+	}
+	if cfg.Gitignore != nil && !flags.Changed("no-gitignore") {
+		noGitignore = !*cfg.Gitignore
+	}
+	if cfg.SkipGenerated != nil && !flags.Changed("skip-generated") {
+		skipGenerated = *cfg.SkipGenerated
+	}
+	if cfg.StableEntries != nil && !flags.Changed("stable-entries") {
+		stableEntries = *cfg.StableEntries
+	}
+	if cfg.Redact != nil && !flags.Changed("no-redact") {
+		noRedact = !*cfg.Redact
+	}
+	if cfg.MaxFileSizeKB > 0 && !flags.Changed("max-file-size") {
+		maxFileSizeKB = cfg.MaxFileSizeKB
+	}
+	if cfg.MaxFileTokens > 0 && !flags.Changed("max-file-tokens") {
+		maxFileTokens = cfg.MaxFileTokens
+	}
+	if cfg.Tokenizer != "" && !flags.Changed("tokenizer") {
+		tokenizerName = cfg.Tokenizer
+	}
+	if len(cfg.RequiredLabels) > 0 && !flags.Changed("required-labels") {
+		requiredLabels = cfg.RequiredLabels
+	}
+	if cfg.TrivialLines != nil && !flags.Changed("trivial-lines") {
+		trivialLines = *cfg.TrivialLines
+	}
+	if cfg.KnowledgeBase != "" && !flags.Changed("knowledge-base") {
+		knowledgeBasePath = cfg.KnowledgeBase
+	}
+	if cfg.PromptFile != "" && !flags.Changed("prompt-file") {
+		promptFilePath = cfg.PromptFile
+	}
+	if cfg.AuditLog != "" && !flags.Changed("audit-log") {
+		auditLogPath = cfg.AuditLog
+	}
+	if len(cfg.KeyFiles) > 0 && !flags.Changed("key-files") {
+		keyFileRules = cfg.KeyFiles
+	}
+	if cfg.ModelAlias != "" && !flags.Changed("model-alias") {
+		modelAliasName = cfg.ModelAlias
+	}
+}
+
+// normalizeExtensions ensures every extension starts with a dot and drops empty entries.
+func normalizeExtensions(exts []string) []string {
+	var normalized []string
+	for _, ext := range exts {
+		ext = strings.TrimSpace(ext)
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		normalized = append(normalized, ext)
+	}
+	return normalized
+}
+
+// bundledConfigPath returns the path of a config file shipped next to the executable,
+// whose provider endpoint rules always apply.
+func bundledConfigPath() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(exe), DefaultConfigFileName)
+}
+
+// loadConfig is run before every command. It applies the config file, if any,
+// resolves the model alias, and normalizes settings that can come from either source. Provider endpoint rules are
+// collected from both the config file and a bundled config next to the executable.
+func loadConfig(cmd *cobra.Command, args []string) error {
+	endpointPolicies = nil
+	dirAliases = nil
+	var aliases map[string]modelAlias
+	path := findConfigFile(args)
+	if path != "" {
+		cfg, err := loadConfigFile(path)
+		if err != nil {
+			return err
+		}
+		slog.Debug("loaded config", "path", path)
+		applyConfig(cmd, cfg)
+		aliases = cfg.ModelAliases
+		dirAliases = normalizeDirAliases(cfg.DirAliases)
+		endpointPolicies = append(endpointPolicies, cfg.ProviderEndpoints.from(path)...)
+	}
+	if bundled := bundledConfigPath(); bundled != "" && !sameFile(bundled, path) {
+		if _, err := os.Stat(bundled); err == nil {
+			cfg, err := loadConfigFile(bundled)
+			if err != nil {
+				return err
+			}
+			slog.Debug("loaded bundled config", "path", bundled)
+			endpointPolicies = append(endpointPolicies, cfg.ProviderEndpoints.from(bundled)...)
+		}
+	}
+	if err := resolveProvider(cmd); err != nil {
+		return err
+	}
+	resolveOutputName(cmd)
+	if err := resolveModelAlias(cmd, aliases); err != nil {
+		return err
+	}
+	if err := validateLogging(); err != nil {
+		return err
+	}
+	if err := validateTokenizer(); err != nil {
+		return err
+	}
+	matchExtensions = normalizeExtensions(matchExtensions)
+	knowledgeBase = nil
+	if knowledgeBasePath != "" {
+		kb, err := loadKnowledgeBase(knowledgeBasePath)
+		if err != nil {
+			return err
+		}
+		knowledgeBase = kb
+	}
+	promptTemplate = nil
+	if promptFilePath != "" {
+		tmpl, err := loadPromptTemplate(promptFilePath)
+		if err != nil {
+			return err
+		}
+		promptTemplate = tmpl
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// summaryChange is one entry added or changed by a run.
+type summaryChange struct {
+	File     string
+	Rel      string
+	Summary  string
+	Previous string
+}
+
+// summaryChanges compares the summaries of a run with those already in the document and
+// returns the entries that were added and changed.
+func summaryChanges(dir string, yamlFiles []string, existing, summaries map[string]string) (added, changed []summaryChange) {
+	for _, file := range yamlFiles {
+		summary := summaries[file]
+		if summary == "" {
+			continue
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			rel = file
+		}
+		previous, ok := existing[rel]
+		switch {
+		case !ok:
+			added = append(added, summaryChange{File: file, Rel: filepath.ToSlash(rel), Summary: summary})
+		case previous != summary:
+			changed = append(changed, summaryChange{File: file, Rel: filepath.ToSlash(rel), Summary: summary, Previous: previous})
+		}
+	}
+	return added, changed
+}
+
+// renderChanges renders added and changed entries as markdown. linkFor returns the link
+// target of a changed file.
+func renderChanges(dir string, added, changed []summaryChange, linkFor func(summaryChange) string) string {
+	var sb strings.Builder
+	sb.WriteString("# YAML Summary Changes\n\n")
+	if len(added) == 0 && len(changed) == 0 {
+		fmt.Fprintf(&sb, "No YAML summaries were added or changed in %s.\n", dir)
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "%d added, %d changed in %s.\n", len(added), len(changed), dir)
+	if len(added) > 0 {
+		sb.WriteString("\n## Added\n")
+		for _, c := range added {
+			fmt.Fprintf(&sb, "- [%s](%s): %s\n", c.Rel, linkFor(c), c.Summary)
+		}
+	}
+	if len(changed) > 0 {
+		sb.WriteString("\n## Changed\n")
+		for _, c := range changed {
+			fmt.Fprintf(&sb, "- [%s](%s): %s\n  - Previously: %s\n", c.Rel, linkFor(c), c.Summary, c.Previous)
+		}
+	}
+	return sb.String()
+}
+
+// writeChangedOnly writes the entries added or changed in this run to --changed-only-output.
+// A file path receives a markdown file with links relative to it; an http(s) URL
+// receives the markdown as a POST body, unless nothing changed.
+func writeChangedOnly(dir string, yamlFiles []string, existing, summaries map[string]string) error {
+	added, changed := summaryChanges(dir, yamlFiles, existing, summaries)
+
+	if strings.HasPrefix(changedOnlyOutput, "http://") || strings.HasPrefix(changedOnlyOutput, "https://") {
+		if len(added) == 0 && len(changed) == 0 {
+			return nil
+		}
+		body := renderChanges(dir, added, changed, func(c summaryChange) string { return c.Rel })
+		client := &http.Client{Timeout: 30 * time.Second}
+		resp, err := client.Post(changedOnlyOutput, "text/markdown; charset=utf-8", bytes.NewBufferString(body))
+		if err != nil {
+			return fmt.Errorf("failed to post changes: %w", err)
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("posting changes returned status %d", resp.StatusCode)
+		}
+		return nil
+	}
+
+	outDir := filepath.Dir(changedOnlyOutput)
+	body := renderChanges(dir, added, changed, func(c summaryChange) string {
+		link, err := filepath.Rel(outDir, c.File)
+		if err != nil {
+			return c.Rel
+		}
+		return filepath.ToSlash(link)
+	})
+	return os.WriteFile(changedOnlyOutput, []byte(body), 0o644)
+}
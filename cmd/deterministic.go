@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// DeterministicSeed is the sampling seed sent to every provider that accepts one.
+const DeterministicSeed = 42
+
+// DefaultOpenAITemperature is the sampling temperature of OpenAI requests outside
+// --deterministic mode.
+const DefaultOpenAITemperature = 0.3
+
+// DefaultAnthropicTemperature is the sampling temperature of Anthropic requests outside
+// --deterministic mode.
+const DefaultAnthropicTemperature = 0.3
+
+// anthropicTemperature returns the temperature for Anthropic requests: 0 with
+// --deterministic. The Messages API has no seed, so this is as close to reproducible as
+// it gets.
+func anthropicTemperature() float64 {
+	if deterministic {
+		return 0
+	}
+	return DefaultAnthropicTemperature
+}
+
+// openAITemperature returns the temperature for OpenAI requests: 0 with --deterministic.
+func openAITemperature() float64 {
+	if deterministic {
+		return 0
+	}
+	return DefaultOpenAITemperature
+}
+
+// generatedAt returns the timestamp recorded in JSON and HTML documents. With
+// --deterministic it is SOURCE_DATE_EPOCH, following the reproducible builds
+// convention, or the Unix epoch if that is unset, so unchanged input produces a
+// byte-identical document.
+func generatedAt() string {
+	if !deterministic {
+		return time.Now().UTC().Format(time.RFC3339)
+	}
+	epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64)
+	if err != nil {
+		epoch = 0
+	}
+	return time.Unix(epoch, 0).UTC().Format(time.RFC3339)
+}
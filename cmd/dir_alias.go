@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// dirAliases maps directories, relative to the documented directory, to the display
+// names their section headings use instead of the path. It is set from the dir_aliases
+// config key.
+var dirAliases map[string]string
+
+// normalizeDirAliases cleans the directory keys of aliases, so "k8s/prod/" and
+// "./k8s/prod" both name the directory k8s/prod. Empty display names are dropped.
+func normalizeDirAliases(aliases map[string]string) map[string]string {
+	if len(aliases) == 0 {
+		return nil
+	}
+	normalized := make(map[string]string, len(aliases))
+	for dir, name := range aliases {
+		if name = strings.TrimSpace(name); name != "" {
+			normalized[path.Clean(filepath.ToSlash(dir))] = name
+		}
+	}
+	return normalized
+}
+
+// dirHeading returns the heading text of a directory section: its display name, or the
+// path followed by a slash.
+func dirHeading(dir string) string {
+	if name := dirAliases[filepath.ToSlash(dir)]; name != "" {
+		return name
+	}
+	return dir + "/"
+}
+
+// aliasedDir returns the directory whose display name is heading, for reading a document
+// back.
+func aliasedDir(heading string) (string, bool) {
+	for dir, name := range dirAliases {
+		if name == heading {
+			return filepath.FromSlash(dir), true
+		}
+	}
+	return "", false
+}
+
+// headingLinkDir returns the directory a markdown heading link points at, for headings
+// whose display name is no longer configured. The link is relative to the document, so
+// this is exact when the document is in the documented directory.
+func headingLinkDir(target string) string {
+	target = strings.TrimSuffix(target, "/")
+	for strings.HasPrefix(target, "../") {
+		target = strings.TrimPrefix(target, "../")
+	}
+	return filepath.FromSlash(path.Clean(target))
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sebrandon1/yaml-to-readme/summarizer"
+	"gopkg.in/yaml.v3"
+)
+
+// maxDriftDifferences caps how many differing fields are listed for one resource.
+const maxDriftDifferences = 20
+
+// DriftPrompt asks the LLM to explain the differences between a declared and a live resource.
+const DriftPrompt = "The following lists the fields where a Kubernetes resource declared in a repository differs from the same resource in the live cluster. In one or two sentences, explain what has drifted and the likely effect. Do not repeat the list verbatim, do not use markdown formatting.\n\n"
+
+// declaredResources returns every Kubernetes resource (a document with kind and
+// metadata.name) declared in a YAML file.
+func declaredResources(file string) ([]map[string]any, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := decodeYAMLDocuments(data)
+	var resources []map[string]any
+	for _, obj := range docs {
+		if kind, _, name := resourceIdentity(obj); kind != "" && name != "" {
+			resources = append(resources, obj)
+		}
+	}
+	return resources, err
+}
+
+// fetchLiveResource returns the live counterpart of a declared resource, or nil if it
+// does not exist in the cluster.
+func fetchLiveResource(kind, namespace, name string) (map[string]any, error) {
+	args := []string{"get", kind + "/" + name, "-o", "yaml", "--ignore-not-found"}
+	if namespace != "" {
+		args = append(args, "--namespace", namespace)
+	}
+	out, err := runKubectl(diffClusterContext, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil
+	}
+	var live map[string]any
+	if err := yaml.Unmarshal(out, &live); err != nil {
+		return nil, fmt.Errorf("failed to parse kubectl output for %s/%s: %w", kind, name, err)
+	}
+	return live, nil
+}
+
+// diffDeclared appends a line for every field set in declared whose value differs in
+// live. Fields only present in live (defaults, status) are not differences. With secret
+// set, or under a key like password, values are redacted: the lines go to the provider
+// and, when it cannot explain them, into the document.
+func diffDeclared(path string, declared, live any, secret bool, diffs *[]string) {
+	if len(*diffs) >= maxDriftDifferences {
+		return
+	}
+	switch d := declared.(type) {
+	case map[string]any:
+		l, ok := live.(map[string]any)
+		if !ok {
+			*diffs = append(*diffs, fmt.Sprintf("%s: declared a mapping, deployed %s", path, describeValue(live, secret)))
+			return
+		}
+		// An env var named like a credential, whose value sits next to its name
+		name, _ := d["name"].(string)
+		_, hasValue := d["value"]
+		secretEnv := hasValue && sensitiveKeyPattern.MatchString(name)
+		for _, key := range slices.Sorted(maps.Keys(d)) {
+			diffDeclared(joinFieldPath(path, key), d[key], l[key], secret || secretEnv || sensitiveKeyPattern.MatchString(key), diffs)
+		}
+	case []any:
+		l, ok := live.([]any)
+		if !ok || len(l) != len(d) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: declared %d items, deployed %s", path, len(d), describeValue(live, secret)))
+			return
+		}
+		for i := range d {
+			diffDeclared(fmt.Sprintf("%s[%d]", path, i), d[i], l[i], secret, diffs)
+		}
+	default:
+		if fmt.Sprint(declared) != fmt.Sprint(live) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: declared %s, deployed %s", path, describeValue(declared, secret), describeValue(live, secret)))
+		}
+	}
+}
+
+// describeValue renders a value for a drift line. Secret and high-entropy values are
+// redacted.
+func describeValue(v any, secret bool) string {
+	switch val := v.(type) {
+	case nil:
+		return "nothing"
+	case []any:
+		return fmt.Sprintf("%d items", len(val))
+	case map[string]any:
+		return "a mapping"
+	default:
+		if secret || isHighEntropy(fmt.Sprint(val)) {
+			return redactedValue
+		}
+		return fmt.Sprintf("%q", fmt.Sprint(val))
+	}
+}
+
+// joinFieldPath appends key to a dotted field path.
+func joinFieldPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// resourceDrift compares one declared resource from file with the cluster and returns a
+// note describing the drift, or "" if the live resource matches.
+func resourceDrift(file string, obj map[string]any, llm LLMProvider) (string, error) {
+	kind, namespace, name := resourceIdentity(obj)
+	ref := kind + "/" + name
+	live, err := fetchLiveResource(kind, namespace, name)
+	if err != nil {
+		return "", err
+	}
+	if live == nil {
+		return fmt.Sprintf(currentLabels().NotDeployed, ref), nil
+	}
+	var diffs []string
+	for _, key := range slices.Sorted(maps.Keys(obj)) {
+		// Server-side bookkeeping in metadata is expected to differ
+		if key == "status" || key == "metadata" {
+			continue
+		}
+		diffDeclared(key, obj[key], live[key], kind == "Secret" && redactSecretFields[key], &diffs)
+	}
+	if meta, ok := obj["metadata"].(map[string]any); ok {
+		liveMeta, _ := live["metadata"].(map[string]any)
+		for _, key := range []string{"labels", "annotations"} {
+			if v, ok := meta[key]; ok {
+				diffDeclared("metadata."+key, v, liveMeta[key], false, &diffs)
+			}
+		}
+	}
+	if len(diffs) == 0 {
+		return "", nil
+	}
+
+	delta := fmt.Sprintf("Resource: %s\n%s\n", ref, strings.Join(diffs, "\n"))
+	explanation, err := llm.Summarize(withAuditFile(context.Background(), file), delta, localizedPrompt(DriftPrompt))
+	if err != nil || strings.TrimSpace(explanation) == "" {
+		slog.Warn("could not explain drift, listing fields instead", "resource", ref, "error", err)
+		return ref + ": " + strings.Join(diffs, "; "), nil
+	}
+	return ref + ": " + truncateToSentences(summarizer.CleanSummary(explanation), summarizer.DefaultMaxSentences), nil
+}
+
+// driftAppendix compares every resource declared in files with the live cluster and
+// returns a "declared vs deployed" appendix listing the files that differ.
+func driftAppendix(dir string, files []string, llm LLMProvider) []docAppendix {
+	var entries []appendixEntry
+	for _, file := range files {
+		resources, err := declaredResources(file)
+		if err != nil {
+			slog.Warn("skipping drift check", "file", file, "error", err)
+			continue
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			rel = file
+		}
+		for _, obj := range resources {
+			note, err := resourceDrift(file, obj, llm)
+			if err != nil {
+				slog.Warn("drift check failed", "file", file, "error", err)
+				continue
+			}
+			if note != "" {
+				entries = append(entries, appendixEntry{Path: rel, Note: note})
+			}
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	l := currentLabels()
+	return []docAppendix{{Title: l.DeclaredVsDeployed, Intro: l.DeclaredVsDeployedIntro, Entries: entries}}
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultElideDataBytes is the size above which ConfigMap and Secret data values are
+// replaced with a placeholder before prompting.
+const DefaultElideDataBytes = 256
+
+// elideDataKinds are the kinds whose data and binaryData values are elided.
+var elideDataKinds = map[string]bool{"ConfigMap": true, "Secret": true}
+
+// elideDataFields are the top-level fields holding ConfigMap and Secret payloads.
+var elideDataFields = map[string]bool{"data": true, "binaryData": true}
+
+// elideData replaces data and binaryData values larger than limit bytes in every
+// ConfigMap and Secret document of content with an "<omitted N bytes>" placeholder.
+// Such payloads, often base64 or whole embedded files, dominate token usage while adding
+// nothing to a summary. Other documents and lines are returned unchanged.
+func elideData(content string, limit int) string {
+	lines := splitCompressLines(content)
+	var out []compressLine
+	for start := 0; start < len(lines); {
+		end := start + 1
+		for end < len(lines) && !isDocumentSeparator(lines[end]) {
+			end++
+		}
+		doc := lines[start:end]
+		if elideDataKinds[documentKind(doc)] {
+			doc = elideDocumentData(doc, limit)
+		}
+		out = append(out, doc...)
+		start = end
+	}
+	elided := joinCompressLines(out)
+	if !strings.HasSuffix(content, "\n") {
+		elided = strings.TrimSuffix(elided, "\n")
+	}
+	return elided
+}
+
+// isDocumentSeparator reports whether a line starts a new YAML document.
+func isDocumentSeparator(l compressLine) bool {
+	return !l.block && (l.text == "---" || strings.HasPrefix(l.text, "--- "))
+}
+
+// documentKind returns the top-level kind of a document's lines.
+func documentKind(doc []compressLine) string {
+	for _, l := range doc {
+		if kind, ok := strings.CutPrefix(l.text, "kind:"); ok && !l.block {
+			return strings.Trim(strings.TrimSpace(kind), `"'`)
+		}
+	}
+	return ""
+}
+
+// elideDocumentData elides the large values under the top-level data fields of doc.
+func elideDocumentData(doc []compressLine, limit int) []compressLine {
+	var out []compressLine
+	inData := false
+	for i := 0; i < len(doc); i++ {
+		l := doc[i]
+		if !l.block && l.indent == 0 && strings.TrimSpace(l.text) != "" {
+			key, _, _ := strings.Cut(l.text, ":")
+			inData = elideDataFields[key]
+		}
+		if !inData || l.block || l.indent == 0 {
+			out = append(out, l)
+			continue
+		}
+		key, value, ok := strings.Cut(l.text, ":")
+		if !ok {
+			out = append(out, l)
+			continue
+		}
+		// Sum the value, inline or as the block scalar that follows
+		size := len(strings.Trim(strings.TrimSpace(value), `"'`))
+		end := i + 1
+		if blockScalarPattern.MatchString(l.text) {
+			size = 0
+			for end < len(doc) && doc[end].block {
+				size += len(strings.TrimSpace(doc[end].text)) + 1
+				end++
+			}
+		}
+		if size <= limit {
+			out = append(out, l)
+			continue
+		}
+		out = append(out, compressLine{text: fmt.Sprintf("%s: <omitted %d bytes>", key, size), indent: l.indent})
+		i = end - 1
+	}
+	return out
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sebrandon1/yaml-to-readme/summarizer"
+	"github.com/spf13/cobra"
+)
+
+// DefaultEmbedBatchSize is how many texts are sent in one embedding request.
+const DefaultEmbedBatchSize = 64
+
+// DefaultEmbeddingsFileName is the export file name, without extension, written next to
+// the document when --file is not set.
+const DefaultEmbeddingsFileName = "yaml_embeddings"
+
+var embeddingsFormat string
+var embeddingsFile string
+
+// embeddingWriters maps each supported --format of embeddings export to its writer.
+var embeddingWriters = map[string]func(io.Writer, []embeddingRecord) error{
+	"jsonl":   writeEmbeddingsJSONL,
+	"parquet": writeEmbeddingsParquet,
+}
+
+// embeddingRecord is one exported file: its identity, summary, and embedding vector.
+type embeddingRecord struct {
+	Path      string    `json:"path"`
+	Kind      string    `json:"kind"`
+	SHA256    string    `json:"sha256"`
+	Summary   string    `json:"summary"`
+	Model     string    `json:"model"`
+	Embedding []float32 `json:"embedding"`
+}
+
+// embeddingRecords embeds every file in the inventory of dir, the same text ask
+// retrieves by, in batches of DefaultEmbedBatchSize.
+func embeddingRecords(ctx context.Context, dir string, llm LLMProvider) ([]embeddingRecord, error) {
+	e, ok := llm.(Embedder)
+	if !ok {
+		return nil, fmt.Errorf("the %s provider does not support embeddings", llm.Name())
+	}
+	model := embedModel
+	if model == "" {
+		model = e.DefaultEmbedModel()
+	}
+	passages, err := loadInventory(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]embeddingRecord, len(passages))
+	for start := 0; start < len(passages); start += DefaultEmbedBatchSize {
+		batch := passages[start:min(start+DefaultEmbedBatchSize, len(passages))]
+		texts := make([]string, len(batch))
+		files := make([]string, len(batch))
+		for i, p := range batch {
+			texts[i] = p.text()
+			files[i] = filepath.Join(dir, filepath.FromSlash(p.Rel))
+		}
+		vectors, err := e.Embed(withAuditFiles(ctx, files), model, texts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed summaries with %s: %w", model, err)
+		}
+		for i, p := range batch {
+			records[start+i] = embeddingRecord{Path: p.Rel, Summary: p.Summary, Model: model, Embedding: vectors[i]}
+			if content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(p.Rel))); err == nil {
+				sum := sha256.Sum256(content)
+				records[start+i].SHA256 = hex.EncodeToString(sum[:])
+				records[start+i].Kind = summarizer.DetectKind(content)
+			}
+		}
+	}
+	return records, nil
+}
+
+// writeEmbeddingsJSONL writes one JSON object per line.
+func writeEmbeddingsJSONL(w io.Writer, records []embeddingRecord) error {
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeEmbeddingsParquet writes the records as a Parquet file with one row per file.
+func writeEmbeddingsParquet(w io.Writer, records []embeddingRecord) error {
+	columns := []parquetStringColumn{{Name: "path"}, {Name: "kind"}, {Name: "sha256"}, {Name: "summary"}, {Name: "model"}}
+	vectors := parquetFloatListColumn{Name: "embedding"}
+	for _, r := range records {
+		if len(r.Embedding) == 0 {
+			return fmt.Errorf("empty embedding for %s", r.Path)
+		}
+		for i, v := range []string{r.Path, r.Kind, r.SHA256, r.Summary, r.Model} {
+			columns[i].Values = append(columns[i].Values, v)
+		}
+		vectors.Values = append(vectors.Values, r.Embedding)
+	}
+	return writeParquet(w, len(records), columns, vectors)
+}
+
+// validateEmbeddingsFormat returns an error if --format names an unsupported export
+// format.
+func validateEmbeddingsFormat() error {
+	if _, ok := embeddingWriters[embeddingsFormat]; ok {
+		return nil
+	}
+	formats := make([]string, 0, len(embeddingWriters))
+	for f := range embeddingWriters {
+		formats = append(formats, f)
+	}
+	sort.Strings(formats)
+	return fmt.Errorf("unsupported --format %q, expected one of: %s", embeddingsFormat, strings.Join(formats, ", "))
+}
+
+// embeddingsPath returns where embeddings export writes for dir: --file, or
+// DefaultEmbeddingsFileName next to the document.
+func embeddingsPath(dir string) string {
+	if embeddingsFile != "" {
+		return embeddingsFile
+	}
+	return filepath.Join(outputDir(dir), DefaultEmbeddingsFileName+"."+embeddingsFormat)
+}
+
+// runEmbeddingsExport is the main logic for the embeddings export command.
+func runEmbeddingsExport(dir string) error {
+	setupLogging()
+	if err := validateEmbeddingsFormat(); err != nil {
+		return err
+	}
+	if err := validateWriteDir(dir); err != nil {
+		return err
+	}
+	llm, err := createProvider()
+	if err != nil {
+		return fmt.Errorf("failed to create %s provider: %w", provider, err)
+	}
+	return runEmbeddingsExportWithProvider(dir, llm)
+}
+
+// runEmbeddingsExportWithProvider embeds the inventory of dir and writes it in
+// --format to embeddingsPath, or to stdout for "-".
+func runEmbeddingsExportWithProvider(dir string, llm LLMProvider) error {
+	var records []embeddingRecord
+	if err := auditRun(dir, llm, func(llm LLMProvider) error {
+		var err error
+		records, err = embeddingRecords(context.Background(), dir, llm)
+		return err
+	}); err != nil {
+		return err
+	}
+	write := embeddingWriters[embeddingsFormat]
+	path := embeddingsPath(dir)
+	if path == "-" {
+		return write(os.Stdout, records)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if err := write(f, records); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	porcelainf("exported", "path", path, "files", len(records))
+	statusf("Exported %d embedding(s) to %s\n", len(records), path)
+	return nil
+}
+
+// embeddingsCmd groups the embeddings subcommands.
+var embeddingsCmd = &cobra.Command{
+	Use:   "embeddings",
+	Short: "Work with embeddings of the generated summaries",
+}
+
+// embeddingsExportCmd exports the summaries and their embeddings for external vector stores.
+var embeddingsExportCmd = &cobra.Command{
+	Use:   "export [directory]",
+	Short: "Export file paths, summaries, and embedding vectors for a vector store",
+	Long: `Embed every file documented in the generated document for the directory and
+export the path, kind, content hash, summary, embedding model, and vector of each,
+so the inventory can be loaded into existing RAG infrastructure. Files are embedded
+from the same text ask retrieves by.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runEmbeddingsExport(args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(embeddingsCmd)
+	embeddingsCmd.AddCommand(embeddingsExportCmd)
+	embeddingsExportCmd.Flags().StringVar(&embeddingsFormat, "format", "jsonl", "Export format: jsonl or parquet")
+	embeddingsExportCmd.Flags().StringVar(&embeddingsFile, "file", "", "File to write, or - for stdout (default: "+DefaultEmbeddingsFileName+".<format> next to the document)")
+	embeddingsExportCmd.Flags().StringVar(&embedModel, "embed-model", "", "Embedding model (default: nomic-embed-text for ollama, text-embedding-3-small for openai)")
+}
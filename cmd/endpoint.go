@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/ollama/ollama/envconfig"
+)
+
+// providerEndpoint returns the base URL requests to the named provider go to, or an
+// empty string if it is not known.
+func providerEndpoint(name string) string {
+	switch name {
+	case "openai":
+		if base := os.Getenv("OPENAI_BASE_URL"); base != "" {
+			return base
+		}
+		return "https://api.openai.com"
+	case "anthropic":
+		if base := os.Getenv("ANTHROPIC_BASE_URL"); base != "" {
+			return base
+		}
+		return "https://api.anthropic.com"
+	case "ollama":
+		return envconfig.Host().String()
+	}
+	return ""
+}
+
+// isRemoteEndpoint reports whether endpoint is on another machine.
+func isRemoteEndpoint(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+	if u.Hostname() == "localhost" {
+		return false
+	}
+	ip := net.ParseIP(u.Hostname())
+	return ip == nil || !ip.IsLoopback()
+}
+
+// lookupIP resolves host names for --local-only; a variable so tests can stub DNS.
+var lookupIP = net.LookupIP
+
+// isPrivateEndpoint reports whether endpoint is on this machine or a private network.
+// Host names are resolved, and every address they resolve to must be private.
+func isPrivateEndpoint(endpoint string) (bool, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false, err
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true, nil
+	}
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		if ips, err = lookupIP(host); err != nil {
+			return false, err
+		}
+	}
+	for _, ip := range ips {
+		if !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() {
+			return false, nil
+		}
+	}
+	return len(ips) > 0, nil
+}
+
+// checkProviderEndpoint enforces the provider endpoint rules of the config files and
+// --local-only before any request is made to the named provider.
+func checkProviderEndpoint(name string) error {
+	endpoint := providerEndpoint(name)
+	if len(endpointPolicies) > 0 {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return fmt.Errorf("invalid %s endpoint %s: %w", name, endpoint, err)
+		}
+		for _, rules := range endpointPolicies {
+			if reason := rules.refusal(u); reason != "" {
+				return fmt.Errorf("%s endpoint %s is %s", name, endpoint, reason)
+			}
+		}
+	}
+	if !localOnly {
+		return nil
+	}
+	private, err := isPrivateEndpoint(endpoint)
+	if err != nil {
+		return fmt.Errorf("--local-only: cannot check %s endpoint %s: %w", name, endpoint, err)
+	}
+	if !private {
+		return fmt.Errorf("--local-only: %s endpoint %s is not on localhost or a private network", name, endpoint)
+	}
+	return nil
+}
+
+// endpointRules lists the provider endpoints a config file allows or denies. Entries
+// with a scheme are base URLs that match endpoints with the same scheme and host and a
+// path under theirs; entries without one are domains that also match their subdomains.
+type endpointRules struct {
+	Allow []string `yaml:"allow"`
+	Deny  []string `yaml:"deny"`
+	// source is the config file the rules came from, named in errors.
+	source string
+}
+
+// endpointPolicies are the rules of every loaded config file; all of them must permit
+// an endpoint.
+var endpointPolicies []endpointRules
+
+// from returns the rules tagged with the config file they came from, or nil if there
+// are none.
+func (r endpointRules) from(source string) []endpointRules {
+	if len(r.Allow) == 0 && len(r.Deny) == 0 {
+		return nil
+	}
+	r.source = source
+	return []endpointRules{r}
+}
+
+// matchEndpoint reports whether endpoint matches a base URL or domain rule.
+func matchEndpoint(rule string, endpoint *url.URL) bool {
+	if !strings.Contains(rule, "://") {
+		domain := strings.ToLower(strings.TrimPrefix(rule, "."))
+		host := strings.ToLower(endpoint.Hostname())
+		return host == domain || strings.HasSuffix(host, "."+domain)
+	}
+	base, err := url.Parse(rule)
+	if err != nil {
+		return false
+	}
+	if !strings.EqualFold(base.Scheme, endpoint.Scheme) || !strings.EqualFold(base.Host, endpoint.Host) {
+		return false
+	}
+	prefix := strings.TrimSuffix(base.Path, "/")
+	return endpoint.Path == prefix || strings.HasPrefix(endpoint.Path, prefix+"/")
+}
+
+// refusal reports why the rules refuse endpoint, or "" if they permit it.
+func (r endpointRules) refusal(endpoint *url.URL) string {
+	for _, rule := range r.Deny {
+		if matchEndpoint(rule, endpoint) {
+			return fmt.Sprintf("denied by %q in %s", rule, r.source)
+		}
+	}
+	if len(r.Allow) == 0 {
+		return ""
+	}
+	for _, rule := range r.Allow {
+		if matchEndpoint(rule, endpoint) {
+			return ""
+		}
+	}
+	return "not in the allowed endpoints of " + r.source
+}
+
+// sameFile reports whether two config paths name the same file.
+func sameFile(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	ia, errA := os.Stat(a)
+	ib, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(ia, ib)
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sebrandon1/yaml-to-readme/summarizer"
+	"github.com/spf13/cobra"
+)
+
+// FileJSONSchemaVersion is the schema version of `file --json` output. Fields may be
+// added within a version; removing, renaming, or changing the meaning of one bumps it.
+const FileJSONSchemaVersion = 1
+
+// fileResult is the `file --json` output for one YAML file.
+type fileResult struct {
+	SchemaVersion int    `json:"schema_version"`
+	Path          string `json:"path"`
+	Summary       string `json:"summary"`
+	// Kind is the kind of the file's first document, empty if it has none.
+	Kind string `json:"kind"`
+	// Tags are the distinct kinds of every document in the file, sorted.
+	Tags []string `json:"tags"`
+	// Hash is the content hash, prefixed with its algorithm.
+	Hash string `json:"hash"`
+	// Cached reports whether the summary came from the existing document rather than
+	// being generated for this request.
+	Cached bool `json:"cached"`
+}
+
+// fileTags returns the sorted kinds of every document in file.
+func fileTags(file string) []string {
+	tags := make([]string, 0)
+	for kind := range fileKinds(file) {
+		tags = append(tags, kind)
+	}
+	slices.Sort(tags)
+	return tags
+}
+
+// describeFile returns the summary and metadata of one YAML file. The summary is read
+// from the document in the nearest directory above the file that has one, or from
+// fileDir if set; it is generated with the provider returned by newProvider only if the
+// document has no entry for the file, the file changed since its entry was generated,
+// or --regenerate is set. Nothing is written.
+func describeFile(file string, newProvider func() (LLMProvider, error)) (*fileResult, error) {
+	if !isYAMLName(file) {
+		return nil, fmt.Errorf("%s is not a YAML file", file)
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	absFile, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+
+	baseDir := fileDir
+	if baseDir == "" {
+		if baseDir, err = findDocDir(file); err != nil {
+			// No document yet: report the path relative to the file's own directory
+			baseDir = filepath.Dir(absFile)
+		}
+	}
+	absBase, err := filepath.Abs(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(absBase, absFile)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return nil, fmt.Errorf("%s is not under %s", file, baseDir)
+	}
+	rel = filepath.ToSlash(rel)
+
+	sum := sha256.Sum256(content)
+	result := &fileResult{
+		SchemaVersion: FileJSONSchemaVersion,
+		Path:          rel,
+		Kind:          summarizer.DetectKind(content),
+		Tags:          fileTags(file),
+		Hash:          "sha256:" + hex.EncodeToString(sum[:]),
+	}
+
+	docPath := docPathFor(baseDir)
+	if summary := parseExistingSummaries(docPath)[rel]; summary != "" && !regenerate && summarizedFrom(docPath, absFile, rel, hex.EncodeToString(sum[:])) {
+		result.Summary = summary
+		result.Cached = true
+		return result, nil
+	}
+	llm, err := newProvider()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s provider: %w", provider, err)
+	}
+	if err := auditRun(baseDir, llm, func(llm LLMProvider) error {
+		result.Summary, err = summarizeYAMLFile(context.Background(), llm, baseDir, file)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// summarizedFrom reports whether the document's summary of rel was generated from the
+// file's current content, whose digest is given: by the digest the document recorded
+// for it, or for documents without one, by the file being no newer than the document.
+func summarizedFrom(docPath, file, rel, digest string) bool {
+	if recorded := readDocSources(docPath)[rel]; recorded != "" {
+		return recorded == digest
+	}
+	docInfo, err := os.Stat(docPath)
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(file)
+	return err == nil && !info.ModTime().After(docInfo.ModTime())
+}
+
+// writeFileResult prints the result as a single JSON line with --json, or just the
+// summary otherwise.
+func writeFileResult(w io.Writer, result *fileResult) error {
+	if !fileJSON {
+		_, err := fmt.Fprintln(w, result.Summary)
+		return err
+	}
+	return json.NewEncoder(w).Encode(result)
+}
+
+// fileCmd prints the summary of a single YAML file without touching the document.
+var fileCmd = &cobra.Command{
+	Use:   "file [file]",
+	Short: "Print the summary of a single YAML file",
+	Long: `Print the summary of one YAML file, for editor extensions and scripts. The summary is
+taken from the document in the nearest directory above the file that has one, and is
+generated on demand if the document has no entry for it or --regenerate is set. The
+document and cache are never modified.
+
+With --json the output is one JSON object with schema_version, path, summary, kind,
+tags, hash, and cached. Fields are only added within a schema version; any other change
+bumps it. Errors are reported on stderr with a non-zero exit status.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		setupLogging()
+		if err := validateLang(); err != nil {
+			return err
+		}
+		if err := validateSummaryLength(); err != nil {
+			return err
+		}
+		result, err := describeFile(args[0], createProvider)
+		if err != nil {
+			return err
+		}
+		return writeFileResult(cmd.OutOrStdout(), result)
+	},
+}
+
+var fileJSON bool
+var fileDir string
+
+func init() {
+	rootCmd.AddCommand(fileCmd)
+	fileCmd.Flags().BoolVar(&fileJSON, "json", false, "Print a versioned JSON object instead of the summary alone")
+	fileCmd.Flags().StringVar(&fileDir, "dir", "", "Directory containing the document (default: nearest directory above the file that has one)")
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// fileRule reports whether a file is selected by a rule such as --refine or --key-files.
+type fileRule func(rel string, size int64, kinds map[string]bool) bool
+
+// parseFileRule parses one rule given to flag: kind=<Kind>, path=<glob>, or
+// min-size=<KB>.
+func parseFileRule(flag, spec string) (fileRule, error) {
+	name, value, ok := strings.Cut(spec, "=")
+	if !ok || value == "" {
+		return nil, fmt.Errorf("unsupported --%s %q, expected kind=<Kind>, path=<glob>, or min-size=<KB>", flag, spec)
+	}
+	switch name {
+	case "kind":
+		return func(_ string, _ int64, kinds map[string]bool) bool { return kinds[value] }, nil
+	case "path":
+		return func(rel string, _ int64, _ map[string]bool) bool { return matchPathGlob(value, rel) }, nil
+	case "min-size":
+		kb, err := strconv.Atoi(value)
+		if err != nil || kb < 0 {
+			return nil, fmt.Errorf("invalid --%s %q: min-size must be a number of KB", flag, spec)
+		}
+		return func(_ string, size int64, _ map[string]bool) bool { return size >= int64(kb)*1024 }, nil
+	default:
+		return nil, fmt.Errorf("unsupported --%s %q, expected kind=<Kind>, path=<glob>, or min-size=<KB>", flag, spec)
+	}
+}
+
+// parseFileRules parses every rule given to flag.
+func parseFileRules(flag string, specs []string) ([]fileRule, error) {
+	var rules []fileRule
+	for _, spec := range specs {
+		rule, err := parseFileRule(flag, strings.TrimSpace(spec))
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// matchFileRules reports whether file, under dir, is selected by any of rules.
+func matchFileRules(dir, file string, rules []fileRule) bool {
+	if len(rules) == 0 {
+		return false
+	}
+	rel, _ := filepath.Rel(dir, file)
+	rel = filepath.ToSlash(rel)
+	var size int64
+	if info, err := os.Stat(file); err == nil {
+		size = info.Size()
+	}
+	kinds := fileKinds(file)
+	for _, rule := range rules {
+		if rule(rel, size, kinds) {
+			return true
+		}
+	}
+	return false
+}
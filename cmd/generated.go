@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// generatedHeaderLines is how many lines at the top of a file are checked for a
+// generated-code marker.
+const generatedHeaderLines = 5
+
+// generatedMarkerPattern matches the conventional markers of machine-generated files,
+// such as Go's "Code generated ... DO NOT EDIT." header.
+var generatedMarkerPattern = regexp.MustCompile(`(?i)(code generated|do not edit|@generated|autogenerated|auto-generated)`)
+
+// isLockFile reports whether name looks like a dependency lock file, such as
+// pnpm-lock.yaml or conda-lock.yml.
+func isLockFile(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.Contains(lower, ".lock.") || strings.Contains(lower, "-lock.")
+}
+
+// hasGeneratedHeader reports whether the first lines of file carry a generated-code marker.
+func hasGeneratedHeader(file string) bool {
+	f, err := os.Open(file)
+	if err != nil {
+		return false
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	scanner := bufio.NewScanner(f)
+	for i := 0; i < generatedHeaderLines && scanner.Scan(); i++ {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") && generatedMarkerPattern.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// generatedReason returns why file should be skipped as machine-generated or too large,
+// or an empty string if it should be summarized.
+func generatedReason(file string) string {
+	if maxFileSizeKB > 0 {
+		if info, err := os.Stat(file); err == nil && info.Size() > int64(maxFileSizeKB)*1024 {
+			return fmt.Sprintf("larger than %d KB", maxFileSizeKB)
+		}
+	}
+	if maxFileTokens > 0 {
+		if content, err := os.ReadFile(file); err == nil {
+			if tokens := activeTokenizer().Count(string(content)); tokens > maxFileTokens {
+				return fmt.Sprintf("larger than %d tokens", maxFileTokens)
+			}
+		}
+	}
+	if !skipGenerated {
+		return ""
+	}
+	if isLockFile(filepath.Base(file)) {
+		return "lock file"
+	}
+	if hasGeneratedHeader(file) {
+		return "generated-code header"
+	}
+	return ""
+}
+
+// partitionGenerated splits yamlFiles into files to summarize and files skipped as
+// generated, the latter listed relative to dir with the reason.
+func partitionGenerated(dir string, yamlFiles []string) ([]string, []appendixEntry) {
+	var keep []string
+	var skipped []appendixEntry
+	for _, file := range yamlFiles {
+		if reason := generatedReason(file); reason != "" {
+			rel, _ := filepath.Rel(dir, file)
+			skipped = append(skipped, appendixEntry{Path: filepath.ToSlash(rel), Note: reason})
+			continue
+		}
+		keep = append(keep, file)
+	}
+	return keep, skipped
+}
+
+// generatedAppendix returns the "skipped as generated" appendix for the skipped files.
+func generatedAppendix(skipped []appendixEntry) []docAppendix {
+	if len(skipped) == 0 {
+		return nil
+	}
+	l := currentLabels()
+	return []docAppendix{{Title: l.SkippedGenerated, Intro: l.SkippedGeneratedIntro, Entries: skipped}}
+}
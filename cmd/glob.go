@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"path"
+	"strings"
+)
+
+// matchPathGlob reports whether a slash-separated relative path matches pattern. Each
+// pattern segment is matched with path.Match, and a "**" segment matches any number of
+// path segments, including none, so "networking/**" matches every file under networking/.
+func matchPathGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// matchSegments matches path segments against pattern segments.
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// matchAnyPathGlob reports whether name matches at least one of patterns.
+func matchAnyPathGlob(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matchPathGlob(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
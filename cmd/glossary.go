@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/sebrandon1/yaml-to-readme/summarizer"
+)
+
+// GlossaryPrompt asks for a one-line explanation of a resource kind, given an example.
+const GlossaryPrompt = "The following YAML is an example of a resource kind used in this repository. In one short sentence, explain to someone new to the project what this kind of resource is and what it is used for, without describing this particular example. Do not use markdown formatting.\n\n"
+
+// glossaryMinFiles is how many files must use a kind for it to get a glossary entry.
+const glossaryMinFiles = 2
+
+// glossaryNoteMarker separates a summary from the glossary links appended to it.
+const glossaryNoteMarker = " 📖 "
+
+// glossaryTerm is a kind that recurs across the repository.
+type glossaryTerm struct {
+	Kind       string
+	APIVersion string
+	// Example is the file the kind was first seen in, sent to the LLM for context.
+	Example string
+}
+
+// collectGlossaryTerms returns the kinds used in at least glossaryMinFiles files,
+// sorted by kind, and the glossary kinds used in each file.
+func collectGlossaryTerms(files []string) ([]glossaryTerm, map[string][]string) {
+	terms := make(map[string]*glossaryTerm)
+	counts := make(map[string]int)
+	kindsByFile := make(map[string][]string)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		docs, _ := decodeYAMLDocuments(data)
+		for _, doc := range docs {
+			kind := stringField(doc, "kind")
+			if kind == "" || slices.Contains(kindsByFile[file], kind) {
+				continue
+			}
+			kindsByFile[file] = append(kindsByFile[file], kind)
+			counts[kind]++
+			if terms[kind] == nil {
+				terms[kind] = &glossaryTerm{Kind: kind, APIVersion: stringField(doc, "apiVersion"), Example: file}
+			}
+		}
+	}
+	var recurring []glossaryTerm
+	for _, kind := range slices.Sorted(maps.Keys(terms)) {
+		if counts[kind] >= glossaryMinFiles {
+			recurring = append(recurring, *terms[kind])
+		}
+	}
+	for file, kinds := range kindsByFile {
+		kindsByFile[file] = slices.DeleteFunc(kinds, func(kind string) bool { return counts[kind] < glossaryMinFiles })
+	}
+	return recurring, kindsByFile
+}
+
+// parseGlossary reads the glossary entries of an existing document, so definitions are
+// only generated once.
+func parseGlossary(lines []string) map[string]string {
+	glossary := make(map[string]string)
+	prefix := fmt.Sprintf("- <a id=\"%s", indexAnchor("glossary", ""))
+	for _, line := range lines {
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		_, rest, ok := strings.Cut(line, "`")
+		if !ok {
+			continue
+		}
+		if kind, desc, ok := strings.Cut(rest, "` — "); ok && desc != "" {
+			glossary[kind] = desc
+		}
+	}
+	return glossary
+}
+
+// defineTerm asks the LLM for a one-line definition of a kind.
+func defineTerm(term glossaryTerm, llm LLMProvider) (string, error) {
+	example, err := os.ReadFile(term.Example)
+	if err != nil {
+		return "", err
+	}
+	content := fmt.Sprintf("Kind: %s\napiVersion: %s\n\n%s", term.Kind, term.APIVersion, promptContent(term.Example, string(example)))
+	answer, err := llm.Summarize(withAuditFile(context.Background(), term.Example), content, localizedPrompt(GlossaryPrompt))
+	if err != nil {
+		return "", err
+	}
+	definition := truncateToSentences(summarizer.CleanSummary(answer), 1)
+	if definition == "" {
+		return "", fmt.Errorf("empty definition")
+	}
+	return definition, nil
+}
+
+// buildGlossary defines every term, preferring the knowledge base, then definitions
+// already in the document (unless --regenerate is set), then the LLM.
+func buildGlossary(terms []glossaryTerm, cached map[string]string, llm LLMProvider) map[string]string {
+	glossary := make(map[string]string)
+	for _, term := range terms {
+		if desc := describeKind(term.APIVersion, term.Kind); desc != "" {
+			glossary[term.Kind] = strings.TrimSpace(desc)
+			continue
+		}
+		if desc := cached[term.Kind]; desc != "" && !regenerate {
+			glossary[term.Kind] = desc
+			continue
+		}
+		desc, err := defineTerm(term, llm)
+		if err != nil {
+			slog.Warn("could not define glossary term", "kind", term.Kind, "error", err)
+			continue
+		}
+		glossary[term.Kind] = desc
+	}
+	return glossary
+}
+
+// glossaryAppendix renders the glossary, giving each term an anchor entries link to.
+func glossaryAppendix(glossary map[string]string) []docAppendix {
+	if len(glossary) == 0 {
+		return nil
+	}
+	var entries []appendixEntry
+	for _, kind := range slices.Sorted(maps.Keys(glossary)) {
+		entries = append(entries, appendixEntry{Path: kind, Note: glossary[kind], Anchor: indexAnchor("glossary", kind)})
+	}
+	l := currentLabels()
+	return []docAppendix{{Title: l.Glossary, Intro: l.GlossaryIntro, Entries: entries}}
+}
+
+// withGlossaryLinks returns a copy of summaries with links to the glossary entries of
+// the kinds each file uses.
+func withGlossaryLinks(summaries map[string]string, kindsByFile map[string][]string, glossary map[string]string) map[string]string {
+	linked := maps.Clone(summaries)
+	for file, kinds := range kindsByFile {
+		summary := linked[file]
+		if summary == "" {
+			continue
+		}
+		var links []string
+		for _, kind := range kinds {
+			if glossary[kind] != "" {
+				links = append(links, fmt.Sprintf("[%s](#%s)", kind, indexAnchor("glossary", kind)))
+			}
+		}
+		if len(links) > 0 {
+			linked[file] = summary + glossaryNoteMarker + strings.Join(links, ", ")
+		}
+	}
+	return linked
+}
+
+// markdownSummariesSupported reports whether the output format renders markdown, such as
+// links and code spans, in entry summaries.
+func markdownSummariesSupported() bool {
+	return outputFormat == "markdown" || outputFormat == "github-wiki"
+}
+
+// documentGlossary builds the --glossary appendix for files, reusing the definitions in
+// the existing document at mdPath, and returns the summaries with glossary links added.
+func documentGlossary(mdPath string, files []string, summaries map[string]string, llm LLMProvider) (map[string]string, []docAppendix) {
+	terms, kindsByFile := collectGlossaryTerms(files)
+	glossary := buildGlossary(terms, parseGlossary(readLinesFromFile(mdPath)), llm)
+	if markdownSummariesSupported() {
+		summaries = withGlossaryLinks(summaries, kindsByFile, glossary)
+	}
+	return summaries, glossaryAppendix(glossary)
+}
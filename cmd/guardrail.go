@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// guardrails re-prompts once for summaries that refuse or mention names neither the
+// file nor its prompt context contains. It is opt-in, since models legitimately name
+// things the check cannot see, such as kinds a Helm template renders.
+var guardrails bool
+
+// backtickedPattern matches the code spans of a summary.
+var backtickedPattern = regexp.MustCompile("`([^`]+)`")
+
+// camelCasePattern matches words like ConfigMap or StatefulSet, which in a summary
+// almost always name a resource kind.
+var camelCasePattern = regexp.MustCompile(`\b[A-Z][a-z0-9]+(?:[A-Z][a-z0-9]*)+\b`)
+
+// summaryReferences returns the names a summary refers to: its code spans and its
+// CamelCase words.
+func summaryReferences(summary string) []string {
+	var refs []string
+	seen := make(map[string]bool)
+	add := func(ref string) {
+		ref = strings.TrimFunc(ref, func(r rune) bool { return unicode.IsPunct(r) || unicode.IsSpace(r) })
+		if ref != "" && !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	for _, m := range backtickedPattern.FindAllStringSubmatch(summary, -1) {
+		add(m[1])
+	}
+	for _, word := range camelCasePattern.FindAllString(backtickedPattern.ReplaceAllString(summary, ""), -1) {
+		add(word)
+	}
+	return refs
+}
+
+// unknownReferences returns the names a summary refers to that do not appear in the
+// text the model was sent, ignoring case.
+func unknownReferences(summary string, sent []byte) []string {
+	lower := strings.ToLower(string(sent))
+	var unknown []string
+	for _, ref := range summaryReferences(summary) {
+		if !strings.Contains(lower, strings.ToLower(ref)) {
+			unknown = append(unknown, ref)
+		}
+	}
+	return unknown
+}
+
+// guardrailProblem describes why a summary should be asked for again, or returns an
+// empty string if it passes. sent is the text the model was sent: the file's content
+// and its prompt context, whose CI profile names and knowledge base kinds are not
+// made up either.
+func guardrailProblem(summary string, sent []byte) string {
+	if refusalPattern.MatchString(strings.TrimSpace(summary)) {
+		return "it declined to summarize the file"
+	}
+	if unknown := unknownReferences(summary, sent); len(unknown) > 0 {
+		return fmt.Sprintf("it mentioned %s, which the file does not contain", strings.Join(unknown, ", "))
+	}
+	return ""
+}
+
+// guardrailPrompt prefixes prompt with the problem of the previous answer.
+func guardrailPrompt(problem, prompt string) string {
+	return fmt.Sprintf("Your previous summary of this file was rejected because %s. "+
+		"Summarize the file as asked, describing only what appears in it.\n\n%s", problem, prompt)
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// helmCommand is the helm binary used to read installed releases.
+var helmCommand = "helm"
+
+// helmRelease is one entry of `helm list --output json`.
+type helmRelease struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Chart     string `json:"chart"`
+}
+
+// runHelm runs helm against the selected kube context and returns its stdout,
+// including stderr in the error.
+func runHelm(args ...string) ([]byte, error) {
+	if clusterContext != "" {
+		args = append(args, "--kube-context", clusterContext)
+	}
+	cmd := exec.Command(helmCommand, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("helm %s failed: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// listHelmReleases returns the installed releases in the given namespace, or in all
+// namespaces if it is empty.
+func listHelmReleases(namespace string) ([]helmRelease, error) {
+	args := []string{"list", "--output", "json"}
+	if namespace == "" {
+		args = append(args, "--all-namespaces")
+	} else {
+		args = append(args, "--namespace", namespace)
+	}
+	out, err := runHelm(args...)
+	if err != nil {
+		return nil, err
+	}
+	var releases []helmRelease
+	if err := json.Unmarshal(out, &releases); err != nil {
+		return nil, fmt.Errorf("failed to parse helm list output: %w", err)
+	}
+	return releases, nil
+}
+
+// stageHelmRelease writes a release's user-supplied values and each resource of its
+// rendered manifest under dir/<namespace>/<release>.
+func stageHelmRelease(dir string, rel helmRelease) error {
+	releaseDir := filepath.Join(dir, rel.Namespace, rel.Name)
+	if err := os.MkdirAll(releaseDir, 0o755); err != nil {
+		return err
+	}
+
+	values, err := runHelm("get", "values", rel.Name, "--namespace", rel.Namespace, "--output", "yaml")
+	if err != nil {
+		return err
+	}
+	header := fmt.Sprintf("# Values for Helm release %s (chart %s)\n", rel.Name, rel.Chart)
+	if err := os.WriteFile(filepath.Join(releaseDir, "values.yaml"), append([]byte(header), values...), 0o644); err != nil {
+		return err
+	}
+
+	manifest, err := runHelm("get", "manifest", rel.Name, "--namespace", rel.Namespace)
+	if err != nil {
+		return err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(manifest))
+	for {
+		var obj map[string]any
+		if err := decoder.Decode(&obj); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to parse manifest of release %s: %w", rel.Name, err)
+		}
+		if obj == nil {
+			continue
+		}
+		kind, _, name := resourceIdentity(obj)
+		if kind == "" || name == "" {
+			continue
+		}
+		if obj["kind"] == "Secret" {
+			delete(obj, "data")
+			delete(obj, "stringData")
+		}
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(releaseDir, strings.ToLower(kind)+"_"+name+".yaml")
+		if err := os.WriteFile(target, data, 0o644); err != nil {
+			return err
+		}
+	}
+}
+
+// stageHelmReleases reads every release in the requested namespaces into dir and
+// returns how many releases were staged.
+func stageHelmReleases(dir string) (int, error) {
+	namespaces := clusterNamespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+	staged := 0
+	for _, namespace := range namespaces {
+		releases, err := listHelmReleases(namespace)
+		if err != nil {
+			return staged, err
+		}
+		for _, rel := range releases {
+			if err := stageHelmRelease(dir, rel); err != nil {
+				slog.Warn("skipping release", "release", rel.Name, "namespace", rel.Namespace, "error", err)
+				continue
+			}
+			staged++
+		}
+	}
+	return staged, nil
+}
+
+// runHelmReleases is the main logic for the helm-releases command.
+func runHelmReleases() error {
+	setupLogging()
+	if err := validateLang(); err != nil {
+		return err
+	}
+	llm, err := createProvider()
+	if err != nil {
+		return fmt.Errorf("failed to create %s provider: %w", provider, err)
+	}
+	return runHelmReleasesWithProvider(llm)
+}
+
+// runHelmReleasesWithProvider inventories the installed Helm releases and summarizes
+// them with the given provider.
+func runHelmReleasesWithProvider(llm LLMProvider) error {
+	dir, err := os.MkdirTemp("", "yaml-to-readme-helm-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	staged, err := stageHelmReleases(dir)
+	if err != nil {
+		return err
+	}
+	statusf("Read %d Helm releases from the cluster\n", staged)
+	report, err := summarizeStagedTree(dir, inventoryDocPath("helm"), llm)
+	if err != nil {
+		return err
+	}
+	printRunReport(report)
+	return nil
+}
+
+// helmReleasesCmd documents the Helm releases installed in a cluster.
+var helmReleasesCmd = &cobra.Command{
+	Use:   "helm-releases",
+	Short: "Summarize the Helm releases installed in a cluster",
+	Long: `List installed Helm releases with the helm CLI, fetch each release's user-supplied values
+and rendered manifest, and summarize them into a document with one section per release.
+Secret values in rendered manifests are never written or sent to the LLM.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runHelmReleases()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(helmReleasesCmd)
+	helmReleasesCmd.Flags().StringVar(&clusterContext, "context", "", "kubeconfig context to read from (default: current context)")
+	helmReleasesCmd.Flags().StringSliceVarP(&clusterNamespaces, "namespace", "n", nil, "Namespaces to read releases from (default: all namespaces)")
+	helmReleasesCmd.Flags().StringVar(&clusterOutputDir, "output-dir", ".", "Directory to write the Helm document to")
+}
@@ -0,0 +1,442 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const DefaultLang = "en"
+
+// docLabels holds the structural text of a generated document in one language.
+type docLabels struct {
+	// Language is the English name of the language, used to instruct the LLM.
+	Language        string
+	Title           string
+	Intro           string
+	HowToUse        string
+	HowToUseLinks   string
+	HowToUseSummary string
+	MaintenanceNote string
+	HTMLIntro       string
+	// SkippedGenerated titles the appendix of files skipped as machine-generated.
+	SkippedGenerated      string
+	SkippedGeneratedIntro string
+	// DeclaredVsDeployed titles the --diff-cluster appendix of files that drifted from the cluster.
+	DeclaredVsDeployed      string
+	DeclaredVsDeployedIntro string
+	// Findings titles the --risk-analysis appendix of risky settings.
+	Findings      string
+	FindingsIntro string
+	// PolicyViolations titles the --policy appendix of Rego policy violations.
+	PolicyViolations      string
+	PolicyViolationsIntro string
+	// NetworkSurface titles the --network-surface appendix of ports and routes.
+	NetworkSurface      string
+	NetworkSurfaceIntro string
+	// LabelTaxonomy titles the --label-report appendix of label and annotation keys.
+	LabelTaxonomy      string
+	LabelTaxonomyIntro string
+	// MissingLabels titles the list of resources missing --required-labels.
+	MissingLabels string
+	// MissingLabelsIntro is a format string taking the required label keys.
+	MissingLabelsIntro string
+	// Overview titles the --stats section of the header, listing the Stats* items.
+	Overview         string
+	StatsFiles       string
+	StatsDirectories string
+	StatsKinds       string
+	StatsReadingTime string
+	// Glossary titles the --glossary appendix of recurring kinds.
+	Glossary      string
+	GlossaryIntro string
+	// ChangeLog titles the --changelog section of recent runs, whose entries list the
+	// ChangeLogAdded, ChangeLogUpdated, and ChangeLogRemoved files.
+	ChangeLog        string
+	ChangeLogIntro   string
+	ChangeLogAdded   string
+	ChangeLogUpdated string
+	ChangeLogRemoved string
+	// KeyFiles titles the --key-files highlight section at the top of the document.
+	KeyFiles      string
+	KeyFilesIntro string
+	// NotDeployed is a format string taking a resource's kind/name.
+	NotDeployed string
+	// EmptyFile is the summary of a file with no content.
+	EmptyFile string
+	// TrivialFile is a format string taking the inlined content of a trivial file.
+	TrivialFile string
+	// DependabotSummary summarizes a dependabot.yml. It is a format string taking the
+	// DependabotEcosystem or DependabotEcosystems count and the DependabotRule list.
+	DependabotSummary    string
+	DependabotEcosystem  string
+	DependabotEcosystems string
+	// DependabotRule is a format string taking an ecosystem and its directory, followed
+	// by the DependabotSchedule of the interval and day, if any.
+	DependabotRule     string
+	DependabotSchedule string
+	// DependabotIgnores is a format string taking the ignored dependencies.
+	DependabotIgnores string
+	// RenovateSummary summarizes a Renovate config. It is a format string taking the
+	// RenovateExtending presets, the RenovateManagers or RenovateAllManagers, and the
+	// RenovateSchedule. The presets and schedule may be empty.
+	RenovateSummary     string
+	RenovateExtending   string
+	RenovateManagers    string
+	RenovateAllManagers string
+	RenovateSchedule    string
+	// RenovateDefines is a format string taking the RenovatePackageRule or
+	// RenovatePackageRules count and the RenovateAutomergeRules clause, if any.
+	RenovateDefines        string
+	RenovatePackageRule    string
+	RenovatePackageRules   string
+	RenovateAutomergeRules string
+	// RenovateAutomerged notes a config without package rules that automerges updates.
+	RenovateAutomerged string
+	// GeneratedAt is a format string taking the timestamp and model name.
+	GeneratedAt string
+}
+
+// labelsByLang maps --lang codes to their document labels.
+var labelsByLang = map[string]docLabels{
+	"en": {
+		Language:                "English",
+		Title:                   "YAML File Details",
+		Intro:                   "This document provides an overview of all YAML files in the repository, organized by directory, with a brief description of what each file does or configures. Use this as a reference for understanding the purpose of each manifest or configuration file.",
+		HowToUse:                "How to Use",
+		HowToUseLinks:           "Click the file links to jump to the file in the repository.",
+		HowToUseSummary:         "Each entry includes a short summary of the file's intent or function.",
+		MaintenanceNote:         "To keep this file up to date, add new YAMLs as they are introduced and provide a short description for each.",
+		HTMLIntro:               "Overview of all YAML files, organized by directory.",
+		SkippedGenerated:        "Skipped as Generated",
+		SkippedGeneratedIntro:   "These files look machine-generated or are too large, so they were not summarized.",
+		DeclaredVsDeployed:      "Declared vs Deployed",
+		DeclaredVsDeployedIntro: "These files declare resources that differ from what is running in the live cluster.",
+		Findings:                "Findings",
+		FindingsIntro:           "These settings are commonly risky. Review them before deploying.",
+		PolicyViolations:        "Policy Violations",
+		PolicyViolationsIntro:   "These manifests violate the supplied policies.",
+		NetworkSurface:          "Network Surface",
+		NetworkSurfaceIntro:     "Service ports, Ingress routes, and Gateway listeners and routes declared in this repository.",
+		LabelTaxonomy:           "Label and Annotation Keys",
+		LabelTaxonomyIntro:      "Every metadata label and annotation key used in this repository, with how often it is used.",
+		MissingLabels:           "Missing Required Labels",
+		MissingLabelsIntro:      "These resources do not set every required label (%s).",
+		Glossary:                "Glossary",
+		Overview:                "Overview",
+		StatsFiles:              "Files",
+		StatsDirectories:        "Directories",
+		StatsKinds:              "Kinds",
+		StatsReadingTime:        "Reading time",
+		GlossaryIntro:           "Resource kinds used throughout this repository. Entries link here for the kinds they use.",
+		ChangeLog:               "Change Log",
+		ChangeLogIntro:          "Entries added, updated, or removed by recent runs, newest first.",
+		ChangeLogAdded:          "Added",
+		ChangeLogUpdated:        "Updated",
+		ChangeLogRemoved:        "Removed",
+		KeyFiles:                "Key Configuration Files",
+		KeyFilesIntro:           "The most important files in this repository. Every file is listed by directory below.",
+		NotDeployed:             "%s is declared but not deployed.",
+		EmptyFile:               "Empty placeholder file.",
+		TrivialFile:             "Contains only %s.",
+		DependabotSummary:       "Dependabot config that checks %s for version updates: %s.",
+		DependabotEcosystem:     "%d ecosystem",
+		DependabotEcosystems:    "%d ecosystems",
+		DependabotRule:          "%s in %s",
+		DependabotSchedule:      "%s on %s",
+		DependabotIgnores:       " It ignores updates to %s.",
+		RenovateSummary:         "Renovate config%s that updates %s%s.",
+		RenovateExtending:       " extending %s",
+		RenovateManagers:        "%s dependencies",
+		RenovateAllManagers:     "all detected package managers",
+		RenovateSchedule:        " on schedule %s",
+		RenovateDefines:         " It defines %s%s.",
+		RenovatePackageRule:     "%d package rule",
+		RenovatePackageRules:    "%d package rules",
+		RenovateAutomergeRules:  ", %d of which automerge",
+		RenovateAutomerged:      " Updates are automerged.",
+		GeneratedAt:             "Generated at %s using model %s",
+	},
+	"de": {
+		Language:                "German",
+		Title:                   "YAML-Dateiübersicht",
+		Intro:                   "Dieses Dokument gibt einen Überblick über alle YAML-Dateien im Repository, gruppiert nach Verzeichnis, mit einer kurzen Beschreibung dessen, was jede Datei tut oder konfiguriert. Verwenden Sie es als Referenz, um den Zweck jedes Manifests und jeder Konfigurationsdatei zu verstehen.",
+		HowToUse:                "Verwendung",
+		HowToUseLinks:           "Klicken Sie auf die Dateilinks, um zur Datei im Repository zu springen.",
+		HowToUseSummary:         "Jeder Eintrag enthält eine kurze Zusammenfassung des Zwecks oder der Funktion der Datei.",
+		MaintenanceNote:         "Um diese Datei aktuell zu halten, fügen Sie neue YAML-Dateien bei ihrer Einführung hinzu und beschreiben Sie sie jeweils kurz.",
+		HTMLIntro:               "Übersicht aller YAML-Dateien, gruppiert nach Verzeichnis.",
+		SkippedGenerated:        "Als generiert übersprungen",
+		SkippedGeneratedIntro:   "Diese Dateien scheinen maschinell erzeugt oder zu groß zu sein und wurden daher nicht zusammengefasst.",
+		DeclaredVsDeployed:      "Deklariert vs. bereitgestellt",
+		DeclaredVsDeployedIntro: "Diese Dateien deklarieren Ressourcen, die von dem abweichen, was im Live-Cluster läuft.",
+		Findings:                "Befunde",
+		FindingsIntro:           "Diese Einstellungen sind häufig riskant. Prüfen Sie sie vor dem Deployment.",
+		PolicyViolations:        "Richtlinienverstöße",
+		PolicyViolationsIntro:   "Diese Manifeste verstoßen gegen die angegebenen Richtlinien.",
+		NetworkSurface:          "Netzwerkoberfläche",
+		NetworkSurfaceIntro:     "In diesem Repository deklarierte Service-Ports, Ingress-Routen sowie Gateway-Listener und -Routen.",
+		LabelTaxonomy:           "Label- und Annotationsschlüssel",
+		LabelTaxonomyIntro:      "Alle in diesem Repository verwendeten Label- und Annotationsschlüssel mit ihrer Häufigkeit.",
+		MissingLabels:           "Fehlende Pflicht-Labels",
+		MissingLabelsIntro:      "Diese Ressourcen setzen nicht alle Pflicht-Labels (%s).",
+		Glossary:                "Glossar",
+		Overview:                "Überblick",
+		StatsFiles:              "Dateien",
+		StatsDirectories:        "Verzeichnisse",
+		StatsKinds:              "Ressourcentypen",
+		StatsReadingTime:        "Lesezeit",
+		GlossaryIntro:           "Ressourcentypen, die in diesem Repository mehrfach verwendet werden. Einträge verlinken hierher für die Typen, die sie verwenden.",
+		ChangeLog:               "Änderungsprotokoll",
+		ChangeLogIntro:          "Einträge, die bei den letzten Läufen hinzugefügt, aktualisiert oder entfernt wurden, die neuesten zuerst.",
+		ChangeLogAdded:          "Hinzugefügt",
+		ChangeLogUpdated:        "Aktualisiert",
+		ChangeLogRemoved:        "Entfernt",
+		KeyFiles:                "Wichtige Konfigurationsdateien",
+		KeyFilesIntro:           "Die wichtigsten Dateien dieses Repositorys. Alle Dateien sind unten nach Verzeichnis aufgeführt.",
+		NotDeployed:             "%s ist deklariert, aber nicht bereitgestellt.",
+		EmptyFile:               "Leere Platzhalterdatei.",
+		TrivialFile:             "Enthält nur %s.",
+		DependabotSummary:       "Dependabot-Konfiguration, die %s auf Versionsupdates prüft: %s.",
+		DependabotEcosystem:     "%d Ökosystem",
+		DependabotEcosystems:    "%d Ökosysteme",
+		DependabotRule:          "%s in %s",
+		DependabotSchedule:      "%s am %s",
+		DependabotIgnores:       " Updates von %s werden ignoriert.",
+		RenovateSummary:         "Renovate-Konfiguration%s, die %s%s aktualisiert.",
+		RenovateExtending:       " auf Basis von %s",
+		RenovateManagers:        "die Abhängigkeiten von %s",
+		RenovateAllManagers:     "alle erkannten Paketmanager",
+		RenovateSchedule:        " nach dem Zeitplan %s",
+		RenovateDefines:         " Sie definiert %s%s.",
+		RenovatePackageRule:     "%d Paketregel",
+		RenovatePackageRules:    "%d Paketregeln",
+		RenovateAutomergeRules:  ", davon %d mit Automerge",
+		RenovateAutomerged:      " Updates werden automatisch zusammengeführt.",
+		GeneratedAt:             "Erstellt am %s mit dem Modell %s",
+	},
+	"es": {
+		Language:                "Spanish",
+		Title:                   "Detalles de archivos YAML",
+		Intro:                   "Este documento ofrece una visión general de todos los archivos YAML del repositorio, organizados por directorio, con una breve descripción de lo que hace o configura cada archivo. Úselo como referencia para comprender el propósito de cada manifiesto o archivo de configuración.",
+		HowToUse:                "Cómo usarlo",
+		HowToUseLinks:           "Haga clic en los enlaces para ir al archivo en el repositorio.",
+		HowToUseSummary:         "Cada entrada incluye un breve resumen de la intención o función del archivo.",
+		MaintenanceNote:         "Para mantener este archivo actualizado, añada los nuevos YAML a medida que se introduzcan y proporcione una breve descripción de cada uno.",
+		HTMLIntro:               "Resumen de todos los archivos YAML, organizados por directorio.",
+		SkippedGenerated:        "Omitidos por ser generados",
+		SkippedGeneratedIntro:   "Estos archivos parecen generados automáticamente o son demasiado grandes, por lo que no se resumieron.",
+		DeclaredVsDeployed:      "Declarado frente a desplegado",
+		DeclaredVsDeployedIntro: "Estos archivos declaran recursos que difieren de lo que se ejecuta en el clúster.",
+		Findings:                "Hallazgos",
+		FindingsIntro:           "Estos ajustes suelen ser arriesgados. Revíselos antes de desplegar.",
+		PolicyViolations:        "Infracciones de políticas",
+		PolicyViolationsIntro:   "Estos manifiestos infringen las políticas proporcionadas.",
+		NetworkSurface:          "Superficie de red",
+		NetworkSurfaceIntro:     "Puertos de Service, rutas de Ingress y listeners y rutas de Gateway declarados en este repositorio.",
+		LabelTaxonomy:           "Claves de etiquetas y anotaciones",
+		LabelTaxonomyIntro:      "Todas las claves de etiquetas y anotaciones usadas en este repositorio, con su frecuencia de uso.",
+		MissingLabels:           "Etiquetas obligatorias ausentes",
+		MissingLabelsIntro:      "Estos recursos no definen todas las etiquetas obligatorias (%s).",
+		Glossary:                "Glosario",
+		Overview:                "Resumen",
+		StatsFiles:              "Archivos",
+		StatsDirectories:        "Directorios",
+		StatsKinds:              "Tipos",
+		StatsReadingTime:        "Tiempo de lectura",
+		GlossaryIntro:           "Tipos de recursos usados en todo este repositorio. Las entradas enlazan aquí los tipos que usan.",
+		ChangeLog:               "Registro de cambios",
+		ChangeLogIntro:          "Entradas añadidas, actualizadas o eliminadas en las ejecuciones recientes, de la más reciente a la más antigua.",
+		ChangeLogAdded:          "Añadidas",
+		ChangeLogUpdated:        "Actualizadas",
+		ChangeLogRemoved:        "Eliminadas",
+		KeyFiles:                "Archivos de configuración clave",
+		KeyFilesIntro:           "Los archivos más importantes de este repositorio. Todos los archivos se enumeran a continuación por directorio.",
+		NotDeployed:             "%s está declarado pero no desplegado.",
+		EmptyFile:               "Archivo de marcador de posición vacío.",
+		TrivialFile:             "Contiene solo %s.",
+		DependabotSummary:       "Configuración de Dependabot que comprueba %s en busca de actualizaciones de versión: %s.",
+		DependabotEcosystem:     "%d ecosistema",
+		DependabotEcosystems:    "%d ecosistemas",
+		DependabotRule:          "%s en %s",
+		DependabotSchedule:      "%s el %s",
+		DependabotIgnores:       " Ignora las actualizaciones de %s.",
+		RenovateSummary:         "Configuración de Renovate%s que actualiza %s%s.",
+		RenovateExtending:       " basada en %s",
+		RenovateManagers:        "las dependencias de %s",
+		RenovateAllManagers:     "todos los gestores de paquetes detectados",
+		RenovateSchedule:        " según la programación %s",
+		RenovateDefines:         " Define %s%s.",
+		RenovatePackageRule:     "%d regla de paquetes",
+		RenovatePackageRules:    "%d reglas de paquetes",
+		RenovateAutomergeRules:  ", %d de ellas con fusión automática",
+		RenovateAutomerged:      " Las actualizaciones se fusionan automáticamente.",
+		GeneratedAt:             "Generado el %s con el modelo %s",
+	},
+	"fr": {
+		Language:                "French",
+		Title:                   "Détails des fichiers YAML",
+		Intro:                   "Ce document présente tous les fichiers YAML du dépôt, organisés par répertoire, avec une brève description de ce que fait ou configure chaque fichier. Utilisez-le comme référence pour comprendre le rôle de chaque manifeste ou fichier de configuration.",
+		HowToUse:                "Utilisation",
+		HowToUseLinks:           "Cliquez sur les liens pour accéder au fichier dans le dépôt.",
+		HowToUseSummary:         "Chaque entrée comprend un court résumé de l'intention ou de la fonction du fichier.",
+		MaintenanceNote:         "Pour maintenir ce fichier à jour, ajoutez les nouveaux YAML au fur et à mesure et fournissez une courte description pour chacun.",
+		HTMLIntro:               "Vue d'ensemble de tous les fichiers YAML, organisés par répertoire.",
+		SkippedGenerated:        "Ignorés car générés",
+		SkippedGeneratedIntro:   "Ces fichiers semblent générés automatiquement ou sont trop volumineux ; ils n'ont donc pas été résumés.",
+		DeclaredVsDeployed:      "Déclaré ou déployé",
+		DeclaredVsDeployedIntro: "Ces fichiers déclarent des ressources qui diffèrent de ce qui s'exécute dans le cluster.",
+		Findings:                "Constats",
+		FindingsIntro:           "Ces paramètres sont souvent risqués. Vérifiez-les avant le déploiement.",
+		PolicyViolations:        "Violations de politiques",
+		PolicyViolationsIntro:   "Ces manifestes enfreignent les politiques fournies.",
+		NetworkSurface:          "Surface réseau",
+		NetworkSurfaceIntro:     "Ports de Service, routes Ingress, ainsi que listeners et routes Gateway déclarés dans ce dépôt.",
+		LabelTaxonomy:           "Clés de labels et d'annotations",
+		LabelTaxonomyIntro:      "Toutes les clés de labels et d'annotations utilisées dans ce dépôt, avec leur fréquence d'utilisation.",
+		MissingLabels:           "Labels obligatoires manquants",
+		MissingLabelsIntro:      "Ces ressources ne définissent pas tous les labels obligatoires (%s).",
+		Glossary:                "Glossaire",
+		Overview:                "Aperçu",
+		StatsFiles:              "Fichiers",
+		StatsDirectories:        "Répertoires",
+		StatsKinds:              "Types",
+		StatsReadingTime:        "Temps de lecture",
+		GlossaryIntro:           "Types de ressources utilisés dans ce dépôt. Les entrées renvoient ici pour les types qu'elles utilisent.",
+		ChangeLog:               "Journal des modifications",
+		ChangeLogIntro:          "Entrées ajoutées, mises à jour ou supprimées lors des dernières exécutions, les plus récentes en premier.",
+		ChangeLogAdded:          "Ajoutées",
+		ChangeLogUpdated:        "Mises à jour",
+		ChangeLogRemoved:        "Supprimées",
+		KeyFiles:                "Fichiers de configuration clés",
+		KeyFilesIntro:           "Les fichiers les plus importants de ce dépôt. Tous les fichiers sont listés ci-dessous par répertoire.",
+		NotDeployed:             "%s est déclaré mais non déployé.",
+		EmptyFile:               "Fichier vide servant d'emplacement réservé.",
+		TrivialFile:             "Contient uniquement %s.",
+		DependabotSummary:       "Configuration Dependabot qui vérifie les mises à jour de version de %s : %s.",
+		DependabotEcosystem:     "%d écosystème",
+		DependabotEcosystems:    "%d écosystèmes",
+		DependabotRule:          "%s dans %s",
+		DependabotSchedule:      "%s le %s",
+		DependabotIgnores:       " Elle ignore les mises à jour de %s.",
+		RenovateSummary:         "Configuration Renovate%s qui met à jour %s%s.",
+		RenovateExtending:       " basée sur %s",
+		RenovateManagers:        "les dépendances %s",
+		RenovateAllManagers:     "tous les gestionnaires de paquets détectés",
+		RenovateSchedule:        " selon le planning %s",
+		RenovateDefines:         " Elle définit %s%s.",
+		RenovatePackageRule:     "%d règle de paquets",
+		RenovatePackageRules:    "%d règles de paquets",
+		RenovateAutomergeRules:  ", dont %d en fusion automatique",
+		RenovateAutomerged:      " Les mises à jour sont fusionnées automatiquement.",
+		GeneratedAt:             "Généré le %s avec le modèle %s",
+	},
+	"ja": {
+		Language:                "Japanese",
+		Title:                   "YAML ファイル詳細",
+		Intro:                   "このドキュメントは、リポジトリ内のすべての YAML ファイルをディレクトリごとに整理し、各ファイルが何を行い何を設定するかを簡潔に説明したものです。各マニフェストや設定ファイルの目的を理解するための参照として利用してください。",
+		HowToUse:                "使い方",
+		HowToUseLinks:           "ファイルのリンクをクリックすると、リポジトリ内のファイルに移動します。",
+		HowToUseSummary:         "各エントリには、ファイルの意図や機能の短い要約が含まれます。",
+		MaintenanceNote:         "このファイルを最新に保つため、新しい YAML を追加したら、それぞれに短い説明を加えてください。",
+		HTMLIntro:               "すべての YAML ファイルをディレクトリごとにまとめた概要です。",
+		SkippedGenerated:        "生成ファイルとしてスキップ",
+		SkippedGeneratedIntro:   "これらのファイルは自動生成されたものか大きすぎるため、要約していません。",
+		DeclaredVsDeployed:      "宣言とデプロイの差分",
+		DeclaredVsDeployedIntro: "これらのファイルが宣言するリソースは、稼働中のクラスターの内容と異なります。",
+		Findings:                "検出事項",
+		FindingsIntro:           "これらの設定は一般にリスクがあります。デプロイ前に確認してください。",
+		PolicyViolations:        "ポリシー違反",
+		PolicyViolationsIntro:   "これらのマニフェストは指定されたポリシーに違反しています。",
+		NetworkSurface:          "ネットワーク公開面",
+		NetworkSurfaceIntro:     "このリポジトリで宣言された Service のポート、Ingress のルート、Gateway のリスナーとルートです。",
+		LabelTaxonomy:           "ラベルとアノテーションのキー",
+		LabelTaxonomyIntro:      "このリポジトリで使われているすべてのラベルとアノテーションのキーと、その使用回数です。",
+		MissingLabels:           "必須ラベルの欠落",
+		MissingLabelsIntro:      "これらのリソースは必須ラベル (%s) の一部を設定していません。",
+		Glossary:                "用語集",
+		Overview:                "概要",
+		StatsFiles:              "ファイル数",
+		StatsDirectories:        "ディレクトリ数",
+		StatsKinds:              "種類",
+		StatsReadingTime:        "読了時間",
+		GlossaryIntro:           "このリポジトリで繰り返し使われているリソースの種類です。各エントリは使用している種類へリンクしています。",
+		ChangeLog:               "変更履歴",
+		ChangeLogIntro:          "最近の実行で追加、更新、削除されたエントリです。新しい順に並んでいます。",
+		ChangeLogAdded:          "追加",
+		ChangeLogUpdated:        "更新",
+		ChangeLogRemoved:        "削除",
+		KeyFiles:                "主要な設定ファイル",
+		KeyFilesIntro:           "このリポジトリで特に重要なファイルです。すべてのファイルは以下にディレクトリごとに記載しています。",
+		NotDeployed:             "%s は宣言されていますが、デプロイされていません。",
+		EmptyFile:               "空のプレースホルダーファイルです。",
+		TrivialFile:             "%s のみを含みます。",
+		DependabotSummary:       "%s のバージョン更新を確認する Dependabot 設定: %s。",
+		DependabotEcosystem:     "%d 個のエコシステム",
+		DependabotEcosystems:    "%d 個のエコシステム",
+		DependabotRule:          "%[2]s の %[1]s",
+		DependabotSchedule:      "%s (%s)",
+		DependabotIgnores:       "%s の更新は無視します。",
+		RenovateSummary:         "%[3]s%[2]s を更新する Renovate 設定%[1]s。",
+		RenovateExtending:       " (%s を拡張)",
+		RenovateManagers:        "%s の依存関係",
+		RenovateAllManagers:     "検出されたすべてのパッケージマネージャー",
+		RenovateSchedule:        "スケジュール %s で ",
+		RenovateDefines:         "%s%sを定義しています。",
+		RenovatePackageRule:     "%d 個のパッケージルール",
+		RenovatePackageRules:    "%d 個のパッケージルール",
+		RenovateAutomergeRules:  " (うち %d 個は自動マージ)",
+		RenovateAutomerged:      "更新は自動マージされます。",
+		GeneratedAt:             "%s にモデル %s で生成",
+	},
+}
+
+// supportedLangs returns the sorted list of --lang codes.
+func supportedLangs() []string {
+	langs := make([]string, 0, len(labelsByLang))
+	for lang := range labelsByLang {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// validateLang returns an error if the --lang flag names an unsupported language.
+func validateLang() error {
+	if _, ok := labelsByLang[lang]; !ok {
+		return fmt.Errorf("unsupported --lang %q, expected one of: %s", lang, strings.Join(supportedLangs(), ", "))
+	}
+	return nil
+}
+
+// currentLabels returns the labels for the --lang flag, falling back to English.
+func currentLabels() docLabels {
+	if labels, ok := labelsByLang[lang]; ok {
+		return labels
+	}
+	return labelsByLang[DefaultLang]
+}
+
+// markdownHeader renders the markdown document header in the --lang language.
+// For English it is identical to MarkdownHeader.
+func markdownHeader() string {
+	l := currentLabels()
+	return fmt.Sprintf("# %s\n\n%s\n\n---\n\n## %s\n- %s\n- %s\n\n---\n\n<!--\n  %s\n-->\n\n",
+		l.Title, l.Intro, l.HowToUse, l.HowToUseLinks, l.HowToUseSummary, l.MaintenanceNote)
+}
+
+// summarizePrompt returns the summarization prompt of the active --audience, with the
+// --max-sentences and --max-chars length, instructing the LLM to answer in the --lang
+// language when it is not English.
+func summarizePrompt() string {
+	return localizedPrompt(lengthPrompt(audiencePrompt()))
+}
+
+// localizedPrompt prefixes prompt with an instruction to answer in the --lang language
+// when it is not English.
+func localizedPrompt(prompt string) string {
+	if lang == DefaultLang {
+		return prompt
+	}
+	return fmt.Sprintf("Write your answer in %s. %s", currentLabels().Language, prompt)
+}
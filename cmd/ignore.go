@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/sebrandon1/yaml-to-readme/summarizer"
+)
+
+// excludePatterns are the --exclude globs. Matching files are not documented and matching
+// directories are not searched.
+var excludePatterns []string
+
+// includePatterns are the --include globs. When set, only matching files are documented.
+var includePatterns []string
+
+// noGitignore disables .gitignore handling.
+var noGitignore bool
+
+// ignoreRule is one pattern of a .gitignore file.
+type ignoreRule struct {
+	pattern string
+	negate  bool
+	dirOnly bool
+	// anchored patterns contain a slash, so they are matched against the path from the
+	// .gitignore's directory; others are matched against the name at any depth.
+	anchored bool
+}
+
+// matches reports whether the rule matches a slash-separated path relative to the
+// directory of its .gitignore.
+func (r ignoreRule) matches(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.anchored {
+		return matchPathGlob(r.pattern, rel)
+	}
+	ok, err := path.Match(r.pattern, path.Base(rel))
+	return err == nil && ok
+}
+
+// parseGitignore parses the patterns of a .gitignore file.
+func parseGitignore(data []byte) []ignoreRule {
+	var rules []ignoreRule
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if !strings.HasSuffix(line, `\ `) {
+			line = strings.TrimRight(line, " ")
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var r ignoreRule
+		if strings.HasPrefix(line, "!") {
+			r.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		r.anchored = strings.Contains(line, "/")
+		r.pattern = strings.TrimPrefix(line, "/")
+		if r.pattern != "" {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// gitignoreMatcher applies the .gitignore files of a tree, from the root of the git work
+// tree containing the searched directory down. Each directory's file is read once, when
+// the search first needs it.
+type gitignoreMatcher struct {
+	root  string
+	mu    sync.Mutex
+	rules map[string][]ignoreRule
+}
+
+// newGitignoreMatcher returns the matcher for a search of dir. Outside a git work tree
+// only the .gitignore files at or below dir apply.
+func newGitignoreMatcher(dir string) *gitignoreMatcher {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		abs = dir
+	}
+	root := abs
+	for d := abs; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			root = d
+			break
+		}
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	return &gitignoreMatcher{root: root, rules: make(map[string][]ignoreRule)}
+}
+
+// rulesFor returns the rules of the .gitignore file in dir, if any.
+func (m *gitignoreMatcher) rulesFor(dir string) []ignoreRule {
+	m.mu.Lock()
+	rules, ok := m.rules[dir]
+	m.mu.Unlock()
+	if ok {
+		return rules
+	}
+	// Read outside the lock, so the walk's workers are not serialized on slow file systems
+	if data, err := os.ReadFile(filepath.Join(dir, ".gitignore")); err == nil {
+		rules = parseGitignore(data)
+	}
+	m.mu.Lock()
+	m.rules[dir] = rules
+	m.mu.Unlock()
+	return rules
+}
+
+// ignored reports whether git would ignore p. As in git, the last matching pattern wins
+// and patterns in deeper directories take precedence. The .git directory is always
+// ignored.
+func (m *gitignoreMatcher) ignored(p string, isDir bool) bool {
+	if isDir && filepath.Base(p) == ".git" {
+		return true
+	}
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(m.root, abs)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	ignored := false
+	dir := m.root
+	for i := range parts {
+		for _, r := range m.rulesFor(dir) {
+			if r.matches(strings.Join(parts[i:], "/"), isDir) {
+				ignored = !r.negate
+			}
+		}
+		dir = filepath.Join(dir, parts[i])
+	}
+	return ignored
+}
+
+// pathFilter returns the function deciding which files and directories a search of dir
+// leaves out: those ignored by git, those matching --exclude, and, with --include, files
+// matching none of its patterns. It returns nil when nothing is left out.
+func pathFilter(dir string) summarizer.SkipFunc {
+	var gitignore *gitignoreMatcher
+	if !noGitignore {
+		gitignore = newGitignoreMatcher(dir)
+	}
+	if gitignore == nil && len(excludePatterns) == 0 && len(includePatterns) == 0 {
+		return nil
+	}
+	return func(p string, isDir bool) bool {
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return false
+		}
+		rel = filepath.ToSlash(rel)
+		if matchAnyPathGlob(excludePatterns, rel) {
+			return true
+		}
+		if !isDir && len(includePatterns) > 0 && !matchAnyPathGlob(includePatterns, rel) {
+			return true
+		}
+		return gitignore != nil && gitignore.ignored(p, isDir)
+	}
+}
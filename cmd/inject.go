@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Markers delimiting the summaries written into an existing file with --inject.
+const (
+	injectStartMarker = "<!-- yaml-summaries:start -->"
+	injectEndMarker   = "<!-- yaml-summaries:end -->"
+)
+
+// injectPath is the --inject file. When set, the summaries are written between its
+// markers instead of into a separate document.
+var injectPath string
+
+// injectTarget returns the --inject file for dir. A relative path is resolved against
+// the directory the document would be written to.
+func injectTarget(dir string) string {
+	if filepath.IsAbs(injectPath) {
+		return injectPath
+	}
+	return filepath.Join(outputDir(dir), injectPath)
+}
+
+// splitInjected splits data around the content between the injection markers. The
+// markers themselves stay in before and after.
+func splitInjected(data []byte) (before, section, after []byte, err error) {
+	start := bytes.Index(data, []byte(injectStartMarker))
+	if start < 0 {
+		return nil, nil, nil, fmt.Errorf("missing %s marker", injectStartMarker)
+	}
+	start += len(injectStartMarker)
+	end := bytes.Index(data[start:], []byte(injectEndMarker))
+	if end < 0 {
+		return nil, nil, nil, fmt.Errorf("missing %s marker after %s", injectEndMarker, injectStartMarker)
+	}
+	end += start
+	return data[:start], data[start:end], data[end:], nil
+}
+
+// injectedLines returns the lines between the injection markers, so headings and lists
+// elsewhere in the file are not mistaken for entries. Lines without markers are returned
+// unchanged.
+func injectedLines(lines []string) []string {
+	_, section, _, err := splitInjected([]byte(strings.Join(lines, "\n")))
+	if err != nil {
+		return lines
+	}
+	return strings.Split(string(section), "\n")
+}
+
+// validateInject checks that --inject is used with a markdown format and that its file
+// has the markers, before any file is summarized.
+func validateInject(dir string) error {
+	if injectPath == "" {
+		return nil
+	}
+	if outputFormat != "markdown" && outputFormat != "github-wiki" {
+		return fmt.Errorf("--inject requires --format markdown or github-wiki, not %s", outputFormat)
+	}
+	path := injectTarget(dir)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read --inject file: %w", err)
+	}
+	if _, _, _, err := splitInjected(data); err != nil {
+		return fmt.Errorf("%s: %w; add %s and %s where the summaries should go", path, err, injectStartMarker, injectEndMarker)
+	}
+	return nil
+}
+
+// writeInjected replaces the content between the markers of the file at path with body,
+// leaving the rest of the file untouched.
+func writeInjected(path string, body []byte) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	before, _, after, err := splitInjected(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	var out bytes.Buffer
+	out.Write(before)
+	out.WriteString("\n")
+	out.Write(body)
+	out.Write(after)
+	return os.WriteFile(path, out.Bytes(), 0o644)
+}
+
+// documentHeader returns the title and usage notes that open a markdown document, or
+// nothing when the summaries are injected into a file that has its own.
+func documentHeader() string {
+	if injectPath != "" {
+		return ""
+	}
+	return markdownHeader()
+}
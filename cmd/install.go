@@ -0,0 +1,328 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultInstallImage is the image the install manifests run, as built from the
+// repository's Dockerfile. Push it to a registry the cluster can pull from.
+const DefaultInstallImage = "readmebuilder:latest"
+
+// DefaultInstallGitImage clones the repository in an init container, since the
+// readmebuilder image is distroless and has no git.
+const DefaultInstallGitImage = "alpine/git:latest"
+
+// Paths inside the pods generated by install.
+const (
+	installRepoPath   = "/repo"
+	installOutputPath = "/output"
+	installConfigPath = "/etc/yaml-to-readme"
+)
+
+// installOptions describes the workload install k8s generates.
+type installOptions struct {
+	Name       string
+	Namespace  string
+	Image      string
+	Workload   string
+	Schedule   string
+	GitURL     string
+	GitRef     string
+	GitImage   string
+	RepoPVC    string
+	OutputPVC  string
+	Path       string
+	OllamaHost string
+	// Config is the content of the config file mounted from a ConfigMap, if any.
+	Config string
+	// Args are extra flags passed to every run.
+	Args []string
+}
+
+// credentialsSecret is the Secret whose keys become environment variables of the
+// container, such as OPENAI_API_KEY or JIRA_API_TOKEN. It is referenced, never generated.
+func (o installOptions) credentialsSecret() string {
+	return o.Name + "-credentials"
+}
+
+// validate checks that the options describe a workload that can run. The repository
+// may be left unset with requireRepo false, for a chart whose values set it later.
+func (o installOptions) validate(requireRepo bool) error {
+	if requireRepo && o.GitURL == "" && o.RepoPVC == "" {
+		return fmt.Errorf("one of --git-url or --repo-pvc is required")
+	}
+	if o.GitURL != "" && o.RepoPVC != "" {
+		return fmt.Errorf("--git-url and --repo-pvc cannot be combined")
+	}
+	switch o.Workload {
+	case "cronjob":
+	case "deployment":
+		if o.GitURL != "" {
+			return fmt.Errorf("--workload deployment watches a mounted repository and requires --repo-pvc; a clone would never be updated")
+		}
+	default:
+		return fmt.Errorf("unsupported --workload %q, expected cronjob or deployment", o.Workload)
+	}
+	if clean := path.Clean(o.Path); clean == ".." || strings.HasPrefix(clean, "../") || path.IsAbs(clean) {
+		return fmt.Errorf("--path %q is outside the repository", o.Path)
+	}
+	return nil
+}
+
+// runArgs are the container arguments of a run over the repository.
+func (o installOptions) runArgs() []string {
+	var args []string
+	if o.Workload == "deployment" {
+		args = append(args, "watch")
+	}
+	args = append(args, "--provider", provider, "--model", ModelName, "--write-dir", installOutputPath)
+	if o.RepoPVC != "" {
+		args = append(args, "--no-write")
+	}
+	if o.Config != "" {
+		args = append(args, "--config", installConfigPath+"/"+DefaultConfigFileName)
+	}
+	args = append(args, o.Args...)
+	return append(args, path.Join(installRepoPath, o.Path))
+}
+
+// podSpec returns the pod that clones or mounts the repository and summarizes it.
+func (o installOptions) podSpec(restartPolicy string) map[string]any {
+	env := []any{}
+	if provider == "ollama" {
+		env = append(env, map[string]any{"name": "OLLAMA_HOST", "value": o.OllamaHost})
+	}
+	container := map[string]any{
+		"name":  "readmebuilder",
+		"image": o.Image,
+		"args":  o.runArgs(),
+		"env":   env,
+		"envFrom": []any{map[string]any{
+			"secretRef": map[string]any{"name": o.credentialsSecret(), "optional": provider == "ollama"},
+		}},
+		"securityContext": map[string]any{
+			"allowPrivilegeEscalation": false,
+			"readOnlyRootFilesystem":   true,
+			"capabilities":             map[string]any{"drop": []any{"ALL"}},
+		},
+	}
+	mounts := []any{
+		map[string]any{"name": "repo", "mountPath": installRepoPath, "readOnly": o.RepoPVC != ""},
+		map[string]any{"name": "output", "mountPath": installOutputPath},
+	}
+	volumes := []any{}
+	if o.RepoPVC != "" {
+		volumes = append(volumes, map[string]any{"name": "repo", "persistentVolumeClaim": map[string]any{"claimName": o.RepoPVC, "readOnly": true}})
+	} else {
+		volumes = append(volumes, map[string]any{"name": "repo", "emptyDir": map[string]any{}})
+	}
+	if o.OutputPVC != "" {
+		volumes = append(volumes, map[string]any{"name": "output", "persistentVolumeClaim": map[string]any{"claimName": o.OutputPVC}})
+	} else {
+		volumes = append(volumes, map[string]any{"name": "output", "emptyDir": map[string]any{}})
+	}
+	if o.Config != "" {
+		mounts = append(mounts, map[string]any{"name": "config", "mountPath": installConfigPath, "readOnly": true})
+		volumes = append(volumes, map[string]any{"name": "config", "configMap": map[string]any{"name": o.Name + "-config"}})
+	}
+	container["volumeMounts"] = mounts
+
+	spec := map[string]any{
+		"restartPolicy": restartPolicy,
+		"securityContext": map[string]any{
+			"runAsNonRoot":   true,
+			"seccompProfile": map[string]any{"type": "RuntimeDefault"},
+		},
+		"containers": []any{container},
+		"volumes":    volumes,
+	}
+	if o.GitURL != "" {
+		clone := []any{"clone", "--depth", "1"}
+		if o.GitRef != "" {
+			clone = append(clone, "--branch", o.GitRef)
+		}
+		spec["initContainers"] = []any{map[string]any{
+			"name":            "clone",
+			"image":           o.GitImage,
+			"args":            append(clone, o.GitURL, installRepoPath),
+			"env":             []any{map[string]any{"name": "HOME", "value": "/tmp"}},
+			"securityContext": map[string]any{"runAsUser": 65532, "allowPrivilegeEscalation": false},
+			"volumeMounts":    []any{map[string]any{"name": "repo", "mountPath": installRepoPath}},
+		}}
+	}
+	return spec
+}
+
+// k8sManifests returns the objects install k8s emits: the namespace, the config
+// ConfigMap if there is a config file, and the CronJob or Deployment.
+func k8sManifests(o installOptions) []map[string]any {
+	labels := map[string]any{"app.kubernetes.io/name": "yaml-to-readme", "app.kubernetes.io/instance": o.Name}
+	metadata := func(name string) map[string]any {
+		return map[string]any{"name": name, "namespace": o.Namespace, "labels": labels}
+	}
+	var objects []map[string]any
+	if o.Namespace != "default" {
+		objects = append(objects, map[string]any{
+			"apiVersion": "v1",
+			"kind":       "Namespace",
+			"metadata":   map[string]any{"name": o.Namespace},
+		})
+	}
+	if o.Config != "" {
+		objects = append(objects, map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   metadata(o.Name + "-config"),
+			"data":       map[string]any{DefaultConfigFileName: o.Config},
+		})
+	}
+	if o.Workload == "deployment" {
+		objects = append(objects, map[string]any{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   metadata(o.Name),
+			"spec": map[string]any{
+				"replicas": 1,
+				"strategy": map[string]any{"type": "Recreate"},
+				"selector": map[string]any{"matchLabels": labels},
+				"template": map[string]any{
+					"metadata": map[string]any{"labels": labels},
+					"spec":     o.podSpec("Always"),
+				},
+			},
+		})
+		return objects
+	}
+	objects = append(objects, map[string]any{
+		"apiVersion": "batch/v1",
+		"kind":       "CronJob",
+		"metadata":   metadata(o.Name),
+		"spec": map[string]any{
+			"schedule":                   o.Schedule,
+			"concurrencyPolicy":          "Forbid",
+			"successfulJobsHistoryLimit": 3,
+			"failedJobsHistoryLimit":     3,
+			"jobTemplate": map[string]any{
+				"spec": map[string]any{
+					"backoffLimit": 1,
+					"template": map[string]any{
+						"metadata": map[string]any{"labels": labels},
+						"spec":     o.podSpec("Never"),
+					},
+				},
+			},
+		},
+	})
+	return objects
+}
+
+// writeK8sManifests writes the manifests as one multi-document YAML stream, preceded by
+// the command that creates the referenced credentials Secret.
+func writeK8sManifests(w io.Writer, o installOptions) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Generated by yaml-to-readme install k8s. API keys and tokens are read from the\n")
+	fmt.Fprintf(&sb, "# %s Secret, which is referenced but not generated:\n", o.credentialsSecret())
+	switch provider {
+	case "openai":
+		fmt.Fprintf(&sb, "#   kubectl -n %s create secret generic %s --from-literal=OPENAI_API_KEY=...\n", o.Namespace, o.credentialsSecret())
+	case "anthropic":
+		fmt.Fprintf(&sb, "#   kubectl -n %s create secret generic %s --from-literal=ANTHROPIC_API_KEY=...\n", o.Namespace, o.credentialsSecret())
+	default:
+		fmt.Fprintf(&sb, "#   kubectl -n %s create secret generic %s --from-literal=JIRA_API_TOKEN=...\n", o.Namespace, o.credentialsSecret())
+		fmt.Fprintf(&sb, "# It is optional with the ollama provider, which needs no key.\n")
+	}
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return err
+	}
+	for _, obj := range k8sManifests(o) {
+		if _, err := io.WriteString(w, "---\n"); err != nil {
+			return err
+		}
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(obj); err != nil {
+			return err
+		}
+		if err := enc.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// installOptionsFromFlags collects the install flags and the config file.
+func installOptionsFromFlags(args []string) (installOptions, error) {
+	o := installOpts
+	o.Args = args
+	if file := findConfigFile(nil); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return o, fmt.Errorf("failed to read config file %s: %w", file, err)
+		}
+		o.Config = string(data)
+	}
+	return o, nil
+}
+
+// installCmd groups the generators of deployment manifests.
+var installCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Generate manifests or a Helm chart for running the tool in a cluster",
+}
+
+// installK8sCmd prints Kubernetes manifests that run the tool in-cluster.
+var installK8sCmd = &cobra.Command{
+	Use:   "k8s [-- extra flags]",
+	Short: "Print ready-to-apply Kubernetes manifests that run the tool in-cluster",
+	Long: `Print a CronJob (or, with --workload deployment, a Deployment running watch) that
+summarizes a repository inside the cluster, either cloned from --git-url on every run or
+mounted read-only from --repo-pvc. The --provider and --model flags, and any flags after
+--, are passed to every run. The config file, if any, is mounted from a ConfigMap. API
+keys come from a referenced Secret whose keys become environment variables; it is never
+generated, so keys do not end up in the manifests.
+
+  summarize-yaml install k8s --git-url https://github.com/org/infra.git | kubectl apply -f -`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		o, err := installOptionsFromFlags(args)
+		if err != nil {
+			return err
+		}
+		if err := o.validate(true); err != nil {
+			return err
+		}
+		return writeK8sManifests(cmd.OutOrStdout(), o)
+	},
+}
+
+var installOpts = installOptions{}
+
+// addInstallFlags registers the workload flags shared by the install subcommands.
+func addInstallFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringVar(&installOpts.Name, "name", "yaml-to-readme", "Name of the generated objects")
+	flags.StringVar(&installOpts.Image, "image", DefaultInstallImage, "readmebuilder image built from the Dockerfile")
+	flags.StringVar(&installOpts.Workload, "workload", "cronjob", "Workload to generate: cronjob, or deployment to run watch")
+	flags.StringVar(&installOpts.Schedule, "schedule", "0 3 * * *", "Cron schedule of the CronJob")
+	flags.StringVar(&installOpts.GitURL, "git-url", "", "Repository to clone on every run")
+	flags.StringVar(&installOpts.GitRef, "git-ref", "", "Branch or tag to clone (default: the remote's default branch)")
+	flags.StringVar(&installOpts.GitImage, "git-image", DefaultInstallGitImage, "Image with git used to clone --git-url")
+	flags.StringVar(&installOpts.RepoPVC, "repo-pvc", "", "PersistentVolumeClaim holding the repository, mounted read-only")
+	flags.StringVar(&installOpts.OutputPVC, "output-pvc", "", "PersistentVolumeClaim the document is written to (default: an emptyDir)")
+	flags.StringVar(&installOpts.Path, "path", ".", "Directory within the repository to summarize")
+	flags.StringVar(&installOpts.OllamaHost, "ollama-host", "http://ollama:11434", "OLLAMA_HOST of the runs with the ollama provider")
+}
+
+func init() {
+	rootCmd.AddCommand(installCmd)
+	installCmd.AddCommand(installK8sCmd)
+	addInstallFlags(installK8sCmd)
+	installK8sCmd.Flags().StringVarP(&installOpts.Namespace, "namespace", "n", "yaml-to-readme", "Namespace to install into; created unless it is default")
+}
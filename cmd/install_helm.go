@@ -0,0 +1,419 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// helmChartYAML is Chart.yaml; the chart name is filled in.
+const helmChartYAML = `apiVersion: v2
+name: %s
+description: Summarize YAML files on a schedule or as a watch service with yaml-to-readme
+type: application
+version: 0.1.0
+appVersion: latest
+`
+
+// helmHelpersTemplate holds the pod shared by the CronJob and the Deployment.
+const helmHelpersTemplate = `{{- define "yaml-to-readme.fullname" -}}
+{{- .Release.Name | trunc 63 | trimSuffix "-" -}}
+{{- end -}}
+
+{{- define "yaml-to-readme.selectorLabels" -}}
+app.kubernetes.io/name: {{ .Chart.Name }}
+app.kubernetes.io/instance: {{ .Release.Name }}
+{{- end -}}
+
+{{- define "yaml-to-readme.labels" -}}
+{{ include "yaml-to-readme.selectorLabels" . }}
+app.kubernetes.io/managed-by: {{ .Release.Service }}
+helm.sh/chart: {{ printf "%s-%s" .Chart.Name .Chart.Version }}
+{{- end -}}
+
+{{- define "yaml-to-readme.credentialsSecret" -}}
+{{- .Values.credentialsSecret | default (printf "%s-credentials" (include "yaml-to-readme.fullname" .)) -}}
+{{- end -}}
+
+{{- define "yaml-to-readme.args" -}}
+{{- if eq .Values.workload "deployment" }}
+- watch
+{{- if .Values.rpc.port }}
+- --rpc-listen
+- {{ printf "0.0.0.0:%v" .Values.rpc.port | quote }}
+{{- end }}
+{{- end }}
+- --provider
+- {{ .Values.provider | quote }}
+- --model
+- {{ .Values.model | quote }}
+- --write-dir
+- /output
+{{- if .Values.repo.pvc }}
+- --no-write
+{{- end }}
+{{- if .Values.config }}
+- --config
+- /etc/yaml-to-readme/.yaml-to-readme.yaml
+{{- end }}
+{{- with .Values.publish.jiraProject }}
+- --jira-project
+- {{ . | quote }}
+{{- end }}
+{{- with .Values.publish.jiraIssue }}
+- --jira-issue
+- {{ . | quote }}
+{{- end }}
+{{- with .Values.publish.changedOnlyOutput }}
+- --changed-only-output
+- {{ . | quote }}
+{{- end }}
+{{- range .Values.extraArgs }}
+- {{ . | quote }}
+{{- end }}
+- {{ printf "/repo/%s" .Values.repo.path | clean | quote }}
+{{- end -}}
+
+{{- define "yaml-to-readme.pod" -}}
+{{- $values := .root.Values -}}
+{{- if and (not $values.repo.url) (not $values.repo.pvc) }}
+{{- fail "set repo.url or repo.pvc" }}
+{{- end }}
+{{- if and $values.repo.url $values.repo.pvc }}
+{{- fail "repo.url and repo.pvc cannot be combined" }}
+{{- end }}
+{{- if and (eq $values.workload "deployment") $values.repo.url }}
+{{- fail "workload deployment watches a mounted repository and requires repo.pvc; a clone would never be updated" }}
+{{- end }}
+restartPolicy: {{ .restartPolicy }}
+securityContext:
+  runAsNonRoot: true
+  seccompProfile:
+    type: RuntimeDefault
+{{- if $values.repo.url }}
+initContainers:
+  - name: clone
+    image: {{ $values.repo.gitImage | quote }}
+    args:
+      - clone
+      - --depth
+      - "1"
+      {{- with $values.repo.ref }}
+      - --branch
+      - {{ . | quote }}
+      {{- end }}
+      - {{ $values.repo.url | quote }}
+      - /repo
+    env:
+      - name: HOME
+        value: /tmp
+    securityContext:
+      runAsUser: 65532
+      allowPrivilegeEscalation: false
+    volumeMounts:
+      - name: repo
+        mountPath: /repo
+{{- end }}
+containers:
+  - name: readmebuilder
+    image: {{ printf "%s%s" $values.image.repository (ternary (printf ":%s" $values.image.tag) "" (ne $values.image.tag "")) | quote }}
+    imagePullPolicy: {{ $values.image.pullPolicy }}
+    args:
+      {{- include "yaml-to-readme.args" .root | trim | nindent 6 }}
+    {{- if eq $values.provider "ollama" }}
+    env:
+      - name: OLLAMA_HOST
+        value: {{ $values.ollamaHost | quote }}
+    {{- end }}
+    envFrom:
+      - secretRef:
+          name: {{ include "yaml-to-readme.credentialsSecret" .root }}
+          optional: {{ eq $values.provider "ollama" }}
+    {{- if and (eq $values.workload "deployment") $values.rpc.port }}
+    ports:
+      - name: rpc
+        containerPort: {{ $values.rpc.port }}
+    {{- end }}
+    {{- with $values.resources }}
+    resources:
+      {{- toYaml . | nindent 6 }}
+    {{- end }}
+    securityContext:
+      allowPrivilegeEscalation: false
+      readOnlyRootFilesystem: true
+      capabilities:
+        drop:
+          - ALL
+    volumeMounts:
+      - name: repo
+        mountPath: /repo
+        readOnly: {{ ne $values.repo.pvc "" }}
+      - name: output
+        mountPath: /output
+      {{- if $values.config }}
+      - name: config
+        mountPath: /etc/yaml-to-readme
+        readOnly: true
+      {{- end }}
+volumes:
+  - name: repo
+    {{- if $values.repo.pvc }}
+    persistentVolumeClaim:
+      claimName: {{ $values.repo.pvc }}
+      readOnly: true
+    {{- else }}
+    emptyDir: {}
+    {{- end }}
+  - name: output
+    {{- if $values.output.pvc }}
+    persistentVolumeClaim:
+      claimName: {{ $values.output.pvc }}
+    {{- else }}
+    emptyDir: {}
+    {{- end }}
+  {{- if $values.config }}
+  - name: config
+    configMap:
+      name: {{ include "yaml-to-readme.fullname" .root }}-config
+  {{- end }}
+{{- end -}}
+`
+
+// helmCronJobTemplate runs the pod on the schedule.
+const helmCronJobTemplate = `{{- if eq .Values.workload "cronjob" }}
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: {{ include "yaml-to-readme.fullname" . }}
+  labels:
+    {{- include "yaml-to-readme.labels" . | nindent 4 }}
+spec:
+  schedule: {{ .Values.schedule | quote }}
+  concurrencyPolicy: Forbid
+  successfulJobsHistoryLimit: 3
+  failedJobsHistoryLimit: 3
+  jobTemplate:
+    spec:
+      backoffLimit: 1
+      template:
+        metadata:
+          labels:
+            {{- include "yaml-to-readme.selectorLabels" . | nindent 12 }}
+        spec:
+          {{- include "yaml-to-readme.pod" (dict "root" . "restartPolicy" "Never") | trim | nindent 10 }}
+{{- end }}
+`
+
+// helmDeploymentTemplate runs watch as a long-lived service.
+const helmDeploymentTemplate = `{{- if eq .Values.workload "deployment" }}
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ include "yaml-to-readme.fullname" . }}
+  labels:
+    {{- include "yaml-to-readme.labels" . | nindent 4 }}
+spec:
+  replicas: 1
+  strategy:
+    type: Recreate
+  selector:
+    matchLabels:
+      {{- include "yaml-to-readme.selectorLabels" . | nindent 6 }}
+  template:
+    metadata:
+      labels:
+        {{- include "yaml-to-readme.selectorLabels" . | nindent 8 }}
+    spec:
+      {{- include "yaml-to-readme.pod" (dict "root" . "restartPolicy" "Always") | trim | nindent 6 }}
+{{- end }}
+`
+
+// helmServiceTemplate exposes the JSON-RPC summary endpoint of the watch service.
+const helmServiceTemplate = `{{- if and (eq .Values.workload "deployment") .Values.rpc.port }}
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{ include "yaml-to-readme.fullname" . }}
+  labels:
+    {{- include "yaml-to-readme.labels" . | nindent 4 }}
+spec:
+  selector:
+    {{- include "yaml-to-readme.selectorLabels" . | nindent 4 }}
+  ports:
+    - name: rpc
+      port: {{ .Values.rpc.port }}
+      targetPort: rpc
+{{- end }}
+`
+
+// helmConfigMapTemplate holds the config file.
+const helmConfigMapTemplate = `{{- if .Values.config }}
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ include "yaml-to-readme.fullname" . }}-config
+  labels:
+    {{- include "yaml-to-readme.labels" . | nindent 4 }}
+data:
+  .yaml-to-readme.yaml: |
+    {{- .Values.config | nindent 4 }}
+{{- end }}
+`
+
+// helmNotesTemplate is printed after install.
+const helmNotesTemplate = `yaml-to-readme is installed as a {{ .Values.workload }}.
+{{- if eq .Values.workload "cronjob" }} It runs on "{{ .Values.schedule }}".{{ end }}
+
+API keys and tokens are read from the {{ include "yaml-to-readme.credentialsSecret" . }} Secret, for example:
+
+  kubectl -n {{ .Release.Namespace }} create secret generic {{ include "yaml-to-readme.credentialsSecret" . }} \
+    --from-literal=OPENAI_API_KEY=... --from-literal=JIRA_API_TOKEN=...
+`
+
+// helmValues renders values.yaml with the defaults taken from the install flags.
+func helmValues(o installOptions) string {
+	repository, tag := o.Image, ""
+	if i := strings.LastIndex(o.Image, ":"); i > strings.LastIndex(o.Image, "/") && !strings.Contains(o.Image, "@") {
+		repository, tag = o.Image[:i], o.Image[i+1:]
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `# Values of the yaml-to-readme chart, generated by summarize-yaml install helm.
+image:
+  repository: %q
+  tag: %q
+  pullPolicy: IfNotPresent
+
+# cronjob runs on schedule; deployment runs watch against repo.pvc as a service.
+workload: %q
+schedule: %q
+
+provider: %q
+model: %q
+# OLLAMA_HOST of the runs with the ollama provider.
+ollamaHost: %q
+
+# The repository to summarize: cloned from url on every run, or mounted read-only from
+# an existing PersistentVolumeClaim. Exactly one of url and pvc is required.
+repo:
+  url: %q
+  ref: %q
+  pvc: %q
+  # Directory within the repository to summarize.
+  path: %q
+  gitImage: %q
+
+# The document is written to an emptyDir unless pvc is set, so runs usually publish.
+output:
+  pvc: %q
+
+publish:
+  # Keep an issue in this Jira project up to date (needs JIRA_URL and JIRA_API_TOKEN).
+  jiraProject: ""
+  # Or comment the digest on this tracking ticket.
+  jiraIssue: ""
+  # File or http(s) URL receiving the entries added or changed in each run.
+  changedOnlyOutput: ""
+
+# Secret whose keys become environment variables, such as OPENAI_API_KEY,
+# ANTHROPIC_API_KEY, JIRA_URL, or JIRA_API_TOKEN (default: <release>-credentials).
+credentialsSecret: ""
+
+# With workload deployment, serve the JSON-RPC summary endpoint on this port behind a
+# Service, for editor plugins and other tools (0 disables it).
+rpc:
+  port: 0
+
+# Extra flags passed to every run, e.g. ["--stats", "--format", "html"].
+extraArgs:
+`, repository, tag, o.Workload, o.Schedule, provider, ModelName, o.OllamaHost,
+		o.GitURL, o.GitRef, o.RepoPVC, o.Path, o.GitImage, o.OutputPVC)
+	if len(o.Args) == 0 {
+		sb.WriteString("  []\n")
+	}
+	for _, arg := range o.Args {
+		fmt.Fprintf(&sb, "  - %q\n", arg)
+	}
+	sb.WriteString("\n# Contents of .yaml-to-readme.yaml, mounted from a ConfigMap when set.\n")
+	if o.Config == "" {
+		sb.WriteString("config: \"\"\n")
+	} else {
+		sb.WriteString("config: |\n")
+		for _, line := range strings.Split(strings.TrimRight(o.Config, "\n"), "\n") {
+			if line == "" {
+				sb.WriteString("\n")
+				continue
+			}
+			sb.WriteString("  " + line + "\n")
+		}
+	}
+	sb.WriteString("\nresources: {}\n")
+	return sb.String()
+}
+
+// writeHelmChart writes the chart for o into dir.
+func writeHelmChart(dir string, o installOptions) error {
+	files := map[string]string{
+		"Chart.yaml":                fmt.Sprintf(helmChartYAML, o.Name),
+		"values.yaml":               helmValues(o),
+		"templates/_helpers.tpl":    helmHelpersTemplate,
+		"templates/cronjob.yaml":    helmCronJobTemplate,
+		"templates/deployment.yaml": helmDeploymentTemplate,
+		"templates/service.yaml":    helmServiceTemplate,
+		"templates/configmap.yaml":  helmConfigMapTemplate,
+		"templates/NOTES.txt":       helmNotesTemplate,
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "templates"), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// installHelmCmd writes a Helm chart that runs the tool in-cluster.
+var installHelmCmd = &cobra.Command{
+	Use:   "helm [-- extra flags]",
+	Short: "Write a Helm chart that runs the tool on a schedule or as a watch service",
+	Long: `Write a Helm chart with the same workloads as install k8s: a CronJob, or with
+workload=deployment a Deployment running watch, optionally serving the JSON-RPC summary
+endpoint behind a Service. The provider, model, schedule, repository, publishing target,
+and extra flags are chart values; the install flags, --provider, --model, the config
+file, and any flags after -- become their defaults.
+
+  summarize-yaml install helm --git-url https://github.com/org/infra.git
+  helm install docs ./yaml-to-readme --set publish.jiraProject=OPS`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		o, err := installOptionsFromFlags(args)
+		if err != nil {
+			return err
+		}
+		if err := o.validate(false); err != nil {
+			return err
+		}
+		// helm lint expects the chart directory to be named after the chart
+		dir := helmChartDir
+		if dir == "" {
+			dir = o.Name
+		}
+		if err := writeHelmChart(dir, o); err != nil {
+			return err
+		}
+		statusf("Wrote Helm chart to %s\n", dir)
+		return nil
+	},
+}
+
+var helmChartDir string
+
+func init() {
+	installCmd.AddCommand(installHelmCmd)
+	addInstallFlags(installHelmCmd)
+	installHelmCmd.Flags().StringVar(&helmChartDir, "dir", "", "Directory to write the chart to (default: the --name)")
+}
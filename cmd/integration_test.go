@@ -1,14 +1,30 @@
 package cmd
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	ollama "github.com/ollama/ollama/api"
+	"github.com/sebrandon1/yaml-to-readme/summarizer"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -62,14 +78,14 @@ func TestIntegrationBasicFlow(t *testing.T) {
 	assert.Len(t, yamlFiles, 4, "Should find 4 YAML files")
 
 	// Process files with mock client
-	summaries, processed, skipped := processYAMLFiles(yamlFiles, tmpDir, make(map[string]string), mockClient, false)
+	summaries, processed, skipped, _ := processYAMLFiles(yamlFiles, tmpDir, make(map[string]string), mockClient, false)
 	assert.Equal(t, 4, processed, "Should process 4 files")
 	assert.Equal(t, 0, skipped, "Should skip 0 files")
 	assert.Len(t, summaries, 4, "Should have 4 summaries")
 
 	// Group and write markdown
 	grouped := groupSummariesByDir(yamlFiles, summaries, tmpDir)
-	assert.NoError(t, writeMarkdownSummary(tmpDir, grouped))
+	assert.NoError(t, writeMarkdownSummary(tmpDir, grouped, nil))
 
 	// Read and verify the generated markdown
 	mdPath := filepath.Join(tmpDir, markdownFileName)
@@ -79,15 +95,15 @@ func TestIntegrationBasicFlow(t *testing.T) {
 
 	// Verify structure
 	assert.Contains(t, mdContent, "# YAML File Details")
-	assert.Contains(t, mdContent, "## [./](.././)") // Root directory
-	assert.Contains(t, mdContent, "## [app/](../app/)")
-	assert.Contains(t, mdContent, "## [database/](../database/)")
+	assert.Contains(t, mdContent, "## [./](./)") // Root directory
+	assert.Contains(t, mdContent, "## [app/](app/)")
+	assert.Contains(t, mdContent, "## [database/](database/)")
 
 	// Verify file entries
-	assert.Contains(t, mdContent, "[config.yaml](.././config.yaml)")
-	assert.Contains(t, mdContent, "[deployment.yaml](../app/deployment.yaml)")
-	assert.Contains(t, mdContent, "[service.yaml](../app/service.yaml)")
-	assert.Contains(t, mdContent, "[postgres.yaml](../database/postgres.yaml)")
+	assert.Contains(t, mdContent, "[config.yaml](config.yaml)")
+	assert.Contains(t, mdContent, "[deployment.yaml](app/deployment.yaml)")
+	assert.Contains(t, mdContent, "[service.yaml](app/service.yaml)")
+	assert.Contains(t, mdContent, "[postgres.yaml](database/postgres.yaml)")
 
 	// Verify summaries are present
 	assert.Contains(t, mdContent, "ConfigMap for application configuration")
@@ -114,10 +130,10 @@ func TestIntegrationRegenerateFlag(t *testing.T) {
 	// First run: generate summaries
 	yamlFiles, err := findYAMLFiles(tmpDir, false)
 	assert.NoError(t, err)
-	summaries, processed, _ := processYAMLFiles(yamlFiles, tmpDir, make(map[string]string), mockClient, false)
+	summaries, processed, _, _ := processYAMLFiles(yamlFiles, tmpDir, make(map[string]string), mockClient, false)
 	assert.Equal(t, 1, processed)
 	grouped := groupSummariesByDir(yamlFiles, summaries, tmpDir)
-	assert.NoError(t, writeMarkdownSummary(tmpDir, grouped))
+	assert.NoError(t, writeMarkdownSummary(tmpDir, grouped, nil))
 
 	// Parse existing summaries
 	mdPath := filepath.Join(tmpDir, markdownFileName)
@@ -126,13 +142,13 @@ func TestIntegrationRegenerateFlag(t *testing.T) {
 	assert.Contains(t, existingSummaries["test.yaml"], "First summary")
 
 	// Second run: without regenerate flag (should skip)
-	_, processed, skipped := processYAMLFiles(yamlFiles, tmpDir, existingSummaries, mockClient, false)
+	_, processed, skipped, _ := processYAMLFiles(yamlFiles, tmpDir, existingSummaries, mockClient, false)
 	assert.Equal(t, 0, processed, "Should process 0 files (all skipped)")
 	assert.Equal(t, 1, skipped, "Should skip 1 file")
 
 	// Third run: with regenerate flag (should reprocess)
 	mockClient.DefaultResponse = "Second summary."
-	summaries, processed, skipped = processYAMLFiles(yamlFiles, tmpDir, existingSummaries, mockClient, true)
+	summaries, processed, skipped, _ = processYAMLFiles(yamlFiles, tmpDir, existingSummaries, mockClient, true)
 	assert.Equal(t, 1, processed, "Should process 1 file (regenerate)")
 	assert.Equal(t, 0, skipped, "Should skip 0 files")
 	assert.Contains(t, summaries[testFile], "Second summary")
@@ -163,9 +179,9 @@ func TestIntegrationHiddenDirectories(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, yamlFiles, 1, "Should find 1 file (hidden excluded)")
 
-	summaries, _, _ := processYAMLFiles(yamlFiles, tmpDir, make(map[string]string), mockClient, false)
+	summaries, _, _, _ := processYAMLFiles(yamlFiles, tmpDir, make(map[string]string), mockClient, false)
 	grouped := groupSummariesByDir(yamlFiles, summaries, tmpDir)
-	assert.NoError(t, writeMarkdownSummary(tmpDir, grouped))
+	assert.NoError(t, writeMarkdownSummary(tmpDir, grouped, nil))
 
 	mdPath := filepath.Join(tmpDir, markdownFileName)
 	content, err := os.ReadFile(mdPath)
@@ -177,9 +193,9 @@ func TestIntegrationHiddenDirectories(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, yamlFiles, 2, "Should find 2 files (hidden included)")
 
-	summaries, _, _ = processYAMLFiles(yamlFiles, tmpDir, make(map[string]string), mockClient, false)
+	summaries, _, _, _ = processYAMLFiles(yamlFiles, tmpDir, make(map[string]string), mockClient, false)
 	grouped = groupSummariesByDir(yamlFiles, summaries, tmpDir)
-	assert.NoError(t, writeMarkdownSummary(tmpDir, grouped))
+	assert.NoError(t, writeMarkdownSummary(tmpDir, grouped, nil))
 
 	content, err = os.ReadFile(mdPath)
 	assert.NoError(t, err)
@@ -216,9 +232,9 @@ func TestIntegrationMarkdownFormat(t *testing.T) {
 	yamlFiles, err := findYAMLFiles(tmpDir, false)
 	assert.NoError(t, err)
 
-	summaries, _, _ := processYAMLFiles(yamlFiles, tmpDir, make(map[string]string), mockClient, false)
+	summaries, _, _, _ := processYAMLFiles(yamlFiles, tmpDir, make(map[string]string), mockClient, false)
 	grouped := groupSummariesByDir(yamlFiles, summaries, tmpDir)
-	assert.NoError(t, writeMarkdownSummary(tmpDir, grouped))
+	assert.NoError(t, writeMarkdownSummary(tmpDir, grouped, nil))
 
 	mdPath := filepath.Join(tmpDir, markdownFileName)
 	content, err := os.ReadFile(mdPath)
@@ -278,7 +294,7 @@ This is sentence one. This is sentence two. This is sentence three. This is sent
 * Another list item
 **Bold text here**`
 
-	summary, err := summarizeYAMLFile(context.Background(), mockClient, testFile)
+	summary, err := summarizeYAMLFile(context.Background(), mockClient, filepath.Dir(testFile), testFile)
 	assert.NoError(t, err)
 
 	// Verify cleaning and truncation
@@ -306,7 +322,7 @@ func TestIntegrationEmptyDirectory(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, yamlFiles, 0, "Should find 0 YAML files in empty directory")
 
-	_, processed, skipped := processYAMLFiles(yamlFiles, tmpDir, make(map[string]string), mockClient, false)
+	_, processed, skipped, _ := processYAMLFiles(yamlFiles, tmpDir, make(map[string]string), mockClient, false)
 	assert.Equal(t, 0, processed)
 	assert.Equal(t, 0, skipped)
 }
@@ -358,7 +374,7 @@ func TestIntegrationLocalCache(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, yamlFiles, 2)
 
-	summaries, processed, skipped := processYAMLFiles(yamlFiles, tmpDir, make(map[string]string), mockClient, false)
+	summaries, processed, skipped, _ := processYAMLFiles(yamlFiles, tmpDir, make(map[string]string), mockClient, false)
 	assert.Equal(t, 2, processed)
 	assert.Equal(t, 0, skipped)
 	assert.Len(t, summaries, 2)
@@ -393,7 +409,7 @@ func TestIntegrationLocalCache(t *testing.T) {
 
 	// Write markdown and verify it also works alongside cache
 	grouped := groupSummariesByDir(yamlFiles, summaries, tmpDir)
-	assert.NoError(t, writeMarkdownSummary(tmpDir, grouped))
+	assert.NoError(t, writeMarkdownSummary(tmpDir, grouped, nil))
 
 	mdPath := filepath.Join(tmpDir, markdownFileName)
 	mdContent, err := os.ReadFile(mdPath)
@@ -438,11 +454,11 @@ func TestIntegrationCustomOutputAndCacheDir(t *testing.T) {
 	yamlFiles, err := findYAMLFiles(tmpDir, false)
 	assert.NoError(t, err)
 
-	summaries, processed, _ := processYAMLFiles(yamlFiles, tmpDir, make(map[string]string), mockClient, false)
+	summaries, processed, _, _ := processYAMLFiles(yamlFiles, tmpDir, make(map[string]string), mockClient, false)
 	assert.Equal(t, 1, processed)
 
 	grouped := groupSummariesByDir(yamlFiles, summaries, tmpDir)
-	assert.NoError(t, writeMarkdownSummary(tmpDir, grouped))
+	assert.NoError(t, writeMarkdownSummary(tmpDir, grouped, nil))
 
 	// Verify custom output filename was used
 	customMdPath := filepath.Join(tmpDir, "custom_output.md")
@@ -510,9 +526,9 @@ func TestIntegrationDryRun(t *testing.T) {
 
 	// Process only one file to create partial existing summaries
 	singleFile := []string{yamlFiles[0]}
-	summaries, _, _ := processYAMLFiles(singleFile, tmpDir, make(map[string]string), mockClient, false)
+	summaries, _, _, _ := processYAMLFiles(singleFile, tmpDir, make(map[string]string), mockClient, false)
 	grouped := groupSummariesByDir(singleFile, summaries, tmpDir)
-	assert.NoError(t, writeMarkdownSummary(tmpDir, grouped))
+	assert.NoError(t, writeMarkdownSummary(tmpDir, grouped, nil))
 
 	// Dry-run should now show 1 existing, 2 new
 	err = runDryRun(tmpDir)
@@ -549,7 +565,7 @@ func TestIntegrationConcurrentProcessing(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, yamlFiles, 10)
 
-	summaries, processed, skipped := processYAMLFiles(yamlFiles, tmpDir, make(map[string]string), mockClient, false)
+	summaries, processed, skipped, _ := processYAMLFiles(yamlFiles, tmpDir, make(map[string]string), mockClient, false)
 	assert.Equal(t, 10, processed, "Should process all 10 files")
 	assert.Equal(t, 0, skipped)
 	assert.Len(t, summaries, 10, "Should have 10 summaries")
@@ -561,7 +577,7 @@ func TestIntegrationConcurrentProcessing(t *testing.T) {
 
 	// Verify output is deterministic by writing and checking markdown
 	grouped := groupSummariesByDir(yamlFiles, summaries, tmpDir)
-	assert.NoError(t, writeMarkdownSummary(tmpDir, grouped))
+	assert.NoError(t, writeMarkdownSummary(tmpDir, grouped, nil))
 
 	mdPath := filepath.Join(tmpDir, markdownFileName)
 	content, err := os.ReadFile(mdPath)
@@ -575,6 +591,56 @@ func TestIntegrationConcurrentProcessing(t *testing.T) {
 	}
 }
 
+// TestIntegrationConcurrentProgress tests that progress never moves backwards when
+// workers finish at the same time, and that output does not depend on concurrency.
+func TestIntegrationConcurrentProgress(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_concurrent_progress_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	origConcurrency, origPorcelainOut, origPorcelain, origDeterministic := concurrency, porcelainOut, porcelain, deterministic
+	defer func() {
+		concurrency, porcelainOut, porcelain, deterministic = origConcurrency, origPorcelainOut, origPorcelain, origDeterministic
+	}()
+	var stdout bytes.Buffer
+	porcelainOut, porcelain, deterministic = &stdout, true, true
+
+	for i := 0; i < 40; i++ {
+		content := fmt.Sprintf("apiVersion: v1\nkind: ConfigMap\nname: config-%d", i)
+		assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, fmt.Sprintf("file%02d.yaml", i)), []byte(content), 0644))
+	}
+	mock := &countingProvider{MockLLMProvider: NewMockLLMProvider(), delay: time.Millisecond}
+	yamlFiles, err := findYAMLFiles(tmpDir, false)
+	assert.NoError(t, err)
+
+	var docs []string
+	for _, c := range []int{1, 8} {
+		concurrency = c
+		stdout.Reset()
+		summaries, processed, _, _ := processYAMLFiles(yamlFiles, tmpDir, nil, mock, true)
+		assert.Equal(t, 40, processed)
+
+		var progress []string
+		for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+			if strings.HasPrefix(line, "progress\t") {
+				progress = append(progress, line)
+			}
+		}
+		if assert.Len(t, progress, 40) {
+			for i, line := range progress {
+				assert.Equal(t, fmt.Sprintf("progress\tcurrent=%d\ttotal=40", i+1), line)
+			}
+		}
+
+		assert.NoError(t, writeMarkdownSummary(tmpDir, groupSummariesByDir(yamlFiles, summaries, tmpDir), nil))
+		content, err := os.ReadFile(filepath.Join(tmpDir, markdownFileName))
+		assert.NoError(t, err)
+		docs = append(docs, string(content))
+	}
+	assert.Equal(t, docs[0], docs[1])
+}
+
 // TestIntegrationModelAvailability tests the model availability check via LLMProvider.
 func TestIntegrationModelAvailability(t *testing.T) {
 	mockProvider := NewMockLLMProvider()
@@ -725,9 +791,9 @@ func TestIntegrationJSONOutputFormat(t *testing.T) {
 	yamlFiles, err := findYAMLFiles(tmpDir, false)
 	assert.NoError(t, err)
 
-	summaries, _, _ := processYAMLFiles(yamlFiles, tmpDir, make(map[string]string), mockClient, false)
+	summaries, _, _, _ := processYAMLFiles(yamlFiles, tmpDir, make(map[string]string), mockClient, false)
 	grouped := groupSummariesByDir(yamlFiles, summaries, tmpDir)
-	assert.NoError(t, writeSummary(tmpDir, grouped))
+	assert.NoError(t, writeSummary(tmpDir, grouped, nil))
 
 	outPath := filepath.Join(tmpDir, markdownFileName)
 	content, err := os.ReadFile(outPath)
@@ -742,6 +808,20 @@ func TestIntegrationJSONOutputFormat(t *testing.T) {
 	assert.Len(t, result.Directories["configs/"], 1)
 	assert.Equal(t, "app.yaml", result.Directories["configs/"][0].File)
 	assert.Contains(t, result.Directories["configs/"][0].Summary, "Application config file")
+	digest, err := fileSHA256(filepath.Join(subDir, "app.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "sha256:"+digest, result.Directories["configs/"][0].Hash)
+	modifiedAt, err := time.Parse(time.RFC3339, result.Directories["configs/"][0].ModifiedAt)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), modifiedAt, time.Minute)
+
+	// The document is named yaml_details.json unless --output is given
+	origName := markdownFileName
+	defer func() {
+		markdownFileName = origName
+	}()
+	resolveOutputName(rootCmd)
+	assert.Equal(t, DefaultJSONFileName, markdownFileName)
 }
 
 // TestIntegrationHTMLOutputFormat tests the --format html flag.
@@ -768,9 +848,9 @@ func TestIntegrationHTMLOutputFormat(t *testing.T) {
 	yamlFiles, err := findYAMLFiles(tmpDir, false)
 	assert.NoError(t, err)
 
-	summaries, _, _ := processYAMLFiles(yamlFiles, tmpDir, make(map[string]string), mockClient, false)
+	summaries, _, _, _ := processYAMLFiles(yamlFiles, tmpDir, make(map[string]string), mockClient, false)
 	grouped := groupSummariesByDir(yamlFiles, summaries, tmpDir)
-	assert.NoError(t, writeSummary(tmpDir, grouped))
+	assert.NoError(t, writeSummary(tmpDir, grouped, nil))
 
 	outPath := filepath.Join(tmpDir, markdownFileName)
 	content, err := os.ReadFile(outPath)
@@ -782,3 +862,3962 @@ func TestIntegrationHTMLOutputFormat(t *testing.T) {
 	assert.Contains(t, htmlContent, "Service deployment config")
 	assert.Contains(t, htmlContent, "deploy/")
 }
+
+// TestIntegrationGitHubWikiOutputFormat tests the --format github-wiki flag.
+func TestIntegrationGitHubWikiOutputFormat(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_wiki_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	origFormat := outputFormat
+	origWikiBaseURL := wikiBaseURL
+	defer func() {
+		outputFormat = origFormat
+		wikiBaseURL = origWikiBaseURL
+	}()
+	outputFormat = "github-wiki"
+
+	subDir := filepath.Join(tmpDir, "deploy")
+	assert.NoError(t, os.MkdirAll(subDir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(subDir, "service.yaml"), []byte("test: svc"), 0644))
+
+	mockClient := NewMockLLMProvider()
+	mockClient.DefaultResponse = "Service deployment config."
+
+	yamlFiles, err := findYAMLFiles(tmpDir, false)
+	assert.NoError(t, err)
+	summaries, _, _, _ := processYAMLFiles(yamlFiles, tmpDir, make(map[string]string), mockClient, false)
+	grouped := groupSummariesByDir(yamlFiles, summaries, tmpDir)
+	outPath := filepath.Join(tmpDir, markdownFileName)
+
+	// Without a base URL, paths are rendered as inline code
+	assert.NoError(t, writeSummary(tmpDir, grouped, nil))
+	content, err := os.ReadFile(outPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "## deploy/")
+	assert.Contains(t, string(content), "- `deploy/service.yaml`: Service deployment config.")
+	assert.NotContains(t, string(content), "](../")
+	assert.Equal(t, "Service deployment config.", parseExistingSummaries(outPath)[filepath.Join("deploy", "service.yaml")])
+
+	// With a base URL, wiki links point at the absolute file URL
+	wikiBaseURL = "https://github.com/org/repo/blob/main/"
+	assert.NoError(t, writeSummary(tmpDir, grouped, nil))
+	content, err = os.ReadFile(outPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "- [[service.yaml|https://github.com/org/repo/blob/main/deploy/service.yaml]]: Service deployment config.")
+	assert.Equal(t, "Service deployment config.", parseExistingSummaries(outPath)[filepath.Join("deploy", "service.yaml")])
+}
+
+// TestIntegrationBatchManifest tests summarizing multiple local repos from a manifest.
+func TestIntegrationBatchManifest(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_batch_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	origBatchConcurrency := batchConcurrency
+	defer func() {
+		batchConcurrency = origBatchConcurrency
+	}()
+	batchConcurrency = 2
+
+	for _, repo := range []string{"alpha", "beta"} {
+		repoDir := filepath.Join(tmpDir, repo, "deploy")
+		assert.NoError(t, os.MkdirAll(repoDir, 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(repoDir, repo+".yaml"), []byte("kind: "+repo), 0644))
+	}
+	manifestPath := filepath.Join(tmpDir, "repos.yaml")
+	assert.NoError(t, os.WriteFile(manifestPath, []byte("repos:\n  - path: alpha\n  - path: beta\n"), 0644))
+
+	mockClient := NewMockLLMProvider()
+	mockClient.MockResponses = map[string]string{
+		"kind: alpha": "Alpha service config.",
+		"kind: beta":  "Beta service config.",
+	}
+
+	assert.NoError(t, runBatchWithProvider(manifestPath, mockClient))
+
+	// Per-repo documents are written into each repo
+	for _, repo := range []string{"alpha", "beta"} {
+		_, err := os.Stat(filepath.Join(tmpDir, repo, markdownFileName))
+		assert.NoError(t, err, "per-repo document should exist for %s", repo)
+	}
+
+	// The combined index groups entries by repo with links relative to the index
+	content, err := os.ReadFile(filepath.Join(tmpDir, DefaultBatchIndexFileName))
+	assert.NoError(t, err)
+	index := string(content)
+	assert.Contains(t, index, "## alpha")
+	assert.Contains(t, index, "## beta")
+	assert.Contains(t, index, "- [deploy/alpha.yaml](alpha/deploy/alpha.yaml): Alpha service config.")
+	assert.Contains(t, index, "- [deploy/beta.yaml](beta/deploy/beta.yaml): Beta service config.")
+	assert.Contains(t, index, "Full document: [yaml_details.md](alpha/yaml_details.md)")
+
+	// Entries are also grouped by kind across repos, with stable anchors
+	assert.Contains(t, index, "  - [alpha](#repo-alpha)\n")
+	assert.Contains(t, index, "  - [beta](#kind-beta) (1)\n")
+	assert.Contains(t, index, "<a id=\"kind-alpha\"></a>\n\n### alpha\n\n- [alpha](#repo-alpha) [deploy/alpha.yaml](alpha/deploy/alpha.yaml): Alpha service config.\n")
+}
+
+// TestIntegrationRepairDoc tests detecting and repairing a manually edited document.
+func TestIntegrationRepairDoc(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_repair_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	origRepairCheckOnly := repairCheckOnly
+	defer func() {
+		repairCheckOnly = origRepairCheckOnly
+	}()
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "app"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "db"), 0755))
+
+	content := MarkdownHeader + `
+## [app/](../app/)
+- [deploy.yaml](../app/deploy.yaml): Deploys the app.
+
+## db
+* postgres.yaml - Runs the database.
+- **backup.yaml**: Nightly backups.
+
+### [./](.././)
+- [root.yaml](.././root.yaml): Root config.
+- app/service.yaml: Exposes the app.
+`
+	mdPath := filepath.Join(tmpDir, markdownFileName)
+	assert.NoError(t, os.WriteFile(mdPath, []byte(content), 0644))
+
+	// The strict parser loses the hand-edited db entries
+	assert.NotContains(t, parseExistingSummaries(mdPath), filepath.Join("db", "postgres.yaml"))
+
+	recovered, issues := lintDocStructure(tmpDir, readLinesFromFile(mdPath))
+	assert.Len(t, recovered, 5)
+	assert.Len(t, issues, 5)
+
+	// --check reports issues without modifying the document
+	repairCheckOnly = true
+	assert.Error(t, runRepairDoc(tmpDir))
+	unchanged, err := os.ReadFile(mdPath)
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(unchanged))
+
+	// Repair rewrites the document canonically without losing summaries
+	repairCheckOnly = false
+	assert.NoError(t, runRepairDoc(tmpDir))
+	summaries := parseExistingSummaries(mdPath)
+	assert.Equal(t, map[string]string{
+		filepath.Join("app", "deploy.yaml"):  "Deploys the app.",
+		filepath.Join("app", "service.yaml"): "Exposes the app.",
+		filepath.Join("db", "postgres.yaml"): "Runs the database.",
+		filepath.Join("db", "backup.yaml"):   "Nightly backups.",
+		"root.yaml":                          "Root config.",
+	}, summaries)
+
+	_, issues = lintDocStructure(tmpDir, readLinesFromFile(mdPath))
+	assert.Empty(t, issues, "repaired document should be canonical")
+}
+
+// TestIntegrationLocalizedHTMLOutput tests that --lang localizes HTML structural text.
+func TestIntegrationLocalizedHTMLOutput(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_lang_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	origFormat := outputFormat
+	origLang := lang
+	defer func() {
+		outputFormat = origFormat
+		lang = origLang
+	}()
+	outputFormat = "html"
+	lang = "fr"
+
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "app.yaml"), []byte("test: app"), 0644))
+	mockClient := NewMockLLMProvider()
+	assert.NoError(t, runSummarizeYamlWithProvider(tmpDir, mockClient))
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, markdownFileName))
+	assert.NoError(t, err)
+	htmlContent := string(content)
+	assert.Contains(t, htmlContent, `<html lang="fr">`)
+	assert.Contains(t, htmlContent, "<title>Détails des fichiers YAML</title>")
+	assert.Contains(t, htmlContent, "avec le modèle "+ModelName)
+}
+
+// countingProvider is an LLMProvider that counts Summarize calls and delays each response,
+// so that concurrent requests overlap.
+type countingProvider struct {
+	*MockLLMProvider
+	calls atomic.Int64
+	delay time.Duration
+}
+
+// Summarize implements LLMProvider.Summarize, counting each call.
+func (c *countingProvider) Summarize(ctx context.Context, content string, prompt string) (string, error) {
+	c.calls.Add(1)
+	time.Sleep(c.delay)
+	return c.MockLLMProvider.Summarize(ctx, content, prompt)
+}
+
+// TestIntegrationRequestCoalescing tests that identical files summarized concurrently share one provider call.
+func TestIntegrationRequestCoalescing(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_coalesce_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	origConcurrency := concurrency
+	defer func() {
+		concurrency = origConcurrency
+	}()
+	concurrency = 4
+
+	// Four byte-identical files and one distinct file
+	for i := range 4 {
+		assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, fmt.Sprintf("copy%d.yaml", i)), []byte("kind: Namespace\nname: shared"), 0644))
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "other.yaml"), []byte("kind: Service"), 0644))
+
+	provider := &countingProvider{MockLLMProvider: NewMockLLMProvider(), delay: 200 * time.Millisecond}
+	provider.MockResponses["kind: Namespace"] = "Shared namespace definition."
+
+	yamlFiles, err := findYAMLFiles(tmpDir, false)
+	assert.NoError(t, err)
+	summaries, processed, _, _ := processYAMLFiles(yamlFiles, tmpDir, make(map[string]string), provider, false)
+
+	assert.Equal(t, 5, processed)
+	assert.Equal(t, int64(2), provider.calls.Load(), "identical in-flight requests should share one call")
+	for i := range 4 {
+		assert.Equal(t, "Shared namespace definition.", summaries[filepath.Join(tmpDir, fmt.Sprintf("copy%d.yaml", i))])
+	}
+}
+
+// TestIntegrationWatchControlSocket tests that the watch daemon re-summarizes on changes and obeys control commands.
+func TestIntegrationWatchControlSocket(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_watch_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "app.yaml"), []byte("test: app"), 0644))
+	d := newWatchDaemon(tmpDir, NewMockLLMProvider())
+	d.settle = 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	socketPath := filepath.Join(tmpDir, "ctl.sock")
+	assert.NoError(t, serveControlSocket(ctx, socketPath, d))
+
+	// The first tick always runs; an unchanged tree does not trigger another run
+	assert.True(t, d.tick(ctx, false))
+	assert.False(t, d.tick(ctx, false))
+
+	st, err := sendControlCommand(socketPath, "status")
+	assert.NoError(t, err)
+	assert.Equal(t, "idle", st.State)
+	assert.Equal(t, 1, st.Runs)
+	assert.Equal(t, 1, st.Files)
+
+	// While paused, changes are not picked up
+	st, err = sendControlCommand(socketPath, "pause")
+	assert.NoError(t, err)
+	assert.Equal(t, "paused", st.State)
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "db.yaml"), []byte("test: db"), 0644))
+	assert.False(t, d.tick(ctx, false))
+
+	st, err = sendControlCommand(socketPath, "resume")
+	assert.NoError(t, err)
+	assert.Equal(t, "idle", st.State)
+	assert.True(t, d.tick(ctx, false))
+
+	// A refresh is queued for the run loop and forces a run on an unchanged tree
+	_, err = sendControlCommand(socketPath, "refresh")
+	assert.NoError(t, err)
+	select {
+	case <-d.refresh:
+		assert.True(t, d.tick(ctx, true))
+	default:
+		t.Fatal("refresh was not queued")
+	}
+	assert.Equal(t, 3, d.status().Runs)
+
+	_, err = sendControlCommand(socketPath, "explode")
+	assert.Error(t, err)
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, markdownFileName))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "db.yaml")
+}
+
+// TestIntegrationWatchSettle tests that a burst of changes is summarized once, after it settles.
+func TestIntegrationWatchSettle(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_watch_settle_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "app.yaml"), []byte("test: app"), 0644))
+	llm := &contentRecorder{MockLLMProvider: NewMockLLMProvider()}
+	llm.MockResponses["app-4"] = "Final app."
+	d := newWatchDaemon(tmpDir, llm)
+	d.settle = 50 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.True(t, d.tick(ctx, false))
+
+	// Files keep changing while the daemon waits; only their final content is summarized
+	change := func(i int) {
+		assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, fmt.Sprintf("f%d.yaml", i)), []byte(fmt.Sprintf("version: %d", i)), 0644))
+		assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "app.yaml"), []byte(fmt.Sprintf("test: app-%d", i)), 0644))
+	}
+	change(0)
+	done := make(chan bool)
+	go func() {
+		done <- d.tick(ctx, false)
+	}()
+	for i := 1; i < 5; i++ {
+		time.Sleep(20 * time.Millisecond)
+		if i == 1 {
+			assert.Equal(t, "settling", d.status().State)
+		}
+		change(i)
+	}
+	assert.True(t, <-done)
+	assert.Equal(t, 2, d.status().Runs)
+	assert.Equal(t, 6, d.status().Files)
+	// Each new file is summarized once, not once per change, and the modified app.yaml
+	// once more, with its final content
+	llm.mu.Lock()
+	assert.Len(t, llm.contents, 7)
+	var app []string
+	for _, content := range llm.contents {
+		if strings.Contains(content, "test: app") {
+			app = append(app, content)
+		}
+	}
+	llm.mu.Unlock()
+	assert.Len(t, app, 2)
+	assert.Contains(t, app[1], "test: app-4")
+	assert.Equal(t, "Final app.", parseExistingSummaries(docPathFor(tmpDir))["app.yaml"])
+
+	// Shutting down while settling skips the run
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "late.yaml"), []byte("test: late"), 0644))
+	cancel()
+	assert.False(t, d.tick(ctx, false))
+	assert.Equal(t, 2, d.status().Runs)
+}
+
+// rpcCall posts a JSON-RPC request to a watch daemon's endpoint and decodes the response.
+func rpcCall(t *testing.T, url, body string) rpcResponse {
+	t.Helper()
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	assert.NoError(t, err)
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	var out struct {
+		rpcResponse
+		Result json.RawMessage `json:"result"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	out.rpcResponse.Result = out.Result
+	return out.rpcResponse
+}
+
+// TestIntegrationWatchRPC tests that the JSON-RPC endpoint serves documented summaries
+// and generates summaries on demand for new or changed files.
+func TestIntegrationWatchRPC(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_watch_rpc_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "app.yaml"), []byte("test: app"), 0644))
+	mock := &countingProvider{MockLLMProvider: NewMockLLMProvider()}
+	d := newWatchDaemon(tmpDir, mock)
+	d.settle = 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.True(t, d.tick(ctx, false))
+	addr, err := serveRPC(ctx, "127.0.0.1:0", d)
+	assert.NoError(t, err)
+	url := "http://" + addr.String()
+	calls := mock.calls.Load()
+
+	summaryOf := func(path string) summaryResult {
+		resp := rpcCall(t, url, fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"summary","params":{"path":%q}}`, path))
+		assert.Nil(t, resp.Error)
+		assert.Equal(t, "1", string(resp.ID))
+		var result summaryResult
+		assert.NoError(t, json.Unmarshal(resp.Result.(json.RawMessage), &result))
+		return result
+	}
+
+	// Unchanged files are answered from the document without calling the provider
+	result := summaryOf("app.yaml")
+	assert.Equal(t, "app.yaml", result.Path)
+	assert.Equal(t, "document", result.Source)
+	assert.Equal(t, "This is a mock summary for testing purposes.", result.Summary)
+	result = summaryOf(filepath.Join(tmpDir, "app.yaml"))
+	assert.Equal(t, "document", result.Source)
+	assert.Equal(t, calls, mock.calls.Load())
+
+	// A file added since the last run is generated once and then served from memory
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "db.yaml"), []byte("test: db"), 0644))
+	result = summaryOf("db.yaml")
+	assert.Equal(t, "generated", result.Source)
+	assert.Equal(t, "This is a mock summary for testing purposes.", result.Summary)
+	assert.Equal(t, calls+1, mock.calls.Load())
+	result = summaryOf("db.yaml")
+	assert.Equal(t, "generated", result.Source)
+	assert.Equal(t, calls+1, mock.calls.Load())
+
+	// Editing a documented file makes its document summary stale
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "app.yaml"), []byte("test: app\nreplicas: 3"), 0644))
+	assert.Equal(t, "generated", summaryOf("app.yaml").Source)
+	assert.Equal(t, calls+2, mock.calls.Load())
+
+	resp := rpcCall(t, url, `{"jsonrpc":"2.0","id":2,"method":"summary","params":{"path":"../outside.yaml"}}`)
+	assert.NotNil(t, resp.Error)
+	assert.Equal(t, rpcInvalidParams, resp.Error.Code)
+
+	resp = rpcCall(t, url, `{"jsonrpc":"2.0","id":3,"method":"summary","params":{}}`)
+	assert.Equal(t, rpcInvalidParams, resp.Error.Code)
+
+	resp = rpcCall(t, url, `{"jsonrpc":"2.0","id":4,"method":"explode"}`)
+	assert.Equal(t, rpcMethodNotFound, resp.Error.Code)
+
+	resp = rpcCall(t, url, `{not json`)
+	assert.Equal(t, rpcParseError, resp.Error.Code)
+	assert.Equal(t, "null", string(resp.ID))
+
+	resp = rpcCall(t, url, `{"jsonrpc":"2.0","id":5,"method":"status"}`)
+	assert.Nil(t, resp.Error)
+	assert.Contains(t, string(resp.Result.(json.RawMessage)), `"runs":1`)
+}
+
+// TestIntegrationQuarantine tests that a directory whose files keep failing is skipped after --quarantine-after failures.
+func TestIntegrationQuarantine(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_quarantine_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	origConcurrency, origQuarantine := concurrency, quarantineAfter
+	defer func() {
+		concurrency, quarantineAfter = origConcurrency, origQuarantine
+	}()
+	concurrency, quarantineAfter = 1, 2
+
+	for _, dir := range []string{"broken", "mixed", "ok"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, dir), 0755))
+	}
+	for i := range 5 {
+		assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "broken", fmt.Sprintf("t%d.yaml", i)), []byte(fmt.Sprintf("soup: %d", i)), 0644))
+	}
+	// A directory with any success is never quarantined
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "mixed", "a.yaml"), []byte("kind: Service"), 0644))
+	for i := range 3 {
+		assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "mixed", fmt.Sprintf("b%d.yaml", i)), []byte(fmt.Sprintf("soup: mixed-%d", i)), 0644))
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "ok", "app.yaml"), []byte("kind: Deployment"), 0644))
+
+	llm := &countingProvider{MockLLMProvider: NewMockLLMProvider()}
+	llm.MockErrors["soup"] = errors.New("template soup")
+	report, err := summarizeDirectory(tmpDir, llm)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"broken"}, report.Quarantined)
+	// Two calls for broken/, four for mixed/, one for ok/
+	assert.Equal(t, int64(7), llm.calls.Load())
+	assert.Len(t, report.Failed, 8)
+	assert.Contains(t, report.Failed, "broken/t4.yaml")
+	assert.Equal(t, 2, report.Processed)
+
+	quarantineAfter = 0
+	llm.calls.Store(0)
+	report, err = summarizeDirectory(tmpDir, llm)
+	assert.NoError(t, err)
+	assert.Empty(t, report.Quarantined)
+	assert.Equal(t, int64(8), llm.calls.Load())
+}
+
+// TestIntegrationNoWrite tests that --write-dir keeps every output out of a read-only source tree.
+func TestIntegrationNoWrite(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_no_write_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	srcDir := filepath.Join(tmpDir, "src")
+	outDir := filepath.Join(tmpDir, "out")
+	assert.NoError(t, os.MkdirAll(filepath.Join(srcDir, "apps"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(srcDir, "apps", "web.yaml"), []byte("kind: Deployment"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(srcDir, "apps", "bad.yaml"), []byte("kind: Broken"), 0644))
+
+	origNoWrite, origWriteDir, origLocalCache := noWrite, writeDir, localCache
+	defer func() {
+		noWrite, writeDir, localCache = origNoWrite, origWriteDir, origLocalCache
+	}()
+
+	noWrite, writeDir = true, ""
+	assert.ErrorContains(t, validateWriteDir(srcDir), "requires --write-dir")
+	writeDir = filepath.Join(srcDir, "out")
+	assert.ErrorContains(t, validateWriteDir(srcDir), "is inside")
+	writeDir = outDir
+	assert.NoError(t, validateWriteDir(srcDir))
+	localCache = true
+
+	listTree := func() []string {
+		var paths []string
+		assert.NoError(t, filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+			paths = append(paths, path)
+			return err
+		}))
+		return paths
+	}
+	before := listTree()
+
+	llm := NewMockLLMProvider()
+	llm.MockErrors["Broken"] = errors.New("boom")
+	report, err := summarizeDirectory(srcDir, llm)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(outDir, markdownFileName), report.OutputPath)
+	assert.Equal(t, before, listTree())
+	assert.FileExists(t, filepath.Join(outDir, markdownFileName))
+	assert.FileExists(t, filepath.Join(outDir, DefaultFailedListFileName))
+	assert.FileExists(t, filepath.Join(outDir, cacheDirName, "apps_web.yaml.md"))
+	assert.Equal(t, []string{"apps/bad.yaml"}, readFailedList(srcDir))
+
+	// The next run reuses the summaries in the document under --write-dir
+	report, err = summarizeDirectory(srcDir, llm)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Skipped)
+	assert.NoError(t, runVerify(srcDir))
+}
+
+// TestIntegrationDeterministic tests that repeated --deterministic runs produce byte-identical documents.
+// modelOllamaClient answers every chat request with a summary naming the model it was
+// sent to.
+type modelOllamaClient struct {
+	*MockOllamaClient
+}
+
+// Chat implements OllamaClient.Chat.
+func (m *modelOllamaClient) Chat(ctx context.Context, req *ollama.ChatRequest, fn func(ollama.ChatResponse) error) error {
+	return fn(ollama.ChatResponse{Message: ollama.Message{Content: "Summarized by " + req.Model + "."}})
+}
+
+func TestIntegrationRefine(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_refine_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "apps"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "networking"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "web.yaml"), []byte("kind: Deployment"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "config.yaml"), []byte("kind: ConfigMap"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "networking", "ingress.yaml"), []byte("kind: Ingress"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "big.yaml"), []byte("data: "+strings.Repeat("x", 2048)), 0644))
+
+	origModel, origRules := refineModel, refineRules
+	defer func() {
+		refineModel, refineRules = origModel, origRules
+	}()
+
+	refineModel, refineRules = "", []string{"kind=Deployment"}
+	assert.ErrorContains(t, validateRefine(), "requires --refine-model")
+	refineModel, refineRules = "quality:latest", nil
+	assert.ErrorContains(t, validateRefine(), "at least one --refine rule")
+	refineRules = []string{"size=big"}
+	assert.ErrorContains(t, validateRefine(), "unsupported --refine")
+	refineRules = []string{"kind=Deployment", "path=networking/**", "min-size=2"}
+	assert.NoError(t, validateRefine())
+
+	// Providers without model switching cannot refine
+	_, err = summarizeDirectory(tmpDir, NewMockLLMProvider())
+	assert.ErrorContains(t, err, "does not support --refine-model")
+
+	client := &modelOllamaClient{MockOllamaClient: NewMockOllamaClient()}
+	llm := NewOllamaProviderFromClient(client)
+	_, err = summarizeDirectory(tmpDir, llm)
+	assert.ErrorContains(t, err, "refine model quality:latest is not available")
+
+	client.AvailableModels = append(client.AvailableModels, "quality:latest")
+	report, err := summarizeDirectory(tmpDir, llm)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, report.Processed)
+	assert.Equal(t, 3, report.Refined)
+	summaries := map[string]string{}
+	for file, summary := range report.Summaries {
+		rel, _ := filepath.Rel(tmpDir, file)
+		summaries[filepath.ToSlash(rel)] = summary
+	}
+	assert.Equal(t, map[string]string{
+		"apps/web.yaml":           "Summarized by quality:latest.",
+		"apps/config.yaml":        "Summarized by " + ModelName + ".",
+		"networking/ingress.yaml": "Summarized by quality:latest.",
+		"big.yaml":                "Summarized by quality:latest.",
+	}, summaries)
+
+	// Summaries reused from the document are not refined again
+	report, err = summarizeDirectory(tmpDir, llm)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.Refined)
+	assert.Equal(t, 4, report.Skipped)
+}
+
+func TestIntegrationKeyFiles(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_key_files_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "apps"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "networking"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "web.yaml"), []byte("kind: Deployment"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "config.yaml"), []byte("kind: ConfigMap"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "networking", "ingress.yaml"), []byte("kind: Ingress"), 0644))
+
+	origRules, origFormat := keyFileRules, outputFormat
+	defer func() {
+		keyFileRules, outputFormat = origRules, origFormat
+	}()
+
+	keyFileRules = []string{"kind"}
+	assert.ErrorContains(t, validateKeyFiles(), "unsupported --key-files")
+	keyFileRules = []string{"kind=Deployment", "path=networking/*"}
+	assert.NoError(t, validateKeyFiles())
+
+	llm := NewMockLLMProvider()
+	llm.MockResponses["Deployment"] = "Runs the web frontend."
+	llm.MockResponses["Ingress"] = "Routes public traffic."
+	assert.NoError(t, runSummarizeYamlWithProvider(tmpDir, llm))
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, markdownFileName))
+	assert.NoError(t, err)
+	doc := string(content)
+	highlight := strings.Index(doc, "## Key Configuration Files")
+	firstDir := strings.Index(doc, "## [apps/]")
+	assert.True(t, highlight >= 0 && highlight < firstDir, doc)
+	assert.Contains(t, doc, "- [`apps/web.yaml`](apps/web.yaml) — Runs the web frontend.\n")
+	assert.Contains(t, doc, "- [`networking/ingress.yaml`](networking/ingress.yaml) — Routes public traffic.\n")
+	assert.NotContains(t, doc[highlight:firstDir], "config.yaml")
+	// Highlighted files keep their entries in the directory listing
+	assert.Contains(t, doc[firstDir:], "- [web.yaml](apps/web.yaml): Runs the web frontend.")
+
+	// The highlight is not read back as summaries, so the next run reuses every entry
+	report, err := summarizeDirectory(tmpDir, llm)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, report.Skipped)
+	assert.NoError(t, runVerify(tmpDir))
+
+	outputFormat = "html"
+	assert.NoError(t, runSummarizeYamlWithProvider(tmpDir, llm))
+	content, err = os.ReadFile(filepath.Join(tmpDir, markdownFileName))
+	assert.NoError(t, err)
+	html := string(content)
+	assert.True(t, strings.Index(html, "<h2>Key Configuration Files</h2>") < strings.Index(html, `<h2><a href="apps/">`), html)
+}
+
+// TestIntegrationAnnotateFiles tests that summaries are written into the files as a
+// comment block, idempotently and without touching the rest of the file.
+func TestIntegrationAnnotateFiles(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_annotate_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	origAnnotate, origRegenerate, origNoWrite := annotateFilesEnabled, regenerate, noWrite
+	defer func() {
+		annotateFilesEnabled, regenerate, noWrite = origAnnotate, origRegenerate, origNoWrite
+	}()
+	annotateFilesEnabled = true
+
+	app := filepath.Join(tmpDir, "app.yaml")
+	appContent := "# Owned by the web team.\nkind: Deployment   # keep this spacing\nreplicas: 3\n"
+	assert.NoError(t, os.WriteFile(app, []byte(appContent), 0600))
+	win := filepath.Join(tmpDir, "win.yaml")
+	assert.NoError(t, os.WriteFile(win, []byte("kind: Service\r\nport: 80\r\n"), 0644))
+
+	recorder := &contentRecorder{MockLLMProvider: NewMockLLMProvider()}
+	recorder.MockResponses["kind: Deployment"] = "Runs the shop frontend."
+	recorder.MockResponses["kind: Service"] = "Exposes the frontend on port 80."
+	report, err := summarizeDirectory(tmpDir, recorder)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Annotated)
+
+	data, err := os.ReadFile(app)
+	assert.NoError(t, err)
+	assert.Equal(t, "# Summary: Runs the shop frontend.\n"+appContent, string(data))
+	info, err := os.Stat(app)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	data, err = os.ReadFile(win)
+	assert.NoError(t, err)
+	assert.Equal(t, "# Summary: Exposes the frontend on port 80.\r\nkind: Service\r\nport: 80\r\n", string(data))
+
+	// An unchanged summary leaves the files alone
+	report, err = summarizeDirectory(tmpDir, recorder)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.Annotated)
+
+	// A new summary replaces the comment, wrapped, and the old one is never sent to the LLM
+	long := strings.TrimSpace(strings.Repeat("Runs the shop frontend behind the public ingress. ", 2))
+	recorder.MockResponses["kind: Deployment"] = long
+	recorder.contents = nil
+	regenerate = true
+	report, err = summarizeDirectory(tmpDir, recorder)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Annotated)
+	for _, content := range recorder.contents {
+		assert.NotContains(t, content, SummaryCommentPrefix)
+	}
+	data, err = os.ReadFile(app)
+	assert.NoError(t, err)
+	comment, rest := splitSummaryComment(string(data))
+	assert.Equal(t, appContent, rest)
+	assert.Equal(t, summaryComment(long, "\n"), comment)
+	lines := strings.Split(strings.TrimSuffix(comment, "\n"), "\n")
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[1], "#   "))
+	for _, line := range lines {
+		assert.LessOrEqual(t, len(line), summaryCommentWidth)
+	}
+
+	noWrite = true
+	assert.Error(t, validateAnnotate())
+}
+
+func TestIntegrationAsk(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_ask_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "apps"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "networking"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "web.yaml"), []byte("kind: Deployment"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "db.yaml"), []byte("kind: StatefulSet"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "networking", "ingress.yaml"), []byte("kind: Ingress\ntls: [secretName: web-tls]"), 0644))
+
+	var out bytes.Buffer
+	assert.ErrorContains(t, runAskWithProvider(&out, tmpDir, "where is TLS terminated?", NewMockLLMProvider()), "no summaries found")
+
+	llm := NewMockLLMProvider()
+	llm.MockResponses["Deployment"] = "Runs the web frontend pods."
+	llm.MockResponses["StatefulSet"] = "Runs the PostgreSQL database."
+	llm.MockResponses["Ingress"] = "Terminates TLS for public traffic and routes it to the web frontend."
+	assert.NoError(t, runSummarizeYamlWithProvider(tmpDir, llm))
+
+	origTopK := askTopK
+	defer func() {
+		askTopK = origTopK
+	}()
+	askTopK = 2
+
+	// Without an embedding API the files sharing the question's words are retrieved
+	asker := NewMockLLMProvider()
+	asker.MockResponses["Question: where is TLS terminated?"] = "TLS is terminated by the public Ingress [1]."
+	out.Reset()
+	assert.NoError(t, runAskWithProvider(&out, tmpDir, "where is TLS terminated?", asker))
+	assert.Equal(t, "TLS is terminated by the public Ingress [1].\n\nSources:\n[1] "+filepath.Join(tmpDir, "networking", "ingress.yaml")+"\n", out.String())
+
+	passages, err := loadInventory(tmpDir)
+	assert.NoError(t, err)
+	ctx := context.Background()
+	content := askContent("where is TLS terminated?", newAskIndex(ctx, asker, tmpDir, passages).retrieve(ctx, "where is TLS terminated?", 2))
+	assert.True(t, strings.HasPrefix(content, "Question: where is TLS terminated?\n\n[1] networking/ingress.yaml\nSummary: Terminates TLS"), content)
+	assert.Contains(t, content, "secretName: web-tls")
+
+	// Providers with an embedding API rank by similarity
+	ollamaLLM := NewOllamaProviderFromClient(NewMockOllamaClient())
+	passages, err = loadInventory(tmpDir)
+	assert.NoError(t, err)
+	retrieved := newAskIndex(ctx, ollamaLLM, tmpDir, passages).retrieve(ctx, "which file runs the database", 1)
+	assert.Equal(t, "apps/db.yaml", retrieved[0].Rel)
+
+	// Uncited answers list every retrieved file
+	numbers, cited := citedPassages("No file says.", passages[:2])
+	assert.Equal(t, []int{1, 2}, numbers)
+	assert.Len(t, cited, 2)
+}
+
+// contentRecorder records the content of every Summarize call.
+type contentRecorder struct {
+	*MockLLMProvider
+	mu       sync.Mutex
+	contents []string
+}
+
+// Summarize implements LLMProvider.Summarize, recording the content.
+func (r *contentRecorder) Summarize(ctx context.Context, content string, prompt string) (string, error) {
+	r.mu.Lock()
+	r.contents = append(r.contents, content)
+	r.mu.Unlock()
+	return r.MockLLMProvider.Summarize(ctx, content, prompt)
+}
+
+func TestIntegrationChat(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_chat_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "networking"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "web.yaml"), []byte("kind: Deployment"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "networking", "ingress.yaml"), []byte("kind: Ingress\ntls: [secretName: web-tls]"), 0644))
+
+	llm := NewMockLLMProvider()
+	llm.MockResponses["Deployment"] = "Runs the web frontend pods."
+	llm.MockResponses["Ingress"] = "Terminates TLS for public traffic."
+	assert.NoError(t, runSummarizeYamlWithProvider(tmpDir, llm))
+
+	origTopK, origHistory := askTopK, chatHistory
+	defer func() {
+		askTopK, chatHistory = origTopK, origHistory
+	}()
+	askTopK, chatHistory = 1, DefaultChatHistory
+
+	chat := &contentRecorder{MockLLMProvider: NewMockLLMProvider()}
+	chat.MockResponses["Question: where is TLS terminated?"] = "At the Ingress [1]."
+	chat.MockResponses["Question: which secret holds the certificate?"] = "The web-tls secret [1]."
+	in := strings.NewReader("where is TLS terminated?\n\nwhich secret holds the certificate?\n/reset\nwhat runs the frontend?\nexit\nignored\n")
+	var out bytes.Buffer
+	assert.NoError(t, runChatWithProvider(in, &out, tmpDir, chat))
+
+	ingress := filepath.Join(tmpDir, "networking", "ingress.yaml")
+	assert.Contains(t, out.String(), "> At the Ingress [1].\n\nSources:\n[1] "+ingress+"\n")
+	assert.Contains(t, out.String(), "> The web-tls secret [1].\n\nSources:\n[1] "+ingress+"\n")
+	assert.Contains(t, out.String(), "> Conversation cleared.\n")
+	if assert.Len(t, chat.contents, 3) {
+		assert.False(t, strings.Contains(chat.contents[0], "Conversation so far"))
+		// Follow-ups carry the conversation
+		assert.True(t, strings.HasPrefix(chat.contents[1], "Conversation so far:\nQ: where is TLS terminated?\nA: At the Ingress [1].\n\nQuestion: which secret"), chat.contents[1])
+		// /reset forgets it
+		assert.True(t, strings.HasPrefix(chat.contents[2], "Question: what runs the frontend?"), chat.contents[2])
+		assert.Contains(t, chat.contents[2], "[1] web.yaml")
+	}
+
+	// Input ending without exit ends the session
+	assert.NoError(t, runChatWithProvider(strings.NewReader("where is TLS terminated?"), &out, tmpDir, chat))
+}
+
+func TestIntegrationEmbeddingsExport(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_embeddings_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "apps"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "web.yaml"), []byte("kind: Deployment"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "values.yaml"), []byte("replicas: 2"), 0644))
+
+	llm := NewMockLLMProvider()
+	llm.MockResponses["Deployment"] = "Runs the web frontend."
+	llm.MockResponses["replicas"] = "Default chart values."
+	assert.NoError(t, runSummarizeYamlWithProvider(tmpDir, llm))
+
+	origFormat, origFile := embeddingsFormat, embeddingsFile
+	defer func() {
+		embeddingsFormat, embeddingsFile = origFormat, origFile
+	}()
+
+	embeddingsFormat, embeddingsFile = "csv", ""
+	assert.ErrorContains(t, validateEmbeddingsFormat(), "expected one of: jsonl, parquet")
+	embeddingsFormat = "jsonl"
+	assert.ErrorContains(t, runEmbeddingsExportWithProvider(tmpDir, llm), "does not support embeddings")
+
+	ollamaLLM := NewOllamaProviderFromClient(NewMockOllamaClient())
+	assert.NoError(t, runEmbeddingsExportWithProvider(tmpDir, ollamaLLM))
+	content, err := os.ReadFile(filepath.Join(tmpDir, DefaultEmbeddingsFileName+".jsonl"))
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if assert.Len(t, lines, 2) {
+		var record embeddingRecord
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+		sum := sha256.Sum256([]byte("kind: Deployment"))
+		assert.Equal(t, "apps/web.yaml", record.Path)
+		assert.Equal(t, "Deployment", record.Kind)
+		assert.Equal(t, hex.EncodeToString(sum[:]), record.SHA256)
+		assert.Equal(t, "Runs the web frontend.", record.Summary)
+		assert.Equal(t, DefaultOllamaEmbedModel, record.Model)
+		assert.Len(t, record.Embedding, 64)
+	}
+
+	embeddingsFormat, embeddingsFile = "parquet", filepath.Join(tmpDir, "vectors.parquet")
+	assert.NoError(t, runEmbeddingsExportWithProvider(tmpDir, ollamaLLM))
+	content, err = os.ReadFile(embeddingsFile)
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(content, []byte("PAR1")))
+	assert.True(t, bytes.HasSuffix(content, []byte("PAR1")))
+	assert.Contains(t, string(content), "apps/web.yaml")
+	assert.Contains(t, string(content), "Default chart values.")
+}
+
+func TestIntegrationDeterministic(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_deterministic_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "apps"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "web.yaml"), []byte("kind: Deployment"), 0644))
+
+	origDeterministic, origFormat, origRegenerate := deterministic, outputFormat, regenerate
+	defer func() {
+		deterministic, outputFormat, regenerate = origDeterministic, origFormat, origRegenerate
+	}()
+	deterministic, regenerate = true, true
+
+	for _, format := range []string{"json", "html"} {
+		outputFormat = format
+		var docs []string
+		for range 2 {
+			_, err := summarizeDirectory(tmpDir, NewMockLLMProvider())
+			assert.NoError(t, err)
+			doc, err := os.ReadFile(filepath.Join(tmpDir, markdownFileName))
+			assert.NoError(t, err)
+			docs = append(docs, string(doc))
+		}
+		assert.Equal(t, docs[0], docs[1], format)
+		assert.Contains(t, docs[0], "1970-01-01T00:00:00Z", format)
+	}
+}
+
+// TestIntegrationRefreshSingleFile tests that refresh updates exactly one entry in place.
+func TestIntegrationRefreshSingleFile(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_refresh_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "app"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "app", "deploy.yaml"), []byte("kind: Deployment"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "app", "svc.yaml"), []byte("kind: Service"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "root.yaml"), []byte("kind: Namespace"), 0644))
+
+	// A hand-edited note in the document must survive the refresh
+	content := MarkdownHeader + `
+## [app/](../app/)
+- [deploy.yaml](../app/deploy.yaml): Old deployment summary.
+- [svc.yaml](../app/svc.yaml): Hand-written service summary.
+
+> Maintainer note: keep this.
+`
+	mdPath := filepath.Join(tmpDir, markdownFileName)
+	assert.NoError(t, os.WriteFile(mdPath, []byte(content), 0644))
+
+	mock := NewMockLLMProvider()
+	mock.MockResponses["kind: Deployment"] = "Fresh deployment summary."
+	mock.MockResponses["kind: Namespace"] = "Root namespace."
+	assert.NoError(t, runRefreshWithProvider(filepath.Join(tmpDir, "app", "deploy.yaml"), mock))
+
+	updated, err := os.ReadFile(mdPath)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Replace(content, "Old deployment summary.", "Fresh deployment summary.", 1), string(updated))
+
+	// A file without an entry is added by regenerating the document
+	assert.NoError(t, runRefreshWithProvider(filepath.Join(tmpDir, "root.yaml"), mock))
+	summaries := parseExistingSummaries(mdPath)
+	assert.Equal(t, "Root namespace.", summaries["root.yaml"])
+	assert.Equal(t, "Hand-written service summary.", summaries[filepath.Join("app", "svc.yaml")])
+
+	// Files outside the document's tree and non-YAML files are rejected
+	assert.Error(t, runRefreshWithProvider(mdPath, mock))
+	outside, err := os.MkdirTemp("", "integration_test_refresh_outside_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(outside)
+	}()
+	assert.NoError(t, os.WriteFile(filepath.Join(outside, "x.yaml"), []byte("a: b"), 0644))
+	assert.Error(t, runRefreshWithProvider(filepath.Join(outside, "x.yaml"), mock))
+}
+
+// TestIntegrationFileJSON tests the single-file JSON output used by editor extensions.
+func TestIntegrationFileJSON(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_file_json_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	origJSON := fileJSON
+	origRegenerate := regenerate
+	defer func() {
+		fileJSON = origJSON
+		regenerate = origRegenerate
+	}()
+	fileJSON = true
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "app"), 0755))
+	deploy := filepath.Join(tmpDir, "app", "deploy.yaml")
+	assert.NoError(t, os.WriteFile(deploy, []byte("kind: Deployment"), 0644))
+	bundle := filepath.Join(tmpDir, "app", "bundle.yaml")
+	assert.NoError(t, os.WriteFile(bundle, []byte("kind: Service\n---\nkind: ConfigMap\n---\nkind: Service"), 0644))
+	content := MarkdownHeader + `
+## [app/](../app/)
+- [deploy.yaml](../app/deploy.yaml): Documented deployment summary.
+`
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, markdownFileName), []byte(content), 0644))
+
+	mock := &countingProvider{MockLLMProvider: NewMockLLMProvider()}
+	mock.MockResponses["kind: Deployment"] = "Fresh deployment summary."
+	mock.MockResponses["kind: Service"] = "Service bundle."
+	newProvider := func() (LLMProvider, error) { return mock, nil }
+
+	// A documented file is answered from the document without a provider call
+	result, err := describeFile(deploy, newProvider)
+	assert.NoError(t, err)
+	var buf bytes.Buffer
+	assert.NoError(t, writeFileResult(&buf, result))
+	var decoded map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	sum := sha256.Sum256([]byte("kind: Deployment"))
+	assert.Equal(t, map[string]any{
+		"schema_version": float64(FileJSONSchemaVersion),
+		"path":           "app/deploy.yaml",
+		"summary":        "Documented deployment summary.",
+		"kind":           "Deployment",
+		"tags":           []any{"Deployment"},
+		"hash":           "sha256:" + hex.EncodeToString(sum[:]),
+		"cached":         true,
+	}, decoded)
+	assert.Equal(t, int64(0), mock.calls.Load())
+
+	// Files without an entry are generated on demand and the document is left alone
+	result, err = describeFile(bundle, newProvider)
+	assert.NoError(t, err)
+	assert.False(t, result.Cached)
+	assert.Equal(t, "Service bundle.", result.Summary)
+	assert.Equal(t, "Service", result.Kind)
+	assert.Equal(t, []string{"ConfigMap", "Service"}, result.Tags)
+	unchanged, err := os.ReadFile(filepath.Join(tmpDir, markdownFileName))
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(unchanged))
+
+	// An entry generated from other content is not served: by the hash the document
+	// recorded, or without one, by the file being newer than the document
+	mdPath := filepath.Join(tmpDir, markdownFileName)
+	assert.NoError(t, os.WriteFile(mdPath, []byte(content+"\n<!-- yaml-to-readme-entry sha256:"+strings.Repeat("0", 64)+" app/deploy.yaml -->\n"), 0644))
+	result, err = describeFile(deploy, newProvider)
+	assert.NoError(t, err)
+	assert.False(t, result.Cached)
+	assert.Equal(t, "Fresh deployment summary.", result.Summary)
+	assert.NoError(t, os.WriteFile(mdPath, []byte(content), 0644))
+	later := time.Now().Add(time.Hour)
+	assert.NoError(t, os.Chtimes(deploy, later, later))
+	result, err = describeFile(deploy, newProvider)
+	assert.NoError(t, err)
+	assert.False(t, result.Cached)
+
+	regenerate = true
+	result, err = describeFile(deploy, newProvider)
+	assert.NoError(t, err)
+	assert.False(t, result.Cached)
+	assert.Equal(t, "Fresh deployment summary.", result.Summary)
+
+	// Without --json only the summary is printed
+	fileJSON = false
+	buf.Reset()
+	assert.NoError(t, writeFileResult(&buf, result))
+	assert.Equal(t, "Fresh deployment summary.\n", buf.String())
+
+	_, err = describeFile(filepath.Join(tmpDir, markdownFileName), newProvider)
+	assert.Error(t, err)
+}
+
+// TestIntegrationRetryFailed tests that failed files are recorded and retry-failed re-attempts only those.
+func TestIntegrationRetryFailed(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_retry_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "app"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "app", "ok.yaml"), []byte("kind: ConfigMap"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "app", "flaky.yaml"), []byte("kind: Secret"), 0644))
+
+	mock := NewMockLLMProvider()
+	mock.MockResponses["kind: ConfigMap"] = "Config map."
+	mock.MockErrors["kind: Secret"] = errors.New("rate limited")
+	report, err := summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app/flaky.yaml"}, report.Failed)
+	assert.Equal(t, []string{"app/flaky.yaml"}, readFailedList(tmpDir))
+
+	// Only the failed file is sent to the provider on retry
+	delete(mock.MockErrors, "kind: Secret")
+	mock.MockResponses["kind: Secret"] = "Secret values."
+	mock.MockResponses["kind: ConfigMap"] = "Should not be regenerated."
+	assert.NoError(t, runRetryFailedWithProvider(tmpDir, mock))
+
+	summaries := parseExistingSummaries(filepath.Join(tmpDir, markdownFileName))
+	assert.Equal(t, "Secret values.", summaries[filepath.Join("app", "flaky.yaml")])
+	assert.Equal(t, "Config map.", summaries[filepath.Join("app", "ok.yaml")])
+	_, err = os.Stat(filepath.Join(tmpDir, DefaultFailedListFileName))
+	assert.True(t, os.IsNotExist(err), "failed list should be removed once everything succeeds")
+
+	assert.NoError(t, runRetryFailedWithProvider(tmpDir, mock))
+}
+
+// TestIntegrationProgressWebhook tests that progress events are POSTed during a run.
+func TestIntegrationProgressWebhook(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_webhook_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	var mu sync.Mutex
+	var events []progressEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev progressEvent
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&ev))
+		mu.Lock()
+		events = append(events, ev)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	origURL, origInterval := progressWebhookURL, progressWebhookInterval
+	defer func() {
+		progressWebhookURL, progressWebhookInterval = origURL, origInterval
+	}()
+	progressWebhookURL = server.URL
+	progressWebhookInterval = 0
+
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "a.yaml"), []byte("kind: ConfigMap"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "b.yaml"), []byte("kind: Secret"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "c.yaml"), []byte("kind: Service"), 0644))
+	mock := NewMockLLMProvider()
+	mock.MockErrors["kind: Secret"] = errors.New("boom")
+
+	yamlFiles, err := findYAMLFiles(tmpDir, false)
+	assert.NoError(t, err)
+	processYAMLFiles(yamlFiles, tmpDir, map[string]string{"c.yaml": "Existing."}, mock, false)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if assert.Len(t, events, 4) {
+		assert.Equal(t, "start", events[0].Event)
+		assert.Equal(t, 1, events[0].Completed)
+		assert.Equal(t, 3, events[0].Total)
+		assert.Equal(t, "progress", events[1].Event)
+		assert.NotEmpty(t, events[1].CurrentFile)
+		last := events[3]
+		assert.Equal(t, "done", last.Event)
+		assert.Equal(t, 3, last.Completed)
+		assert.Equal(t, 100.0, last.Percent)
+		assert.Equal(t, 1, last.Failures)
+	}
+}
+
+// TestIntegrationSkipGenerated tests that generated and oversized files are listed in an appendix instead of summarized.
+func TestIntegrationSkipGenerated(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_generated_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	origSkip, origMax := skipGenerated, maxFileSizeKB
+	defer func() {
+		skipGenerated, maxFileSizeKB = origSkip, origMax
+	}()
+	skipGenerated = true
+	maxFileSizeKB = 1
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "crds"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "app.yaml"), []byte("kind: Deployment"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "pnpm-lock.yaml"), []byte("lockfileVersion: 6"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "crds", "widgets.yaml"), []byte("# Code generated by controller-gen. DO NOT EDIT.\nkind: CustomResourceDefinition"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "crds", "big.yaml"), []byte("data: "+strings.Repeat("x", 2048)), 0644))
+
+	mock := NewMockLLMProvider()
+	report, err := summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Processed)
+	assert.Equal(t, 3, report.Generated)
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, markdownFileName))
+	assert.NoError(t, err)
+	doc := string(content)
+	assert.Contains(t, doc, "## Skipped as Generated")
+	assert.Contains(t, doc, "- `pnpm-lock.yaml` — lock file")
+	assert.Contains(t, doc, "- `crds/widgets.yaml` — generated-code header")
+	assert.Contains(t, doc, "- `crds/big.yaml` — larger than 1 KB")
+
+	// Appendix entries are not read back as summaries
+	summaries := parseExistingSummaries(filepath.Join(tmpDir, markdownFileName))
+	assert.Len(t, summaries, 1)
+	assert.Contains(t, summaries, "app.yaml")
+
+	// With detection disabled, generated files are summarized like any other
+	skipGenerated = false
+	maxFileSizeKB = 0
+	report, err = summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.Generated)
+	assert.Equal(t, 3, report.Processed)
+}
+
+// TestIntegrationArchiveInput tests summarizing the YAML contents of .tar.gz and .zip archives.
+func TestIntegrationArchiveInput(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_archive_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	files := map[string]string{
+		"bundle/deploy.yaml":      "kind: Deployment",
+		"bundle/config/app.yml":   "kind: ConfigMap",
+		"bundle/README.txt":       "not yaml",
+		"bundle/charts/chart.yml": "kind: Service",
+	}
+
+	// Build a .tar.gz
+	tgzPath := filepath.Join(tmpDir, "release-1.0.tar.gz")
+	tgz, err := os.Create(tgzPath)
+	assert.NoError(t, err)
+	gz := gzip.NewWriter(tgz)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err = tw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+	assert.NoError(t, tgz.Close())
+
+	// Build a .zip
+	zipPath := filepath.Join(tmpDir, "export.zip")
+	zf, err := os.Create(zipPath)
+	assert.NoError(t, err)
+	zw := zip.NewWriter(zf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+	assert.NoError(t, zf.Close())
+
+	mock := NewMockLLMProvider()
+	mock.MockResponses["kind: Deployment"] = "Deploys the release."
+	for _, archive := range []string{tgzPath, zipPath} {
+		assert.True(t, isArchive(archive))
+		report, err := summarizeArchive(archive, mock)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, report.Processed)
+		assert.Equal(t, archiveDocPath(archive), report.OutputPath)
+
+		summaries := parseExistingSummaries(report.OutputPath)
+		assert.Equal(t, "Deploys the release.", summaries[filepath.Join("bundle", "deploy.yaml")])
+		assert.Len(t, summaries, 3)
+
+		// A second run reuses the summaries in the existing document
+		report, err = summarizeArchive(archive, mock)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, report.Processed)
+		assert.Equal(t, 3, report.Skipped)
+	}
+	assert.Equal(t, filepath.Join(tmpDir, "release-1.0_"+markdownFileName), archiveDocPath(tgzPath))
+	assert.False(t, isArchive(tmpDir))
+
+	// Entries escaping the extraction directory are rejected
+	evilPath := filepath.Join(tmpDir, "evil.zip")
+	ef, err := os.Create(evilPath)
+	assert.NoError(t, err)
+	ezw := zip.NewWriter(ef)
+	w, err := ezw.Create("../escape.yaml")
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("a: b"))
+	assert.NoError(t, err)
+	assert.NoError(t, ezw.Close())
+	assert.NoError(t, ef.Close())
+	_, err = extractArchive(evilPath)
+	assert.Error(t, err)
+
+	// Only documented files are extracted
+	for _, archive := range []string{tgzPath, zipPath} {
+		extracted, err := extractArchive(archive)
+		assert.NoError(t, err)
+		assert.FileExists(t, filepath.Join(extracted, "bundle", "deploy.yaml"))
+		assert.NoFileExists(t, filepath.Join(extracted, "bundle", "README.txt"))
+		_ = os.RemoveAll(extracted)
+	}
+
+	// Extraction stops once the archive exceeds its entry or size budget
+	budget := &extractionBudget{entries: 1, bytes: 4}
+	assert.NoError(t, budget.entry())
+	assert.ErrorContains(t, budget.entry(), "entries")
+	assert.NoError(t, writeArchiveEntry(filepath.Join(tmpDir, "budget", "a.yaml"), strings.NewReader("a: b"), budget))
+	assert.ErrorContains(t, writeArchiveEntry(filepath.Join(tmpDir, "budget", "b.yaml"), strings.NewReader("c"), budget), "more than")
+}
+
+func TestIntegrationClusterInventory(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_cluster_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	// A fake kubectl that records its arguments and returns a fixed List per kind
+	argsLog := filepath.Join(tmpDir, "args.log")
+	script := `#!/bin/sh
+echo "$@" >> "` + argsLog + `"
+case "$*" in
+*"get deployments"*)
+cat <<'YAML'
+apiVersion: v1
+kind: List
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: web
+    namespace: shop
+    uid: abc-123
+    resourceVersion: "42"
+    managedFields:
+    - manager: kubectl
+    annotations:
+      kubectl.kubernetes.io/last-applied-configuration: "{}"
+  spec:
+    replicas: 2
+  status:
+    readyReplicas: 2
+YAML
+;;
+*"get secrets"*)
+cat <<'YAML'
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Secret
+  metadata:
+    name: creds
+    namespace: shop
+  data:
+    password: c3VwZXJzZWNyZXQ=
+YAML
+;;
+*) echo "error: unknown kind" >&2; exit 1 ;;
+esac
+`
+	fakeKubectl := filepath.Join(tmpDir, "kubectl")
+	assert.NoError(t, os.WriteFile(fakeKubectl, []byte(script), 0755))
+
+	origCommand, origContext, origNamespaces, origKinds, origOutputDir := kubectlCommand, clusterContext, clusterNamespaces, clusterKinds, clusterOutputDir
+	defer func() {
+		kubectlCommand, clusterContext, clusterNamespaces, clusterKinds, clusterOutputDir = origCommand, origContext, origNamespaces, origKinds, origOutputDir
+	}()
+	kubectlCommand = fakeKubectl
+	clusterContext = "prod"
+	clusterNamespaces = []string{"shop"}
+	clusterKinds = []string{"deployments", "secrets"}
+	clusterOutputDir = tmpDir
+
+	mock := NewMockLLMProvider()
+	mock.DefaultResponse = "Summarized resource."
+	assert.NoError(t, runClusterWithProvider(mock))
+
+	logged, err := os.ReadFile(argsLog)
+	assert.NoError(t, err)
+	assert.Contains(t, string(logged), "--context prod get deployments -o yaml --namespace shop")
+
+	docPath := filepath.Join(tmpDir, "cluster_prod_"+markdownFileName)
+	summaries := parseExistingSummaries(docPath)
+	assert.Equal(t, "Summarized resource.", summaries[filepath.Join("shop", "deployment", "web.yaml")])
+	assert.Contains(t, summaries, filepath.Join("shop", "secret", "creds.yaml"))
+
+	// Server-populated fields and secret values are stripped before summarizing
+	obj := map[string]any{
+		"kind":     "Secret",
+		"metadata": map[string]any{"name": "creds", "uid": "x", "managedFields": []any{}},
+		"data":     map[string]any{"password": "c3VwZXJzZWNyZXQ="},
+		"status":   map[string]any{},
+	}
+	summarizer.SanitizeResource(obj)
+	assert.NotContains(t, obj, "data")
+	assert.NotContains(t, obj, "status")
+	assert.Equal(t, map[string]any{"name": "creds"}, obj["metadata"])
+
+	// kubectl failures surface with their stderr
+	clusterKinds = []string{"widgets"}
+	err = runClusterWithProvider(mock)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown kind")
+
+	// A missing kubectl is reported before anything is read
+	kubectlCommand = filepath.Join(tmpDir, "no-kubectl")
+	assert.ErrorContains(t, runClusterWithProvider(mock), "reads resources with kubectl, which must be on PATH")
+}
+
+func TestIntegrationHelmReleases(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_helm_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	script := `#!/bin/sh
+case "$*" in
+"list --output json --all-namespaces --kube-context prod")
+echo '[{"name":"shop","namespace":"apps","chart":"shop-1.2.0"}]'
+;;
+"get values shop --namespace apps --output yaml --kube-context prod")
+echo "replicaCount: 3"
+;;
+"get manifest shop --namespace apps --kube-context prod")
+cat <<'YAML'
+---
+# Source: shop/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: shop-web
+---
+# Source: shop/templates/secret.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: shop-creds
+data:
+  password: c3VwZXJzZWNyZXQ=
+YAML
+;;
+*) echo "unexpected: $*" >&2; exit 1 ;;
+esac
+`
+	fakeHelm := filepath.Join(tmpDir, "helm")
+	assert.NoError(t, os.WriteFile(fakeHelm, []byte(script), 0755))
+
+	origCommand, origContext, origNamespaces, origOutputDir := helmCommand, clusterContext, clusterNamespaces, clusterOutputDir
+	defer func() {
+		helmCommand, clusterContext, clusterNamespaces, clusterOutputDir = origCommand, origContext, origNamespaces, origOutputDir
+	}()
+	helmCommand = fakeHelm
+	clusterContext = "prod"
+	clusterNamespaces = nil
+	clusterOutputDir = tmpDir
+
+	mock := NewMockLLMProvider()
+	mock.DefaultResponse = "Summarized release file."
+	mock.MockResponses["c3VwZXJzZWNyZXQ="] = "leaked"
+	assert.NoError(t, runHelmReleasesWithProvider(mock))
+
+	summaries := parseExistingSummaries(filepath.Join(tmpDir, "helm_prod_"+markdownFileName))
+	assert.Len(t, summaries, 3)
+	assert.Equal(t, "Summarized release file.", summaries[filepath.Join("apps", "shop", "values.yaml")])
+	assert.Equal(t, "Summarized release file.", summaries[filepath.Join("apps", "shop", "deployment_shop-web.yaml")])
+	assert.Equal(t, "Summarized release file.", summaries[filepath.Join("apps", "shop", "secret_shop-creds.yaml")])
+}
+
+func TestIntegrationDiffCluster(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_diff_cluster_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	repoDir := filepath.Join(tmpDir, "repo")
+	assert.NoError(t, os.MkdirAll(repoDir, 0755))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(repoDir, "web.yaml"), []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+spec:
+  replicas: 2
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: shop
+`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(repoDir, "cache.yaml"), []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cache
+data:
+  size: "64"
+`), 0644))
+
+	// The live deployment was scaled, the service was never applied, the configmap matches
+	script := `#!/bin/sh
+case "$*" in
+*"get Deployment/web"*)
+cat <<'YAML'
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+  uid: abc
+spec:
+  replicas: 5
+  strategy:
+    type: RollingUpdate
+status:
+  readyReplicas: 5
+YAML
+;;
+*"get ConfigMap/cache"*)
+cat <<'YAML'
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cache
+  namespace: default
+data:
+  size: "64"
+YAML
+;;
+*"get Service/web"*) ;;
+*) echo "unexpected: $*" >&2; exit 1 ;;
+esac
+`
+	fakeKubectl := filepath.Join(tmpDir, "kubectl")
+	assert.NoError(t, os.WriteFile(fakeKubectl, []byte(script), 0755))
+
+	origCommand, origDiff, origContext := kubectlCommand, diffCluster, diffClusterContext
+	defer func() {
+		kubectlCommand, diffCluster, diffClusterContext = origCommand, origDiff, origContext
+	}()
+	kubectlCommand = fakeKubectl
+	diffCluster = true
+	diffClusterContext = "prod"
+
+	mock := NewMockLLMProvider()
+	mock.DefaultResponse = "Summarized."
+	mock.MockResponses["spec.replicas: declared \"2\", deployed \"5\""] = "The deployment was scaled from 2 to 5 replicas outside of the repository."
+	_, err = summarizeDirectory(repoDir, mock)
+	assert.NoError(t, err)
+
+	doc, err := os.ReadFile(filepath.Join(repoDir, markdownFileName))
+	assert.NoError(t, err)
+	content := string(doc)
+	assert.Contains(t, content, "## Declared vs Deployed")
+	assert.Contains(t, content, "- `web.yaml` — Deployment/web: The deployment was scaled from 2 to 5 replicas outside of the repository.")
+	assert.Contains(t, content, "- `web.yaml` — Service/web is declared but not deployed.")
+	assert.NotContains(t, content, "`cache.yaml` —")
+
+	// Appendix entries are not read back as summaries
+	summaries := parseExistingSummaries(filepath.Join(repoDir, markdownFileName))
+	assert.Equal(t, "Summarized.", summaries["web.yaml"])
+	assert.Len(t, summaries, 2)
+}
+
+func TestIntegrationRiskAnalysis(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_risk_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "apps"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "web.yaml"), []byte("kind: Pod\nspec:\n  containers:\n  - image: nginx:latest\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "safe.yaml"), []byte("kind: ConfigMap\ndata:\n  mode: safe\n"), 0644))
+
+	origRisk := riskAnalysis
+	defer func() {
+		riskAnalysis = origRisk
+	}()
+	riskAnalysis = RiskAnalysisLLM
+
+	mock := NewMockLLMProvider()
+	mock.DefaultResponse = "Summarized."
+	mock.MockResponses["mode: safe"] = "NONE"
+	_, err = summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+
+	mdPath := filepath.Join(tmpDir, markdownFileName)
+	doc, err := os.ReadFile(mdPath)
+	assert.NoError(t, err)
+	content := string(doc)
+	assert.Contains(t, content, "- [web.yaml](apps/web.yaml): Summarized. ⚠ latest image tag, flagged by review\n")
+	assert.Contains(t, content, "## Findings")
+	assert.Contains(t, content, "- `apps/web.yaml` — ⚠ latest image tag: spec.containers[0].image (nginx:latest)")
+	assert.Contains(t, content, "- `apps/web.yaml` — ⚠ flagged by review: Summarized.")
+	assert.NotContains(t, content, "`apps/safe.yaml` —")
+
+	// Notes are recomputed rather than read back into the summaries
+	summaries := parseExistingSummaries(mdPath)
+	assert.Equal(t, "Summarized.", summaries[filepath.Join("apps", "web.yaml")])
+	assert.Len(t, summaries, 2)
+
+	riskAnalysis = "strict"
+	assert.Error(t, validateRiskAnalysis())
+}
+
+func TestIntegrationPolicy(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_policy_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	repoDir := filepath.Join(tmpDir, "repo")
+	assert.NoError(t, os.MkdirAll(repoDir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(repoDir, "pod.yaml"), []byte("kind: Pod\nmetadata:\n  name: web\nspec:\n  hostNetwork: true\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(repoDir, "cm.yaml"), []byte("kind: ConfigMap\nmetadata:\n  name: settings\n"), 0644))
+	policy := filepath.Join(tmpDir, "policy.rego")
+	assert.NoError(t, os.WriteFile(policy, []byte("package main\n"), 0644))
+
+	// A fake opa that denies manifests using the host network
+	argsLog := filepath.Join(tmpDir, "args.log")
+	script := `#!/bin/sh
+echo "$@" > "` + argsLog + `"
+input=$(cat)
+case "$input" in
+*'"hostNetwork":true'*)
+echo '{"result":[{"expressions":[{"value":["host networking is not allowed",{"msg":"pods need an owner label"}]}]}]}'
+;;
+*) echo '{}' ;;
+esac
+`
+	fakeOpa := filepath.Join(tmpDir, "opa")
+	assert.NoError(t, os.WriteFile(fakeOpa, []byte(script), 0755))
+
+	origCommand, origPolicies, origQuery := opaCommand, policyPaths, policyQuery
+	defer func() {
+		opaCommand, policyPaths, policyQuery = origCommand, origPolicies, origQuery
+	}()
+	opaCommand = fakeOpa
+	policyPaths = []string{policy}
+	policyQuery = DefaultPolicyQuery
+
+	mock := NewMockLLMProvider()
+	mock.DefaultResponse = "Summarized."
+	_, err = summarizeDirectory(repoDir, mock)
+	assert.NoError(t, err)
+
+	logged, err := os.ReadFile(argsLog)
+	assert.NoError(t, err)
+	assert.Equal(t, "eval --format json --stdin-input --data "+policy+" data.main.deny\n", string(logged))
+
+	doc, err := os.ReadFile(filepath.Join(repoDir, markdownFileName))
+	assert.NoError(t, err)
+	content := string(doc)
+	assert.Contains(t, content, "- [pod.yaml](pod.yaml): Summarized. ⚠ policy violation\n")
+	assert.Contains(t, content, "- [cm.yaml](cm.yaml): Summarized.\n")
+	assert.Contains(t, content, "## Policy Violations")
+	assert.Contains(t, content, "- `pod.yaml` — ✗ host networking is not allowed")
+	assert.Contains(t, content, "- `pod.yaml` — ✗ pods need an owner label")
+}
+
+func TestIntegrationNetworkSurface(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_network_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "net"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "net", "web.yaml"), []byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: shop
+spec:
+  type: NodePort
+  ports:
+  - port: 80
+    targetPort: 8080
+    nodePort: 30080
+  - port: 443
+    protocol: TCP
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: web
+spec:
+  rules:
+  - host: shop.example.com
+    http:
+      paths:
+      - path: /api
+        backend:
+          service:
+            name: api
+            port:
+              number: 80
+`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "net", "gateway.yaml"), []byte(`apiVersion: gateway.networking.k8s.io/v1
+kind: Gateway
+metadata:
+  name: public
+spec:
+  listeners:
+  - name: https
+    port: 443
+    protocol: HTTPS
+    hostname: "*.example.com"
+---
+apiVersion: gateway.networking.k8s.io/v1
+kind: HTTPRoute
+metadata:
+  name: store
+spec:
+  hostnames: [store.example.com]
+  rules:
+  - matches:
+    - path:
+        type: PathPrefix
+        value: /cart
+    backendRefs:
+    - name: cart
+      port: 8080
+`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "net", "cm.yaml"), []byte("kind: ConfigMap\nmetadata:\n  name: x\n"), 0644))
+
+	origEnabled, origFormat := networkSurfaceEnabled, outputFormat
+	defer func() {
+		networkSurfaceEnabled, outputFormat = origEnabled, origFormat
+	}()
+	networkSurfaceEnabled = true
+	outputFormat = "markdown"
+
+	mock := NewMockLLMProvider()
+	mock.DefaultResponse = "Summarized."
+	_, err = summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+
+	doc, err := os.ReadFile(filepath.Join(tmpDir, markdownFileName))
+	assert.NoError(t, err)
+	content := string(doc)
+	section := content[strings.Index(content, "## Network Surface"):strings.Index(content, "<!-- yaml-to-readme-entry")]
+	assert.Contains(t, section, "- [`net/gateway.yaml`](net/gateway.yaml) — Gateway public: listener https 443/HTTPS *.example.com\n"+
+		"- [`net/gateway.yaml`](net/gateway.yaml) — HTTPRoute store: store.example.com/cart → cart:8080\n"+
+		"- [`net/web.yaml`](net/web.yaml) — Ingress web: shop.example.com/api → api:80\n"+
+		"- [`net/web.yaml`](net/web.yaml) — Service shop/web: 80/TCP → 8080 (nodePort 30080), 443/TCP (NodePort)\n")
+	assert.NotContains(t, section, "cm.yaml")
+
+	// The appendix is not read back as summaries
+	assert.Len(t, parseExistingSummaries(filepath.Join(tmpDir, markdownFileName)), 3)
+}
+
+func TestIntegrationLabelReport(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_labels_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "web.yaml"), []byte(`kind: Deployment
+metadata:
+  name: web
+  labels:
+    app: web
+    team: shop
+  annotations:
+    owner: shop@example.com
+---
+kind: Service
+metadata:
+  name: web
+  labels:
+    app: web
+`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "db.yaml"), []byte(`kind: StatefulSet
+metadata:
+  name: db
+  labels:
+    app: db
+    team: data
+`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, DefaultConfigFileName), []byte("required_labels: [app, team]\n"), 0644))
+
+	origRequired, origReport, origConfig := requiredLabels, labelReport, configPath
+	defer func() {
+		requiredLabels, labelReport, configPath = origRequired, origReport, origConfig
+	}()
+	configPath = ""
+	assert.NoError(t, loadConfig(rootCmd, []string{tmpDir}))
+	assert.Equal(t, []string{"app", "team"}, requiredLabels)
+
+	mock := NewMockLLMProvider()
+	mock.DefaultResponse = "Summarized."
+	_, err = summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+
+	doc, err := os.ReadFile(filepath.Join(tmpDir, markdownFileName))
+	assert.NoError(t, err)
+	content := string(doc)
+	assert.Contains(t, content, "## Label and Annotation Keys\n\n"+
+		"Every metadata label and annotation key used in this repository, with how often it is used.\n\n"+
+		"- `app` — label, 3 uses in 2 files\n"+
+		"- `team` — label, 2 uses in 2 files\n"+
+		"- `owner` — annotation, 1 use in 1 file\n")
+	assert.Contains(t, content, "## Missing Required Labels\n\nThese resources do not set every required label (app, team).\n\n"+
+		"- [`web.yaml`](web.yaml) — Service/web: missing team\n")
+}
+
+func TestIntegrationGlossary(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_glossary_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	files := map[string]string{
+		"web.yaml":    "apiVersion: apps/v1\nkind: Deployment\n---\napiVersion: v1\nkind: Service\n",
+		"worker.yaml": "apiVersion: apps/v1\nkind: Deployment\n",
+		"api.yaml":    "apiVersion: v1\nkind: Service\n",
+		"job.yaml":    "apiVersion: batch/v1\nkind: CronJob\n",
+	}
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "k8s"), 0755))
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "k8s", name), []byte(content), 0644))
+	}
+
+	origGlossary := glossaryEnabled
+	defer func() {
+		glossaryEnabled = origGlossary
+	}()
+	glossaryEnabled = true
+
+	mock := NewMockLLMProvider()
+	mock.DefaultResponse = "Summarized."
+	mock.MockResponses["Kind: Deployment"] = "Runs a replicated set of pods."
+	mock.MockResponses["Kind: Service"] = "Gives pods a stable network address."
+	_, err = summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+
+	mdPath := filepath.Join(tmpDir, markdownFileName)
+	doc, err := os.ReadFile(mdPath)
+	assert.NoError(t, err)
+	content := string(doc)
+	assert.Contains(t, content, "- [web.yaml](k8s/web.yaml): Summarized. 📖 [Deployment](#glossary-deployment), [Service](#glossary-service)\n")
+	assert.Contains(t, content, "- [job.yaml](k8s/job.yaml): Summarized.\n")
+	assert.Contains(t, content, "## Glossary\n\nResource kinds used throughout this repository. Entries link here for the kinds they use.\n\n"+
+		"- <a id=\"glossary-deployment\"></a>`Deployment` — Runs a replicated set of pods.\n"+
+		"- <a id=\"glossary-service\"></a>`Service` — Gives pods a stable network address.\n")
+	assert.Equal(t, "Summarized.", parseExistingSummaries(mdPath)[filepath.Join("k8s", "web.yaml")])
+	// Glossary links resolve to the entries' anchors
+	broken, err := checkDocLinks(mdPath, false)
+	assert.NoError(t, err)
+	for _, link := range broken {
+		assert.NotEqual(t, "anchor not found", link.Reason, link.Target)
+	}
+
+	// Definitions are read back from the document instead of asking the LLM again
+	mock.MockErrors["Kind: "] = fmt.Errorf("should not be called")
+	_, err = summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+	doc, err = os.ReadFile(mdPath)
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(doc))
+}
+
+func TestIntegrationStatsHeader(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_stats_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	files := map[string]string{
+		"apps/web.yaml":    "kind: Deployment\n---\nkind: Service\n",
+		"apps/worker.yaml": "kind: Deployment\n",
+		"config/app.yaml":  "debug: true\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	origStats, origFormat := statsEnabled, outputFormat
+	defer func() {
+		statsEnabled, outputFormat = origStats, origFormat
+	}()
+	statsEnabled = true
+	outputFormat = "markdown"
+
+	mock := NewMockLLMProvider()
+	mock.DefaultResponse = "Summarized."
+	_, err = summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+
+	mdPath := filepath.Join(tmpDir, markdownFileName)
+	doc, err := os.ReadFile(mdPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(doc), docSchemaMarker()+"\n## Overview\n"+
+		"- **Files:** 3\n"+
+		"- **Directories:** 2\n"+
+		"- **Kinds:** Deployment (2), Service (1)\n"+
+		"- **Reading time:** ~1 min\n"+
+		"\n## [apps/](apps/)\n")
+	// The overview is not mistaken for a directory section
+	assert.Len(t, parseExistingSummaries(mdPath), 3)
+
+	outputFormat = "json"
+	assert.NoError(t, writeSummary(tmpDir, groupRecoveredSummaries(tmpDir, parseExistingSummaries(mdPath)), nil))
+	var output JSONOutput
+	data, err := os.ReadFile(mdPath)
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(data, &output))
+	assert.Equal(t, &docStats{Files: 3, Directories: 2, Kinds: []kindCount{{"Deployment", 2}, {"Service", 1}}, ReadingMinutes: 1}, output.Stats)
+}
+
+func TestIntegrationAuditLog(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_audit_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "apps"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "web.yaml"), []byte("kind: Deployment\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "empty.yaml"), nil, 0644))
+
+	origAudit := auditLogPath
+	defer func() {
+		auditLogPath = origAudit
+	}()
+	auditLogPath = filepath.Join(tmpDir, "audit.jsonl")
+
+	mock := NewMockLLMProvider()
+	mock.DefaultResponse = "Summarized."
+	for range 2 {
+		_, err = summarizeDirectory(tmpDir, mock)
+		assert.NoError(t, err)
+	}
+
+	// One line per run; the second run reuses the existing summary and sends nothing
+	data, err := os.ReadFile(auditLogPath)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, lines, 2)
+	var first, second auditRecord
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.Equal(t, "mock", first.Provider)
+	assert.Equal(t, ModelName, first.Model)
+	assert.False(t, first.Remote)
+	assert.NotEmpty(t, first.Time)
+	sum := sha256.Sum256([]byte("kind: Deployment\n"))
+	assert.Equal(t, []auditSend{{Path: "apps/web.yaml", SHA256: hex.EncodeToString(sum[:]), Model: ModelName}}, first.Sent)
+	assert.Empty(t, second.Sent)
+
+	assert.True(t, isRemoteEndpoint("https://api.openai.com"))
+	assert.False(t, isRemoteEndpoint("http://127.0.0.1:11434"))
+	assert.False(t, isRemoteEndpoint("http://localhost:8000"))
+}
+
+func TestIntegrationAttestation(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_attestation_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "apps"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "web.yaml"), []byte("kind: Deployment\n"), 0644))
+
+	origAttestation := attestationPath
+	defer func() {
+		attestationPath = origAttestation
+	}()
+	attestationPath = filepath.Join(tmpDir, "yaml_details.intoto.json")
+
+	mock := NewMockLLMProvider()
+	_, err = summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(attestationPath)
+	assert.NoError(t, err)
+	var st inTotoStatement
+	assert.NoError(t, json.Unmarshal(data, &st))
+	assert.Equal(t, "https://in-toto.io/Statement/v1", st.Type)
+	assert.Equal(t, "https://slsa.dev/provenance/v1", st.PredicateType)
+
+	docDigest, err := fileSHA256(filepath.Join(tmpDir, markdownFileName))
+	assert.NoError(t, err)
+	assert.Equal(t, []resourceDescriptor{{Name: markdownFileName, Digest: map[string]string{"sha256": docDigest}}}, st.Subject)
+	sum := sha256.Sum256([]byte("kind: Deployment\n"))
+	assert.Equal(t, []resourceDescriptor{{Name: "apps/web.yaml", Digest: map[string]string{"sha256": hex.EncodeToString(sum[:])}}}, st.Predicate.BuildDefinition.ResolvedDependencies)
+	assert.Equal(t, "mock", st.Predicate.BuildDefinition.ExternalParameters["provider"])
+	assert.Equal(t, ModelName, st.Predicate.BuildDefinition.ExternalParameters["model"])
+	assert.NotEmpty(t, st.Predicate.RunDetails.Builder.Version["yaml-to-readme"])
+}
+
+func TestIntegrationVerify(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_verify_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "apps"), 0755))
+	webPath := filepath.Join(tmpDir, "apps", "web.yaml")
+	assert.NoError(t, os.WriteFile(webPath, []byte("kind: Deployment\n"), 0644))
+
+	origFormat := outputFormat
+	defer func() {
+		outputFormat = origFormat
+	}()
+
+	for _, format := range []string{"markdown", "json", "html", "github-wiki", "asciidoc"} {
+		outputFormat = format
+		assert.NoError(t, os.WriteFile(webPath, []byte("kind: Deployment\n"), 0644))
+		_, err = summarizeDirectory(tmpDir, NewMockLLMProvider())
+		assert.NoError(t, err, format)
+		assert.NoError(t, runVerify(tmpDir), format)
+
+		// Editing a file makes the document out of date
+		assert.NoError(t, os.WriteFile(webPath, []byte("kind: StatefulSet\n"), 0644))
+		assert.ErrorContains(t, runVerify(tmpDir), "is out of date", format)
+		assert.NoError(t, os.Remove(filepath.Join(tmpDir, markdownFileName)))
+	}
+
+	// Adding a file does too
+	outputFormat = "markdown"
+	_, err = summarizeDirectory(tmpDir, NewMockLLMProvider())
+	assert.NoError(t, err)
+	doc, err := os.ReadFile(filepath.Join(tmpDir, markdownFileName))
+	assert.NoError(t, err)
+	assert.Regexp(t, `\n<!-- yaml-to-readme-inputs sha256:[0-9a-f]{64} -->\n$`, string(doc))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "svc.yaml"), []byte("kind: Service\n"), 0644))
+	assert.Error(t, runVerify(tmpDir))
+
+	// Documents without a checksum cannot be verified
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, markdownFileName), []byte(MarkdownHeader), 0644))
+	assert.ErrorContains(t, runVerify(tmpDir), "has no inputs checksum")
+}
+
+func TestIntegrationChangedOnlyOutput(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_changed_only_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	repoDir := filepath.Join(tmpDir, "repo")
+	assert.NoError(t, os.MkdirAll(filepath.Join(repoDir, "apps"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(repoDir, "apps", "web.yaml"), []byte("kind: Deployment"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(repoDir, "apps", "db.yaml"), []byte("kind: StatefulSet"), 0644))
+
+	origOutput, origRegenerate := changedOnlyOutput, regenerate
+	defer func() {
+		changedOnlyOutput, regenerate = origOutput, origRegenerate
+	}()
+	deltaPath := filepath.Join(tmpDir, "changes.md")
+	changedOnlyOutput = deltaPath
+
+	mock := NewMockLLMProvider()
+	mock.MockResponses["kind: Deployment"] = "Runs the web tier."
+	mock.MockResponses["kind: StatefulSet"] = "Runs the database."
+	_, err = summarizeDirectory(repoDir, mock)
+	assert.NoError(t, err)
+	delta, err := os.ReadFile(deltaPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(delta), "2 added, 0 changed")
+	assert.Contains(t, string(delta), "- [apps/web.yaml](repo/apps/web.yaml): Runs the web tier.\n")
+
+	// A regenerated summary and a new file show up; the unchanged entry does not
+	assert.NoError(t, os.WriteFile(filepath.Join(repoDir, "apps", "cache.yaml"), []byte("kind: Cache"), 0644))
+	mock.MockResponses["kind: Deployment"] = "Runs the web tier with autoscaling."
+	mock.DefaultResponse = "Caches sessions."
+	regenerate = true
+	_, err = summarizeDirectory(repoDir, mock)
+	assert.NoError(t, err)
+	delta, err = os.ReadFile(deltaPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "# YAML Summary Changes\n\n1 added, 1 changed in "+repoDir+".\n\n"+
+		"## Added\n- [apps/cache.yaml](repo/apps/cache.yaml): Caches sessions.\n\n"+
+		"## Changed\n- [apps/web.yaml](repo/apps/web.yaml): Runs the web tier with autoscaling.\n  - Previously: Runs the web tier.\n", string(delta))
+
+	// With a URL the changes are posted instead, and nothing is posted without changes
+	var posted []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		posted = append(posted, string(body))
+		mu.Unlock()
+	}))
+	defer server.Close()
+	changedOnlyOutput = server.URL
+	mock.MockResponses["kind: StatefulSet"] = "Runs the primary database."
+	_, err = summarizeDirectory(repoDir, mock)
+	assert.NoError(t, err)
+	regenerate = false
+	_, err = summarizeDirectory(repoDir, mock)
+	assert.NoError(t, err)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, posted, 1)
+	assert.Contains(t, posted[0], "- [apps/db.yaml](apps/db.yaml): Runs the primary database.\n  - Previously: Runs the database.\n")
+}
+
+func TestIntegrationJiraPublish(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_jira_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	repoDir := filepath.Join(tmpDir, "infra")
+	assert.NoError(t, os.MkdirAll(repoDir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(repoDir, "web.yaml"), []byte("kind: Deployment"), 0644))
+
+	type jiraRequest struct {
+		Method, Path, Auth string
+		Body               map[string]any
+	}
+	var requests []jiraRequest
+	var issues []map[string]any
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		req := jiraRequest{Method: r.Method, Path: r.URL.Path, Auth: r.Header.Get("Authorization")}
+		_ = json.NewDecoder(r.Body).Decode(&req.Body)
+		requests = append(requests, req)
+		switch {
+		case r.URL.Path == "/rest/api/2/search":
+			_ = json.NewEncoder(w).Encode(map[string]any{"issues": issues})
+		case r.Method == http.MethodPost && r.URL.Path == "/rest/api/2/issue":
+			fields := req.Body["fields"].(map[string]any)
+			issues = append(issues, map[string]any{"key": "OPS-7", "fields": map[string]any{"summary": fields["summary"]}})
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"key":"OPS-7"}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	origIssue, origProject, origRegenerate := jiraIssue, jiraProject, regenerate
+	defer func() {
+		jiraIssue, jiraProject, regenerate = origIssue, origProject, origRegenerate
+	}()
+	t.Setenv("JIRA_URL", server.URL)
+	t.Setenv("JIRA_USER", "")
+	t.Setenv("JIRA_API_TOKEN", "secret")
+	jiraProject = "OPS"
+	assert.NoError(t, validateJira())
+
+	// The first run creates the labeled issue, the next one updates it
+	mock := NewMockLLMProvider()
+	mock.MockResponses["kind: Deployment"] = "Runs the web tier."
+	_, err = summarizeDirectory(repoDir, mock)
+	assert.NoError(t, err)
+	mock.MockResponses["kind: Deployment"] = "Runs the web tier with autoscaling."
+	regenerate = true
+	_, err = summarizeDirectory(repoDir, mock)
+	assert.NoError(t, err)
+
+	mu.Lock()
+	assert.Len(t, requests, 4)
+	create := requests[1]
+	assert.Equal(t, http.MethodPost, create.Method)
+	assert.Equal(t, "Bearer secret", create.Auth)
+	fields := create.Body["fields"].(map[string]any)
+	assert.Equal(t, "YAML summaries: infra", fields["summary"])
+	assert.Equal(t, []any{JiraLabel}, fields["labels"])
+	assert.Contains(t, fields["description"], "* {{web.yaml}}: Runs the web tier.\n")
+	update := requests[3]
+	assert.Equal(t, http.MethodPut, update.Method)
+	assert.Equal(t, "/rest/api/2/issue/OPS-7", update.Path)
+	assert.Contains(t, update.Body["fields"].(map[string]any)["description"], "** Previously: Runs the web tier.\n")
+	requests = nil
+	mu.Unlock()
+
+	// A tracking ticket gets a comment only when an entry was added or changed
+	jiraProject, jiraIssue = "", "OPS-1"
+	t.Setenv("JIRA_USER", "bot@example.com")
+	regenerate = false
+	_, err = summarizeDirectory(repoDir, mock)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(repoDir, "db.yaml"), []byte("kind: StatefulSet"), 0644))
+	_, err = summarizeDirectory(repoDir, mock)
+	assert.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, requests, 1)
+	assert.Equal(t, "/rest/api/2/issue/OPS-1/comment", requests[0].Path)
+	assert.True(t, strings.HasPrefix(requests[0].Auth, "Basic "))
+	assert.Contains(t, requests[0].Body["body"], "1 added, 0 changed")
+
+	jiraProject = "OPS"
+	assert.Error(t, validateJira())
+}
+
+func TestIntegrationBackstage(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_backstage_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	repoDir := filepath.Join(tmpDir, "My Infra")
+	assert.NoError(t, os.MkdirAll(filepath.Join(repoDir, "apps"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(repoDir, "apps", "web.yaml"), []byte("kind: Deployment"), 0644))
+
+	origBackstage, origBaseURL := backstageEnabled, wikiBaseURL
+	defer func() {
+		backstageEnabled, wikiBaseURL = origBackstage, origBaseURL
+	}()
+	backstageEnabled = true
+
+	mock := NewMockLLMProvider()
+	mock.MockResponses["kind: Deployment"] = "Runs the web tier."
+	_, err = summarizeDirectory(repoDir, mock)
+	assert.NoError(t, err)
+
+	// A new site and catalog entity are generated, with the inventory as the index page
+	catalog, err := os.ReadFile(filepath.Join(repoDir, "catalog-info.yaml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(catalog), "  name: My-Infra\n")
+	assert.Contains(t, string(catalog), "    backstage.io/techdocs-ref: dir:.\n")
+	mkdocs, err := os.ReadFile(filepath.Join(repoDir, "mkdocs.yml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(mkdocs), "docs_dir: techdocs\n")
+	page, err := os.ReadFile(filepath.Join(repoDir, "techdocs", "index.md"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(page), "\n## apps/\n- `apps/web.yaml`: Runs the web tier.\n")
+	assert.NotContains(t, string(page), "yaml-to-readme-inputs")
+
+	// Later runs refresh the page and leave edited files alone
+	assert.NoError(t, os.WriteFile(filepath.Join(repoDir, "catalog-info.yaml"), []byte("kind: Component\n"), 0644))
+	wikiBaseURL = "https://github.com/org/infra/blob/main/"
+	mock.MockResponses["kind: Component"] = "Registers the component."
+	_, err = summarizeDirectory(repoDir, mock)
+	assert.NoError(t, err)
+	catalog, err = os.ReadFile(filepath.Join(repoDir, "catalog-info.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "kind: Component\n", string(catalog))
+	page, err = os.ReadFile(filepath.Join(repoDir, "techdocs", "index.md"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(page), "- [`apps/web.yaml`](https://github.com/org/infra/blob/main/apps/web.yaml): Runs the web tier.\n")
+
+	// An existing MkDocs site gets the inventory as an extra page in its docs_dir
+	siteDir := filepath.Join(tmpDir, "site")
+	assert.NoError(t, os.MkdirAll(siteDir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(siteDir, "app.yaml"), []byte("kind: Deployment"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(siteDir, "mkdocs.yml"), []byte("site_name: Site\ndocs_dir: handbook\n"), 0644))
+	_, err = summarizeDirectory(siteDir, mock)
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(siteDir, "handbook", "yaml-inventory.md"))
+	assert.NoError(t, err)
+}
+
+func TestIntegrationMetrics(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_metrics_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "apps"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "web.yaml"), []byte("kind: Deployment"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "api.yaml"), []byte("kind: Deployment\n---\nkind: Service"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "values.yaml"), []byte("replicas: 2"), 0644))
+
+	origFormat, origFile, origHistory := metricsFormat, metricsFile, metricsHistory
+	defer func() {
+		metricsFormat, metricsFile, metricsHistory = origFormat, origFile, origHistory
+	}()
+
+	// Before the first run every file is stale
+	metricsFormat, metricsFile, metricsHistory = "json", "", filepath.Join(tmpDir, "history.jsonl")
+	var out bytes.Buffer
+	assert.NoError(t, runMetrics(&out, tmpDir))
+	var m repoMetrics
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &m))
+	assert.Equal(t, 3, m.Files)
+	assert.Equal(t, 0, m.Documented)
+	assert.Equal(t, 3, m.Stale)
+	assert.Equal(t, []dirCount{{Directory: ".", Files: 1}, {Directory: "apps", Files: 2}}, m.Directories)
+	assert.Equal(t, []kindCount{{Kind: "Deployment", Count: 2}, {Kind: "Service", Count: 1}}, m.Kinds)
+
+	_, err = summarizeDirectory(tmpDir, NewMockLLMProvider())
+	assert.NoError(t, err)
+	past := time.Now().Add(-time.Hour)
+	assert.NoError(t, os.Chtimes(docPathFor(tmpDir), past, past))
+	for _, f := range []string{"apps/web.yaml", "apps/api.yaml", "values.yaml"} {
+		assert.NoError(t, os.Chtimes(filepath.Join(tmpDir, f), past.Add(-time.Minute), past.Add(-time.Minute)))
+	}
+	// Editing a file after the document was written makes its summary stale
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "values.yaml"), []byte("replicas: 3"), 0644))
+
+	metricsFormat, metricsFile = "prometheus", filepath.Join(tmpDir, "yaml.prom")
+	out.Reset()
+	assert.NoError(t, runMetrics(&out, tmpDir))
+	assert.Empty(t, out.String())
+	prom, err := os.ReadFile(metricsFile)
+	assert.NoError(t, err)
+	abs, _ := filepath.Abs(tmpDir)
+	assert.Contains(t, string(prom), "# TYPE yaml_to_readme_stale_summaries gauge\n")
+	assert.Contains(t, string(prom), fmt.Sprintf("yaml_to_readme_documented_files{dir=%q} 3\n", abs))
+	assert.Contains(t, string(prom), fmt.Sprintf("yaml_to_readme_stale_summaries{dir=%q} 1\n", abs))
+	assert.Contains(t, string(prom), fmt.Sprintf("yaml_to_readme_directory_files{dir=%q,directory=\"apps\"} 2\n", abs))
+	assert.Contains(t, string(prom), fmt.Sprintf("yaml_to_readme_kind_resources{dir=%q,kind=\"Service\"} 1\n", abs))
+
+	history, err := os.ReadFile(metricsHistory)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(history)), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.NoError(t, json.Unmarshal([]byte(lines[1]), &m))
+		assert.Equal(t, 1, m.Stale)
+	}
+
+	metricsFormat = "csv"
+	assert.Error(t, runMetrics(&out, tmpDir))
+}
+
+func TestIntegrationStatusOutput(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_status_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "a.yaml"), []byte("kind: A"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "b.yaml"), []byte("kind: B"), 0644))
+
+	origStatus, origPorcelainOut, origPorcelain := statusOut, porcelainOut, porcelain
+	defer func() {
+		statusOut, porcelainOut, porcelain = origStatus, origPorcelainOut, origPorcelain
+	}()
+	var status, stdout bytes.Buffer
+	statusOut, porcelainOut = &status, &stdout
+
+	mock := NewMockLLMProvider()
+	mock.DefaultResponse = "Summarized."
+	mock.MockErrors["kind: B"] = errors.New("model overloaded")
+
+	// Human-readable progress and stats go to the status stream only
+	assert.NoError(t, runSummarizeYamlWithProvider(tmpDir, mock))
+	assert.Contains(t, status.String(), "Processing YAML files:")
+	assert.Contains(t, status.String(), "Files processed (new summaries): 1")
+	assert.Empty(t, stdout.String())
+
+	// --porcelain replaces them with stable key=value lines
+	status.Reset()
+	porcelain = true
+	assert.NoError(t, runSummarizeYamlWithProvider(tmpDir, mock))
+	assert.Empty(t, status.String())
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	assert.Equal(t, []string{
+		"progress\tcurrent=2\ttotal=2",
+		"failed\tpath=b.yaml",
+	}, lines[:2])
+	assert.True(t, strings.HasPrefix(lines[2], "result\tdir="+tmpDir+"\toutput="+filepath.Join(tmpDir, markdownFileName)+"\tformat=markdown\tprocessed=0\tskipped=1\tgenerated=0\tfailed=1\telapsed_ms="), lines[2])
+	assert.True(t, strings.HasSuffix(lines[2], "\trefined=0\tmodel="+ModelName+"\trouted=0"), lines[2])
+}
+
+func TestIntegrationTrivialFiles(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_trivial_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	files := map[string]string{
+		"empty.yaml":    "",
+		"comments.yaml": "# TODO: fill in\n---\n",
+		"single.yaml":   "# Feature flag\nenabled: true\n",
+		"full.yaml":     "kind: Deployment\nmetadata:\n  name: web\n",
+	}
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644))
+	}
+
+	origTrivial := trivialLines
+	defer func() {
+		trivialLines = origTrivial
+	}()
+
+	mock := NewMockLLMProvider()
+	mock.DefaultResponse = "Summarized by the LLM."
+
+	// By default only empty files skip the LLM
+	trivialLines = DefaultTrivialLines
+	report, err := summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+	summaries := parseExistingSummaries(report.OutputPath)
+	assert.Equal(t, "Empty placeholder file.", summaries["empty.yaml"])
+	assert.Equal(t, "Empty placeholder file.", summaries["comments.yaml"])
+	assert.Equal(t, "Summarized by the LLM.", summaries["single.yaml"])
+
+	// Raising the threshold inlines single-line files
+	trivialLines = 1
+	regenerateOrig := regenerate
+	defer func() {
+		regenerate = regenerateOrig
+	}()
+	regenerate = true
+	report, err = summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+	summaries = parseExistingSummaries(report.OutputPath)
+	assert.Equal(t, "Contains only `enabled: true`.", summaries["single.yaml"])
+	assert.Equal(t, "Summarized by the LLM.", summaries["full.yaml"])
+}
+
+func TestIntegrationCheck(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_check_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "apps"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "web.yaml"), []byte("kind: Deployment"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "old.yaml"), []byte("kind: Service"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "values.yaml"), []byte("replicas: 2"), 0644))
+
+	var out bytes.Buffer
+	assert.ErrorContains(t, runCheck(&out, tmpDir), "does not exist")
+
+	_, err = summarizeDirectory(tmpDir, NewMockLLMProvider())
+	assert.NoError(t, err)
+	assert.NoError(t, runCheck(&out, tmpDir))
+	assert.Contains(t, out.String(), "is up to date")
+
+	// Without git, files modified after the document are changed
+	past := time.Now().Add(-time.Hour)
+	assert.NoError(t, os.Chtimes(docPathFor(tmpDir), past, past))
+	for _, f := range []string{"apps/web.yaml", "apps/old.yaml", "values.yaml"} {
+		assert.NoError(t, os.Chtimes(filepath.Join(tmpDir, f), past.Add(-time.Minute), past.Add(-time.Minute)))
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "values.yaml"), []byte("replicas: 3"), 0644))
+	assert.NoError(t, os.Remove(filepath.Join(tmpDir, "apps", "old.yaml")))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "api.yaml"), []byte("kind: Service"), 0644))
+
+	s, err := checkDocStaleness(tmpDir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"apps/api.yaml"}, s.New)
+	assert.Equal(t, []string{"values.yaml"}, s.Changed)
+	assert.Equal(t, []string{"apps/old.yaml"}, s.Removed)
+	out.Reset()
+	assert.ErrorContains(t, runCheck(&out, tmpDir), "1 new, 1 changed, 1 removed")
+	assert.Contains(t, out.String(), "  changed  values.yaml\n")
+
+	// A touched but unchanged file does not fail the check: the recorded hash is exact
+	_, err = summarizeDirectory(tmpDir, NewMockLLMProvider())
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chtimes(docPathFor(tmpDir), past, past))
+	assert.NoError(t, runCheck(&out, tmpDir))
+
+	// An edited file whose entry was rewritten without summarizing it again is changed,
+	// although the document is newer
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "values.yaml"), []byte("replicas: 4"), 0644))
+	assert.NoError(t, repairDoc(tmpDir, parseExistingSummaries(docPathFor(tmpDir))))
+	s, err = checkDocStaleness(tmpDir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"values.yaml"}, s.Changed)
+	_, err = summarizeDirectory(tmpDir, NewMockLLMProvider())
+	assert.NoError(t, err)
+	assert.NoError(t, runCheck(&out, tmpDir))
+
+	// With a committed document, changes are those since the commit, whatever the mtimes
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", tmpDir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+	}
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "Add configs and summaries")
+	future := time.Now().Add(time.Hour)
+	assert.NoError(t, os.Chtimes(filepath.Join(tmpDir, "apps", "web.yaml"), future, future))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "api.yaml"), []byte("kind: ConfigMap"), 0644))
+	assert.NoError(t, os.Chtimes(filepath.Join(tmpDir, "apps", "api.yaml"), past, past))
+	s, err = checkDocStaleness(tmpDir)
+	assert.NoError(t, err)
+	assert.Empty(t, s.New)
+	assert.Equal(t, []string{"apps/api.yaml"}, s.Changed)
+	assert.Empty(t, s.Removed)
+}
+
+func TestIntegrationStableEntries(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_stable_entries_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "apps"), 0755))
+	webPath := filepath.Join(tmpDir, "apps", "web.yaml")
+	assert.NoError(t, os.WriteFile(webPath, []byte("kind: Deployment\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "values.yaml"), []byte("replicas: 2\n"), 0644))
+
+	origStable, origStats, origFormat := stableEntries, statsEnabled, outputFormat
+	defer func() {
+		stableEntries, statsEnabled, outputFormat = origStable, origStats, origFormat
+	}()
+	stableEntries, statsEnabled = true, true
+	docPath := filepath.Join(tmpDir, markdownFileName)
+
+	for _, format := range []string{"markdown", "github-wiki"} {
+		outputFormat = format
+		assert.NoError(t, os.WriteFile(webPath, []byte("kind: Deployment\n"), 0644))
+		_, err = summarizeDirectory(tmpDir, NewMockLLMProvider())
+		assert.NoError(t, err, format)
+		doc, err := os.ReadFile(docPath)
+		assert.NoError(t, err)
+
+		// Every entry carries its key; the footer and overview are left out
+		assert.Regexp(t, `: This is a mock summary for testing purposes\. <!-- yaml-to-readme-entry sha256:[0-9a-f]{64} apps/web\.yaml -->\n`, string(doc), format)
+		assert.Contains(t, string(doc), " values.yaml -->\n", format)
+		assert.NotContains(t, string(doc), "yaml-to-readme-inputs", format)
+		assert.NotContains(t, string(doc), "## Overview", format)
+		assert.Equal(t, map[string]string{
+			"apps/web.yaml": "This is a mock summary for testing purposes.",
+			"values.yaml":   "This is a mock summary for testing purposes.",
+		}, parseExistingSummaries(docPath), format)
+
+		// verify rebuilds the inputs checksum from the keys
+		assert.NoError(t, runVerify(tmpDir), format)
+
+		// Refreshing an entry keeps its key
+		lines := strings.Split(string(doc), "\n")
+		assert.True(t, replaceDocEntry(lines, "apps/web.yaml", "Runs the web app."), format)
+		assert.Regexp(t, `: Runs the web app\. <!-- yaml-to-readme-entry sha256:[0-9a-f]{64} apps/web\.yaml -->\n`, strings.Join(lines, "\n"), format)
+
+		assert.NoError(t, os.WriteFile(webPath, []byte("kind: StatefulSet\n"), 0644))
+		assert.ErrorContains(t, runVerify(tmpDir), "is out of date", format)
+		assert.NoError(t, os.Remove(docPath))
+	}
+}
+
+func TestIntegrationKindPrefix(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_kind_prefix_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "apps"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "web.yaml"), []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web-app\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "all.yaml"), []byte(`kind: Namespace
+metadata: {name: shop}
+---
+kind: ConfigMap
+metadata: {name: a}
+---
+kind: ConfigMap
+metadata: {name: b}
+---
+kind: ConfigMap
+metadata: {name: a}
+---
+kind: List
+`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "values.yaml"), []byte("replicas: 2\n"), 0644))
+
+	origPrefix, origFormat := kindPrefixEnabled, outputFormat
+	defer func() {
+		kindPrefixEnabled, outputFormat = origPrefix, origFormat
+	}()
+	kindPrefixEnabled, outputFormat = true, "markdown"
+	docPath := filepath.Join(tmpDir, markdownFileName)
+
+	// A second run reads the prefixes back without doubling them
+	for range 2 {
+		_, err = summarizeDirectory(tmpDir, NewMockLLMProvider())
+		assert.NoError(t, err)
+		doc, err := os.ReadFile(docPath)
+		assert.NoError(t, err)
+		assert.Contains(t, string(doc), "- [web.yaml](apps/web.yaml): `Deployment/web-app` — This is a mock summary for testing purposes.\n")
+		assert.Contains(t, string(doc), "- [all.yaml](apps/all.yaml): `Namespace/shop`, `ConfigMap/a`, `ConfigMap/b` +1 — This is a mock summary for testing purposes.\n")
+		assert.Contains(t, string(doc), "values.yaml): This is a mock summary for testing purposes.\n")
+		for _, summary := range parseExistingSummaries(docPath) {
+			assert.Equal(t, "This is a mock summary for testing purposes.", summary)
+		}
+	}
+
+	// JSON summaries stay plain text
+	outputFormat = "json"
+	_, err = summarizeDirectory(tmpDir, NewMockLLMProvider())
+	assert.NoError(t, err)
+	doc, err := os.ReadFile(docPath)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(doc), "Deployment/web-app")
+}
+
+func TestIntegrationProviderAnomalies(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_anomalies_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "apps"), 0755))
+	for name, content := range map[string]string{
+		"ok.yaml":      "name: ok\nreplicas: 2\n",
+		"refuse.yaml":  "name: refuse\nreplicas: 2\n",
+		"cut.yaml":     "name: cut\nreplicas: 2\n",
+		"silent.yaml":  "name: silent\nreplicas: 2\n",
+		"refuse2.yaml": "name: refuse2\nreplicas: 2\n",
+	} {
+		assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", name), []byte(content), 0644))
+	}
+
+	// Without guardrails every response is kept, so each file is asked once
+	origGuardrails := guardrails
+	defer func() {
+		guardrails = origGuardrails
+	}()
+	guardrails = false
+
+	mock := NewMockLLMProvider()
+	mock.MockResponses["name: refuse"] = "I'm sorry, but I can't help with that."
+	mock.MockResponses["name: cut"] = "This Deployment runs the web frontend with three"
+	mock.MockResponses["name: silent"] = "   "
+	report, err := summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+	assert.Equal(t, []providerAnomaly{
+		{Path: "apps/cut.yaml", Kind: anomalyTruncated},
+		{Path: "apps/refuse.yaml", Kind: anomalyRefusal},
+		{Path: "apps/refuse2.yaml", Kind: anomalyRefusal},
+		{Path: "apps/silent.yaml", Kind: anomalyEmpty},
+	}, report.Anomalies)
+	assert.Equal(t, "1 empty, 2 refusal, 1 truncated", anomalyBreakdown(report.Anomalies))
+}
+
+func TestIntegrationInject(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_inject_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "apps"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "web.yaml"), []byte("kind: Deployment\n"), 0644))
+	readme := filepath.Join(tmpDir, "README.md")
+	before := "# My Infra\n\n## [Docs](docs/)\n- [guide.md](docs/guide.md): How to deploy.\n\n## Inventory\n\n" + injectStartMarker + "\n"
+	after := injectEndMarker + "\n\n## License\n\nMIT\n"
+	assert.NoError(t, os.WriteFile(readme, []byte(before+"stale content\n"+after), 0644))
+
+	origInject, origFormat := injectPath, outputFormat
+	defer func() {
+		injectPath, outputFormat = origInject, origFormat
+	}()
+	injectPath, outputFormat = "README.md", "markdown"
+	assert.NoError(t, validateInject(tmpDir))
+	assert.Equal(t, readme, docPathFor(tmpDir))
+
+	mock := NewMockLLMProvider()
+	mock.DefaultResponse = "Runs the web app."
+	_, err = summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+	data, err := os.ReadFile(readme)
+	assert.NoError(t, err)
+	doc := string(data)
+	assert.True(t, strings.HasPrefix(doc, before), doc)
+	assert.True(t, strings.HasSuffix(doc, after), doc)
+	assert.NotContains(t, doc, "stale content")
+	assert.NotContains(t, doc, MarkdownHeader)
+	assert.Contains(t, doc, "web.yaml): Runs the web app.\n")
+	assert.NoFileExists(t, filepath.Join(tmpDir, DefaultMarkdownFileName))
+
+	// Only entries between the markers are read back, and a second run reuses them
+	assert.Equal(t, map[string]string{"apps/web.yaml": "Runs the web app."}, parseExistingSummaries(readme))
+	report, err := summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Skipped)
+	assert.NoError(t, runVerify(tmpDir))
+	again, err := os.ReadFile(readme)
+	assert.NoError(t, err)
+	assert.Equal(t, doc, string(again))
+
+	// Files without markers and other formats are rejected up front
+	assert.NoError(t, os.WriteFile(readme, []byte("# My Infra\n"), 0644))
+	assert.ErrorContains(t, validateInject(tmpDir), "missing "+injectStartMarker)
+	outputFormat = "json"
+	assert.ErrorContains(t, validateInject(tmpDir), "--inject requires --format markdown")
+}
+
+// retryingProvider answers re-prompted requests from RetryResponses, keyed by content
+// snippet like MockResponses.
+type retryingProvider struct {
+	*MockLLMProvider
+	RetryResponses map[string]string
+	prompts        []string
+}
+
+// Summarize implements LLMProvider.Summarize.
+func (r *retryingProvider) Summarize(ctx context.Context, content string, prompt string) (string, error) {
+	r.prompts = append(r.prompts, prompt)
+	if strings.Contains(prompt, "was rejected") {
+		for key, response := range r.RetryResponses {
+			if strings.Contains(content, key) {
+				return response, nil
+			}
+		}
+	}
+	return r.MockLLMProvider.Summarize(ctx, content, prompt)
+}
+
+func TestIntegrationGuardrails(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_guardrails_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	write := func(name, content string) string {
+		path := filepath.Join(tmpDir, name)
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+	web := write("web.yaml", "kind: Deployment\nmetadata:\n  name: web\nspec:\n  template:\n    spec:\n      containers: [{envFrom: [{configMapRef: {name: web-env}}]}]\n")
+	refuse := write("refuse.yaml", "name: refuse\n")
+	stubborn := write("stubborn.yaml", "name: stubborn\n")
+
+	origGuardrails := guardrails
+	defer func() {
+		guardrails = origGuardrails
+	}()
+	guardrails = true
+
+	mock := NewMockLLMProvider()
+	mock.MockResponses["name: web"] = "Runs the `web` Deployment next to a `redis-cache` StatefulSet."
+	mock.MockResponses["name: refuse"] = "I cannot summarize this file."
+	mock.MockResponses["name: stubborn"] = "Configures the PaymentGateway."
+	llm := &retryingProvider{MockLLMProvider: mock, RetryResponses: map[string]string{
+		"name: web":    "Runs the `web` Deployment with its environment from the `web-env` ConfigMap.",
+		"name: refuse": "I'm sorry, I can't help with that.",
+	}}
+
+	// A hallucinated name is asked for again, and the corrected answer kept
+	summary, err := summarizeYAMLFile(context.Background(), llm, filepath.Dir(web), web)
+	assert.NoError(t, err)
+	assert.Equal(t, "Runs the `web` Deployment with its environment from the `web-env` ConfigMap.", summary)
+	assert.Len(t, llm.prompts, 2)
+	assert.Contains(t, llm.prompts[1], "because it mentioned redis-cache, StatefulSet, which the file does not contain")
+
+	// Refusing twice fails the file
+	_, err = summarizeYAMLFile(context.Background(), llm, filepath.Dir(refuse), refuse)
+	assert.ErrorContains(t, err, "declined to summarize")
+
+	// Other problems are only asked about once
+	summary, err = summarizeYAMLFile(context.Background(), llm, filepath.Dir(stubborn), stubborn)
+	assert.NoError(t, err)
+	assert.Equal(t, "Configures the PaymentGateway.", summary)
+	assert.Len(t, llm.prompts, 6)
+
+	// Names the prompt context gave the model, such as the CI system, are accepted
+	ci := write(".gitlab-ci.yml", "stages: [build]\nbuild:\n  script: make\n")
+	mock.MockResponses["stages: [build]"] = "Builds the project on GitLab CI."
+	summary, err = summarizeYAMLFile(context.Background(), llm, filepath.Dir(ci), ci)
+	assert.NoError(t, err)
+	assert.Equal(t, "Builds the project on GitLab CI.", summary)
+	assert.Len(t, llm.prompts, 7)
+
+	// Without guardrails the first answer is kept
+	guardrails = false
+	summary, err = summarizeYAMLFile(context.Background(), llm, filepath.Dir(refuse), refuse)
+	assert.NoError(t, err)
+	assert.Equal(t, "I cannot summarize this file.", summary)
+	assert.Len(t, llm.prompts, 8)
+}
+
+func TestIntegrationOutputPath(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_output_path_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	otherDir, err := os.MkdirTemp("", "integration_test_output_path_other_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(otherDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "apps"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "web.yaml"), []byte("kind: Deployment\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "values.yaml"), []byte("replicas: 2\n"), 0644))
+
+	origOutput, origFormat, origOut := markdownFileName, outputFormat, documentOut
+	defer func() {
+		markdownFileName, outputFormat, documentOut = origOutput, origFormat, origOut
+	}()
+	outputFormat = "markdown"
+	mock := NewMockLLMProvider()
+	mock.DefaultResponse = "Summarized."
+
+	// At the default location links are relative to the documented directory
+	_, err = summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+	doc, err := os.ReadFile(filepath.Join(tmpDir, DefaultMarkdownFileName))
+	assert.NoError(t, err)
+	assert.Contains(t, string(doc), "## [./](./)\n- [values.yaml](values.yaml): Summarized.\n")
+	assert.Contains(t, string(doc), "## [apps/](apps/)\n- [web.yaml](apps/web.yaml): Summarized.\n")
+
+	// A nested output path is created and links climb back to the directory
+	markdownFileName = filepath.Join("docs", "yaml.md")
+	_, err = summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+	nested := filepath.Join(tmpDir, "docs", "yaml.md")
+	doc, err = os.ReadFile(nested)
+	assert.NoError(t, err)
+	assert.Contains(t, string(doc), "- [web.yaml](../apps/web.yaml): Summarized.\n")
+	broken, err := checkDocLinks(nested, false)
+	assert.NoError(t, err)
+	assert.Empty(t, broken)
+
+	// An absolute path outside the directory is used as is
+	markdownFileName = filepath.Join(otherDir, "inventory.md")
+	_, err = summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+	broken, err = checkDocLinks(markdownFileName, false)
+	assert.NoError(t, err)
+	assert.Empty(t, broken)
+
+	// -o - writes the document to stdout and no file
+	markdownFileName = stdoutOutput
+	var out bytes.Buffer
+	documentOut = &out
+	report, err := summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+	assert.Equal(t, stdoutOutput, report.OutputPath)
+	assert.Contains(t, out.String(), "- [web.yaml](apps/web.yaml): Summarized.\n")
+	assert.NoFileExists(t, filepath.Join(tmpDir, stdoutOutput))
+	archive := filepath.Join(otherDir, "charts.tgz")
+	assert.NoError(t, os.WriteFile(archive, nil, 0644))
+	assert.ErrorContains(t, validateOutput(archive), "cannot be used with archives")
+}
+
+func TestIntegrationChartArchives(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_chart_archives_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "app", "charts"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "app", "Chart.yaml"), []byte("apiVersion: v2\nname: app\n"), 0644))
+	// A .tgz outside charts/ is not a vendored chart
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "backup.tgz"), []byte("not a chart"), 0644))
+
+	entries := [][2]string{
+		{"redis/Chart.yaml", "apiVersion: v2\nname: redis\nversion: 17.3.0\ndescription: In-memory data store\n"},
+		{"redis/values.yaml", "replicas: 3\n"},
+		{"redis/templates/statefulset.yaml", "kind: StatefulSet\n"},
+		{"redis/templates/service.yaml", "kind: Service\n"},
+		{"redis/charts/common/Chart.yaml", "apiVersion: v2\nname: common\n"},
+	}
+	chartPath := filepath.Join(tmpDir, "app", "charts", "redis-17.3.0.tgz")
+	tgz, err := os.Create(chartPath)
+	assert.NoError(t, err)
+	gz := gzip.NewWriter(tgz)
+	tw := tar.NewWriter(gz)
+	for _, entry := range entries {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: entry[0], Mode: 0644, Size: int64(len(entry[1])), Typeflag: tar.TypeReg}))
+		_, err = tw.Write([]byte(entry[1]))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+	assert.NoError(t, tgz.Close())
+
+	origChartArchives := chartArchives
+	defer func() {
+		chartArchives = origChartArchives
+	}()
+
+	// Without the flag packaged charts are not documented
+	files, err := findYAMLFiles(tmpDir, false)
+	assert.NoError(t, err)
+	assert.NotContains(t, files, chartPath)
+
+	chartArchives = true
+	files, err = findYAMLFiles(tmpDir, false)
+	assert.NoError(t, err)
+	assert.Contains(t, files, chartPath)
+	assert.NotContains(t, files, filepath.Join(tmpDir, "backup.tgz"))
+
+	// Only the chart's own metadata and template names are summarized
+	metadata, err := chartArchiveMetadata(chartPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "# Chart.yaml of the packaged Helm chart redis-17.3.0.tgz\n"+entries[0][1]+
+		"# Templates: statefulset.yaml, service.yaml\n", string(metadata))
+
+	mock := NewMockLLMProvider()
+	mock.DefaultResponse = "Summarized."
+	mock.MockResponses["name: redis"] = "Vendored Redis chart providing an in-memory data store."
+	mock.MockErrors["kind: StatefulSet"] = fmt.Errorf("templates should not be sent")
+	_, err = summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+	doc, err := os.ReadFile(filepath.Join(tmpDir, markdownFileName))
+	assert.NoError(t, err)
+	assert.Contains(t, string(doc), "## [app/charts/](app/charts/)\n- [redis-17.3.0.tgz](app/charts/redis-17.3.0.tgz): Vendored Redis chart providing an in-memory data store.\n")
+
+	// An archive without a Chart.yaml is reported rather than summarized
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "app", "charts", "broken.tgz"), []byte("not gzip"), 0644))
+	_, err = chartArchiveMetadata(filepath.Join(tmpDir, "app", "charts", "broken.tgz"))
+	assert.ErrorContains(t, err, "failed to read chart archive")
+}
+
+// flakyProvider fails the first Failures requests for content containing a snippet with
+// the snippet's error, then answers like the wrapped mock.
+type flakyProvider struct {
+	*MockLLMProvider
+	Errors   map[string]error
+	Failures int
+	calls    map[string]int
+}
+
+// Summarize implements LLMProvider.Summarize.
+func (f *flakyProvider) Summarize(ctx context.Context, content string, prompt string) (string, error) {
+	for key, err := range f.Errors {
+		if strings.Contains(content, key) {
+			f.calls[key]++
+			if f.calls[key] <= f.Failures {
+				return "", err
+			}
+		}
+	}
+	return f.MockLLMProvider.Summarize(ctx, content, prompt)
+}
+
+func TestIntegrationRetryBackoff(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_retry_backoff_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "busy.yaml"), []byte("kind: Deployment\nname: busy\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "denied.yaml"), []byte("kind: Service\nname: denied\n"), 0644))
+
+	origRetries, origBackoff, origFailOnError := providerRetries, retryBackoff, failOnError
+	defer func() {
+		providerRetries, retryBackoff, failOnError = origRetries, origBackoff, origFailOnError
+	}()
+	providerRetries, retryBackoff = 2, time.Millisecond
+
+	assert.True(t, retryable(&statusError{API: "openai API", StatusCode: 503}))
+	assert.True(t, retryable(fmt.Errorf("wrapped: %w", ollama.StatusError{StatusCode: 429})))
+	assert.False(t, retryable(&statusError{API: "openai API", StatusCode: 401}))
+	assert.False(t, retryable(fmt.Errorf("model not found")))
+
+	mock := NewMockLLMProvider()
+	mock.DefaultResponse = "Summarized."
+	flaky := &flakyProvider{
+		MockLLMProvider: mock,
+		Errors: map[string]error{
+			"name: busy":   &statusError{API: "openai API", StatusCode: 503, Body: "overloaded"},
+			"name: denied": &statusError{API: "openai API", StatusCode: 401, Body: "invalid key"},
+		},
+		Failures: 2,
+		calls:    make(map[string]int),
+	}
+
+	// A transient failure is retried until it succeeds; a permanent one is not retried
+	report, err := summarizeDirectory(tmpDir, flaky)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, flaky.calls["name: busy"])
+	assert.Equal(t, 1, flaky.calls["name: denied"])
+	assert.Equal(t, "Summarized.", report.Summaries[filepath.Join(tmpDir, "busy.yaml")])
+	assert.Equal(t, []string{"denied.yaml"}, report.Failed)
+	assert.Equal(t, map[string]string{"denied.yaml": "openai API returned status 401: invalid key"}, report.FailureReasons)
+
+	// With --fail-on-error the run exits non-zero after writing the document
+	failOnError = true
+	flaky.calls = make(map[string]int)
+	err = runSummarizeYamlWithProvider(tmpDir, flaky)
+	assert.EqualError(t, err, "summarization failed for 1 of 2 files")
+	assert.FileExists(t, filepath.Join(tmpDir, markdownFileName))
+}
+
+func TestIntegrationFollowSymlinks(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_symlinks_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "manifests", "web"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "workspaces", "shop"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "workspaces", "admin"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "manifests", "web", "deploy.yaml"), []byte("kind: Deployment\n"), 0644))
+	assert.NoError(t, os.Symlink(filepath.Join(tmpDir, "manifests", "web"), filepath.Join(tmpDir, "workspaces", "shop", "web")))
+	assert.NoError(t, os.Symlink(filepath.Join("..", "..", "manifests", "web"), filepath.Join(tmpDir, "workspaces", "admin", "web")))
+
+	origFollow := followSymlinks
+	defer func() {
+		followSymlinks = origFollow
+	}()
+
+	// Without the flag symlinked directories are not searched
+	files, err := findYAMLFiles(tmpDir, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(tmpDir, "manifests", "web", "deploy.yaml")}, files)
+
+	// With it the file is listed once, under its real path, with the other paths noted
+	followSymlinks = true
+	files, aliases, err := findYAMLFilesWithAliases(tmpDir, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(tmpDir, "manifests", "web", "deploy.yaml")}, files)
+	assert.Equal(t, map[string][]string{
+		filepath.Join(tmpDir, "manifests", "web", "deploy.yaml"): {
+			filepath.Join(tmpDir, "workspaces", "admin", "web", "deploy.yaml"),
+			filepath.Join(tmpDir, "workspaces", "shop", "web", "deploy.yaml"),
+		},
+	}, aliases)
+
+	mock := NewMockLLMProvider()
+	mock.DefaultResponse = "Deploys the web app."
+	report, err := summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Processed)
+	doc, err := os.ReadFile(filepath.Join(tmpDir, markdownFileName))
+	assert.NoError(t, err)
+	assert.Contains(t, string(doc), "- [deploy.yaml](manifests/web/deploy.yaml): Deploys the web app. 🔗 also at `workspaces/admin/web/deploy.yaml`, `workspaces/shop/web/deploy.yaml`\n")
+	assert.NotContains(t, string(doc), "## [workspaces/")
+
+	// The note is not read back as part of the summary
+	assert.Equal(t, map[string]string{filepath.Join("manifests", "web", "deploy.yaml"): "Deploys the web app."}, parseExistingSummaries(filepath.Join(tmpDir, markdownFileName)))
+	report, err = summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Skipped)
+}
+
+func TestIntegrationDirAliases(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_dir_aliases_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	prod := filepath.Join("k8s", "overlays", "prod-us-east-1")
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, prod), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "k8s", "base"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, prod, "deploy.yaml"), []byte("kind: Deployment\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "k8s", "base", "svc.yaml"), []byte("kind: Service\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, DefaultConfigFileName),
+		[]byte("dir_aliases:\n  k8s/overlays/prod-us-east-1/: Production — US East\n"), 0644))
+
+	origAliases, origConfig, origFormat := dirAliases, configPath, outputFormat
+	defer func() {
+		dirAliases, configPath, outputFormat = origAliases, origConfig, origFormat
+	}()
+	configPath = ""
+	assert.NoError(t, loadConfig(rootCmd, []string{tmpDir}))
+	assert.Equal(t, map[string]string{"k8s/overlays/prod-us-east-1": "Production — US East"}, dirAliases)
+
+	mock := NewMockLLMProvider()
+	mock.DefaultResponse = "Summarized."
+	mdPath := filepath.Join(tmpDir, markdownFileName)
+	for _, format := range []string{"markdown", "github-wiki"} {
+		outputFormat = format
+		_, err = summarizeDirectory(tmpDir, mock)
+		assert.NoError(t, err)
+		doc, err := os.ReadFile(mdPath)
+		assert.NoError(t, err)
+		// Directories without an alias keep their path as the heading
+		if format == "markdown" {
+			assert.Contains(t, string(doc), "## [Production — US East](k8s/overlays/prod-us-east-1/)\n")
+			assert.Contains(t, string(doc), "## [k8s/base/](k8s/base/)\n")
+		} else {
+			assert.Contains(t, string(doc), "## Production — US East\n")
+			assert.Contains(t, string(doc), "## k8s/base/\n")
+		}
+		// Entries under the display name are read back under their directory
+		assert.Equal(t, map[string]string{
+			filepath.Join(prod, "deploy.yaml"):       "Summarized.",
+			filepath.Join("k8s", "base", "svc.yaml"): "Summarized.",
+		}, parseExistingSummaries(mdPath), format)
+		assert.NoError(t, os.Remove(mdPath))
+	}
+
+	// A markdown heading whose alias was removed from the config is read from its link
+	assert.NoError(t, os.WriteFile(mdPath, []byte("## [Old Name](k8s/overlays/prod-us-east-1/)\n- [deploy.yaml](k8s/overlays/prod-us-east-1/deploy.yaml): Summarized.\n"), 0644))
+	dirAliases = nil
+	assert.Equal(t, map[string]string{filepath.Join(prod, "deploy.yaml"): "Summarized."}, parseExistingSummaries(mdPath))
+}
+
+// audienceProvider answers with the audience its prompt was written for.
+type audienceProvider struct {
+	*MockLLMProvider
+}
+
+func (a *audienceProvider) Summarize(ctx context.Context, content string, prompt string) (string, error) {
+	switch {
+	case strings.Contains(prompt, "for a developer"):
+		return "Deployment web with image nginx.", nil
+	case strings.Contains(prompt, "non-technical reader"):
+		return "Serves the public website.", nil
+	}
+	return a.MockLLMProvider.Summarize(ctx, content, prompt)
+}
+
+func TestIntegrationAudience(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_audience_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "web.yaml"), []byte("kind: Deployment\nmetadata:\n  name: web\n"), 0644))
+
+	origAudiences, origActive := audiences, activeAudience
+	defer func() {
+		audiences, activeAudience = origAudiences, origActive
+	}()
+
+	// Without --audience the default prompt is used
+	audiences = nil
+	assert.NoError(t, validateAudiences())
+	assert.Equal(t, SummarizePrompt, summarizePrompt())
+
+	audiences = []string{"dev", "exec"}
+	assert.NoError(t, validateAudiences())
+	assert.Equal(t, "dev", activeAudience)
+
+	mock := &audienceProvider{NewMockLLMProvider()}
+	mock.DefaultResponse = "Default summary."
+	assert.NoError(t, runSummarizeYamlWithProvider(tmpDir, mock))
+
+	// The first audience writes --output, each further one its own document
+	doc, err := os.ReadFile(filepath.Join(tmpDir, markdownFileName))
+	assert.NoError(t, err)
+	assert.Contains(t, string(doc), "Deployment web with image nginx.")
+	execDoc, err := os.ReadFile(filepath.Join(tmpDir, "yaml_details.exec.md"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(execDoc), "Serves the public website.")
+	assert.NotContains(t, string(execDoc), "nginx")
+	assert.Equal(t, "dev", activeAudience)
+
+	audiences = []string{"cfo"}
+	assert.ErrorContains(t, validateAudiences(), `unsupported --audience "cfo"`)
+	audiences = []string{"ops", "ops"}
+	assert.ErrorContains(t, validateAudiences(), "--audience ops is given twice")
+}
+
+// promptRecorder records every prompt and content it is sent.
+type promptRecorder struct {
+	*MockLLMProvider
+	prompts  []string
+	contents []string
+}
+
+func (p *promptRecorder) Summarize(ctx context.Context, content string, prompt string) (string, error) {
+	p.prompts = append(p.prompts, prompt)
+	p.contents = append(p.contents, content)
+	return p.MockLLMProvider.Summarize(ctx, content, prompt)
+}
+
+func TestIntegrationPromptFile(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_prompt_file_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "apps"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "web.yaml"), []byte("kind: Deployment\nmetadata:\n  name: web\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "prompt.tmpl"),
+		[]byte("Describe the {{.Kind}} in {{.Path}} (directory {{.Dir}}) for the platform handbook:\n{{.Content}}"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, DefaultConfigFileName), []byte("prompt_file: prompt.tmpl\n"), 0644))
+
+	origPath, origTemplate, origConfig := promptFilePath, promptTemplate, configPath
+	defer func() {
+		promptFilePath, promptTemplate, configPath = origPath, origTemplate, origConfig
+	}()
+	configPath = ""
+	assert.NoError(t, loadConfig(rootCmd, []string{tmpDir}))
+	assert.NotNil(t, promptTemplate)
+
+	mock := &promptRecorder{MockLLMProvider: NewMockLLMProvider()}
+	mock.DefaultResponse = "Runs the web frontend."
+	_, err = summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+
+	// The template replaces the prompt and, since it places the content, nothing follows it
+	assert.Equal(t, []string{"Describe the Deployment in apps/web.yaml (directory apps) for the platform handbook:\nkind: Deployment\nmetadata:\n  name: web\n"}, mock.prompts)
+	assert.Equal(t, []string{""}, mock.contents)
+
+	// Unknown variables are rejected when the template is loaded
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "prompt.tmpl"), []byte("Summarize {{.Filename}}"), 0644))
+	assert.ErrorContains(t, loadConfig(rootCmd, []string{tmpDir}), "invalid prompt file")
+}
+
+func TestIntegrationChangelog(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_changelog_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	for _, name := range []string{"a.yaml", "b.yaml"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, name), []byte("kind: ConfigMap\nmetadata:\n  name: "+name+"\n"), 0644))
+	}
+
+	origRuns, origRegenerate := changelogRuns, regenerate
+	defer func() {
+		changelogRuns, regenerate = origRuns, origRegenerate
+	}()
+	changelogRuns = 2
+	mdPath := filepath.Join(tmpDir, markdownFileName)
+	mock := NewMockLLMProvider()
+	mock.DefaultResponse = "Holds settings."
+	run := func() string {
+		_, err := summarizeDirectory(tmpDir, mock)
+		assert.NoError(t, err)
+		doc, err := os.ReadFile(mdPath)
+		assert.NoError(t, err)
+		return string(doc)
+	}
+
+	doc := run()
+	assert.Contains(t, doc, "## Change Log\n")
+	assert.Regexp(t, "- `[0-9T:-]+Z` — Added: `a.yaml`, `b.yaml`\n", doc)
+	// The section is at the bottom, after the directory sections
+	assert.Greater(t, strings.Index(doc, "## Change Log"), strings.Index(doc, "- [b.yaml]"))
+
+	// A run that changes nothing adds no entry
+	assert.Equal(t, 1, strings.Count(run(), "— Added"))
+
+	assert.NoError(t, os.Remove(filepath.Join(tmpDir, "a.yaml")))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "c.yaml"), []byte("kind: Secret\n"), 0644))
+	regenerate = true
+	mock.MockResponses["name: b.yaml"] = "Holds the b settings."
+	doc = run()
+	assert.Regexp(t, "- `[0-9T:-]+Z` — Added: `c.yaml`; Updated: `b.yaml`; Removed: `a.yaml`\n", doc)
+	assert.Less(t, strings.Index(doc, "Removed: `a.yaml`"), strings.Index(doc, "Added: `a.yaml`"))
+	// Entries are parsed back and the summaries are unaffected by the section
+	assert.Equal(t, map[string]string{"b.yaml": "Holds the b settings.", "c.yaml": "Holds settings."}, parseExistingSummaries(mdPath))
+
+	// Only the last changelogRuns runs are kept
+	assert.NoError(t, os.Remove(filepath.Join(tmpDir, "c.yaml")))
+	doc = run()
+	assert.Contains(t, doc, "Removed: `c.yaml`")
+	assert.NotContains(t, doc, "Added: `a.yaml`")
+	assert.Len(t, parseChangelog(strings.Split(doc, "\n")), 2)
+
+	// Long lists name the first files and count the rest
+	assert.Equal(t, "Added: `1`, `2`, `3`, `4`, `5` +2", changelogFiles("Added", []string{"1", "2", "3", "4", "5", "6", "7"}))
+
+	origFormat := outputFormat
+	defer func() {
+		outputFormat = origFormat
+	}()
+	outputFormat = "json"
+	assert.ErrorContains(t, validateChangelog(), "--changelog requires --format markdown or github-wiki")
+}
+
+func TestIntegrationConfidenceNotes(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_confidence_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "busy.yaml"), []byte("kind: Deployment\nname: busy\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "odd.yaml"), []byte("kind: Deployment\nname: odd\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "calm.yaml"), []byte("kind: Service\nname: calm\n"), 0644))
+
+	origNotes, origRetries, origBackoff, origGuardrails, origRegenerate := confidenceNotes, providerRetries, retryBackoff, guardrails, regenerate
+	defer func() {
+		confidenceNotes, providerRetries, retryBackoff, guardrails, regenerate = origNotes, origRetries, origBackoff, origGuardrails, origRegenerate
+	}()
+	confidenceNotes, providerRetries, retryBackoff, guardrails = true, 2, time.Millisecond, true
+
+	mock := NewMockLLMProvider()
+	mock.DefaultResponse = "Summarized."
+	// Names a service the file does not contain, so the guardrail asks again
+	mock.MockResponses["name: odd"] = "Runs `redis-cache` next to the app."
+	flaky := &flakyProvider{
+		MockLLMProvider: mock,
+		Errors:          map[string]error{"name: busy": &statusError{API: "openai API", StatusCode: 503}},
+		Failures:        2,
+		calls:           make(map[string]int),
+	}
+	mdPath := filepath.Join(tmpDir, markdownFileName)
+	run := func() string {
+		_, err := summarizeDirectory(tmpDir, flaky)
+		assert.NoError(t, err)
+		doc, err := os.ReadFile(mdPath)
+		assert.NoError(t, err)
+		return string(doc)
+	}
+
+	doc := run()
+	assert.Contains(t, doc, "- [busy.yaml](busy.yaml): Summarized. 🔍 low confidence: needed 2 retries\n")
+	assert.Contains(t, doc, "- [odd.yaml](odd.yaml): Runs `redis-cache` next to the app. 🔍 low confidence: rewritten after a guardrail check\n")
+	assert.Contains(t, doc, "- [calm.yaml](calm.yaml): Summarized.\n")
+	// Notes are not part of the summary read back
+	assert.Equal(t, "Summarized.", parseExistingSummaries(mdPath)["busy.yaml"])
+
+	// Reused summaries keep their note until they are summarized again
+	assert.Equal(t, doc, run())
+	regenerate = true
+	mock.MockResponses["name: odd"] = "Runs the odd app."
+	doc = run()
+	assert.NotContains(t, doc, "🔍")
+}
+
+func TestIntegrationCacheImportFromDoc(t *testing.T) {
+	origDir, err := os.Getwd()
+	assert.NoError(t, err)
+	origStable, origDryRun, origFormat, origLocalCache := stableEntries, dryRun, outputFormat, localCache
+	defer func() {
+		_ = os.Chdir(origDir)
+		stableEntries, dryRun, outputFormat, localCache = origStable, origDryRun, origFormat, origLocalCache
+	}()
+	tmpDir, err := os.MkdirTemp("", "integration_test_cache_import_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.Chdir(tmpDir))
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "apps"), 0755))
+	for name, content := range map[string]string{"web.yaml": "kind: Deployment\n", "api.yaml": "kind: Service\n", "old.yaml": "kind: Job\n", "db.yaml": "kind: StatefulSet\n"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", name), []byte(content), 0644))
+	}
+	mock := NewMockLLMProvider()
+	mock.DefaultResponse = "Summarized."
+	stableEntries = true
+	_, err = summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+	docPath := filepath.Join(tmpDir, markdownFileName)
+
+	// One file was removed, one changed, and one already has a cache entry
+	assert.NoError(t, os.Remove(filepath.Join(tmpDir, "apps", "old.yaml")))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "api.yaml"), []byte("kind: Service\nspec: {}\n"), 0644))
+	dbEntry := cacheEntryPath(tmpDir, filepath.Join("apps", "db.yaml"))
+	assert.NoError(t, os.MkdirAll(filepath.Dir(dbEntry), 0755))
+	assert.NoError(t, os.WriteFile(dbEntry, []byte("Newer summary."), 0644))
+
+	dryRun = true
+	assert.NoError(t, runCacheImport(docPath, tmpDir))
+	_, err = os.Stat(cacheEntryPath(tmpDir, filepath.Join("apps", "web.yaml")))
+	assert.True(t, os.IsNotExist(err))
+
+	dryRun = false
+	assert.NoError(t, runCacheImport(docPath, tmpDir))
+	entries, err := os.ReadDir(filepath.Join(tmpDir, cacheDirName))
+	assert.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Equal(t, []string{"apps_db.yaml.md", "apps_web.yaml.md"}, names)
+	web, ok := cachedSummary(tmpDir, tmpDir, filepath.Join(tmpDir, "apps", "web.yaml"))
+	assert.True(t, ok)
+	assert.Equal(t, "Summarized.", web)
+	db, err := os.ReadFile(dbEntry)
+	assert.NoError(t, err)
+	assert.Equal(t, "Newer summary.", string(db))
+
+	// Without the document, a run reuses the imported entry instead of calling the
+	// provider; the unkeyed db entry cannot be verified and is summarized again
+	assert.NoError(t, os.Remove(docPath))
+	localCache = true
+	recorder := &contentRecorder{MockLLMProvider: NewMockLLMProvider()}
+	recorder.DefaultResponse = "Summarized again."
+	_, err = summarizeDirectory(tmpDir, recorder)
+	assert.NoError(t, err)
+	assert.Len(t, recorder.contents, 2)
+	for _, content := range recorder.contents {
+		assert.NotContains(t, content, "kind: Deployment")
+	}
+	doc, err := os.ReadFile(docPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(doc), "Summarized.")
+	assert.Contains(t, string(doc), "Summarized again.")
+
+	// Once the file changes, its entry is no longer reused
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "web.yaml"), []byte("kind: Deployment\nspec: {}\n"), 0644))
+	_, ok = cachedSummary(tmpDir, tmpDir, filepath.Join(tmpDir, "apps", "web.yaml"))
+	assert.False(t, ok)
+
+	// JSON documents record hashes as well
+	stableEntries, outputFormat = false, "json"
+	_, _, err = docEntries(filepath.Join(tmpDir, "missing.json"))
+	assert.Error(t, err)
+	assert.NoError(t, writeSummary(tmpDir, groupSummariesByDir([]string{filepath.Join(tmpDir, "apps", "web.yaml")}, map[string]string{filepath.Join(tmpDir, "apps", "web.yaml"): "From JSON."}, tmpDir), nil))
+	summaries, hashes, err := docEntries(filepath.Join(tmpDir, markdownFileName))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{filepath.Join("apps", "web.yaml"): "From JSON."}, summaries)
+	assert.Len(t, hashes[filepath.Join("apps", "web.yaml")], 64)
+}
+
+func TestIntegrationSince(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_since_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	origSince, origRegenerate := sinceRef, regenerate
+	defer func() {
+		sinceRef, regenerate = origSince, origRegenerate
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "apps"), 0755))
+	for name, content := range map[string]string{"apps/web.yaml": "kind: Deployment\n", "apps/api.yaml": "kind: Service\n"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644))
+	}
+	mock := NewMockLLMProvider()
+	mock.DefaultResponse = "First summary."
+	_, err = summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", tmpDir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+	}
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "Add configs and summaries")
+
+	// Only the file changed since the ref is summarized again; new files always are
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "api.yaml"), []byte("kind: Service\nspec: {}\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "db.yaml"), []byte("kind: StatefulSet\n"), 0644))
+	mock.DefaultResponse = "Second summary."
+	sinceRef = "HEAD"
+	_, err = summarizeDirectory(tmpDir, mock)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"apps/web.yaml": "First summary.",
+		"apps/api.yaml": "Second summary.",
+		"apps/db.yaml":  "Second summary.",
+	}, parseExistingSummaries(docPathFor(tmpDir)))
+
+	sinceRef = "no-such-ref"
+	_, err = summarizeDirectory(tmpDir, mock)
+	assert.ErrorContains(t, err, "git diff no-such-ref failed")
+
+	// A ref that git would parse as an option is rejected, and reaches git as a ref
+	sinceRef = "--output=/tmp/diff"
+	assert.ErrorContains(t, validateSince(), "must not start with")
+	_, err = summarizeDirectory(tmpDir, mock)
+	assert.ErrorContains(t, err, "git diff --output=/tmp/diff failed")
+
+	sinceRef, regenerate = "HEAD", true
+	assert.ErrorContains(t, validateSince(), "--since cannot be combined with --regenerate")
+}
+
+func TestIntegrationLargeModel(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_large_model_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	crd := "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: widgets.example.com\n"
+	manyDocs := strings.Repeat("kind: ConfigMap\n---\n", 2) + "kind: ConfigMap\n"
+	for name, content := range map[string]string{
+		"small.yaml": "kind: Deployment\n",
+		"crd.yaml":   crd,
+		"many.yaml":  manyDocs,
+		"big.yaml":   "data: " + strings.Repeat("x", 2048) + "\n",
+	} {
+		assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644))
+	}
+
+	origModel, origSize, origDocs := largeModel, largeFileSizeKB, largeFileDocuments
+	defer func() {
+		largeModel, largeFileSizeKB, largeFileDocuments = origModel, origSize, origDocs
+	}()
+	largeModel, largeFileSizeKB, largeFileDocuments = "quality:latest", -1, 3
+	assert.ErrorContains(t, validateLargeModel(), "--large-file-size must not be negative")
+	largeFileSizeKB = 2
+	assert.NoError(t, validateLargeModel())
+
+	// Providers without model switching cannot route
+	_, err = summarizeDirectory(tmpDir, NewMockLLMProvider())
+	assert.ErrorContains(t, err, "does not support --large-model")
+
+	client := &modelOllamaClient{MockOllamaClient: NewMockOllamaClient()}
+	llm := NewOllamaProviderFromClient(client)
+	_, err = summarizeDirectory(tmpDir, llm)
+	assert.ErrorContains(t, err, "large model quality:latest is not available")
+
+	client.AvailableModels = append(client.AvailableModels, "quality:latest")
+	report, err := summarizeDirectory(tmpDir, llm)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, report.Processed)
+	assert.Equal(t, 3, report.Routed)
+	assert.Equal(t, map[string]string{
+		"small.yaml": "Summarized by " + ModelName + ".",
+		"crd.yaml":   "Summarized by quality:latest.",
+		"many.yaml":  "Summarized by quality:latest.",
+		"big.yaml":   "Summarized by quality:latest.",
+	}, parseExistingSummaries(docPathFor(tmpDir)))
+
+	// Disabled thresholds leave only CRDs to the large model
+	largeFileSizeKB, largeFileDocuments = 0, 0
+	assert.Equal(t, "", largeFileReason(filepath.Join(tmpDir, "big.yaml")))
+	assert.Equal(t, "", largeFileReason(filepath.Join(tmpDir, "many.yaml")))
+	assert.Equal(t, "crd", largeFileReason(filepath.Join(tmpDir, "crd.yaml")))
+}
+
+func TestIntegrationRedactAskAndDrift(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_redact_ask_drift_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	repoDir := filepath.Join(tmpDir, "repo")
+	assert.NoError(t, os.MkdirAll(repoDir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(repoDir, "db.yaml"), []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: db
+data:
+  password: aHVudGVyMg==
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  template:
+    spec:
+      containers:
+        - name: api
+          env:
+            - name: DB_PASSWORD
+              value: hunter2
+            - name: LOG_LEVEL
+              value: info
+`), 0644))
+
+	// The live Secret and env var hold other values, and the log level drifted
+	script := `#!/bin/sh
+case "$*" in
+*"get Secret/db"*)
+cat <<'YAML'
+apiVersion: v1
+kind: Secret
+metadata:
+  name: db
+data:
+  password: c3dvcmRmaXNo
+YAML
+;;
+*"get Deployment/api"*)
+cat <<'YAML'
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  template:
+    spec:
+      containers:
+        - name: api
+          env:
+            - name: DB_PASSWORD
+              value: swordfish
+            - name: LOG_LEVEL
+              value: debug
+YAML
+;;
+*) echo "unexpected: $*" >&2; exit 1 ;;
+esac
+`
+	fakeKubectl := filepath.Join(tmpDir, "kubectl")
+	assert.NoError(t, os.WriteFile(fakeKubectl, []byte(script), 0755))
+	origCommand, origDiff := kubectlCommand, diffCluster
+	defer func() {
+		kubectlCommand, diffCluster = origCommand, origDiff
+	}()
+	kubectlCommand, diffCluster = fakeKubectl, true
+
+	// An empty explanation lists the drifted fields in the document instead
+	recorder := &contentRecorder{MockLLMProvider: NewMockLLMProvider()}
+	recorder.MockResponses["Resource: "] = ""
+	_, err = summarizeDirectory(repoDir, recorder)
+	assert.NoError(t, err)
+	doc, err := os.ReadFile(filepath.Join(repoDir, markdownFileName))
+	assert.NoError(t, err)
+	assert.Contains(t, string(doc), `Secret/db: data.password: declared <redacted>, deployed <redacted>`)
+	assert.Contains(t, string(doc), `spec.template.spec.containers[0].env[0].value: declared <redacted>, deployed <redacted>`)
+	assert.Contains(t, string(doc), `spec.template.spec.containers[0].env[1].value: declared "info", deployed "debug"`)
+	for _, content := range append(recorder.contents, string(doc)) {
+		for _, secret := range []string{"aHVudGVyMg==", "c3dvcmRmaXNo", "hunter2", "swordfish"} {
+			assert.NotContains(t, content, secret)
+		}
+	}
+
+	// Ask, chat, and embeddings export passages carry the redacted content
+	passages, err := loadInventory(repoDir)
+	assert.NoError(t, err)
+	if assert.Len(t, passages, 1) {
+		assert.Contains(t, passages[0].Content, "password: <redacted>")
+		assert.Contains(t, passages[0].Content, "value: <redacted>")
+		assert.NotContains(t, passages[0].Content, "hunter2")
+	}
+	origNoRedact := noRedact
+	defer func() {
+		noRedact = origNoRedact
+	}()
+	noRedact = true
+	passages, err = loadInventory(repoDir)
+	assert.NoError(t, err)
+	assert.Contains(t, passages[0].Content, "value: hunter2")
+}
+
+// TestIntegrationSourceDigests tests that documents record the content each summary was
+// generated from, so an edited file is summarized again and never stamped as current.
+func TestIntegrationSourceDigests(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_source_digests_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	origFormat, origStable := outputFormat, stableEntries
+	defer func() {
+		outputFormat, stableEntries = origFormat, origStable
+	}()
+	appPath := filepath.Join(tmpDir, "app.yaml")
+
+	for _, tc := range []struct {
+		format string
+		stable bool
+	}{{"markdown", false}, {"markdown", true}, {"github-wiki", false}} {
+		outputFormat, stableEntries = tc.format, tc.stable
+		assert.NoError(t, os.WriteFile(appPath, []byte("kind: Deployment\n"), 0644))
+		mock := NewMockLLMProvider()
+		mock.MockResponses["Deployment"] = "OLD SUMMARY"
+		mock.MockResponses["StatefulSet"] = "NEW SUMMARY"
+		_, err = summarizeDirectory(tmpDir, mock)
+		assert.NoError(t, err, tc.format)
+		digest, err := fileSHA256(appPath)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"app.yaml": digest}, readDocSources(docPathFor(tmpDir)), tc.format)
+
+		// A rewrite that summarizes nothing keeps the recorded digest of the old content
+		assert.NoError(t, os.WriteFile(appPath, []byte("kind: StatefulSet\n"), 0644))
+		assert.NoError(t, repairDoc(tmpDir, parseExistingSummaries(docPathFor(tmpDir))), tc.format)
+		assert.ErrorContains(t, runVerify(tmpDir), "is out of date", tc.format)
+
+		// A normal run summarizes the edited file again instead of reusing its summary
+		report, err := summarizeDirectory(tmpDir, mock)
+		assert.NoError(t, err, tc.format)
+		assert.Equal(t, 1, report.Processed, tc.format)
+		assert.Equal(t, "NEW SUMMARY", parseExistingSummaries(docPathFor(tmpDir))["app.yaml"], tc.format)
+		assert.NoError(t, runVerify(tmpDir), tc.format)
+
+		// An unchanged file is still reused
+		report, err = summarizeDirectory(tmpDir, mock)
+		assert.NoError(t, err, tc.format)
+		assert.Equal(t, 0, report.Processed, tc.format)
+		assert.NoError(t, os.Remove(docPathFor(tmpDir)))
+	}
+
+	// JSON entries record the digest as their hash
+	outputFormat, stableEntries = "json", false
+	_, err = summarizeDirectory(tmpDir, NewMockLLMProvider())
+	assert.NoError(t, err)
+	digest, err := fileSHA256(appPath)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"app.yaml": digest}, readDocSources(docPathFor(tmpDir)))
+}
+
+// TestIntegrationRewritesKeepAppendices tests that retry-failed, refresh, and repair-doc
+// keep the appendices of the document they rewrite from its entries.
+func TestIntegrationRewritesKeepAppendices(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_keep_appendices_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "web.yaml"), []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: web\nspec:\n  type: NodePort\n  ports:\n  - port: 80\n    nodePort: 30080\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "app.yaml"), []byte("kind: Deployment"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "flaky.yaml"), []byte("kind: Secret"), 0644))
+
+	origRules, origNetwork := keyFileRules, networkSurfaceEnabled
+	defer func() {
+		keyFileRules, networkSurfaceEnabled = origRules, origNetwork
+	}()
+	keyFileRules = []string{"kind=Deployment"}
+	networkSurfaceEnabled = true
+
+	llm := NewMockLLMProvider()
+	llm.MockResponses["kind: Deployment"] = "Runs the app."
+	llm.MockResponses["kind: Service"] = "Exposes the web port."
+	llm.MockErrors["kind: Secret"] = errors.New("rate limited")
+	_, err = summarizeDirectory(tmpDir, llm)
+	assert.NoError(t, err)
+
+	docPath := filepath.Join(tmpDir, markdownFileName)
+	appendices := readDocAppendices(docPath)
+	if assert.Len(t, appendices, 2) {
+		assert.Equal(t, "Key Configuration Files", appendices[0].Title)
+		assert.True(t, appendices[0].Leading)
+		assert.Equal(t, []appendixEntry{{Path: "app.yaml", Note: "Runs the app."}}, appendices[0].Entries)
+		assert.Equal(t, "Network Surface", appendices[1].Title)
+		assert.False(t, appendices[1].Leading)
+	}
+	content, err := os.ReadFile(docPath)
+	assert.NoError(t, err)
+	section := func(doc string) string {
+		start := strings.Index(doc, "## Network Surface")
+		end := strings.Index(doc, "<!-- yaml-to-readme-entry")
+		if start < 0 || end < start {
+			return ""
+		}
+		return doc[start:end]
+	}
+	network := section(string(content))
+	assert.NotEmpty(t, network)
+
+	delete(llm.MockErrors, "kind: Secret")
+	llm.MockResponses["kind: Secret"] = "Holds credentials."
+	assert.NoError(t, runRetryFailedWithProvider(tmpDir, llm))
+	assert.Equal(t, appendices, readDocAppendices(docPath))
+	content, err = os.ReadFile(docPath)
+	assert.NoError(t, err)
+	assert.Equal(t, network, section(string(content)))
+	assert.Equal(t, "Holds credentials.", parseExistingSummaries(docPath)["flaky.yaml"])
+
+	// A refreshed file without an entry regenerates the document from its entries
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "extra.yaml"), []byte("kind: ConfigMap"), 0644))
+	assert.NoError(t, updateDocEntry(tmpDir, "extra.yaml", "Extra settings.", currentDigest(filepath.Join(tmpDir, "extra.yaml"))))
+	assert.Equal(t, appendices, readDocAppendices(docPath))
+
+	// Appendix entries are neither recovered as summaries nor dropped by repair-doc
+	recovered, issues := lintDocStructure(tmpDir, readLinesFromFile(docPath))
+	assert.Empty(t, issues)
+	assert.Len(t, recovered, 4)
+	assert.NoError(t, repairDoc(tmpDir, recovered))
+	assert.Equal(t, appendices, readDocAppendices(docPath))
+}
+
+// TestIntegrationRefreshRestamps tests that refresh records the refreshed file's content
+// in the checksum footer, the --stable-entries key, and the JSON hash, and finds the
+// document written with --write-dir.
+func TestIntegrationRefreshRestamps(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_refresh_restamp_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	srcDir := filepath.Join(tmpDir, "src")
+	assert.NoError(t, os.MkdirAll(filepath.Join(srcDir, "app"), 0755))
+	appPath := filepath.Join(srcDir, "app", "app.yaml")
+	assert.NoError(t, os.WriteFile(appPath, []byte("kind: Deployment\nreplicas: 1\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(srcDir, "svc.yaml"), []byte("kind: Service"), 0644))
+
+	origFormat, origName, origStable, origWriteDir := outputFormat, markdownFileName, stableEntries, writeDir
+	defer func() {
+		outputFormat, markdownFileName, stableEntries, writeDir = origFormat, origName, origStable, origWriteDir
+	}()
+
+	llm := NewMockLLMProvider()
+	for _, tc := range []struct {
+		format, name string
+		stable       bool
+	}{
+		{"markdown", DefaultMarkdownFileName, false},
+		{"markdown", DefaultMarkdownFileName, true},
+		{"json", DefaultJSONFileName, false},
+	} {
+		outputFormat, markdownFileName, stableEntries = tc.format, tc.name, tc.stable
+		assert.NoError(t, os.WriteFile(appPath, []byte("kind: Deployment\nreplicas: 1\n"), 0644))
+		assert.NoError(t, runSummarizeYamlWithProvider(srcDir, llm))
+		assert.NoError(t, runVerify(srcDir), tc)
+
+		// After an edit and a refresh, the document records the new content again
+		assert.NoError(t, os.WriteFile(appPath, []byte("kind: Deployment\nreplicas: 3\n"), 0644))
+		assert.Error(t, runVerify(srcDir), tc)
+		assert.NoError(t, runRefreshWithProvider(appPath, llm), tc)
+		assert.NoError(t, runVerify(srcDir), tc)
+		digest, err := fileSHA256(appPath)
+		assert.NoError(t, err)
+		assert.Equal(t, digest, readDocSources(docPathFor(srcDir))["app/app.yaml"], tc)
+
+	}
+
+	// The document under --write-dir is found from the file's entry
+	outputFormat, markdownFileName, stableEntries = "markdown", DefaultMarkdownFileName, false
+	writeDir = filepath.Join(tmpDir, "out")
+	assert.NoError(t, validateWriteDir(srcDir))
+	assert.NoError(t, runSummarizeYamlWithProvider(srcDir, llm))
+	found, err := findDocDir(appPath)
+	assert.NoError(t, err)
+	assert.Equal(t, srcDir, found)
+
+	// A file without an entry cannot be placed in a shared document
+	assert.NoError(t, os.WriteFile(filepath.Join(srcDir, "app", "new.yaml"), []byte("kind: ConfigMap"), 0644))
+	_, err = findDocDir(filepath.Join(srcDir, "app", "new.yaml"))
+	assert.ErrorContains(t, err, "--dir")
+}
+
+// TestIntegrationAuditLogCommands tests that every command sending content to the
+// provider appends an audit record, with the model of each request.
+func TestIntegrationAuditLogCommands(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_audit_commands_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	smallPath := filepath.Join(tmpDir, "small.yaml")
+	assert.NoError(t, os.WriteFile(smallPath, []byte("kind: Deployment\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "crd.yaml"), []byte("apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\n"), 0644))
+
+	origAudit, origModel, origFormat, origFile := auditLogPath, largeModel, embeddingsFormat, embeddingsFile
+	defer func() {
+		auditLogPath, largeModel, embeddingsFormat, embeddingsFile = origAudit, origModel, origFormat, origFile
+	}()
+	auditLogPath = filepath.Join(tmpDir, "audit.jsonl")
+	largeModel = "quality:latest"
+	embeddingsFormat, embeddingsFile = "jsonl", ""
+
+	client := &modelOllamaClient{MockOllamaClient: NewMockOllamaClient()}
+	client.AvailableModels = append(client.AvailableModels, "quality:latest")
+	llm := NewOllamaProviderFromClient(client)
+	_, err = summarizeDirectory(tmpDir, llm)
+	assert.NoError(t, err)
+	largeModel = ""
+
+	assert.NoError(t, runRefreshWithProvider(smallPath, llm))
+	var out bytes.Buffer
+	assert.NoError(t, runAskWithProvider(&out, tmpDir, "what runs here?", llm))
+	assert.NoError(t, runEmbeddingsExportWithProvider(tmpDir, llm))
+
+	data, err := os.ReadFile(auditLogPath)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if !assert.Len(t, lines, 4) {
+		return
+	}
+	models := func(line string) map[string]string {
+		var rec auditRecord
+		assert.NoError(t, json.Unmarshal([]byte(line), &rec))
+		sent := make(map[string]string)
+		for _, s := range rec.Sent {
+			sent[s.Path] += s.Model + ";"
+		}
+		return sent
+	}
+	// Files routed to the large model are recorded with it
+	assert.Equal(t, map[string]string{"small.yaml": ModelName + ";", "crd.yaml": "quality:latest;"}, models(lines[0]))
+	assert.Equal(t, map[string]string{"small.yaml": ModelName + ";"}, models(lines[1]))
+	// Embedded passages are recorded with the embedding model, the question with the model
+	assert.Equal(t, map[string]string{"small.yaml": DefaultOllamaEmbedModel + ";", "crd.yaml": DefaultOllamaEmbedModel + ";", "": DefaultOllamaEmbedModel + ";" + ModelName + ";"}, models(lines[2]))
+	assert.Equal(t, map[string]string{"small.yaml": DefaultOllamaEmbedModel + ";", "crd.yaml": DefaultOllamaEmbedModel + ";"}, models(lines[3]))
+}
+
+func TestIntegrationMigrate(t *testing.T) {
+	origDir, err := os.Getwd()
+	assert.NoError(t, err)
+	origMigrateCheckOnly := migrateCheckOnly
+	defer func() {
+		_ = os.Chdir(origDir)
+		migrateCheckOnly = origMigrateCheckOnly
+	}()
+
+	tmpDir, err := os.MkdirTemp("", "integration_test_migrate_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.Chdir(tmpDir))
+
+	srcDir := filepath.Join(tmpDir, "src")
+	assert.NoError(t, os.MkdirAll(filepath.Join(srcDir, "apps"), 0755))
+	deployPath := filepath.Join(srcDir, "apps", "deploy.yaml")
+	assert.NoError(t, os.WriteFile(deployPath, []byte("kind: Deployment"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(srcDir, "apps", "svc.yaml"), []byte("kind: Service"), 0644))
+
+	mock := NewMockLLMProvider()
+	_, err = summarizeDirectory(srcDir, mock)
+	assert.NoError(t, err)
+
+	// A version 2 document written before entries recorded their source
+	docPath := docPathFor(srcDir)
+	var v2 []string
+	for _, line := range readLinesFromFile(docPath) {
+		if entryKeyPattern.MatchString(line) || inputsChecksumPattern.MatchString(line) {
+			continue
+		}
+		v2 = append(v2, strings.Replace(line, docSchemaMarker()[:len(docSchemaMarker())-1], "<!-- yaml-to-readme schema:2 -->", 1))
+	}
+	assert.NoError(t, os.WriteFile(docPath, []byte(strings.Join(v2, "\n")+"\n"), 0644))
+	assert.ErrorContains(t, runVerify(srcDir), "has no inputs checksum")
+
+	// A cache entry written before entries were keyed
+	cachePath := cacheEntryPath(tmpDir, filepath.Join("apps", "deploy.yaml"))
+	assert.NoError(t, os.MkdirAll(filepath.Dir(cachePath), 0755))
+	assert.NoError(t, os.WriteFile(cachePath, []byte("Cached summary."), 0644))
+	_, ok := cachedSummary(tmpDir, srcDir, deployPath)
+	assert.False(t, ok)
+
+	migrateCheckOnly = true
+	assert.ErrorContains(t, runMigrate(srcDir), "needs 2 migration(s)")
+	assert.Error(t, runVerify(srcDir))
+
+	migrateCheckOnly = false
+	assert.NoError(t, runMigrate(srcDir))
+	assert.NoError(t, runVerify(srcDir))
+	summary, ok := cachedSummary(tmpDir, srcDir, deployPath)
+	assert.True(t, ok)
+	assert.Equal(t, "Cached summary.", summary)
+	assert.NoError(t, runMigrate(srcDir))
+
+	// Every summary is still reused
+	report, err := summarizeDirectory(srcDir, mock)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.Processed)
+}
+
+func TestIntegrationDocCommandsOutput(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "integration_test_doc_output_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "a.yaml"), []byte("kind: A"), 0644))
+
+	origStatus, origPorcelainOut, origPorcelain := statusOut, porcelainOut, porcelain
+	origRepairCheckOnly, origMigrateCheckOnly := repairCheckOnly, migrateCheckOnly
+	defer func() {
+		statusOut, porcelainOut, porcelain = origStatus, origPorcelainOut, origPorcelain
+		repairCheckOnly, migrateCheckOnly = origRepairCheckOnly, origMigrateCheckOnly
+	}()
+	var status, stdout bytes.Buffer
+	statusOut, porcelainOut = &status, &stdout
+	repairCheckOnly, migrateCheckOnly = true, true
+
+	_, err = summarizeDirectory(tmpDir, NewMockLLMProvider())
+	assert.NoError(t, err)
+	docPath := docPathFor(tmpDir)
+	run := func() {
+		assert.NoError(t, runVerify(tmpDir))
+		assert.NoError(t, runMigrate(tmpDir))
+		assert.NoError(t, runRepairDoc(tmpDir))
+		assert.NoError(t, runLintDoc(tmpDir))
+	}
+
+	// Human-readable results go to the status stream only
+	porcelain = false
+	run()
+	assert.Contains(t, status.String(), docPath+" is up to date with 1 YAML files\n")
+	assert.Contains(t, status.String(), docPath+" is already at schema")
+	assert.Contains(t, status.String(), docPath+" is in canonical form (1 entries)\n")
+	assert.Contains(t, status.String(), "All links in "+docPath+" resolve\n")
+	assert.Empty(t, stdout.String())
+
+	// --porcelain replaces them with stable key=value lines
+	status.Reset()
+	porcelain = true
+	run()
+	assert.Empty(t, status.String())
+	assert.Equal(t, []string{
+		"verify\tstatus=up-to-date\tfiles=1",
+		fmt.Sprintf("migrate\tstatus=up-to-date\tpath=%s\tschema=%d", docPath, DocSchemaVersion),
+		"repair\tstatus=canonical\tentries=1",
+		"lint\tstatus=ok",
+	}, strings.Split(strings.TrimSpace(stdout.String()), "\n"))
+
+	// Problems are reported line by line
+	stdout.Reset()
+	assert.NoError(t, os.WriteFile(docPath, []byte(MarkdownHeader+"\n## apps\n* [a.yaml](missing.yaml) - Does A.\n"), 0644))
+	assert.Error(t, runRepairDoc(tmpDir))
+	assert.Error(t, runLintDoc(tmpDir))
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	assert.Contains(t, lines, "lint\tstatus=broken\tbroken=1")
+	entryLine := strings.Count(MarkdownHeader+"\n## apps\n", "\n") + 1
+	assert.Contains(t, lines, fmt.Sprintf("broken\tline=%d\ttarget=missing.yaml\treason=file not found", entryLine))
+	assert.Contains(t, lines, fmt.Sprintf("issue\tline=%d\tmessage=entry for a.yaml is not in canonical form", entryLine))
+	for _, line := range lines {
+		assert.Regexp(t, `^(issue\tline=\d+\tmessage=|repair\tstatus=malformed\tissues=\d+$|broken\t|lint\t)`, line)
+	}
+}
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// JiraLabel marks the issues created by --jira-project, so later runs find and update
+// the same issue.
+const JiraLabel = "yaml-to-readme"
+
+// jiraIssueType is the type of the issues created by --jira-project.
+const jiraIssueType = "Task"
+
+// jiraClient talks to the Jira REST API (v2, which Jira Cloud and Data Center share).
+// JIRA_URL is the site URL. With JIRA_USER set, JIRA_API_TOKEN is sent with basic auth
+// as Jira Cloud expects; otherwise it is sent as a bearer personal access token.
+type jiraClient struct {
+	baseURL string
+	user    string
+	token   string
+	client  *http.Client
+}
+
+// newJiraClient returns a client configured from the environment.
+func newJiraClient() (*jiraClient, error) {
+	baseURL := os.Getenv("JIRA_URL")
+	if baseURL == "" {
+		return nil, fmt.Errorf("JIRA_URL must be set to publish to Jira")
+	}
+	return &jiraClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		user:    os.Getenv("JIRA_USER"),
+		token:   os.Getenv("JIRA_API_TOKEN"),
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// do sends one request with a JSON body and decodes a JSON response into out, if set.
+func (c *jiraClient) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case c.user != "":
+		req.SetBasicAuth(c.user, c.token)
+	case c.token != "":
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("jira API request failed: %w", err)
+	}
+	data, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("jira API returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+	return nil
+}
+
+// comment adds a comment to an issue.
+func (c *jiraClient) comment(ctx context.Context, issue, body string) error {
+	return c.do(ctx, http.MethodPost, "/rest/api/2/issue/"+url.PathEscape(issue)+"/comment", map[string]string{"body": body}, nil)
+}
+
+// findIssue returns the key of the issue in project labeled JiraLabel with exactly the
+// given title, or "" if there is none.
+func (c *jiraClient) findIssue(ctx context.Context, project, title string) (string, error) {
+	jql := fmt.Sprintf("project = %q AND labels = %q AND summary ~ %q ORDER BY created ASC", project, JiraLabel, title)
+	var result struct {
+		Issues []struct {
+			Key    string `json:"key"`
+			Fields struct {
+				Summary string `json:"summary"`
+			} `json:"fields"`
+		} `json:"issues"`
+	}
+	query := url.Values{"jql": {jql}, "fields": {"summary"}}
+	if err := c.do(ctx, http.MethodGet, "/rest/api/2/search?"+query.Encode(), nil, &result); err != nil {
+		return "", err
+	}
+	// summary ~ is a text search, so check for the exact title
+	for _, issue := range result.Issues {
+		if issue.Fields.Summary == title {
+			return issue.Key, nil
+		}
+	}
+	return "", nil
+}
+
+// createIssue creates a labeled issue in project and returns its key.
+func (c *jiraClient) createIssue(ctx context.Context, project, title, description string) (string, error) {
+	fields := map[string]any{
+		"project":     map[string]string{"key": project},
+		"issuetype":   map[string]string{"name": jiraIssueType},
+		"summary":     title,
+		"description": description,
+		"labels":      []string{JiraLabel},
+	}
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/rest/api/2/issue", map[string]any{"fields": fields}, &created); err != nil {
+		return "", err
+	}
+	return created.Key, nil
+}
+
+// updateIssue replaces the description of an issue.
+func (c *jiraClient) updateIssue(ctx context.Context, issue, description string) error {
+	fields := map[string]any{"description": description}
+	return c.do(ctx, http.MethodPut, "/rest/api/2/issue/"+url.PathEscape(issue), map[string]any{"fields": fields}, nil)
+}
+
+// jiraIssueTitle is the title of the --jira-project issue for dir.
+func jiraIssueTitle(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		abs = dir
+	}
+	return "YAML summaries: " + filepath.Base(abs)
+}
+
+// jiraEscape escapes the characters Jira wiki markup would otherwise interpret in a
+// summary or path.
+func jiraEscape(s string) string {
+	return strings.NewReplacer("{", `\{`, "}", `\}`, "[", `\[`, "]", `\]`, "|", `\|`).Replace(s)
+}
+
+// renderJiraDigest renders the digest of a run as Jira wiki markup: totals, then the
+// entries added and changed in this run.
+func renderJiraDigest(report *runReport, added, changed []summaryChange) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "h2. YAML summary digest for %s\n\n", jiraEscape(report.Dir))
+	fmt.Fprintf(&sb, "Run at %s with %s. %d files summarized, %d added, %d changed, %d failed.\n",
+		time.Now().UTC().Format(time.RFC3339), jiraEscape(report.Model), len(report.Summaries), len(added), len(changed), len(report.Failed))
+	if len(added) > 0 {
+		sb.WriteString("\nh3. Added\n")
+		for _, c := range added {
+			fmt.Fprintf(&sb, "* {{%s}}: %s\n", jiraEscape(c.Rel), jiraEscape(c.Summary))
+		}
+	}
+	if len(changed) > 0 {
+		sb.WriteString("\nh3. Changed\n")
+		for _, c := range changed {
+			fmt.Fprintf(&sb, "* {{%s}}: %s\n** Previously: %s\n", jiraEscape(c.Rel), jiraEscape(c.Summary), jiraEscape(c.Previous))
+		}
+	}
+	return sb.String()
+}
+
+// publishJira publishes the digest of a run to Jira. With --jira-issue the digest is
+// added as a comment on that tracking ticket, unless no entry was added or changed. With
+// --jira-project the description of the run's issue in that project is replaced with the
+// digest, creating the issue on the first run.
+func publishJira(report *runReport, existing map[string]string) error {
+	client, err := newJiraClient()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	added, changed := summaryChanges(report.Dir, report.YAMLFiles, existing, report.Summaries)
+	digest := renderJiraDigest(report, added, changed)
+
+	if jiraIssue != "" {
+		if len(added) == 0 && len(changed) == 0 {
+			return nil
+		}
+		if err := client.comment(ctx, jiraIssue, digest); err != nil {
+			return fmt.Errorf("failed to comment on %s: %w", jiraIssue, err)
+		}
+		porcelainf("jira", "issue", jiraIssue, "action", "commented")
+		return nil
+	}
+
+	title := jiraIssueTitle(report.Dir)
+	key, err := client.findIssue(ctx, jiraProject, title)
+	if err != nil {
+		return fmt.Errorf("failed to search %s: %w", jiraProject, err)
+	}
+	if key == "" {
+		if key, err = client.createIssue(ctx, jiraProject, title, digest); err != nil {
+			return fmt.Errorf("failed to create issue in %s: %w", jiraProject, err)
+		}
+		porcelainf("jira", "issue", key, "action", "created")
+		return nil
+	}
+	if err := client.updateIssue(ctx, key, digest); err != nil {
+		return fmt.Errorf("failed to update %s: %w", key, err)
+	}
+	porcelainf("jira", "issue", key, "action", "updated")
+	return nil
+}
+
+// validateJira checks the Jira flags before any file is summarized, so a misconfigured
+// publisher fails fast rather than after a long run.
+func validateJira() error {
+	if jiraIssue == "" && jiraProject == "" {
+		return nil
+	}
+	if jiraIssue != "" && jiraProject != "" {
+		return fmt.Errorf("--jira-issue and --jira-project cannot be combined")
+	}
+	if os.Getenv("JIRA_URL") == "" {
+		return fmt.Errorf("JIRA_URL must be set to publish to Jira")
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"path/filepath"
+)
+
+// keyFileRules are the --key-files values selecting the files highlighted at the top
+// of the document.
+var keyFileRules []string
+
+// validateKeyFiles checks the --key-files values before any file is summarized.
+func validateKeyFiles() error {
+	_, err := parseFileRules("key-files", keyFileRules)
+	return err
+}
+
+// keyFilesAppendix returns the highlight section listing the summaries of the files
+// matched by --key-files, rendered before the exhaustive directory listing. The files
+// stay in their directory sections as well.
+func keyFilesAppendix(dir string, yamlFiles []string, summaries map[string]string) []docAppendix {
+	rules, err := parseFileRules("key-files", keyFileRules)
+	if err != nil || len(rules) == 0 {
+		return nil
+	}
+	l := currentLabels()
+	highlight := docAppendix{Title: l.KeyFiles, Intro: l.KeyFilesIntro, Link: true, Leading: true}
+	for _, file := range yamlFiles {
+		summary := summaries[file]
+		if summary == "" || !matchFileRules(dir, file, rules) {
+			continue
+		}
+		rel, _ := filepath.Rel(dir, file)
+		highlight.Entries = append(highlight.Entries, appendixEntry{Path: filepath.ToSlash(rel), Note: summary})
+	}
+	return []docAppendix{highlight}
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// kindPrefixEnabled prefixes markdown and GitHub wiki entries with the kind and name of
+// the resources their file declares.
+var kindPrefixEnabled bool
+
+// maxPrefixResources is how many resources a kind prefix names before counting the rest.
+const maxPrefixResources = 3
+
+// kindPrefixSeparator separates a kind prefix from the summary.
+const kindPrefixSeparator = " — "
+
+// kindPrefixPattern matches a kind prefix at the start of a summary read back from a
+// document.
+var kindPrefixPattern = regexp.MustCompile("^`[^`]+`(?:, `[^`]+`)*(?: \\+\\d+)?" + kindPrefixSeparator)
+
+// resourceRefs returns "Kind/name" for every distinct resource declared in a YAML file,
+// in document order. Documents without a kind are left out, and resources without a
+// name are listed by kind alone.
+func resourceRefs(file string) []string {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	docs, _ := decodeYAMLDocuments(data)
+	var refs []string
+	seen := make(map[string]bool)
+	for _, doc := range docs {
+		kind, _, name := resourceIdentity(doc)
+		if kind == "" {
+			continue
+		}
+		ref := kind
+		if name != "" {
+			ref += "/" + name
+		}
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// kindPrefix renders the prefix of a file's entry, such as "`Deployment/web-app` — ",
+// or an empty string if the file declares no resources.
+func kindPrefix(file string) string {
+	refs := resourceRefs(file)
+	if len(refs) == 0 {
+		return ""
+	}
+	shown := refs
+	if len(shown) > maxPrefixResources {
+		shown = shown[:maxPrefixResources]
+	}
+	prefix := "`" + strings.Join(shown, "`, `") + "`"
+	if more := len(refs) - len(shown); more > 0 {
+		prefix += fmt.Sprintf(" +%d", more)
+	}
+	return prefix + kindPrefixSeparator
+}
+
+// withKindPrefixes returns a copy of summaries with every summarized file's entry
+// prefixed with the resources it declares.
+func withKindPrefixes(summaries map[string]string, files []string) map[string]string {
+	prefixed := maps.Clone(summaries)
+	for _, file := range files {
+		if summary := prefixed[file]; summary != "" {
+			prefixed[file] = kindPrefix(file) + summary
+		}
+	}
+	return prefixed
+}
+
+// stripKindPrefix removes a kind prefix from a summary read back from a document, so it
+// is recomputed rather than fed back into the next run.
+func stripKindPrefix(summary string) string {
+	return kindPrefixPattern.ReplaceAllString(summary, "")
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// knowledgeBaseFile is the format of a --knowledge-base file.
+type knowledgeBaseFile struct {
+	// Kinds maps a kind, or group/kind to tell apart kinds with the same name, to a
+	// one-line description of what its resources do.
+	Kinds map[string]string `yaml:"kinds"`
+}
+
+// knowledgeBase holds the descriptions loaded from --knowledge-base.
+var knowledgeBase map[string]string
+
+// loadKnowledgeBase reads a knowledge base file.
+func loadKnowledgeBase(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read knowledge base %s: %w", path, err)
+	}
+	var kb knowledgeBaseFile
+	if err := yaml.Unmarshal(data, &kb); err != nil {
+		return nil, fmt.Errorf("failed to parse knowledge base %s: %w", path, err)
+	}
+	if len(kb.Kinds) == 0 {
+		return nil, fmt.Errorf("knowledge base %s has no kinds", path)
+	}
+	return kb.Kinds, nil
+}
+
+// describeKind returns the knowledge base description for a resource, preferring a
+// group/kind entry over a plain kind entry.
+func describeKind(apiVersion, kind string) string {
+	if group, _, ok := strings.Cut(apiVersion, "/"); ok {
+		if desc := knowledgeBase[group+"/"+kind]; desc != "" {
+			return desc
+		}
+	}
+	return knowledgeBase[kind]
+}
+
+// knowledgeContext describes the kinds in file that have a knowledge base entry, so
+// in-house custom resources are summarized accurately instead of guessed at.
+func knowledgeContext(file string) string {
+	if len(knowledgeBase) == 0 {
+		return ""
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	docs, _ := decodeYAMLDocuments(data)
+	var seen, descriptions []string
+	for _, doc := range docs {
+		kind := stringField(doc, "kind")
+		if kind == "" || slices.Contains(seen, kind) {
+			continue
+		}
+		seen = append(seen, kind)
+		if desc := describeKind(stringField(doc, "apiVersion"), kind); desc != "" {
+			descriptions = append(descriptions, fmt.Sprintf("%s: %s", kind, strings.TrimSpace(desc)))
+		}
+	}
+	if len(descriptions) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Reference descriptions of resource kinds in this file, use them rather than guessing what the kinds do. %s\n", strings.Join(descriptions, " "))
+}
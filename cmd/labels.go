@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// keyUsage counts how often one label or annotation key is used.
+type keyUsage struct {
+	Uses  int
+	Files map[string]bool
+}
+
+// labelTaxonomy collects the label and annotation keys used across a set of files and
+// the resources missing required labels.
+type labelTaxonomy struct {
+	Labels      map[string]*keyUsage
+	Annotations map[string]*keyUsage
+	Missing     []appendixEntry
+}
+
+// recordKeys counts the keys of a metadata map in usage.
+func recordKeys(usage map[string]*keyUsage, keys map[string]any, file string) {
+	for key := range keys {
+		u := usage[key]
+		if u == nil {
+			u = &keyUsage{Files: make(map[string]bool)}
+			usage[key] = u
+		}
+		u.Uses++
+		u.Files[file] = true
+	}
+}
+
+// collectLabelTaxonomy reads the metadata of every resource in files, relative to dir.
+func collectLabelTaxonomy(dir string, files []string) *labelTaxonomy {
+	tax := &labelTaxonomy{Labels: make(map[string]*keyUsage), Annotations: make(map[string]*keyUsage)}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			slog.Warn("skipping label report", "file", file, "error", err)
+			continue
+		}
+		docs, err := decodeYAMLDocuments(data)
+		if err != nil {
+			slog.Debug("label report stopped at unparsable document", "file", file, "error", err)
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			rel = file
+		}
+		rel = filepath.ToSlash(rel)
+		for _, doc := range docs {
+			kind, _, name := resourceIdentity(doc)
+			if kind == "" || name == "" {
+				continue
+			}
+			meta, _ := doc["metadata"].(map[string]any)
+			labels, _ := meta["labels"].(map[string]any)
+			annotations, _ := meta["annotations"].(map[string]any)
+			recordKeys(tax.Labels, labels, rel)
+			recordKeys(tax.Annotations, annotations, rel)
+
+			var missing []string
+			for _, required := range requiredLabels {
+				if _, ok := labels[required]; !ok {
+					missing = append(missing, required)
+				}
+			}
+			if len(missing) > 0 {
+				tax.Missing = append(tax.Missing, appendixEntry{Path: rel, Note: fmt.Sprintf("%s/%s: missing %s", kind, name, strings.Join(missing, ", "))})
+			}
+		}
+	}
+	return tax
+}
+
+// usageEntries lists keys sorted by descending use count, then by name.
+func usageEntries(kind string, usage map[string]*keyUsage) []appendixEntry {
+	keys := slices.Sorted(maps.Keys(usage))
+	slices.SortStableFunc(keys, func(a, b string) int {
+		return usage[b].Uses - usage[a].Uses
+	})
+	var entries []appendixEntry
+	for _, key := range keys {
+		u := usage[key]
+		entries = append(entries, appendixEntry{Path: key, Note: fmt.Sprintf("%s, %s in %s", kind, pluralize(u.Uses, "use"), pluralize(len(u.Files), "file"))})
+	}
+	return entries
+}
+
+// pluralize returns "1 file" or "2 files".
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// labelAppendix renders the governance section: every label and annotation key with its
+// usage counts, and the resources missing any --required-labels.
+func labelAppendix(dir string, files []string) []docAppendix {
+	tax := collectLabelTaxonomy(dir, files)
+	l := currentLabels()
+	entries := append(usageEntries("label", tax.Labels), usageEntries("annotation", tax.Annotations)...)
+	appendices := []docAppendix{{Title: l.LabelTaxonomy, Intro: l.LabelTaxonomyIntro, Entries: entries}}
+	if len(tax.Missing) > 0 {
+		appendices = append(appendices, docAppendix{Title: l.MissingLabels, Intro: fmt.Sprintf(l.MissingLabelsIntro, strings.Join(requiredLabels, ", ")), Entries: tax.Missing, Link: true})
+	}
+	return appendices
+}
@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/spf13/cobra"
+)
+
+// markdownLinkPattern matches inline markdown links: [text](target).
+var markdownLinkPattern = regexp.MustCompile(`\[[^\]]*\]\(([^)\s]+)\)`)
+
+// wikiLinkPattern matches GitHub wiki links with an explicit target: [[text|target]].
+var wikiLinkPattern = regexp.MustCompile(`\[\[[^|\]]*\|([^\]]+)\]\]`)
+
+// headingLinkPattern matches a markdown link inside a heading, capturing its text.
+var headingLinkPattern = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+
+// htmlAnchorPattern matches an explicit HTML anchor, capturing its id.
+var htmlAnchorPattern = regexp.MustCompile(`<a id="([^"]+)"></a>`)
+
+// brokenLink describes a link in a document that does not resolve.
+type brokenLink struct {
+	Line   int
+	Target string
+	Reason string
+}
+
+// headingAnchor converts a markdown heading into the anchor slug GitHub generates for it.
+func headingAnchor(heading string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(heading)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			sb.WriteRune(r)
+		case r == ' ':
+			sb.WriteRune('-')
+		}
+	}
+	return sb.String()
+}
+
+// collectAnchors returns the set of heading anchors defined in the given markdown lines,
+// numbering duplicates the way GitHub does (foo, foo-1, foo-2, ...), plus any explicit
+// HTML anchors such as those of glossary entries.
+func collectAnchors(lines []string) map[string]bool {
+	anchors := make(map[string]bool)
+	counts := make(map[string]int)
+	for _, line := range lines {
+		for _, m := range htmlAnchorPattern.FindAllStringSubmatch(line, -1) {
+			anchors[m[1]] = true
+		}
+		if !strings.HasPrefix(line, "#") {
+			continue
+		}
+		heading := strings.TrimLeft(line, "#")
+		if heading == line || !strings.HasPrefix(heading, " ") {
+			continue
+		}
+		// Headings that are links use the link text for the anchor
+		if m := headingLinkPattern.FindStringSubmatch(heading); m != nil {
+			heading = strings.Replace(heading, m[0], m[1], 1)
+		}
+		slug := headingAnchor(heading)
+		if n := counts[slug]; n > 0 {
+			anchors[fmt.Sprintf("%s-%d", slug, n)] = true
+		} else {
+			anchors[slug] = true
+		}
+		counts[slug]++
+	}
+	return anchors
+}
+
+// checkDocLinks verifies every link in the document at docPath. Relative links must
+// point at existing files, anchors must match a heading, and remote URLs are checked
+// with a HEAD request when checkRemote is true.
+func checkDocLinks(docPath string, checkRemote bool) ([]brokenLink, error) {
+	lines := readLinesFromFile(docPath)
+	if lines == nil {
+		if _, err := os.Stat(docPath); err != nil {
+			return nil, err
+		}
+	}
+	docDir := filepath.Dir(docPath)
+	anchors := collectAnchors(lines)
+	remoteStatus := make(map[string]string)
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	var broken []brokenLink
+	for i, line := range lines {
+		var targets []string
+		for _, m := range markdownLinkPattern.FindAllStringSubmatch(line, -1) {
+			targets = append(targets, m[1])
+		}
+		for _, m := range wikiLinkPattern.FindAllStringSubmatch(line, -1) {
+			targets = append(targets, m[1])
+		}
+
+		for _, target := range targets {
+			var reason string
+			switch {
+			case strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://"):
+				if !checkRemote {
+					continue
+				}
+				status, seen := remoteStatus[target]
+				if !seen {
+					status = checkRemoteLink(client, target)
+					remoteStatus[target] = status
+				}
+				reason = status
+			case strings.HasPrefix(target, "mailto:"):
+				continue
+			case strings.HasPrefix(target, "#"):
+				if !anchors[strings.TrimPrefix(target, "#")] {
+					reason = "anchor not found"
+				}
+			default:
+				reason = checkLocalLink(docDir, target)
+			}
+			if reason != "" {
+				broken = append(broken, brokenLink{Line: i + 1, Target: target, Reason: reason})
+			}
+		}
+	}
+	return broken, nil
+}
+
+// checkLocalLink resolves a relative link against docDir and returns why it is broken,
+// or an empty string if the target exists.
+func checkLocalLink(docDir, target string) string {
+	pathPart, anchor, _ := strings.Cut(target, "#")
+	if unescaped, err := url.PathUnescape(pathPart); err == nil {
+		pathPart = unescaped
+	}
+	resolved := filepath.Join(docDir, filepath.FromSlash(pathPart))
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "file not found"
+	}
+	if anchor != "" && !info.IsDir() && strings.HasSuffix(resolved, ".md") {
+		if !collectAnchors(readLinesFromFile(resolved))[anchor] {
+			return "anchor not found"
+		}
+	}
+	return ""
+}
+
+// checkRemoteLink issues a HEAD request and returns why the URL is broken, or an empty
+// string if it responded successfully.
+func checkRemoteLink(client *http.Client, target string) string {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodHead, target, nil)
+	if err != nil {
+		return fmt.Sprintf("invalid URL: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Sprintf("request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Sprintf("HTTP %d", resp.StatusCode)
+	}
+	return ""
+}
+
+// runLintDoc is the main logic for the lint-doc command.
+func runLintDoc(dir string) error {
+	setupLogging()
+	docPath := docPathFor(dir)
+	broken, err := checkDocLinks(docPath, lintCheckRemote)
+	if err != nil {
+		return fmt.Errorf("failed to check links in %s: %w", docPath, err)
+	}
+	if len(broken) == 0 {
+		porcelainf("lint", "status", "ok")
+		statusf("All links in %s resolve\n", docPath)
+		return nil
+	}
+	statusf("Broken links in %s:\n", docPath)
+	for _, b := range broken {
+		porcelainf("broken", "line", b.Line, "target", b.Target, "reason", b.Reason)
+		statusf("  line %d: %s (%s)\n", b.Line, b.Target, b.Reason)
+	}
+	porcelainf("lint", "status", "broken", "broken", len(broken))
+	return fmt.Errorf("%d broken link(s) found", len(broken))
+}
+
+// lintDocCmd checks that every link in the generated document resolves.
+var lintDocCmd = &cobra.Command{
+	Use:   "lint-doc [directory]",
+	Short: "Verify that every link in the generated document resolves",
+	Args:  cobra.ExactArgs(1),
+	// Broken links are reported by runLintDoc; the usage text adds nothing.
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLintDoc(args[0])
+	},
+}
+
+var lintCheckRemote bool
+
+func init() {
+	rootCmd.AddCommand(lintDocCmd)
+	lintDocCmd.Flags().BoolVar(&lintCheckRemote, "check-remote", false, "Also check remote http(s) links with a HEAD request")
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// quiet limits output to errors: progress and status messages are left out and only
+// error logs are written.
+var quiet bool
+
+// logFormat is the --log-format of log records: text or json.
+var logFormat = "text"
+
+// progressLine is set while the progress bar is drawn on a line without a trailing
+// newline. It is guarded by progressMu.
+var progressLine bool
+
+// validateLogging checks --log-format and that --verbose and --quiet are not combined.
+func validateLogging() error {
+	if logFormat != "text" && logFormat != "json" {
+		return fmt.Errorf("unsupported --log-format %q, expected text or json", logFormat)
+	}
+	if verbose && quiet {
+		return fmt.Errorf("--verbose and --quiet cannot be combined")
+	}
+	return nil
+}
+
+// logWriter writes log records to w one at a time, moving past a progress bar line
+// first so concurrent workers never log into the middle of it.
+type logWriter struct {
+	w io.Writer
+}
+
+// Write implements io.Writer.
+func (l logWriter) Write(p []byte) (int, error) {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	if progressLine {
+		if _, err := io.WriteString(l.w, "\n"); err != nil {
+			return 0, err
+		}
+		progressLine = false
+	}
+	return l.w.Write(p)
+}
+
+// setupLogging installs the default logger: warnings and errors as text on stderr,
+// debug records with --verbose, only errors with --quiet, and JSON records with
+// --log-format json.
+func setupLogging() {
+	level := slog.LevelWarn
+	switch {
+	case verbose:
+		level = slog.LevelDebug
+	case quiet:
+		level = slog.LevelError
+	}
+	opts := &slog.HandlerOptions{Level: level}
+	out := logWriter{w: os.Stderr}
+	var handler slog.Handler = slog.NewTextHandler(out, opts)
+	if logFormat == "json" {
+		handler = slog.NewJSONHandler(out, opts)
+	}
+	slog.SetDefault(slog.New(handler))
+}
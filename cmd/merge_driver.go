@@ -0,0 +1,328 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// mergeSummaries merges the entries of two versions of a document with those of their
+// common ancestor, keyed by path. An entry changed on one side only takes that side's
+// summary; an entry changed differently on both sides takes ours if preferOurs is set
+// and theirs otherwise. An entry removed on one side stays removed unless the other side
+// changed it. It returns the merged entries and the paths changed on both sides.
+func mergeSummaries(base, ours, theirs map[string]string, preferOurs bool) (map[string]string, []string) {
+	merged := make(map[string]string)
+	var both []string
+	for key, o := range ours {
+		b, inBase := base[key]
+		t, inTheirs := theirs[key]
+		switch {
+		case !inTheirs:
+			// Added on our side, or removed on theirs unless we changed it
+			if !inBase || o != b {
+				merged[key] = o
+			}
+		case o == t || (inBase && t == b):
+			merged[key] = o
+		case inBase && o == b:
+			merged[key] = t
+		default:
+			both = append(both, key)
+			if preferOurs {
+				merged[key] = o
+			} else {
+				merged[key] = t
+			}
+		}
+	}
+	for key, t := range theirs {
+		if _, inOurs := ours[key]; inOurs {
+			continue
+		}
+		if b, inBase := base[key]; !inBase || t != b {
+			merged[key] = t
+		}
+	}
+	sort.Strings(both)
+	return merged, both
+}
+
+// docLine is one line of a markdown or GitHub wiki document: a directory heading, an
+// entry, or anything else.
+type docLine struct {
+	text    string
+	dir     string
+	rel     string
+	heading bool
+}
+
+// parseDocLines classifies the lines of a markdown or GitHub wiki document.
+func parseDocLines(lines []string) []docLine {
+	parsed := make([]docLine, len(lines))
+	var currentDir string
+	for i, line := range lines {
+		parsed[i].text = line
+		if rel, _, ok := parseSummaryLine(line, &currentDir); ok {
+			parsed[i].rel, parsed[i].dir = rel, filepath.Dir(rel)
+		} else if strings.HasPrefix(line, "## ") && currentDir != "" {
+			parsed[i].heading, parsed[i].dir = true, filepath.Clean(currentDir)
+		}
+	}
+	return parsed
+}
+
+// mergeMarkdownDoc merges the entries into our version of a markdown or GitHub wiki
+// document, leaving the rest of it as it was. Entries and directories only in theirs are
+// copied from theirs at their sorted position; entries and directories no longer in the
+// merge are dropped.
+func mergeMarkdownDoc(ours, theirs []docLine, oursSummaries, merged map[string]string) []string {
+	theirEntries := make(map[string]string)
+	theirHeadings := make(map[string]string)
+	for _, l := range theirs {
+		switch {
+		case l.rel != "":
+			theirEntries[l.rel] = l.text
+		case l.heading:
+			theirHeadings[l.dir] = l.text
+		}
+	}
+	ourDirs := make(map[string]bool)
+	for _, l := range ours {
+		if l.heading {
+			ourDirs[l.dir] = true
+		}
+	}
+
+	// Entries to add, by directory, and directories to add, sorted
+	added := make(map[string][]string)
+	var newDirs []string
+	for rel := range merged {
+		if _, ok := oursSummaries[rel]; ok {
+			continue
+		}
+		dir := filepath.Dir(rel)
+		if !ourDirs[dir] && len(added[dir]) == 0 {
+			newDirs = append(newDirs, dir)
+		}
+		added[dir] = append(added[dir], rel)
+	}
+	for _, rels := range added {
+		sort.Slice(rels, func(i, j int) bool { return filepath.Base(rels[i]) < filepath.Base(rels[j]) })
+	}
+	sort.Strings(newDirs)
+
+	// The directory sections of ours keep their entries
+	remaining := make(map[string]int)
+	for rel := range merged {
+		if _, ok := oursSummaries[rel]; ok {
+			remaining[filepath.Dir(rel)]++
+		}
+	}
+	lastSection := -1
+	for i, l := range ours {
+		if l.heading || l.rel != "" {
+			lastSection = i
+		}
+	}
+
+	var out []string
+	var section string
+	skipping := false
+	flushEntries := func(dir, before string) {
+		rels := added[dir]
+		for len(rels) > 0 && (before == "" || filepath.Base(rels[0]) < before) {
+			out = append(out, theirEntries[rels[0]])
+			rels = rels[1:]
+		}
+		added[dir] = rels
+	}
+	flushDirs := func(before string) {
+		for len(newDirs) > 0 && (before == "" || newDirs[0] < before) {
+			dir := newDirs[0]
+			newDirs = newDirs[1:]
+			out = append(out, theirHeadings[dir])
+			flushEntries(dir, "")
+			out = append(out, "")
+		}
+	}
+	for i, l := range ours {
+		switch {
+		case l.heading:
+			flushDirs(l.dir)
+			section, skipping = l.dir, remaining[l.dir]+len(added[l.dir]) == 0
+			if skipping {
+				// Drop the blank line before the heading of an emptied directory
+				if len(out) > 0 && out[len(out)-1] == "" {
+					out = out[:len(out)-1]
+				}
+				continue
+			}
+			out = append(out, l.text)
+		case l.rel != "":
+			flushEntries(l.dir, filepath.Base(l.rel))
+			if summary, ok := merged[l.rel]; ok {
+				if summary == oursSummaries[l.rel] {
+					out = append(out, l.text)
+				} else {
+					out = append(out, theirEntries[l.rel])
+				}
+			}
+		default:
+			if section != "" {
+				flushEntries(section, "")
+				section = ""
+			}
+			out = append(out, l.text)
+		}
+		if i == lastSection {
+			if section != "" {
+				flushEntries(section, "")
+				section = ""
+			}
+			if len(newDirs) > 0 {
+				out = append(out, "")
+				flushDirs("")
+				out = out[:len(out)-1]
+			}
+		}
+	}
+	if section != "" {
+		flushEntries(section, "")
+	}
+	if len(newDirs) > 0 {
+		out = append(out, "")
+		flushDirs("")
+	}
+	return out
+}
+
+// jsonDocSummaries returns the summaries of a JSON document by path, and the entry of
+// each path.
+func jsonDocSummaries(output *JSONOutput) (map[string]string, map[string]JSONFileEntry) {
+	summaries := make(map[string]string)
+	entries := make(map[string]JSONFileEntry)
+	for _, list := range output.Directories {
+		for _, entry := range list {
+			rel := filepath.Clean(entry.Path)
+			summaries[rel] = entry.Summary
+			entries[rel] = entry
+		}
+	}
+	return summaries, entries
+}
+
+// mergeJSONDoc merges JSON documents. Entries changed on both sides take the summary of
+// the document generated last.
+func mergeJSONDoc(baseData, oursData, theirsData []byte) ([]byte, []string, error) {
+	var base, ours, theirs JSONOutput
+	for _, doc := range []struct {
+		name string
+		data []byte
+		out  *JSONOutput
+	}{{"base", baseData, &base}, {"ours", oursData, &ours}, {"theirs", theirsData, &theirs}} {
+		if len(strings.TrimSpace(string(doc.data))) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(doc.data, doc.out); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s JSON document: %w", doc.name, err)
+		}
+	}
+	baseSummaries, _ := jsonDocSummaries(&base)
+	oursSummaries, ourEntries := jsonDocSummaries(&ours)
+	theirsSummaries, theirEntries := jsonDocSummaries(&theirs)
+	merged, both := mergeSummaries(baseSummaries, oursSummaries, theirsSummaries, ours.GeneratedAt > theirs.GeneratedAt)
+
+	ours.Directories = make(map[string][]JSONFileEntry)
+	for rel, summary := range merged {
+		entry, ok := ourEntries[rel]
+		if !ok || entry.Summary != summary {
+			entry = theirEntries[rel]
+		}
+		dir := filepath.Dir(rel) + "/"
+		ours.Directories[dir] = append(ours.Directories[dir], entry)
+	}
+	for _, entries := range ours.Directories {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].File < entries[j].File })
+	}
+	if theirs.GeneratedAt > ours.GeneratedAt {
+		ours.GeneratedAt, ours.Model = theirs.GeneratedAt, theirs.Model
+	}
+	data, err := json.MarshalIndent(ours, "", "  ")
+	return data, both, err
+}
+
+// runMergeDriver merges the document versions git passes to a merge driver, writing the
+// result over ours. Only entries are merged; the rest of the document, including its
+// statistics and inputs checksum, is ours until the next run.
+func runMergeDriver(basePath, oursPath, theirsPath string) error {
+	setupLogging()
+	var data [3][]byte
+	for i, path := range []string{basePath, oursPath, theirsPath} {
+		var err error
+		if data[i], err = os.ReadFile(path); err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+	}
+
+	var out []byte
+	var both []string
+	switch detectDocKind(data[1]) {
+	case docKindHTML, docKindAsciiDoc:
+		return fmt.Errorf("merge-driver supports markdown, GitHub wiki, and JSON documents; regenerate the document after resolving the conflict")
+	case docKindJSON:
+		var err error
+		if out, both, err = mergeJSONDoc(data[0], data[1], data[2]); err != nil {
+			return err
+		}
+	default:
+		var lines [3][]string
+		var summaries [3]map[string]string
+		for i := range data {
+			lines[i] = strings.Split(string(data[i]), "\n")
+			summaries[i] = make(map[string]string)
+			parseSummaryLines(lines[i], summaries[i])
+		}
+		var merged map[string]string
+		merged, both = mergeSummaries(summaries[0], summaries[1], summaries[2], false)
+		out = []byte(strings.Join(mergeMarkdownDoc(parseDocLines(lines[1]), parseDocLines(lines[2]), summaries[1], merged), "\n"))
+	}
+
+	if err := os.WriteFile(oursPath, out, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", oursPath, err)
+	}
+	for _, rel := range both {
+		porcelainf("merged", "path", filepath.ToSlash(rel))
+		statusf("Both sides changed the summary of %s; kept the newer one\n", filepath.ToSlash(rel))
+	}
+	return nil
+}
+
+// mergeDriverCmd merges two versions of the document for git.
+var mergeDriverCmd = &cobra.Command{
+	Use:   "merge-driver <base> <ours> <theirs>",
+	Short: "Merge two versions of the document as a git merge driver",
+	Long: `Merge the entries of two versions of the document with their common ancestor, so
+branches that both regenerated it merge without conflicts. Entries added on either side
+are kept, entries removed on one side are dropped unless the other side changed them,
+and an entry changed on both sides takes the newer summary: the one of the document
+generated last for JSON, and theirs (the branch being merged in) otherwise. The result
+is written over <ours>. Register it in .gitattributes and the git config:
+
+  echo 'yaml_details.md merge=yaml-to-readme' >> .gitattributes
+  git config merge.yaml-to-readme.driver 'summarize-yaml merge-driver %O %A %B'`,
+	Args:         cobra.ExactArgs(3),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMergeDriver(args[0], args[1], args[2])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mergeDriverCmd)
+}
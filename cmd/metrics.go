@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// dirCount is how many YAML files a directory holds.
+type dirCount struct {
+	Directory string `json:"directory"`
+	Files     int    `json:"files"`
+}
+
+// repoMetrics is the composition of a directory exported by the metrics command.
+type repoMetrics struct {
+	Time string `json:"time"`
+	Dir  string `json:"dir"`
+	// Files is the number of YAML files that would be documented.
+	Files int `json:"files"`
+	// Documented is the number of those files with a summary in the document.
+	Documented int `json:"documented"`
+	// Stale is the number of files without a summary or changed since the document was
+	// written.
+	Stale       int         `json:"stale"`
+	Directories []dirCount  `json:"directories"`
+	Kinds       []kindCount `json:"kinds"`
+}
+
+// computeRepoMetrics counts the YAML files of dir by directory and kind, and compares
+// them with the document. No LLM calls are made.
+func computeRepoMetrics(dir string) (*repoMetrics, error) {
+	yamlFiles, err := findYAMLFiles(dir, includeHidden)
+	if err != nil {
+		return nil, err
+	}
+	yamlFiles, _ = partitionGenerated(dir, yamlFiles)
+
+	docPath := docPathFor(dir)
+	existing := parseExistingSummaries(docPath)
+	var docTime time.Time
+	if info, err := os.Stat(docPath); err == nil {
+		docTime = info.ModTime()
+	}
+
+	m := &repoMetrics{
+		Time:        time.Now().UTC().Format(time.RFC3339),
+		Dir:         dir,
+		Files:       len(yamlFiles),
+		Directories: []dirCount{},
+		Kinds:       []kindCount{},
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		m.Dir = abs
+	}
+	perDir := make(map[string]int)
+	for _, file := range yamlFiles {
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return nil, err
+		}
+		perDir[filepath.ToSlash(filepath.Dir(rel))]++
+		if existing[filepath.ToSlash(rel)] == "" {
+			m.Stale++
+			continue
+		}
+		m.Documented++
+		if info, err := os.Stat(file); err == nil && info.ModTime().After(docTime) {
+			m.Stale++
+		}
+	}
+	for d, n := range perDir {
+		m.Directories = append(m.Directories, dirCount{Directory: d, Files: n})
+	}
+	sort.Slice(m.Directories, func(i, j int) bool {
+		return m.Directories[i].Directory < m.Directories[j].Directory
+	})
+	if kinds := computeDocStats(dir, groupSummariesByDir(yamlFiles, nil, dir)).Kinds; kinds != nil {
+		m.Kinds = kinds
+	}
+	return m, nil
+}
+
+// promLabelValue escapes a Prometheus label value.
+func promLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// writeMetricsPrometheus writes m in the Prometheus text exposition format, for the
+// node_exporter textfile collector or a Pushgateway.
+func writeMetricsPrometheus(w io.Writer, m *repoMetrics) error {
+	dir := promLabelValue(m.Dir)
+	var sb strings.Builder
+	gauge := func(name, help string) {
+		fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	}
+	gauge("yaml_to_readme_files", "YAML files that would be documented.")
+	fmt.Fprintf(&sb, "yaml_to_readme_files{dir=\"%s\"} %d\n", dir, m.Files)
+	gauge("yaml_to_readme_documented_files", "YAML files with a summary in the document.")
+	fmt.Fprintf(&sb, "yaml_to_readme_documented_files{dir=\"%s\"} %d\n", dir, m.Documented)
+	gauge("yaml_to_readme_stale_summaries", "YAML files without a summary or changed since the document was written.")
+	fmt.Fprintf(&sb, "yaml_to_readme_stale_summaries{dir=\"%s\"} %d\n", dir, m.Stale)
+	gauge("yaml_to_readme_directory_files", "YAML files per directory.")
+	for _, d := range m.Directories {
+		fmt.Fprintf(&sb, "yaml_to_readme_directory_files{dir=\"%s\",directory=\"%s\"} %d\n", dir, promLabelValue(d.Directory), d.Files)
+	}
+	gauge("yaml_to_readme_kind_resources", "Resources declared per kind.")
+	for _, k := range m.Kinds {
+		fmt.Fprintf(&sb, "yaml_to_readme_kind_resources{dir=\"%s\",kind=\"%s\"} %d\n", dir, promLabelValue(k.Kind), k.Count)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeMetricsJSON writes m as one JSON object, for Grafana's JSON and Infinity data
+// sources.
+func writeMetricsJSON(w io.Writer, m *repoMetrics) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// metricsWriters maps each metrics --format to its writer.
+var metricsWriters = map[string]func(io.Writer, *repoMetrics) error{
+	"prometheus": writeMetricsPrometheus,
+	"json":       writeMetricsJSON,
+}
+
+// appendMetricsHistory appends m as one JSON line to path, so stale counts and sprawl
+// can be charted over time.
+func appendMetricsHistory(path string, m *repoMetrics) error {
+	line, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open metrics history %s: %w", path, err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write metrics history %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// writeMetricsFile writes the metrics to path through a temporary file, so collectors
+// reading it never see a partial file.
+func writeMetricsFile(path string, write func(io.Writer, *repoMetrics) error, m *repoMetrics) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if err := write(tmp, m); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// runMetrics is the main logic for the metrics command.
+func runMetrics(w io.Writer, dir string) error {
+	setupLogging()
+	write, ok := metricsWriters[metricsFormat]
+	if !ok {
+		return fmt.Errorf("unsupported --format %q, expected json or prometheus", metricsFormat)
+	}
+	m, err := computeRepoMetrics(dir)
+	if err != nil {
+		return err
+	}
+	if metricsHistory != "" {
+		if err := appendMetricsHistory(metricsHistory, m); err != nil {
+			return err
+		}
+	}
+	if metricsFile == "" || metricsFile == "-" {
+		return write(w, m)
+	}
+	return writeMetricsFile(metricsFile, write, m)
+}
+
+// metricsCmd exports the composition of a directory for dashboards.
+var metricsCmd = &cobra.Command{
+	Use:   "metrics [directory]",
+	Short: "Export file, kind, and stale summary counts for Grafana dashboards",
+	Long: `Count the YAML files of a directory per directory and resource kind, and how many
+have no summary in the document or changed since it was written, without calling the
+LLM. The default Prometheus text format suits the node_exporter textfile collector or a
+Pushgateway; --format json suits Grafana's JSON and Infinity data sources. --history
+appends one JSON line per run, so stale counts can be charted over time.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMetrics(cmd.OutOrStdout(), args[0])
+	},
+}
+
+var metricsFormat string
+var metricsFile string
+var metricsHistory string
+
+func init() {
+	rootCmd.AddCommand(metricsCmd)
+	metricsCmd.Flags().StringVar(&metricsFormat, "format", "prometheus", "Metrics format: prometheus or json")
+	metricsCmd.Flags().StringVar(&metricsFile, "file", "", "File to write atomically instead of stdout")
+	metricsCmd.Flags().StringVar(&metricsHistory, "history", "", "JSON lines file to append this run's metrics to")
+}
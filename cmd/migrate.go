@@ -0,0 +1,415 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// docSchemaPattern matches the schema marker comment in markdown and AsciiDoc documents.
+var docSchemaPattern = regexp.MustCompile(`(?:<!--|//) yaml-to-readme schema:(\d+)`)
+
+// htmlSchemaPattern matches the schema meta tag in HTML documents.
+var htmlSchemaPattern = regexp.MustCompile(`<meta name="yaml-to-readme-schema" content="(\d+)">`)
+
+// docKind identifies the serialization of a generated document.
+type docKind int
+
+const (
+	docKindMarkdown docKind = iota
+	docKindJSON
+	docKindHTML
+	docKindAsciiDoc
+)
+
+// unknownSourceDigest is recorded by migrations for an entry whose file was modified
+// after the document was written. No content has it, so the entry is summarized again
+// and verify reports the document out of date until then.
+const unknownSourceDigest = "unknown"
+
+// migrationContext describes the document a migration upgrades.
+type migrationContext struct {
+	Kind docKind
+	// BaseDir is the documented directory.
+	BaseDir string
+	// Written is when the document was last written.
+	Written time.Time
+}
+
+// docMigration upgrades a document from one schema version to the next. Apply
+// receives the document content and returns the upgraded content.
+type docMigration struct {
+	From        int
+	Description string
+	Apply       func(mc migrationContext, data []byte) ([]byte, error)
+}
+
+// docMigrations lists every schema upgrade in order. Each entry upgrades From to From+1.
+var docMigrations = []docMigration{
+	{
+		From:        1,
+		Description: "record the schema version in the document",
+		Apply:       migrateAddSchemaMarker,
+	},
+	{
+		From:        2,
+		Description: "record the content hash of every entry's file and the inputs checksum",
+		Apply:       migrateRecordSources,
+	},
+}
+
+// detectDocKind determines whether a document is markdown, JSON, HTML, or AsciiDoc.
+func detectDocKind(data []byte) docKind {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return docKindJSON
+	case bytes.HasPrefix(trimmed, []byte("<!DOCTYPE html")):
+		return docKindHTML
+	case bytes.HasPrefix(trimmed, []byte("= ")):
+		return docKindAsciiDoc
+	default:
+		return docKindMarkdown
+	}
+}
+
+// detectDocSchema returns the schema version of a document. Documents written before
+// versioning was introduced carry no marker and are version 1.
+func detectDocSchema(kind docKind, data []byte) (int, error) {
+	switch kind {
+	case docKindJSON:
+		var versioned struct {
+			SchemaVersion int `json:"schema_version"`
+		}
+		if err := json.Unmarshal(data, &versioned); err != nil {
+			return 0, fmt.Errorf("failed to parse JSON document: %w", err)
+		}
+		return max(versioned.SchemaVersion, 1), nil
+	case docKindHTML:
+		if m := htmlSchemaPattern.FindSubmatch(data); m != nil {
+			return strconv.Atoi(string(m[1]))
+		}
+	default:
+		if m := docSchemaPattern.FindSubmatch(data); m != nil {
+			return strconv.Atoi(string(m[1]))
+		}
+	}
+	return 1, nil
+}
+
+// migrateAddSchemaMarker upgrades a version 1 document to version 2 by adding the
+// schema marker, leaving all entries untouched.
+func migrateAddSchemaMarker(mc migrationContext, data []byte) ([]byte, error) {
+	switch mc.Kind {
+	case docKindJSON:
+		var output JSONOutput
+		if err := json.Unmarshal(data, &output); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON document: %w", err)
+		}
+		output.SchemaVersion = 2
+		return json.MarshalIndent(output, "", "  ")
+	case docKindHTML:
+		viewport := `<meta name="viewport" content="width=device-width, initial-scale=1.0">`
+		if !bytes.Contains(data, []byte(viewport)) {
+			return nil, fmt.Errorf("unrecognized HTML document structure")
+		}
+		return bytes.Replace(data, []byte(viewport), []byte(viewport+"\n"+`<meta name="yaml-to-readme-schema" content="2">`), 1), nil
+	default:
+		// Insert the marker where writeMarkdownSummary puts it: after the header, right
+		// before the first directory section.
+		lines := strings.SplitAfter(string(data), "\n")
+		insertAt := len(lines)
+		for i, line := range lines {
+			if strings.HasPrefix(line, "## ") && strings.TrimSpace(line) != "## How to Use" {
+				insertAt = i
+				break
+			}
+		}
+		// Keep the blank line that separates the header from the first section
+		if insertAt > 0 && strings.TrimSpace(lines[insertAt-1]) == "" {
+			insertAt--
+		}
+		marker := "<!-- yaml-to-readme schema:2 -->\n"
+		out := strings.Join(lines[:insertAt], "") + marker + strings.Join(lines[insertAt:], "")
+		return []byte(out), nil
+	}
+}
+
+// migratedDigest returns the digest a migration records for the entry of file in a
+// document written at written: that of the file's current content if the file was not
+// modified since, or unknownSourceDigest if it was.
+func migratedDigest(file string, written time.Time) string {
+	info, err := os.Stat(file)
+	if err != nil {
+		return "missing"
+	}
+	if info.ModTime().After(written) {
+		return unknownSourceDigest
+	}
+	return currentDigest(file)
+}
+
+// migratedFileDigests returns the digests a migration records for the YAML files that
+// would be documented in the base directory, for documents whose entries it cannot read
+// back, keyed by slash-separated path.
+func migratedFileDigests(mc migrationContext) (map[string]string, error) {
+	yamlFiles, err := findYAMLFiles(mc.BaseDir, includeHidden)
+	if err != nil {
+		return nil, err
+	}
+	yamlFiles, _ = partitionGenerated(mc.BaseDir, yamlFiles)
+	digests := make(map[string]string, len(yamlFiles))
+	for _, file := range yamlFiles {
+		rel, err := filepath.Rel(mc.BaseDir, file)
+		if err != nil {
+			return nil, err
+		}
+		digests[filepath.ToSlash(rel)] = migratedDigest(file, mc.Written)
+	}
+	return digests, nil
+}
+
+// migrateRecordSources upgrades a version 2 document to version 3 by recording the
+// content hash of every entry's file and the inputs checksum, as verify, check, and
+// later runs expect. Files modified after the document was written are recorded as
+// unknown, so their entries are summarized again. Documents written with the hashes
+// before the version was bumped only get the new version.
+func migrateRecordSources(mc migrationContext, data []byte) ([]byte, error) {
+	switch mc.Kind {
+	case docKindJSON:
+		var output JSONOutput
+		if err := json.Unmarshal(data, &output); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON document: %w", err)
+		}
+		sources := make(map[string]string)
+		for _, entries := range output.Directories {
+			for i := range entries {
+				entry := &entries[i]
+				file := filepath.Join(mc.BaseDir, entry.Path)
+				if entry.Hash == "" {
+					if digest := migratedDigest(file, mc.Written); digest != "missing" {
+						entry.Hash = "sha256:" + digest
+					}
+					if info, err := os.Stat(file); err == nil && entry.ModifiedAt == "" {
+						entry.ModifiedAt = info.ModTime().UTC().Format(time.RFC3339)
+					}
+				}
+				digest := strings.TrimPrefix(entry.Hash, "sha256:")
+				if digest == "" {
+					digest = "missing"
+				}
+				sources[filepath.ToSlash(filepath.Clean(entry.Path))] = digest
+			}
+		}
+		if output.InputsSHA256 == "" {
+			output.InputsSHA256 = digestsChecksum(sources)
+		}
+		output.SchemaVersion = 3
+		return json.MarshalIndent(output, "", "  ")
+	case docKindHTML:
+		data = htmlSchemaPattern.ReplaceAll(data, []byte(`<meta name="yaml-to-readme-schema" content="3">`))
+		if inputsChecksumPattern.Match(data) {
+			return data, nil
+		}
+		digests, err := migratedFileDigests(mc)
+		if err != nil {
+			return nil, err
+		}
+		schemaMeta := `<meta name="yaml-to-readme-schema" content="3">`
+		inputsMeta := fmt.Sprintf(`<meta name="yaml-to-readme-inputs" content="sha256:%s">`, digestsChecksum(digests))
+		return bytes.Replace(data, []byte(schemaMeta), []byte(schemaMeta+"\n"+inputsMeta), 1), nil
+	case docKindAsciiDoc:
+		data = docSchemaPattern.ReplaceAll(data, []byte("// yaml-to-readme schema:3"))
+		if inputsChecksumPattern.Match(data) {
+			return data, nil
+		}
+		digests, err := migratedFileDigests(mc)
+		if err != nil {
+			return nil, err
+		}
+		return fmt.Appendf(bytes.TrimRight(data, "\n"), "\n\n// yaml-to-readme-inputs sha256:%s\n", digestsChecksum(digests)), nil
+	default:
+		data = docSchemaPattern.ReplaceAll(data, []byte("<!-- yaml-to-readme schema:3"))
+		if inputsChecksumPattern.Match(data) || entryKeysChecksum(data) != "" {
+			return data, nil
+		}
+		// The footer goes at the end of the document, or of the section between the
+		// --inject markers
+		before, section, after, err := splitInjected(data)
+		if err != nil {
+			before, section, after = nil, data, nil
+		}
+		existing := make(map[string]string)
+		parseSummaryLines(strings.Split(string(section), "\n"), existing)
+		digests := make(map[string]string, len(existing))
+		for rel := range existing {
+			digests[filepath.ToSlash(rel)] = migratedDigest(filepath.Join(mc.BaseDir, rel), mc.Written)
+		}
+		section = append(bytes.TrimRight(section, "\n"), '\n')
+		section = append(section, formatChecksumFooter(digests)...)
+		return slices.Concat(before, section, after), nil
+	}
+}
+
+// migrateDoc upgrades the document of baseDir, last written at written, to
+// DocSchemaVersion, returning the upgraded content and the descriptions of the
+// migrations applied.
+func migrateDoc(data []byte, baseDir string, written time.Time) ([]byte, []string, error) {
+	kind := detectDocKind(data)
+	mc := migrationContext{Kind: kind, BaseDir: baseDir, Written: written}
+	version, err := detectDocSchema(kind, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if version > DocSchemaVersion {
+		return nil, nil, fmt.Errorf("document schema %d is newer than this tool supports (%d)", version, DocSchemaVersion)
+	}
+
+	var applied []string
+	for _, m := range docMigrations {
+		if m.From < version {
+			continue
+		}
+		data, err = m.Apply(mc, data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("migration from schema %d failed: %w", m.From, err)
+		}
+		applied = append(applied, fmt.Sprintf("schema %d -> %d: %s", m.From, m.From+1, m.Description))
+		version = m.From + 1
+	}
+	return data, applied, nil
+}
+
+// unkeyedCacheEntries returns the YAML files in dir whose --localcache entry was written
+// before entries were keyed with the content hash of their file, and so is never
+// reused. Files modified after their entry was written are left out: their entry is
+// regenerated instead.
+func unkeyedCacheEntries(dir string) ([]string, error) {
+	yamlFiles, err := findYAMLFiles(dir, includeHidden)
+	if err != nil {
+		return nil, err
+	}
+	repoRoot := cacheRoot()
+	var unkeyed []string
+	for _, file := range yamlFiles {
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return nil, err
+		}
+		entryPath := cacheEntryPath(repoRoot, rel)
+		data, err := os.ReadFile(entryPath)
+		if err != nil || entryKeyPattern.Match(data) {
+			continue
+		}
+		entryInfo, err := os.Stat(entryPath)
+		if err != nil {
+			continue
+		}
+		if info, err := os.Stat(file); err == nil && !info.ModTime().After(entryInfo.ModTime()) {
+			unkeyed = append(unkeyed, file)
+		}
+	}
+	return unkeyed, nil
+}
+
+// keyCacheEntries rewrites the --localcache entries of files with the key of their
+// file's current content.
+func keyCacheEntries(dir string, files []string) error {
+	repoRoot := cacheRoot()
+	for _, file := range files {
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(cacheEntryPath(repoRoot, rel))
+		if err != nil {
+			return err
+		}
+		if err := writeIndividualSummary(repoRoot, dir, file, strings.TrimSpace(string(data))); err != nil {
+			return fmt.Errorf("failed to key cache entry for %s: %w", rel, err)
+		}
+	}
+	return nil
+}
+
+// runMigrate is the main logic for the migrate command.
+func runMigrate(dir string) error {
+	setupLogging()
+	docPath := docPathFor(dir)
+	data, err := os.ReadFile(docPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", docPath, err)
+	}
+	info, err := os.Stat(docPath)
+	if err != nil {
+		return err
+	}
+
+	migrated, applied, err := migrateDoc(data, dir, info.ModTime())
+	if err != nil {
+		return fmt.Errorf("failed to migrate %s: %w", docPath, err)
+	}
+	unkeyed, err := unkeyedCacheEntries(dir)
+	if err != nil {
+		return err
+	}
+	if len(unkeyed) > 0 {
+		applied = append(applied, fmt.Sprintf("cache: key %d --localcache entries with the content hash of their file", len(unkeyed)))
+	}
+	if len(applied) == 0 {
+		porcelainf("migrate", "status", "up-to-date", "path", docPath, "schema", DocSchemaVersion)
+		statusf("%s is already at schema %d\n", docPath, DocSchemaVersion)
+		return nil
+	}
+	for _, step := range applied {
+		porcelainf("migration", "step", step)
+		statusf("  %s\n", step)
+	}
+	if migrateCheckOnly {
+		porcelainf("migrate", "status", "pending", "path", docPath, "migrations", len(applied))
+		return fmt.Errorf("%s needs %d migration(s) to reach schema %d", docPath, len(applied), DocSchemaVersion)
+	}
+	if !bytes.Equal(migrated, data) {
+		if err := os.WriteFile(docPath, migrated, 0o644); err != nil {
+			return err
+		}
+	}
+	if err := keyCacheEntries(dir, unkeyed); err != nil {
+		return err
+	}
+	porcelainf("migrate", "status", "migrated", "path", docPath, "schema", DocSchemaVersion, "migrations", len(applied))
+	statusf("Migrated %s to schema %d\n", docPath, DocSchemaVersion)
+	return nil
+}
+
+// migrateCmd upgrades an existing document to the current schema version in place.
+var migrateCmd = &cobra.Command{
+	Use:   "migrate [directory]",
+	Short: "Upgrade an existing document to the current schema version in place",
+	Long: `Upgrade an existing markdown, JSON, HTML, or AsciiDoc document to the current schema
+version without regenerating any summaries. Migrations are applied one version at a
+time. Entries of files modified after the document was written are marked so they are
+summarized again. --localcache entries written without a content hash are keyed with
+that of their file, unless it was modified after the entry was written.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMigrate(args[0])
+	},
+}
+
+var migrateCheckOnly bool
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+	migrateCmd.Flags().BoolVar(&migrateCheckOnly, "check", false, "Only report pending migrations and exit non-zero if any are needed")
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"hash/fnv"
 	"strings"
 
 	ollama "github.com/ollama/ollama/api"
@@ -30,8 +31,8 @@ func NewMockOllamaClient() *MockOllamaClient {
 func (m *MockOllamaClient) Chat(ctx context.Context, req *ollama.ChatRequest, fn func(ollama.ChatResponse) error) error {
 	// Extract the YAML content from the request
 	var content string
-	if len(req.Messages) > 0 {
-		content = req.Messages[0].Content
+	for _, msg := range req.Messages {
+		content += msg.Content
 	}
 
 	// Find a matching mock response based on content
@@ -64,3 +65,20 @@ func (m *MockOllamaClient) List(ctx context.Context) (*ollama.ListResponse, erro
 		Models: models,
 	}, nil
 }
+
+// Embed implements OllamaClient.Embed for the mock. Each input is embedded as a hashed
+// bag of its words, so texts sharing words are similar.
+func (m *MockOllamaClient) Embed(ctx context.Context, req *ollama.EmbedRequest) (*ollama.EmbedResponse, error) {
+	inputs, _ := req.Input.([]string)
+	resp := &ollama.EmbedResponse{Model: req.Model}
+	for _, input := range inputs {
+		vector := make([]float32, 64)
+		for _, word := range strings.Fields(strings.ToLower(input)) {
+			h := fnv.New32a()
+			_, _ = h.Write([]byte(strings.Trim(word, ".,:;?!()[]`\"'")))
+			vector[h.Sum32()%64]++
+		}
+		resp.Embeddings = append(resp.Embeddings, vector)
+	}
+	return resp, nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// modelAliasName is the alias selected with --model-alias or the config file's model_alias.
+var modelAliasName string
+
+// modelAlias maps provider names to the model an alias stands for. The empty key holds
+// the model used for providers without their own entry.
+type modelAlias map[string]string
+
+// UnmarshalYAML accepts either a single model name for every provider or a mapping of
+// provider names to models.
+func (a *modelAlias) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*a = modelAlias{"": node.Value}
+		return nil
+	}
+	var models map[string]string
+	if err := node.Decode(&models); err != nil {
+		return err
+	}
+	*a = models
+	return nil
+}
+
+// model returns the model the alias stands for with providerName.
+func (a modelAlias) model(providerName string) (string, bool) {
+	if model, ok := a[providerName]; ok && model != "" {
+		return model, true
+	}
+	model, ok := a[""]
+	return model, ok && model != ""
+}
+
+// resolveModelAlias sets ModelName from SUMMARIZE_MODEL or the selected alias. An explicit
+// --model wins over an alias from the config file, but cannot be combined with
+// --model-alias. SUMMARIZE_MODEL is used when neither flag is given, and also wins over
+// the config file's model_alias.
+func resolveModelAlias(cmd *cobra.Command, aliases map[string]modelAlias) error {
+	flags := cmd.Flags()
+	if env := os.Getenv(ModelEnvVar); env != "" && !flags.Changed("model") && !flags.Changed("model-alias") {
+		ModelName = env
+		return nil
+	}
+	if modelAliasName == "" {
+		return nil
+	}
+	if flags.Changed("model") {
+		if flags.Changed("model-alias") {
+			return fmt.Errorf("--model and --model-alias cannot be used together")
+		}
+		return nil
+	}
+	alias, ok := aliases[modelAliasName]
+	if !ok {
+		if len(aliases) == 0 {
+			return fmt.Errorf("unknown model alias %q: no model_aliases are defined in the config file", modelAliasName)
+		}
+		return fmt.Errorf("unknown model alias %q, expected one of: %s", modelAliasName, strings.Join(slices.Sorted(maps.Keys(aliases)), ", "))
+	}
+	model, ok := alias.model(provider)
+	if !ok {
+		return fmt.Errorf("model alias %q has no model for provider %s", modelAliasName, provider)
+	}
+	ModelName = model
+	return nil
+}
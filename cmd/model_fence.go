@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// ollamaConcurrentModels lets Ollama requests for different models run at the same time,
+// for servers with the memory to keep all of them loaded.
+var ollamaConcurrentModels bool
+
+// modelFence serializes Ollama requests by model. Requests for the model in use run in
+// parallel; a request for another model waits until they finish, so the server never has
+// to swap models in and out of VRAM between concurrent requests. Once a request for
+// another model is waiting, new requests for the model in use wait behind it, so no
+// model is starved.
+type modelFence struct {
+	mu sync.Mutex
+	// model is the model of the requests running, or last run.
+	model string
+	// active is how many requests for model are running.
+	active int
+	// waiting is how many requests for another model wait for model's requests to
+	// finish.
+	waiting int
+	// changed is closed, and replaced, when a waiting request may be able to run.
+	changed chan struct{}
+}
+
+// newModelFence returns a fence with no model in use.
+func newModelFence() *modelFence {
+	return &modelFence{changed: make(chan struct{})}
+}
+
+// enter blocks until a request for model may run, or ctx is done, in which case it
+// returns ctx's error. Every enter that returns nil must be followed by a leave. A nil
+// fence, or --ollama-concurrent-models, lets every request run.
+func (f *modelFence) enter(ctx context.Context, model string) error {
+	if f == nil || ollamaConcurrentModels {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for {
+		if f.active == 0 {
+			if f.model != "" && f.model != model {
+				slog.Debug("switching ollama model", "from", f.model, "to", model)
+			}
+			f.model = model
+			break
+		}
+		if f.model == model && f.waiting == 0 {
+			break
+		}
+		other := f.model != model
+		if other {
+			f.waiting++
+		}
+		changed := f.changed
+		f.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+		}
+		f.mu.Lock()
+		if other {
+			f.waiting--
+		}
+		if err := ctx.Err(); err != nil {
+			// Requests held back only by this one may run now
+			if other && f.waiting == 0 {
+				f.wake()
+			}
+			return err
+		}
+	}
+	f.active++
+	return nil
+}
+
+// leave marks a request let in by enter as finished.
+func (f *modelFence) leave() {
+	if f == nil || ollamaConcurrentModels {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.active--
+	if f.active == 0 {
+		f.wake()
+	}
+}
+
+// wake lets every waiting request check again whether it may run. f.mu must be held.
+func (f *modelFence) wake() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// routeKinds are the Gateway API route kinds listed in the network surface appendix.
+var routeKinds = map[string]bool{"HTTPRoute": true, "GRPCRoute": true, "TCPRoute": true, "TLSRoute": true, "UDPRoute": true}
+
+// stringField returns the string value at key, or the number formatted as a string.
+func stringField(m map[string]any, key string) string {
+	switch v := m[key].(type) {
+	case string:
+		return v
+	case int, float64:
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// mapSlice returns the mappings in the list at key.
+func mapSlice(m map[string]any, key string) []map[string]any {
+	items, _ := m[key].([]any)
+	var result []map[string]any
+	for _, item := range items {
+		if mm, ok := item.(map[string]any); ok {
+			result = append(result, mm)
+		}
+	}
+	return result
+}
+
+// qualifiedName returns "namespace/name", or just the name without a namespace.
+func qualifiedName(doc map[string]any) string {
+	_, namespace, name := resourceIdentity(doc)
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+// servicePorts describes the ports a Service exposes.
+func servicePorts(doc map[string]any) []string {
+	spec, _ := doc["spec"].(map[string]any)
+	var ports []string
+	for _, p := range mapSlice(spec, "ports") {
+		protocol := stringField(p, "protocol")
+		if protocol == "" {
+			protocol = "TCP"
+		}
+		port := stringField(p, "port") + "/" + protocol
+		if target := stringField(p, "targetPort"); target != "" && target != stringField(p, "port") {
+			port += " → " + target
+		}
+		if nodePort := stringField(p, "nodePort"); nodePort != "" {
+			port += " (nodePort " + nodePort + ")"
+		}
+		ports = append(ports, port)
+	}
+	if len(ports) == 0 {
+		return nil
+	}
+	note := fmt.Sprintf("Service %s: %s", qualifiedName(doc), strings.Join(ports, ", "))
+	if svcType := stringField(spec, "type"); svcType != "" && svcType != "ClusterIP" {
+		note += " (" + svcType + ")"
+	}
+	return []string{note}
+}
+
+// ingressBackend renders an Ingress backend as service:port.
+func ingressBackend(backend map[string]any) string {
+	service, _ := backend["service"].(map[string]any)
+	port, _ := service["port"].(map[string]any)
+	target := stringField(port, "number")
+	if target == "" {
+		target = stringField(port, "name")
+	}
+	name := stringField(service, "name")
+	if name == "" {
+		// networking.k8s.io/v1beta1
+		name, target = stringField(backend, "serviceName"), stringField(backend, "servicePort")
+	}
+	if target == "" {
+		return name
+	}
+	return name + ":" + target
+}
+
+// ingressRoutes describes the host and path routes of an Ingress.
+func ingressRoutes(doc map[string]any) []string {
+	spec, _ := doc["spec"].(map[string]any)
+	var routes []string
+	for _, rule := range mapSlice(spec, "rules") {
+		host := stringField(rule, "host")
+		if host == "" {
+			host = "*"
+		}
+		http, _ := rule["http"].(map[string]any)
+		for _, p := range mapSlice(http, "paths") {
+			backend, _ := p["backend"].(map[string]any)
+			path := stringField(p, "path")
+			if path == "" {
+				path = "/"
+			}
+			routes = append(routes, fmt.Sprintf("Ingress %s: %s%s → %s", qualifiedName(doc), host, path, ingressBackend(backend)))
+		}
+	}
+	if defaultBackend, ok := spec["defaultBackend"].(map[string]any); ok {
+		routes = append(routes, fmt.Sprintf("Ingress %s: default → %s", qualifiedName(doc), ingressBackend(defaultBackend)))
+	}
+	return routes
+}
+
+// gatewayListeners describes the listeners of a Gateway.
+func gatewayListeners(doc map[string]any) []string {
+	spec, _ := doc["spec"].(map[string]any)
+	var listeners []string
+	for _, l := range mapSlice(spec, "listeners") {
+		listener := stringField(l, "port") + "/" + stringField(l, "protocol")
+		if host := stringField(l, "hostname"); host != "" {
+			listener += " " + host
+		}
+		listeners = append(listeners, fmt.Sprintf("Gateway %s: listener %s %s", qualifiedName(doc), stringField(l, "name"), listener))
+	}
+	return listeners
+}
+
+// gatewayRoutes describes the hostnames, path matches, and backends of a Gateway API route.
+func gatewayRoutes(doc map[string]any) []string {
+	kind, _, _ := resourceIdentity(doc)
+	spec, _ := doc["spec"].(map[string]any)
+	hosts := "*"
+	if hostnames, ok := spec["hostnames"].([]any); ok && len(hostnames) > 0 {
+		var names []string
+		for _, h := range hostnames {
+			names = append(names, fmt.Sprint(h))
+		}
+		hosts = strings.Join(names, ",")
+	}
+	var routes []string
+	for _, rule := range mapSlice(spec, "rules") {
+		var backends []string
+		for _, ref := range mapSlice(rule, "backendRefs") {
+			backend := stringField(ref, "name")
+			if port := stringField(ref, "port"); port != "" {
+				backend += ":" + port
+			}
+			backends = append(backends, backend)
+		}
+		paths := []string{""}
+		if matches := mapSlice(rule, "matches"); len(matches) > 0 {
+			paths = nil
+			for _, match := range matches {
+				path, _ := match["path"].(map[string]any)
+				paths = append(paths, stringField(path, "value"))
+			}
+		}
+		for _, path := range paths {
+			routes = append(routes, fmt.Sprintf("%s %s: %s%s → %s", kind, qualifiedName(doc), hosts, path, strings.Join(backends, ", ")))
+		}
+	}
+	return routes
+}
+
+// networkSurface returns the network-facing descriptions of one manifest.
+func networkSurface(doc map[string]any) []string {
+	kind, _, name := resourceIdentity(doc)
+	if name == "" {
+		return nil
+	}
+	switch {
+	case kind == "Service":
+		return servicePorts(doc)
+	case kind == "Ingress":
+		return ingressRoutes(doc)
+	case kind == "Gateway":
+		return gatewayListeners(doc)
+	case routeKinds[kind]:
+		return gatewayRoutes(doc)
+	}
+	return nil
+}
+
+// networkAppendix lists the Service ports, Ingress routes, and Gateway listeners and
+// routes declared in files, sorted so the section only changes when the manifests do.
+func networkAppendix(dir string, files []string) []docAppendix {
+	var entries []appendixEntry
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			slog.Warn("skipping network surface", "file", file, "error", err)
+			continue
+		}
+		docs, err := decodeYAMLDocuments(data)
+		if err != nil {
+			slog.Debug("network surface stopped at unparsable document", "file", file, "error", err)
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			rel = file
+		}
+		for _, doc := range docs {
+			for _, note := range networkSurface(doc) {
+				entries = append(entries, appendixEntry{Path: filepath.ToSlash(rel), Note: note})
+			}
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Path != entries[j].Path {
+			return entries[i].Path < entries[j].Path
+		}
+		return entries[i].Note < entries[j].Note
+	})
+	l := currentLabels()
+	return []docAppendix{{Title: l.NetworkSurface, Intro: l.NetworkSurfaceIntro, Entries: entries, Link: true}}
+}
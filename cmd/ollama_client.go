@@ -11,6 +11,7 @@ import (
 type OllamaClient interface {
 	Chat(ctx context.Context, req *ollama.ChatRequest, fn func(ollama.ChatResponse) error) error
 	List(ctx context.Context) (*ollama.ListResponse, error)
+	Embed(ctx context.Context, req *ollama.EmbedRequest) (*ollama.EmbedResponse, error)
 }
 
 // RealOllamaClient is a wrapper around the actual Ollama client that implements OllamaClient.
@@ -36,3 +37,8 @@ func (r *RealOllamaClient) Chat(ctx context.Context, req *ollama.ChatRequest, fn
 func (r *RealOllamaClient) List(ctx context.Context) (*ollama.ListResponse, error) {
 	return r.client.List(ctx)
 }
+
+// Embed implements OllamaClient.Embed
+func (r *RealOllamaClient) Embed(ctx context.Context, req *ollama.EmbedRequest) (*ollama.EmbedResponse, error) {
+	return r.client.Embed(ctx, req)
+}
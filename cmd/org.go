@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const DefaultOrgIndexFileName = "org_inventory.md"
+
+// githubRepo is the subset of the GitHub repository API response used for org crawls.
+type githubRepo struct {
+	Name     string   `json:"name"`
+	CloneURL string   `json:"clone_url"`
+	Archived bool     `json:"archived"`
+	Fork     bool     `json:"fork"`
+	Topics   []string `json:"topics"`
+}
+
+// parseOrgTarget splits an org reference such as "github.com/my-org" or "my-org" into
+// the GitHub API base URL and organization name. GITHUB_API_URL overrides the API URL,
+// which is required for GitHub Enterprise hosts.
+func parseOrgTarget(target string) (string, string, error) {
+	target = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(target, "https://"), "http://"), "/")
+	host, org, found := strings.Cut(target, "/")
+	if !found {
+		host, org = "github.com", target
+	}
+	if org == "" || strings.Contains(org, "/") {
+		return "", "", fmt.Errorf("invalid organization %q, expected github.com/<org> or <org>", target)
+	}
+
+	apiURL := os.Getenv("GITHUB_API_URL")
+	if apiURL == "" {
+		if host == "github.com" {
+			apiURL = "https://api.github.com"
+		} else {
+			apiURL = "https://" + host + "/api/v3"
+		}
+	}
+	return strings.TrimSuffix(apiURL, "/"), org, nil
+}
+
+// listOrgRepos pages through the repositories of a GitHub organization.
+// GITHUB_TOKEN is sent when set, which is required for private repositories.
+func listOrgRepos(ctx context.Context, apiURL, org string) ([]githubRepo, error) {
+	client := &http.Client{}
+	token := os.Getenv("GITHUB_TOKEN")
+
+	var repos []githubRepo
+	for page := 1; ; page++ {
+		url := fmt.Sprintf("%s/orgs/%s/repos?per_page=100&page=%d", apiURL, org, page)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("github API request failed: %w", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("github API returned status %d: %s", resp.StatusCode, string(body))
+		}
+
+		var pageRepos []githubRepo
+		if err := json.Unmarshal(body, &pageRepos); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		repos = append(repos, pageRepos...)
+		if len(pageRepos) < 100 {
+			return repos, nil
+		}
+	}
+}
+
+// githubCloneAuthHeader returns the header that authenticates git clones with
+// GITHUB_TOKEN, so private repositories can be cloned too, or "" when it is unset.
+func githubCloneAuthHeader() string {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		return ""
+	}
+	return "Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte("x-access-token:"+token))
+}
+
+// filterOrgRepos returns the repositories matching the --topic and --match filters,
+// skipping archived repositories and forks unless explicitly included.
+func filterOrgRepos(repos []githubRepo) ([]BatchRepo, error) {
+	var selected []BatchRepo
+	for _, repo := range repos {
+		if repo.Archived && !orgIncludeArchived {
+			continue
+		}
+		if repo.Fork && !orgIncludeForks {
+			continue
+		}
+		if orgTopic != "" && !slices.Contains(repo.Topics, orgTopic) {
+			continue
+		}
+		if orgMatch != "" {
+			ok, err := filepath.Match(orgMatch, repo.Name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid --match pattern %q: %w", orgMatch, err)
+			}
+			if !ok {
+				continue
+			}
+		}
+		selected = append(selected, BatchRepo{Name: repo.Name, URL: repo.CloneURL, authHeader: githubCloneAuthHeader()})
+	}
+	return selected, nil
+}
+
+// runOrg is the main logic for the org command.
+func runOrg(target string) error {
+	setupLogging()
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if err := validateRunFlags(baseDir); err != nil {
+		return err
+	}
+	apiURL, org, err := parseOrgTarget(target)
+	if err != nil {
+		return err
+	}
+	repos, err := listOrgRepos(context.Background(), apiURL, org)
+	if err != nil {
+		return fmt.Errorf("failed to list repositories for %s: %w", org, err)
+	}
+	selected, err := filterOrgRepos(repos)
+	if err != nil {
+		return err
+	}
+	if len(selected) == 0 {
+		return fmt.Errorf("no repositories in %s matched the given filters", org)
+	}
+	statusf("Summarizing %d of %d repositories in %s\n", len(selected), len(repos), org)
+
+	llm, err := createProvider()
+	if err != nil {
+		return fmt.Errorf("failed to create %s provider: %w", provider, err)
+	}
+	return runBatchRepos(selected, baseDir, orgIndexFileName, llm)
+}
+
+// orgCmd crawls a GitHub organization and summarizes the matching repositories.
+var orgCmd = &cobra.Command{
+	Use:   "org [github.com/org]",
+	Short: "Summarize YAML files across the repositories of a GitHub organization",
+	Long: `List the repositories of a GitHub organization via the GitHub API, clone those matching
+the filters into the work directory, summarize each, and write a fleet-wide inventory
+document in the current directory. Set GITHUB_TOKEN to include private repositories:
+it authenticates both the API requests and the clones, passed to git as an HTTP header
+through the environment rather than in the clone URL. Set GITHUB_API_URL for GitHub
+Enterprise.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runOrg(args[0])
+	},
+}
+
+var orgTopic string
+var orgMatch string
+var orgIncludeArchived bool
+var orgIncludeForks bool
+var orgIndexFileName string
+
+func init() {
+	rootCmd.AddCommand(orgCmd)
+	orgCmd.Flags().StringVar(&orgTopic, "topic", "", "Only include repositories tagged with this topic")
+	orgCmd.Flags().StringVar(&orgMatch, "match", "", "Only include repositories whose name matches this glob pattern")
+	orgCmd.Flags().BoolVar(&orgIncludeArchived, "include-archived", false, "Include archived repositories")
+	orgCmd.Flags().BoolVar(&orgIncludeForks, "include-forks", false, "Include forked repositories")
+	orgCmd.Flags().IntVar(&batchConcurrency, "repo-concurrency", 1, "Number of repositories to summarize concurrently")
+	orgCmd.Flags().StringVar(&orgIndexFileName, "index", DefaultOrgIndexFileName, "Fleet-wide inventory filename, written to the current directory")
+	orgCmd.Flags().StringVar(&batchWorkDir, "workdir", DefaultBatchWorkDirName, "Directory where repositories are cloned")
+}
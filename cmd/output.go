@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// statusOut receives human-readable progress, stats, and diagnostics, keeping stdout
+// free for document and JSON output.
+var statusOut io.Writer = os.Stderr
+
+// porcelainOut receives --porcelain status lines.
+var porcelainOut io.Writer = os.Stdout
+
+// statusEnabled reports whether human-readable status messages are printed: not with
+// --porcelain or --quiet.
+func statusEnabled() bool {
+	return !porcelain && !quiet
+}
+
+// statusf prints a human-readable status message. It is silent with --porcelain and
+// --quiet.
+func statusf(format string, args ...any) {
+	if !statusEnabled() {
+		return
+	}
+	_, _ = fmt.Fprintf(statusOut, format, args...)
+}
+
+// porcelainf prints one --porcelain status line: the event name followed by
+// tab-separated key=value fields. The format is stable across releases. When the
+// document is written to stdout with -o -, the lines go to statusOut instead.
+func porcelainf(event string, fields ...any) {
+	if !porcelain {
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString(event)
+	for i := 0; i+1 < len(fields); i += 2 {
+		fmt.Fprintf(&sb, "\t%v=%v", fields[i], fields[i+1])
+	}
+	sb.WriteString("\n")
+	out := porcelainOut
+	if writesToStdout() {
+		out = statusOut
+	}
+	_, _ = io.WriteString(out, sb.String())
+}
@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// This file implements the small subset of Apache Parquet that embeddings export
+// needs: one row group of required UTF-8 string columns and one required list of
+// floats, PLAIN encoded in a single uncompressed data page per column. The file
+// metadata is written with the Thrift compact protocol by hand to avoid a dependency.
+
+// Parquet physical types, repetition types, converted types, and encodings.
+const (
+	parquetFloat     = 4
+	parquetByteArray = 6
+
+	parquetRequired = 0
+	parquetRepeated = 2
+
+	parquetUTF8 = 0
+	parquetList = 3
+
+	parquetPlain = 0
+	parquetRLE   = 3
+)
+
+// parquetMagic starts and ends every Parquet file.
+const parquetMagic = "PAR1"
+
+// parquetStringColumn is a required UTF-8 string column.
+type parquetStringColumn struct {
+	Name   string
+	Values []string
+}
+
+// parquetFloatListColumn is a required list of required floats, one list per row.
+type parquetFloatListColumn struct {
+	Name   string
+	Values [][]float32
+}
+
+// writeParquet writes a Parquet file with the string columns followed by the float list
+// column. Every column must have one value per row, and every list must be non-empty.
+func writeParquet(w io.Writer, rows int, strs []parquetStringColumn, list parquetFloatListColumn) error {
+	var file bytes.Buffer
+	file.WriteString(parquetMagic)
+
+	var chunks []parquetChunk
+	for _, col := range strs {
+		var data bytes.Buffer
+		for _, v := range col.Values {
+			_ = binary.Write(&data, binary.LittleEndian, uint32(len(v)))
+			data.WriteString(v)
+		}
+		chunks = append(chunks, writeParquetPage(&file, []string{col.Name}, parquetByteArray, len(col.Values), data.Bytes()))
+	}
+
+	// Repetition levels start each row at 0; definition levels are all 1 because
+	// every list is non-empty. Both are RLE runs with a bit width of 1.
+	var levels, values bytes.Buffer
+	var reps bytes.Buffer
+	count := 0
+	for _, vector := range list.Values {
+		writeRLERun(&reps, 0, 1)
+		if len(vector) > 1 {
+			writeRLERun(&reps, 1, len(vector)-1)
+		}
+		for _, f := range vector {
+			_ = binary.Write(&values, binary.LittleEndian, math.Float32bits(f))
+		}
+		count += len(vector)
+	}
+	var defs bytes.Buffer
+	writeRLERun(&defs, 1, count)
+	_ = binary.Write(&levels, binary.LittleEndian, uint32(reps.Len()))
+	levels.Write(reps.Bytes())
+	_ = binary.Write(&levels, binary.LittleEndian, uint32(defs.Len()))
+	levels.Write(defs.Bytes())
+	levels.Write(values.Bytes())
+	chunks = append(chunks, writeParquetPage(&file, []string{list.Name, "list", "element"}, parquetFloat, count, levels.Bytes()))
+
+	var meta bytes.Buffer
+	writeParquetMetadata(&meta, rows, strs, list, chunks)
+	file.Write(meta.Bytes())
+	_ = binary.Write(&file, binary.LittleEndian, uint32(meta.Len()))
+	file.WriteString(parquetMagic)
+
+	_, err := w.Write(file.Bytes())
+	return err
+}
+
+// parquetChunk describes one column chunk for the file metadata.
+type parquetChunk struct {
+	path      []string
+	typ       int32
+	numValues int
+	offset    int64
+	size      int64
+}
+
+// writeParquetPage appends a data page header and its data to file.
+func writeParquetPage(file *bytes.Buffer, path []string, typ int32, numValues int, data []byte) parquetChunk {
+	offset := int64(file.Len())
+	t := &thriftWriter{buf: file}
+	t.fieldI32(1, 0) // DATA_PAGE
+	t.fieldI32(2, int32(len(data)))
+	t.fieldI32(3, int32(len(data)))
+	t.fieldStruct(5)
+	t.fieldI32(1, int32(numValues))
+	t.fieldI32(2, parquetPlain)
+	t.fieldI32(3, parquetRLE)
+	t.fieldI32(4, parquetRLE)
+	t.endStruct()
+	t.endStruct()
+	file.Write(data)
+	return parquetChunk{path: path, typ: typ, numValues: numValues, offset: offset, size: int64(file.Len()) - offset}
+}
+
+// writeParquetMetadata writes the FileMetaData struct.
+func writeParquetMetadata(buf *bytes.Buffer, rows int, strs []parquetStringColumn, list parquetFloatListColumn, chunks []parquetChunk) {
+	t := &thriftWriter{buf: buf}
+	t.fieldI32(1, 1)
+
+	t.fieldList(2, thriftStruct, len(strs)+4)
+	t.schemaElement("schema", -1, -1, len(strs)+1, -1)
+	for _, col := range strs {
+		t.schemaElement(col.Name, parquetByteArray, parquetRequired, -1, parquetUTF8)
+	}
+	t.schemaElement(list.Name, -1, parquetRequired, 1, parquetList)
+	t.schemaElement("list", -1, parquetRepeated, 1, -1)
+	t.schemaElement("element", parquetFloat, parquetRequired, -1, -1)
+
+	t.fieldI64(3, int64(rows))
+
+	var total int64
+	for _, c := range chunks {
+		total += c.size
+	}
+	t.fieldList(4, thriftStruct, 1)
+	t.beginStruct()
+	t.fieldList(1, thriftStruct, len(chunks))
+	for _, c := range chunks {
+		t.beginStruct()
+		t.fieldI64(2, c.offset)
+		t.fieldStruct(3)
+		t.fieldI32(1, c.typ)
+		t.fieldList(2, thriftI32, 2)
+		t.varint(zigzag(parquetPlain))
+		t.varint(zigzag(parquetRLE))
+		t.fieldList(3, thriftBinary, len(c.path))
+		for _, p := range c.path {
+			t.binary(p)
+		}
+		t.fieldI32(4, 0) // UNCOMPRESSED
+		t.fieldI64(5, int64(c.numValues))
+		t.fieldI64(6, c.size)
+		t.fieldI64(7, c.size)
+		t.fieldI64(9, c.offset)
+		t.endStruct()
+		t.endStruct()
+	}
+	t.fieldI64(2, total)
+	t.fieldI64(3, int64(rows))
+	t.endStruct()
+
+	t.fieldBinary(6, "yaml-to-readme")
+	t.endStruct()
+}
+
+// writeRLERun appends an RLE run of count copies of a 1-bit value.
+func writeRLERun(buf *bytes.Buffer, value byte, count int) {
+	buf.Write(binary.AppendUvarint(nil, uint64(count)<<1))
+	buf.WriteByte(value)
+}
+
+// Thrift compact protocol field types.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter writes Thrift compact protocol structs. Field ids are delta encoded
+// against the previous field of the enclosing struct.
+type thriftWriter struct {
+	buf  *bytes.Buffer
+	last []int16
+	id   int16
+}
+
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+func (t *thriftWriter) varint(v uint64) {
+	t.buf.Write(binary.AppendUvarint(nil, v))
+}
+
+func (t *thriftWriter) binary(s string) {
+	t.varint(uint64(len(s)))
+	t.buf.WriteString(s)
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	if delta := id - t.id; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(zigzag(int64(id)))
+	}
+	t.id = id
+}
+
+func (t *thriftWriter) fieldI32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(zigzag(int64(v)))
+}
+
+func (t *thriftWriter) fieldI64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(zigzag(v))
+}
+
+func (t *thriftWriter) fieldBinary(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.binary(s)
+}
+
+// fieldStruct starts a struct field; end it with endStruct.
+func (t *thriftWriter) fieldStruct(id int16) {
+	t.field(id, thriftStruct)
+	t.beginStruct()
+}
+
+// fieldList starts a list field of n elements. Struct elements are each written
+// between beginStruct and endStruct.
+func (t *thriftWriter) fieldList(id int16, elem byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf.WriteByte(byte(n)<<4 | elem)
+	} else {
+		t.buf.WriteByte(0xF0 | elem)
+		t.varint(uint64(n))
+	}
+}
+
+func (t *thriftWriter) beginStruct() {
+	t.last = append(t.last, t.id)
+	t.id = 0
+}
+
+func (t *thriftWriter) endStruct() {
+	t.buf.WriteByte(0)
+	if n := len(t.last); n > 0 {
+		t.id = t.last[n-1]
+		t.last = t.last[:n-1]
+	}
+}
+
+// schemaElement writes one SchemaElement list element. Negative values leave the
+// optional fields unset.
+func (t *thriftWriter) schemaElement(name string, typ, repetition int32, children int, converted int32) {
+	t.beginStruct()
+	if typ >= 0 {
+		t.fieldI32(1, typ)
+	}
+	if repetition >= 0 {
+		t.fieldI32(3, repetition)
+	}
+	t.fieldBinary(4, name)
+	if children >= 0 {
+		t.fieldI32(5, int32(children))
+	}
+	if converted >= 0 {
+		t.fieldI32(6, converted)
+	}
+	t.endStruct()
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// opaCommand is the opa binary used to evaluate --policy files.
+var opaCommand = "opa"
+
+// DefaultPolicyQuery is the Rego query evaluated against each manifest, following the
+// conftest convention of a deny rule in package main that yields messages.
+const DefaultPolicyQuery = "data.main.deny"
+
+// policyViolationRule is the risk note shown on entries that violate a policy.
+const policyViolationRule = "policy violation"
+
+// opaEvalOutput is the JSON written by `opa eval --format json`.
+type opaEvalOutput struct {
+	Result []struct {
+		Expressions []struct {
+			Value any `json:"value"`
+		} `json:"expressions"`
+	} `json:"result"`
+}
+
+// violationMessages extracts messages from a deny rule's value, which is a set of
+// strings or of objects with a msg field.
+func violationMessages(value any) []string {
+	var messages []string
+	items, ok := value.([]any)
+	if !ok {
+		items = []any{value}
+	}
+	for _, item := range items {
+		switch v := item.(type) {
+		case string:
+			messages = append(messages, v)
+		case map[string]any:
+			if msg, ok := v["msg"].(string); ok {
+				messages = append(messages, msg)
+			}
+		case bool:
+			if v {
+				messages = append(messages, "denied by "+policyQuery)
+			}
+		}
+	}
+	return messages
+}
+
+// evaluatePolicies runs the --policy files against one manifest and returns the
+// violation messages.
+func evaluatePolicies(doc map[string]any) ([]string, error) {
+	input, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"eval", "--format", "json", "--stdin-input"}
+	for _, policy := range policyPaths {
+		args = append(args, "--data", policy)
+	}
+	args = append(args, policyQuery)
+
+	cmd := exec.Command(opaCommand, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("opa eval failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	var result opaEvalOutput
+	if err := json.Unmarshal(out, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse opa output: %w", err)
+	}
+	var messages []string
+	for _, r := range result.Result {
+		for _, expr := range r.Expressions {
+			messages = append(messages, violationMessages(expr.Value)...)
+		}
+	}
+	return messages, nil
+}
+
+// checkPolicies evaluates every manifest in files against the --policy files and
+// returns the violations per file.
+func checkPolicies(files []string) map[string][]riskFinding {
+	violations := make(map[string][]riskFinding)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			slog.Warn("skipping policy check", "file", file, "error", err)
+			continue
+		}
+		docs, err := decodeYAMLDocuments(data)
+		if err != nil {
+			slog.Debug("policy check stopped at unparsable document", "file", file, "error", err)
+		}
+		for _, doc := range docs {
+			messages, err := evaluatePolicies(doc)
+			if err != nil {
+				slog.Warn("policy check failed", "file", file, "error", err)
+				continue
+			}
+			for _, msg := range messages {
+				violations[file] = append(violations[file], riskFinding{Rule: policyViolationRule, Detail: msg})
+			}
+		}
+	}
+	return violations
+}
+
+// policyAppendix lists every policy violation with the file it was found in.
+func policyAppendix(dir string, violations map[string][]riskFinding) []docAppendix {
+	if len(violations) == 0 {
+		return nil
+	}
+	var entries []appendixEntry
+	for _, file := range slices.Sorted(maps.Keys(violations)) {
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			rel = file
+		}
+		for _, v := range violations[file] {
+			entries = append(entries, appendixEntry{Path: rel, Note: "✗ " + v.Detail})
+		}
+	}
+	l := currentLabels()
+	return []docAppendix{{Title: l.PolicyViolations, Intro: l.PolicyViolationsIntro, Entries: entries}}
+}
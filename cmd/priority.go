@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// priorityRule orders files for --prioritize. less reports whether a should be
+// summarized before b; rules given later only break ties of earlier ones.
+type priorityRule struct {
+	spec string
+	less func(a, b prioritizedFile) bool
+}
+
+// prioritizedFile holds what the priority rules compare.
+type prioritizedFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+	kinds   map[string]bool
+}
+
+// parsePriorityRule parses one --prioritize value: changed, small-first, or kind=<Kind>.
+func parsePriorityRule(spec string) (priorityRule, error) {
+	switch {
+	case spec == "changed":
+		return priorityRule{spec, func(a, b prioritizedFile) bool { return a.modTime.After(b.modTime) }}, nil
+	case spec == "small-first":
+		return priorityRule{spec, func(a, b prioritizedFile) bool { return a.size < b.size }}, nil
+	case strings.HasPrefix(spec, "kind="):
+		kind := strings.TrimPrefix(spec, "kind=")
+		if kind == "" {
+			return priorityRule{}, fmt.Errorf("--prioritize %q names no kind", spec)
+		}
+		return priorityRule{spec, func(a, b prioritizedFile) bool { return a.kinds[kind] && !b.kinds[kind] }}, nil
+	default:
+		return priorityRule{}, fmt.Errorf("unsupported --prioritize %q, expected changed, small-first, or kind=<Kind>", spec)
+	}
+}
+
+// parsePriorityRules parses every --prioritize value.
+func parsePriorityRules(specs []string) ([]priorityRule, error) {
+	var rules []priorityRule
+	for _, spec := range specs {
+		rule, err := parsePriorityRule(strings.TrimSpace(spec))
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// validatePrioritize checks the --prioritize values before any file is summarized.
+func validatePrioritize() error {
+	_, err := parsePriorityRules(prioritize)
+	return err
+}
+
+// prioritizeFiles returns files in the order given by --prioritize, keeping walk order
+// among files the rules consider equal. Files are started in this order, so with
+// --local-cache the most valuable summaries are saved first if the run is interrupted.
+func prioritizeFiles(files []string) []string {
+	rules, err := parsePriorityRules(prioritize)
+	if err != nil || len(rules) == 0 {
+		return files
+	}
+	needKinds := false
+	for _, rule := range rules {
+		needKinds = needKinds || strings.HasPrefix(rule.spec, "kind=")
+	}
+
+	candidates := make([]prioritizedFile, len(files))
+	for i, file := range files {
+		candidates[i] = prioritizedFile{path: file}
+		if info, err := os.Stat(file); err == nil {
+			candidates[i].size = info.Size()
+			candidates[i].modTime = info.ModTime()
+		}
+		if needKinds {
+			candidates[i].kinds = fileKinds(file)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		for _, rule := range rules {
+			if rule.less(candidates[i], candidates[j]) {
+				return true
+			}
+			if rule.less(candidates[j], candidates[i]) {
+				return false
+			}
+		}
+		return false
+	})
+
+	ordered := make([]string, len(candidates))
+	for i, c := range candidates {
+		ordered[i] = c.path
+	}
+	return ordered
+}
+
+// fileKinds returns the kinds of every document in a YAML file.
+func fileKinds(file string) map[string]bool {
+	kinds := make(map[string]bool)
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return kinds
+	}
+	docs, _ := decodeYAMLDocuments(data)
+	for _, doc := range docs {
+		if kind := stringField(doc, "kind"); kind != "" {
+			kinds[kind] = true
+		}
+	}
+	return kinds
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxSiblingNames caps how many sibling file names are listed in the prompt context.
+const maxSiblingNames = 20
+
+// gitContextCommits is how many recent commit subjects --use-git-context includes.
+const gitContextCommits = 5
+
+// findRepoName returns the name of the git repository containing dir, or an empty
+// string if dir is not inside one.
+func findRepoName(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(abs, ".git")); err == nil {
+			return filepath.Base(abs)
+		}
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return ""
+		}
+		abs = parent
+	}
+}
+
+// siblingYAMLNames returns the sorted names of the other YAML files in file's directory.
+func siblingYAMLNames(file string) []string {
+	entries, err := os.ReadDir(filepath.Dir(file))
+	if err != nil {
+		return nil
+	}
+	self := filepath.Base(file)
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() || e.Name() == self || !isYAMLName(e.Name()) {
+			continue
+		}
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// siblingContext describes where a file lives: its directory, repository, and sibling
+// YAML files. Generically named files such as values.yaml are much easier to summarize
+// with this context.
+func siblingContext(file string) string {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		abs = file
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Context: the file is named %s and is in the directory %s", filepath.Base(abs), filepath.Base(filepath.Dir(abs)))
+	if repo := findRepoName(filepath.Dir(abs)); repo != "" {
+		fmt.Fprintf(&b, " of the repository %s", repo)
+	}
+	b.WriteString(".")
+	if names := siblingYAMLNames(abs); len(names) > 0 {
+		if len(names) > maxSiblingNames {
+			names = append(names[:maxSiblingNames], fmt.Sprintf("and %d more", len(names)-maxSiblingNames))
+		}
+		fmt.Fprintf(&b, " Other YAML files in the same directory: %s.", strings.Join(names, ", "))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// gitCommitSubjects returns the subjects of the most recent commits touching file, newest
+// first. It returns nil if file is not tracked by git or git is unavailable.
+func gitCommitSubjects(file string, n int) []string {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil
+	}
+	out, err := exec.Command("git", "-C", filepath.Dir(abs), "log", "-n", fmt.Sprint(n), "--format=%s", "--", filepath.Base(abs)).Output()
+	if err != nil {
+		slog.Debug("no git history for file", "file", file, "error", err)
+		return nil
+	}
+	var subjects []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			subjects = append(subjects, line)
+		}
+	}
+	return subjects
+}
+
+// gitContext lists the file's recent commit subjects so the model can explain why the
+// file exists rather than restating its keys.
+func gitContext(file string) string {
+	subjects := gitCommitSubjects(file, gitContextCommits)
+	if len(subjects) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Recent commit messages for this file, newest first: %s.\n", strings.Join(quoteAll(subjects), "; "))
+}
+
+// quoteAll wraps each string in double quotes.
+func quoteAll(values []string) []string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return quoted
+}
+
+// templateEngines maps template file suffixes to the engine that renders them.
+var templateEngines = map[string]string{
+	".tpl":    "Go template",
+	".tmpl":   "Go template",
+	".gotmpl": "Go template",
+	".j2":     "Jinja2",
+	".jinja":  "Jinja2",
+	".jinja2": "Jinja2",
+	".erb":    "ERB",
+}
+
+// templateContext tells the LLM that a file matched with --match-extensions is a
+// template rendering YAML, so it describes the rendered configuration rather than the
+// template syntax. It returns an empty string for plain YAML files.
+func templateContext(file string) string {
+	name := filepath.Base(file)
+	if strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml") {
+		return ""
+	}
+	engine := templateEngines[filepath.Ext(name)]
+	if engine == "" {
+		engine = "text"
+	}
+	return fmt.Sprintf("This file is a %s template that renders YAML. Summarize the configuration it renders and ignore the template syntax.\n", engine)
+}
+
+// maxDocumentKeys caps how many top-level keys describe a document without a kind.
+const maxDocumentKeys = 5
+
+// describeDocument names one document of a multi-document file: its kind and name, or
+// its top-level keys when it is not a Kubernetes-style resource.
+func describeDocument(doc map[string]any) string {
+	kind := stringField(doc, "kind")
+	if kind != "" {
+		if name := qualifiedName(doc); name != "" {
+			return kind + " " + name
+		}
+		return kind
+	}
+	keys := make([]string, 0, len(doc))
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if len(keys) > maxDocumentKeys {
+		keys = append(keys[:maxDocumentKeys], "...")
+	}
+	return "a document with the keys " + strings.Join(keys, ", ")
+}
+
+// documentsContext lists the documents of a file holding several ---separated YAML
+// documents, so the summary covers all of them rather than just the first resource. It
+// returns an empty string for single-document files and files that do not parse.
+func documentsContext(file string) string {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	docs, err := decodeYAMLDocuments(data)
+	if err != nil || len(docs) < 2 {
+		return ""
+	}
+	items := make([]string, len(docs))
+	for i, doc := range docs {
+		items[i] = describeDocument(doc)
+	}
+	return fmt.Sprintf("This file contains %d YAML documents: %s. Summarize what they configure together, covering every document rather than only the first.\n",
+		len(docs), outlineList(items))
+}
+
+// fileContext returns the per-file context: a template hint for templated YAML, an
+// outline of recognized CI pipeline files, knowledge base descriptions of the file's
+// kinds, the documents of multi-document files, plus any optional context enabled by
+// flags.
+// It is empty for other plain YAML files by default.
+func fileContext(file string) string {
+	context := templateContext(file) + ciContext(file) + knowledgeContext(file) + documentsContext(file)
+	if siblingContextEnabled {
+		context += siblingContext(file)
+	}
+	if useGitContext {
+		context += gitContext(file)
+	}
+	return context
+}
+
+// filePrompt returns the prompt for summarizing file, prefixed with any optional context
+// enabled by flags.
+func filePrompt(file string) string {
+	return fileContext(file) + summarizePrompt()
+}
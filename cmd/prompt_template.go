@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/sebrandon1/yaml-to-readme/summarizer"
+)
+
+// promptFilePath is the --prompt-file template that replaces the summarization prompt.
+var promptFilePath string
+
+// promptTemplate is the template loaded from --prompt-file, or nil for the built-in
+// prompt.
+var promptTemplate *template.Template
+
+// promptData is what a --prompt-file template is executed with.
+type promptData struct {
+	// Path is the file's slash-separated path relative to the documented directory.
+	Path string
+	// Dir is the directory of Path, "." for files at the top.
+	Dir string
+	// Kind is the top-level kind of the file's first YAML document, if any.
+	Kind string
+	// Content is the file content as it would otherwise be sent after the prompt.
+	Content string
+}
+
+// loadPromptTemplate parses a --prompt-file template. Referring to a variable that does
+// not exist is an error when the template is loaded, not halfway through a run.
+func loadPromptTemplate(file string) (*template.Template, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt file %s: %w", file, err)
+	}
+	tmpl, err := template.New(filepath.Base(file)).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prompt file %s: %w", file, err)
+	}
+	if err := tmpl.Execute(&bytes.Buffer{}, promptData{}); err != nil {
+		return nil, fmt.Errorf("invalid prompt file %s: %w", file, err)
+	}
+	return tmpl, nil
+}
+
+// promptTemplateUsesContent reports whether the --prompt-file template places the file
+// content itself, in which case it is not sent again after the prompt.
+func promptTemplateUsesContent() bool {
+	return promptTemplate != nil && strings.Contains(promptTemplate.Tree.Root.String(), ".Content")
+}
+
+// templatedPrompt executes the --prompt-file template for file, under the documented
+// directory dir, whose raw content is content and whose content to send is body. It
+// returns the instruction, localized like the built-in prompt, and the body to send
+// after it.
+func templatedPrompt(dir, file string, content []byte, body string) (string, string, error) {
+	rel := file
+	if r, err := filepath.Rel(dir, file); err == nil {
+		rel = r
+	}
+	rel = filepath.ToSlash(rel)
+	var b strings.Builder
+	err := promptTemplate.Execute(&b, promptData{
+		Path:    rel,
+		Dir:     path.Dir(rel),
+		Kind:    summarizer.DetectKind(content),
+		Content: body,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to render prompt file for %s: %w", file, err)
+	}
+	if promptTemplateUsesContent() {
+		body = ""
+	}
+	return localizedPrompt(b.String()), body, nil
+}
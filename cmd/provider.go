@@ -1,6 +1,9 @@
 package cmd
 
-import "context"
+import (
+	"context"
+	"fmt"
+)
 
 // LLMProvider defines a provider-agnostic interface for LLM operations.
 // Implementations include Ollama (default) and OpenAI-compatible APIs.
@@ -12,3 +15,63 @@ type LLMProvider interface {
 	// Name returns the provider name for display purposes.
 	Name() string
 }
+
+// Warmer is implemented by providers that can preload their model, so the first file
+// summarized does not pay the model load latency.
+type Warmer interface {
+	// WarmUp loads the configured model without summarizing anything.
+	WarmUp(ctx context.Context) error
+}
+
+// ModelSwitcher is implemented by providers that can send requests to another model of
+// the same backend, as --refine-model does.
+type ModelSwitcher interface {
+	// WithModel returns a copy of the provider that uses model instead of --model.
+	WithModel(model string) LLMProvider
+}
+
+// switchModel returns llm switched to model, given with --flag, after checking the model
+// is available. role names the model in errors, as in "refine model".
+func switchModel(llm LLMProvider, model, flag, role string) (LLMProvider, error) {
+	switcher, ok := llm.(ModelSwitcher)
+	if !ok {
+		return nil, fmt.Errorf("the %s provider does not support --%s", llm.Name(), flag)
+	}
+	switched := switcher.WithModel(model)
+	available, err := switched.Available(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to check %s model availability: %w", role, err)
+	}
+	if !available {
+		return nil, fmt.Errorf("%s model %s is not available. Please ensure it is downloaded and available in your %s provider", role, model, llm.Name())
+	}
+	return switched, nil
+}
+
+// providerModel returns the model provider sends requests to.
+func providerModel(provider LLMProvider) string {
+	if m, ok := provider.(interface{ Model() string }); ok {
+		return m.Model()
+	}
+	return ModelName
+}
+
+// Embedder is implemented by providers that can embed text for retrieval, as ask does.
+type Embedder interface {
+	// Embed returns one embedding vector per text, computed with model.
+	Embed(ctx context.Context, model string, texts []string) ([][]float32, error)
+	// DefaultEmbedModel is the embedding model used when --embed-model is not set.
+	DefaultEmbedModel() string
+}
+
+// statusError is a non-OK HTTP response from a provider API.
+type statusError struct {
+	// API names the endpoint, such as "openai API".
+	API        string
+	StatusCode int
+	Body       string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s returned status %d: %s", e.API, e.StatusCode, e.Body)
+}
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// anthropicVersion is the Messages API version requests are written against.
+const anthropicVersion = "2023-06-01"
+
+// AnthropicProvider implements LLMProvider using the Anthropic Messages API.
+type AnthropicProvider struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+	// model overrides ModelName; see WithModel.
+	model string
+}
+
+// NewAnthropicProvider creates a new AnthropicProvider from environment variables.
+// Requires ANTHROPIC_API_KEY. ANTHROPIC_BASE_URL defaults to https://api.anthropic.com.
+func NewAnthropicProvider() (*AnthropicProvider, error) {
+	apiKey := os.Getenv("ANTHROPIC_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("ANTHROPIC_API_KEY environment variable is required for the anthropic provider")
+	}
+	baseURL := os.Getenv("ANTHROPIC_BASE_URL")
+	if baseURL == "" {
+		baseURL = "https://api.anthropic.com"
+	}
+	return &AnthropicProvider{
+		apiKey:  apiKey,
+		baseURL: baseURL,
+		client:  &http.Client{},
+	}, nil
+}
+
+type anthropicMessagesRequest struct {
+	Model       string             `json:"model"`
+	MaxTokens   int                `json:"max_tokens"`
+	System      []anthropicContent `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	Temperature float64            `json:"temperature"`
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+	// CacheControl marks the end of a prefix the API may cache.
+	CacheControl *anthropicCacheControl `json:"cache_control,omitempty"`
+}
+
+type anthropicCacheControl struct {
+	Type string `json:"type"`
+}
+
+type anthropicMessagesResponse struct {
+	Content []anthropicContent `json:"content"`
+	Error   *anthropicError    `json:"error,omitempty"`
+}
+
+type anthropicError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// newRequest creates a request carrying the Anthropic authentication and version headers.
+func (a *AnthropicProvider) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("x-api-key", a.apiKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// Summarize implements LLMProvider.Summarize using the Anthropic Messages API.
+func (a *AnthropicProvider) Summarize(ctx context.Context, content string, prompt string) (string, error) {
+	reqBody := anthropicMessagesRequest{
+		Model:     a.Model(),
+		MaxTokens: contextResponseTokens,
+		Messages: []anthropicMessage{
+			{
+				Role:    "user",
+				Content: prompt + content,
+			},
+		},
+		Temperature: anthropicTemperature(),
+	}
+	if promptCache {
+		// Put the static instruction in a cached system block so it forms a reusable prefix
+		reqBody.System = []anthropicContent{
+			{Type: "text", Text: prompt, CacheControl: &anthropicCacheControl{Type: "ephemeral"}},
+		}
+		reqBody.Messages = []anthropicMessage{{Role: "user", Content: content}}
+	}
+
+	bodyBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := a.newRequest(ctx, http.MethodPost, "/v1/messages", bytes.NewReader(bodyBytes))
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("anthropic API request failed: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &statusError{API: "anthropic API", StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+
+	var msgResp anthropicMessagesResponse
+	if err := json.Unmarshal(respBody, &msgResp); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if msgResp.Error != nil {
+		return "", fmt.Errorf("anthropic API error: %s", msgResp.Error.Message)
+	}
+
+	var text string
+	for _, block := range msgResp.Content {
+		if block.Type == "text" {
+			text += block.Text
+		}
+	}
+	if text == "" {
+		return "", fmt.Errorf("anthropic API returned no text")
+	}
+	return text, nil
+}
+
+// Available implements LLMProvider.Available by looking the model up in the models endpoint.
+func (a *AnthropicProvider) Available(ctx context.Context) (bool, error) {
+	req, err := a.newRequest(ctx, http.MethodGet, "/v1/models/"+url.PathEscape(a.Model()), nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("anthropic API request failed: %w", err)
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode == http.StatusOK, nil
+}
+
+// Model returns the model requests are sent to.
+func (a *AnthropicProvider) Model() string {
+	if a.model != "" {
+		return a.model
+	}
+	return ModelName
+}
+
+// WithModel implements ModelSwitcher.
+func (a *AnthropicProvider) WithModel(model string) LLMProvider {
+	switched := *a
+	switched.model = model
+	return &switched
+}
+
+// Name implements LLMProvider.Name.
+func (a *AnthropicProvider) Name() string {
+	return "anthropic"
+}
@@ -9,6 +9,8 @@ import (
 type MockLLMProvider struct {
 	// MockResponses maps content snippets to mock summaries.
 	MockResponses map[string]string
+	// MockErrors maps content snippets to errors returned instead of a summary.
+	MockErrors map[string]error
 	// DefaultResponse is returned when no matching snippet is found.
 	DefaultResponse string
 	// ModelAvailable controls the return value of Available().
@@ -19,6 +21,7 @@ type MockLLMProvider struct {
 func NewMockLLMProvider() *MockLLMProvider {
 	return &MockLLMProvider{
 		MockResponses:   make(map[string]string),
+		MockErrors:      make(map[string]error),
 		DefaultResponse: "This is a mock summary for testing purposes.",
 		ModelAvailable:  true,
 	}
@@ -26,6 +29,11 @@ func NewMockLLMProvider() *MockLLMProvider {
 
 // Summarize implements LLMProvider.Summarize for the mock.
 func (m *MockLLMProvider) Summarize(ctx context.Context, content string, prompt string) (string, error) {
+	for key, err := range m.MockErrors {
+		if strings.Contains(content, key) {
+			return "", err
+		}
+	}
 	for key, response := range m.MockResponses {
 		if strings.Contains(content, key) {
 			return response, nil
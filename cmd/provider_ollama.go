@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"strings"
+	"time"
 
 	ollama "github.com/ollama/ollama/api"
 )
@@ -10,40 +12,81 @@ import (
 // OllamaProvider implements LLMProvider using the Ollama API.
 type OllamaProvider struct {
 	client OllamaClient
+	// keepAlive is how long Ollama keeps the model loaded after a request; nil uses
+	// the server default.
+	keepAlive *ollama.Duration
+	// model overrides ModelName; see WithModel.
+	model string
+	// fence is shared with the providers returned by WithModel, so requests for
+	// different models of the same server do not run at the same time.
+	fence *modelFence
 }
 
 // NewOllamaProvider creates a new OllamaProvider from the environment.
 func NewOllamaProvider() (*OllamaProvider, error) {
+	keepAlive, err := parseKeepAlive(ollamaKeepAlive)
+	if err != nil {
+		return nil, err
+	}
 	client, err := NewRealOllamaClient()
 	if err != nil {
 		return nil, err
 	}
-	return &OllamaProvider{client: client}, nil
+	return &OllamaProvider{client: client, keepAlive: keepAlive, fence: newModelFence()}, nil
+}
+
+// parseKeepAlive parses the --ollama-keep-alive flag. An empty value leaves the server
+// default in place; a negative duration keeps the model loaded indefinitely.
+func parseKeepAlive(value string) (*ollama.Duration, error) {
+	if value == "" {
+		return nil, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --ollama-keep-alive %q: %w", value, err)
+	}
+	return &ollama.Duration{Duration: d}, nil
 }
 
 // NewOllamaProviderFromClient creates an OllamaProvider from an existing OllamaClient.
 // Used for testing with MockOllamaClient.
 func NewOllamaProviderFromClient(client OllamaClient) *OllamaProvider {
-	return &OllamaProvider{client: client}
+	return &OllamaProvider{client: client, fence: newModelFence()}
 }
 
 // Summarize implements LLMProvider.Summarize using the Ollama Chat API.
 func (o *OllamaProvider) Summarize(ctx context.Context, content string, prompt string) (string, error) {
 	falseVar := false
-	chatReq := &ollama.ChatRequest{
-		Model: ModelName,
-		Messages: []ollama.Message{
-			{
-				Role:    "user",
-				Content: prompt + content,
-			},
+	messages := []ollama.Message{
+		{
+			Role:    "user",
+			Content: prompt + content,
 		},
+	}
+	if promptCache {
+		// A stable system prompt lets Ollama reuse the cached prefix between files
+		messages = []ollama.Message{
+			{Role: "system", Content: prompt},
+			{Role: "user", Content: content},
+		}
+	}
+	chatReq := &ollama.ChatRequest{
+		Model:    o.Model(),
+		Messages: messages,
 		Options: map[string]interface{}{
-			"seed": 42,
+			"seed": DeterministicSeed,
 		},
-		Stream: &falseVar,
+		Stream:    &falseVar,
+		KeepAlive: o.keepAlive,
+	}
+	if deterministic {
+		chatReq.Options["temperature"] = 0
 	}
 
+	if err := o.fence.enter(ctx, chatReq.Model); err != nil {
+		return "", err
+	}
+	defer o.fence.leave()
 	var sb strings.Builder
 	err := o.client.Chat(ctx, chatReq, func(resp ollama.ChatResponse) error {
 		sb.WriteString(resp.Message.Content)
@@ -55,6 +98,23 @@ func (o *OllamaProvider) Summarize(ctx context.Context, content string, prompt s
 	return sb.String(), nil
 }
 
+// WarmUp implements Warmer. A chat request without messages makes Ollama load the
+// model into memory without generating anything.
+func (o *OllamaProvider) WarmUp(ctx context.Context) error {
+	if err := o.fence.enter(ctx, o.Model()); err != nil {
+		return err
+	}
+	defer o.fence.leave()
+	falseVar := false
+	return o.client.Chat(ctx, &ollama.ChatRequest{
+		Model:     o.Model(),
+		Stream:    &falseVar,
+		KeepAlive: o.keepAlive,
+	}, func(ollama.ChatResponse) error {
+		return nil
+	})
+}
+
 // Available implements LLMProvider.Available by checking the Ollama model list.
 func (o *OllamaProvider) Available(ctx context.Context) (bool, error) {
 	response, err := o.client.List(ctx)
@@ -62,13 +122,56 @@ func (o *OllamaProvider) Available(ctx context.Context) (bool, error) {
 		return false, err
 	}
 	for _, model := range response.Models {
-		if model.Name == ModelName {
+		if model.Name == o.Model() {
 			return true, nil
 		}
 	}
 	return false, nil
 }
 
+// DefaultOllamaEmbedModel is the Ollama embedding model used by ask.
+const DefaultOllamaEmbedModel = "nomic-embed-text"
+
+// Embed implements Embedder using the Ollama embed API.
+func (o *OllamaProvider) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	if err := o.fence.enter(ctx, model); err != nil {
+		return nil, err
+	}
+	defer o.fence.leave()
+	resp, err := o.client.Embed(ctx, &ollama.EmbedRequest{
+		Model:     model,
+		Input:     texts,
+		KeepAlive: o.keepAlive,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("ollama returned %d embeddings for %d inputs", len(resp.Embeddings), len(texts))
+	}
+	return resp.Embeddings, nil
+}
+
+// DefaultEmbedModel implements Embedder.
+func (o *OllamaProvider) DefaultEmbedModel() string {
+	return DefaultOllamaEmbedModel
+}
+
+// Model returns the model requests are sent to.
+func (o *OllamaProvider) Model() string {
+	if o.model != "" {
+		return o.model
+	}
+	return ModelName
+}
+
+// WithModel implements ModelSwitcher.
+func (o *OllamaProvider) WithModel(model string) LLMProvider {
+	switched := *o
+	switched.model = model
+	return &switched
+}
+
 // Name implements LLMProvider.Name.
 func (o *OllamaProvider) Name() string {
 	return "ollama"
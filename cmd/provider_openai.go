@@ -3,6 +3,8 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -16,6 +18,8 @@ type OpenAIProvider struct {
 	apiKey  string
 	baseURL string
 	client  *http.Client
+	// model overrides ModelName; see WithModel.
+	model string
 }
 
 // NewOpenAIProvider creates a new OpenAIProvider from environment variables.
@@ -40,6 +44,10 @@ type openAIChatRequest struct {
 	Model       string          `json:"model"`
 	Messages    []openAIMessage `json:"messages"`
 	Temperature float64         `json:"temperature"`
+	// Seed requests best-effort deterministic sampling.
+	Seed *int `json:"seed,omitempty"`
+	// PromptCacheKey groups requests sharing a prompt prefix for server-side caching.
+	PromptCacheKey string `json:"prompt_cache_key,omitempty"`
 }
 
 type openAIMessage struct {
@@ -71,14 +79,26 @@ type openAIModel struct {
 // Summarize implements LLMProvider.Summarize using the OpenAI chat completions API.
 func (o *OpenAIProvider) Summarize(ctx context.Context, content string, prompt string) (string, error) {
 	reqBody := openAIChatRequest{
-		Model: ModelName,
+		Model: o.Model(),
 		Messages: []openAIMessage{
 			{
 				Role:    "user",
 				Content: prompt + content,
 			},
 		},
-		Temperature: 0.3,
+		Temperature: openAITemperature(),
+	}
+	if deterministic {
+		seed := DeterministicSeed
+		reqBody.Seed = &seed
+	}
+	if promptCache {
+		// Put the static instruction first so it forms a cacheable prefix
+		reqBody.Messages = []openAIMessage{
+			{Role: "system", Content: prompt},
+			{Role: "user", Content: content},
+		}
+		reqBody.PromptCacheKey = promptCacheKey(o.Model(), prompt)
 	}
 
 	bodyBytes, err := json.Marshal(reqBody)
@@ -108,7 +128,7 @@ func (o *OpenAIProvider) Summarize(ctx context.Context, content string, prompt s
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("openai API returned status %d: %s", resp.StatusCode, string(respBody))
+		return "", &statusError{API: "openai API", StatusCode: resp.StatusCode, Body: string(respBody)}
 	}
 
 	var chatResp openAIChatResponse
@@ -127,6 +147,86 @@ func (o *OpenAIProvider) Summarize(ctx context.Context, content string, prompt s
 	return chatResp.Choices[0].Message.Content, nil
 }
 
+// DefaultOpenAIEmbedModel is the OpenAI embedding model used by ask.
+const DefaultOpenAIEmbedModel = "text-embedding-3-small"
+
+type openAIEmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type openAIEmbeddingResponse struct {
+	Data  []openAIEmbedding `json:"data"`
+	Error *openAIError      `json:"error,omitempty"`
+}
+
+type openAIEmbedding struct {
+	Index     int       `json:"index"`
+	Embedding []float32 `json:"embedding"`
+}
+
+// Embed implements Embedder using the OpenAI embeddings API.
+func (o *OpenAIProvider) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	bodyBytes, err := json.Marshal(openAIEmbeddingRequest{Model: model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := o.baseURL + "/v1/embeddings"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+o.apiKey)
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("openai API request failed: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{API: "openai API", StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+
+	var embResp openAIEmbeddingResponse
+	if err := json.Unmarshal(respBody, &embResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if embResp.Error != nil {
+		return nil, fmt.Errorf("openai API error: %s", embResp.Error.Message)
+	}
+	if len(embResp.Data) != len(texts) {
+		return nil, fmt.Errorf("openai API returned %d embeddings for %d inputs", len(embResp.Data), len(texts))
+	}
+	embeddings := make([][]float32, len(texts))
+	for _, d := range embResp.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("openai API returned an embedding for unknown input %d", d.Index)
+		}
+		embeddings[d.Index] = d.Embedding
+	}
+	return embeddings, nil
+}
+
+// DefaultEmbedModel implements Embedder.
+func (o *OpenAIProvider) DefaultEmbedModel() string {
+	return DefaultOpenAIEmbedModel
+}
+
+// promptCacheKey identifies requests that share the same model and instruction prefix.
+func promptCacheKey(model, prompt string) string {
+	sum := sha256.Sum256([]byte(model + "\x00" + prompt))
+	return "yaml-to-readme-" + hex.EncodeToString(sum[:8])
+}
+
 // Available implements LLMProvider.Available by checking the OpenAI models endpoint.
 func (o *OpenAIProvider) Available(ctx context.Context) (bool, error) {
 	url := o.baseURL + "/v1/models"
@@ -159,13 +259,28 @@ func (o *OpenAIProvider) Available(ctx context.Context) (bool, error) {
 	}
 
 	for _, model := range modelList.Data {
-		if model.ID == ModelName {
+		if model.ID == o.Model() {
 			return true, nil
 		}
 	}
 	return false, nil
 }
 
+// Model returns the model requests are sent to.
+func (o *OpenAIProvider) Model() string {
+	if o.model != "" {
+		return o.model
+	}
+	return ModelName
+}
+
+// WithModel implements ModelSwitcher.
+func (o *OpenAIProvider) WithModel(model string) LLMProvider {
+	switched := *o
+	switched.model = model
+	return &switched
+}
+
 // Name implements LLMProvider.Name.
 func (o *OpenAIProvider) Name() string {
 	return "openai"
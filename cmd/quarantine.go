@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// DefaultQuarantineAfter is how many files of a directory may fail, with none
+// succeeding, before the rest of the directory is skipped.
+const DefaultQuarantineAfter = 5
+
+// dirQuarantine stops summarizing a directory once its files keep failing, for example
+// because they are unreadable or are templates the model cannot make sense of, so one
+// broken directory does not flood the run with errors and provider calls.
+type dirQuarantine struct {
+	// limit is the number of failures without a success that quarantines a directory;
+	// zero disables quarantine.
+	limit int
+
+	mu        sync.Mutex
+	failures  map[string]int
+	succeeded map[string]bool
+	skipped   map[string]int
+}
+
+// newDirQuarantine creates a quarantine that trips after limit failures.
+func newDirQuarantine(limit int) *dirQuarantine {
+	return &dirQuarantine{
+		limit:     limit,
+		failures:  make(map[string]int),
+		succeeded: make(map[string]bool),
+		skipped:   make(map[string]int),
+	}
+}
+
+// isQuarantined reports whether dir has reached the failure limit.
+func (q *dirQuarantine) isQuarantined(dir string) bool {
+	return q.limit > 0 && !q.succeeded[dir] && q.failures[dir] >= q.limit
+}
+
+// skip reports whether file's directory is quarantined, counting the file as skipped
+// if so.
+func (q *dirQuarantine) skip(file string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	dir := filepath.Dir(file)
+	if !q.isQuarantined(dir) {
+		return false
+	}
+	q.skipped[dir]++
+	return true
+}
+
+// record notes the outcome of summarizing file and reports whether this failure
+// quarantined its directory.
+func (q *dirQuarantine) record(file string, err error) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	dir := filepath.Dir(file)
+	if err == nil {
+		q.succeeded[dir] = true
+		return false
+	}
+	wasQuarantined := q.isQuarantined(dir)
+	q.failures[dir]++
+	return !wasQuarantined && q.isQuarantined(dir)
+}
+
+// quarantined returns the quarantined directories relative to baseDir, sorted.
+func (q *dirQuarantine) quarantined(baseDir string) []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var dirs []string
+	for dir := range q.failures {
+		if q.isQuarantined(dir) {
+			rel, err := filepath.Rel(baseDir, dir)
+			if err != nil {
+				rel = dir
+			}
+			dirs = append(dirs, filepath.ToSlash(rel))
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
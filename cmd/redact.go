@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"log/slog"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// noRedact sends file content to the provider without redacting secrets first.
+var noRedact bool
+
+// redactedValue replaces every redacted value. It carries no length, so nothing about
+// the secret reaches the provider.
+const redactedValue = "<redacted>"
+
+// redactSecretFields are the top-level Secret fields whose values are always redacted.
+var redactSecretFields = map[string]bool{"data": true, "stringData": true}
+
+// sensitiveKeyPattern matches keys, and env var names, whose values are credentials,
+// such as password, DB_PASSWORD, apiKey, or client_secret. Keys that only refer to a
+// credential, like secretName or tokenTTL, do not end in one of these words.
+var sensitiveKeyPattern = regexp.MustCompile(`(?i)(password|passwd|passphrase|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|credentials?)$`)
+
+// highEntropyCandidatePattern matches a value shaped like a token: one long word of
+// base64 or URL-safe characters. Colons and spaces are excluded, so image references,
+// URLs, and prose never qualify.
+var highEntropyCandidatePattern = regexp.MustCompile(`^[A-Za-z0-9+/=_.\-]{20,}$`)
+
+// highEntropyBits is the Shannon entropy per character above which a candidate value is
+// treated as a secret.
+const highEntropyBits = 4.0
+
+// redactSecrets replaces credentials in content with a placeholder before it is sent to
+// a provider: the data and stringData values of Secret documents, values of keys like
+// password or apiKey, env var values whose name looks like one, private keys, and
+// high-entropy strings. It returns the redacted content and how many values it replaced.
+func redactSecrets(content string) (string, int) {
+	lines := splitCompressLines(content)
+	var out []compressLine
+	count := 0
+	for start := 0; start < len(lines); {
+		end := start + 1
+		for end < len(lines) && !isDocumentSeparator(lines[end]) {
+			end++
+		}
+		doc, n := redactDocument(lines[start:end], documentKind(lines[start:end]) == "Secret")
+		out = append(out, doc...)
+		count += n
+		start = end
+	}
+	if count == 0 {
+		return content, 0
+	}
+	redacted := joinCompressLines(out)
+	if !strings.HasSuffix(content, "\n") {
+		redacted = strings.TrimSuffix(redacted, "\n")
+	}
+	return redacted, count
+}
+
+// redactDocument redacts the secret values of one document's lines. With secret set,
+// every value under the top-level data fields is redacted.
+func redactDocument(doc []compressLine, secret bool) ([]compressLine, int) {
+	var out []compressLine
+	count := 0
+	inData := false
+	sensitiveName := false
+	for i := 0; i < len(doc); i++ {
+		l := doc[i]
+		if l.block {
+			if strings.Contains(l.text, "-----BEGIN") && strings.Contains(l.text, "PRIVATE KEY") {
+				// A private key embedded under an innocuous key; drop the rest of the block
+				out = append(out, compressLine{text: strings.Repeat(" ", l.indent) + redactedValue, indent: l.indent, block: true})
+				count++
+				for i+1 < len(doc) && doc[i+1].block {
+					i++
+				}
+				continue
+			}
+			out = append(out, l)
+			continue
+		}
+		trimmed := strings.TrimSpace(l.text)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			out = append(out, l)
+			continue
+		}
+		item := strings.TrimPrefix(trimmed, "- ")
+		rawKey, value, ok := strings.Cut(item, ":")
+		if l.indent == 0 {
+			inData = secret && ok && redactSecretFields[rawKey]
+		}
+		afterName := sensitiveName
+		sensitiveName = false
+		if !ok || (value != "" && !strings.HasPrefix(value, " ")) {
+			// A plain scalar, or a colon inside a value rather than after a key
+			if item != trimmed && isHighEntropy(strings.Trim(item, `"'`)) {
+				out = append(out, compressLine{text: strings.Replace(l.text, item, redactedValue, 1), indent: l.indent})
+				count++
+				continue
+			}
+			out = append(out, l)
+			continue
+		}
+		key := strings.Trim(rawKey, `"'`)
+		value = strings.TrimSpace(value)
+		if key == "name" && sensitiveKeyPattern.MatchString(strings.Trim(value, `"'`)) {
+			// An env var entry, whose value follows on the next line
+			sensitiveName = true
+			out = append(out, l)
+			continue
+		}
+		sensitive := (inData && l.indent > 0) || sensitiveKeyPattern.MatchString(key) || (afterName && key == "value")
+		if !(sensitive && redactableValue(value)) && !isHighEntropy(strings.Trim(value, `"'`)) {
+			out = append(out, l)
+			continue
+		}
+		prefix := l.text[:strings.Index(l.text, item)+len(rawKey)]
+		out = append(out, compressLine{text: prefix + ": " + redactedValue, indent: l.indent})
+		count++
+		if blockScalarPattern.MatchString(l.text) {
+			for i+1 < len(doc) && doc[i+1].block {
+				i++
+			}
+		}
+	}
+	return out, count
+}
+
+// redactableValue reports whether a value under a sensitive key holds a credential, as
+// opposed to a nested mapping, a flag, or a template placeholder filled in elsewhere.
+func redactableValue(value string) bool {
+	switch strings.ToLower(strings.Trim(value, `"'`)) {
+	case "", "true", "false", "null", "~":
+		return false
+	}
+	return !strings.Contains(value, "{{") && !strings.Contains(value, "${")
+}
+
+// isHighEntropy reports whether a value looks like a generated token or key: long, a
+// single word, mixing upper case, lower case, and digits, with high Shannon entropy.
+func isHighEntropy(value string) bool {
+	if !highEntropyCandidatePattern.MatchString(value) {
+		return false
+	}
+	var upper, lower, digit bool
+	counts := make(map[rune]int)
+	for _, r := range value {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= '0' && r <= '9':
+			digit = true
+		}
+		counts[r]++
+	}
+	if !upper || !lower || !digit {
+		return false
+	}
+	entropy := 0.0
+	for _, n := range counts {
+		p := float64(n) / float64(len(value))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy >= highEntropyBits
+}
+
+// promptContent returns the content of file as it may be sent to a provider: with its
+// secrets redacted, unless --no-redact is set.
+func promptContent(file, content string) string {
+	if noRedact {
+		return content
+	}
+	redacted, n := redactSecrets(content)
+	if n > 0 {
+		slog.Debug("redacted secrets before prompting", "file", file, "values", n)
+	}
+	return redacted
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+)
+
+// refineModel is the higher-quality model that re-summarizes the files matched by
+// refineRules after every file has been drafted with --model.
+var refineModel string
+
+// refineRules are the --refine values selecting the files worth the refine model.
+var refineRules []string
+
+// validateRefine checks --refine-model and --refine before any file is summarized.
+func validateRefine() error {
+	if refineModel == "" {
+		if len(refineRules) > 0 {
+			return fmt.Errorf("--refine requires --refine-model")
+		}
+		return nil
+	}
+	if len(refineRules) == 0 {
+		return fmt.Errorf("--refine-model requires at least one --refine rule")
+	}
+	_, err := parseFileRules("refine", refineRules)
+	return err
+}
+
+// newRefineProvider returns llm switched to --refine-model, after checking the model
+// is available.
+func newRefineProvider(llm LLMProvider) (LLMProvider, error) {
+	return switchModel(llm, refineModel, "refine-model", "refine")
+}
+
+// refineCandidates returns the files drafted in this run that match any --refine rule.
+// Summaries reused from the existing document are left alone, so they are refined
+// once, when the file is first summarized.
+func refineCandidates(dir string, yamlFiles []string, existingSummaries, summaries map[string]string) []string {
+	rules, err := parseFileRules("refine", refineRules)
+	if err != nil {
+		return nil
+	}
+	var candidates []string
+	for _, file := range yamlFiles {
+		if summaries[file] == "" {
+			continue
+		}
+		if _, reused := reusableSummary(dir, file, existingSummaries, regenerate); reused {
+			continue
+		}
+		if matchFileRules(dir, file, rules) {
+			candidates = append(candidates, file)
+		}
+	}
+	return candidates
+}
+
+// refineSummaries re-summarizes the refine candidates with the refine provider,
+// replacing their drafts. A file whose refinement fails keeps its draft. It returns
+// the number of files refined.
+func refineSummaries(dir string, yamlFiles []string, existingSummaries, summaries map[string]string, refined LLMProvider) int {
+	candidates := refineCandidates(dir, yamlFiles, existingSummaries, summaries)
+	if len(candidates) == 0 {
+		return 0
+	}
+	statusf("\nRefining %d file(s) with %s...\n", len(candidates), refineModel)
+	refinedSummaries, processed, _, _ := processYAMLFiles(candidates, dir, nil, refined, true)
+	for file, summary := range refinedSummaries {
+		summaries[file] = summary
+	}
+	return processed
+}
@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// findDocDir returns the directory file is documented under: the nearest directory at
+// or above file's directory whose document, by docPathFor, has an entry for it, or
+// without one the nearest that has a document at all. A document shared by every
+// directory, with --write-dir or an absolute --output, must have an entry for file.
+func findDocDir(file string) (string, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+	nearest := ""
+	dir := filepath.Dir(abs)
+	for {
+		if docPath := docPathFor(dir); docPath != stdoutOutput {
+			if _, err := os.Stat(docPath); err == nil {
+				rel, _ := filepath.Rel(dir, abs)
+				if hasDocEntry(docPath, rel) {
+					return dir, nil
+				}
+				if nearest == "" {
+					nearest = dir
+				}
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	switch {
+	case nearest == "":
+		return "", fmt.Errorf("no %s found in any directory containing %s; use --dir", markdownFileName, file)
+	case sharedDocPath():
+		return "", fmt.Errorf("%s has no entry for %s; use --dir to name the documented directory", docPathFor(nearest), file)
+	}
+	return nearest, nil
+}
+
+// hasDocEntry reports whether the document at docPath has an entry for rel.
+func hasDocEntry(docPath, rel string) bool {
+	if _, ok := parseExistingSummaries(docPath)[rel]; ok {
+		return true
+	}
+	_, ok := readDocSources(docPath)[filepath.ToSlash(rel)]
+	return ok
+}
+
+// replaceDocEntry replaces the summary of the entry for rel in a markdown or GitHub wiki
+// document, leaving every other line untouched. It reports whether the entry was found.
+func replaceDocEntry(lines []string, rel, summary string) bool {
+	var currentDir string
+	for i, line := range lines {
+		key, old, ok := parseSummaryLine(line, &currentDir)
+		if !ok || key != rel {
+			continue
+		}
+		// The summary is always the tail of the entry line, before any --stable-entries key
+		line, key := cutEntryKey(line)
+		prefix := strings.TrimSuffix(strings.TrimRight(line, " "), old)
+		if !strings.HasSuffix(prefix, " ") {
+			prefix += " "
+		}
+		lines[i] = prefix + summary + key
+		return true
+	}
+	return false
+}
+
+// restampDocEntry records digest as the source of the entry for rel in the lines of a
+// markdown or GitHub wiki document, in its entry key or footer key, and updates the
+// inputs checksum to match.
+func restampDocEntry(lines []string, baseDir, rel, digest string) {
+	rel = filepath.ToSlash(rel)
+	sources := make(map[string]string)
+	for i, line := range lines {
+		m := entryKeyPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if m[2] == rel {
+			lines[i] = strings.Replace(line, "sha256:"+m[1]+" "+rel+" -->", "sha256:"+digest+" "+rel+" -->", 1)
+			m[1] = digest
+		}
+		sources[m[2]] = m[1]
+	}
+	sources[rel] = digest
+
+	existing := make(map[string]string)
+	parseSummaryLines(lines, existing)
+	rels := make([]string, 0, len(existing))
+	for key := range existing {
+		rels = append(rels, key)
+	}
+	checksum := digestsChecksum(sourceDigests(baseDir, rels, sources))
+	for i, line := range lines {
+		if inputsChecksumPattern.MatchString(line) && strings.HasPrefix(line, "<!--") {
+			lines[i] = fmt.Sprintf("<!-- yaml-to-readme-inputs sha256:%s -->", checksum)
+		}
+	}
+}
+
+// replaceJSONEntry replaces the summary of the entry for rel in a JSON document and
+// records digest as its source, updating the inputs checksum to match. It reports
+// whether the entry was found.
+func replaceJSONEntry(data []byte, baseDir, rel, summary, digest string) ([]byte, bool, error) {
+	var output JSONOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, false, fmt.Errorf("failed to parse JSON document: %w", err)
+	}
+	found := false
+	var rels []string
+	sources := make(map[string]string)
+	for dir, entries := range output.Directories {
+		for i, entry := range entries {
+			path := filepath.Clean(entry.Path)
+			if path == rel {
+				output.Directories[dir][i].Summary = summary
+				addFileMetadata(baseDir, &output.Directories[dir][i], map[string]string{filepath.ToSlash(rel): digest})
+				found = true
+			}
+			rels = append(rels, path)
+			if hash, ok := strings.CutPrefix(output.Directories[dir][i].Hash, "sha256:"); ok {
+				sources[filepath.ToSlash(path)] = hash
+			}
+		}
+	}
+	if !found {
+		return nil, false, nil
+	}
+	output.InputsSHA256 = digestsChecksum(sourceDigests(baseDir, rels, sources))
+	out, err := json.MarshalIndent(output, "", "  ")
+	return out, true, err
+}
+
+// updateDocEntry surgically updates the summary for rel in the document under baseDir,
+// recording digest as the source it was generated from. If the file has no entry yet,
+// the document is regenerated from its existing entries and appendices plus the new
+// one.
+func updateDocEntry(baseDir, rel, summary, digest string) error {
+	docPath := docPathFor(baseDir)
+	data, err := os.ReadFile(docPath)
+	if err != nil {
+		return err
+	}
+
+	switch detectDocKind(data) {
+	case docKindHTML, docKindAsciiDoc:
+		return fmt.Errorf("refresh does not support HTML or AsciiDoc documents; rerun summarize-yaml with --regenerate-path %s", filepath.ToSlash(rel))
+	case docKindJSON:
+		out, found, err := replaceJSONEntry(data, baseDir, rel, summary, digest)
+		if err != nil {
+			return err
+		}
+		if found {
+			return os.WriteFile(docPath, out, 0o644)
+		}
+		return fmt.Errorf("%s has no entry for %s; rerun summarize-yaml to add it", docPath, rel)
+	}
+
+	lines := strings.Split(string(data), "\n")
+	if replaceDocEntry(lines, rel, summary) {
+		restampDocEntry(lines, baseDir, rel, digest)
+		return os.WriteFile(docPath, []byte(strings.Join(lines, "\n")), 0o644)
+	}
+	existing := make(map[string]string)
+	parseSummaryLines(lines, existing)
+	existing[rel] = summary
+	sources := readDocSources(docPath)
+	sources[filepath.ToSlash(rel)] = digest
+	return writeSummary(baseDir, groupRecoveredSummaries(baseDir, existing), sources, readDocAppendices(docPath)...)
+}
+
+// runRefresh is the main logic for the refresh command.
+func runRefresh(file string) error {
+	setupLogging()
+	dir := refreshDir
+	if dir == "" {
+		dir = filepath.Dir(file)
+	}
+	if err := validateRunFlags(dir); err != nil {
+		return err
+	}
+	llm, err := createProvider()
+	if err != nil {
+		return fmt.Errorf("failed to create %s provider: %w", provider, err)
+	}
+	return runRefreshWithProvider(file, llm)
+}
+
+// runRefreshWithProvider re-summarizes a single file with the given provider and updates
+// its entry in the document and, if present, its cache entry.
+func runRefreshWithProvider(file string, llm LLMProvider) error {
+	if !isYAMLName(file) {
+		return fmt.Errorf("%s is not a YAML file", file)
+	}
+	if _, err := os.Stat(file); err != nil {
+		return err
+	}
+
+	baseDir := refreshDir
+	if baseDir == "" {
+		var err error
+		if baseDir, err = findDocDir(file); err != nil {
+			return err
+		}
+	}
+	absBase, err := filepath.Abs(baseDir)
+	if err != nil {
+		return err
+	}
+	absFile, err := filepath.Abs(file)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(absBase, absFile)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("%s is not under %s", file, baseDir)
+	}
+
+	available, err := llm.Available(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to check model availability: %w", err)
+	}
+	if !available {
+		return fmt.Errorf("model %s is not available. Please ensure it is downloaded and available in your %s provider", providerModel(llm), llm.Name())
+	}
+
+	// Taken before summarizing, so an edit made meanwhile is summarized next time
+	digest := currentDigest(file)
+	var summary string
+	if err := auditRun(baseDir, llm, func(llm LLMProvider) error {
+		summary, err = summarizeYAMLFile(context.Background(), llm, baseDir, file)
+		return err
+	}); err != nil {
+		return err
+	}
+	if err := updateDocEntry(baseDir, rel, summary, digest); err != nil {
+		return fmt.Errorf("failed to update %s: %w", docPathFor(baseDir), err)
+	}
+
+	repoRoot := cacheRoot()
+	if _, statErr := os.Stat(cacheEntryPath(repoRoot, rel)); localCache || statErr == nil {
+		if err := writeIndividualSummary(repoRoot, absBase, absFile, summary); err != nil {
+			return fmt.Errorf("failed to update cache entry: %w", err)
+		}
+	}
+
+	porcelainf("refreshed", "path", filepath.ToSlash(rel))
+	statusf("Refreshed %s: %s\n", filepath.ToSlash(rel), summary)
+	return nil
+}
+
+// refreshCmd re-summarizes exactly one file and updates its entry in place.
+var refreshCmd = &cobra.Command{
+	Use:   "refresh [file]",
+	Short: "Re-summarize a single YAML file and update its entry in place",
+	Long: `Re-summarize exactly one YAML file and surgically update its line in the generated
+document and its cache entry, leaving every other entry untouched. Unless --dir is
+set, the documented directory is the nearest one above the file whose document has an
+entry for it, or else the nearest one with a document.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRefresh(args[0])
+	},
+}
+
+var refreshDir string
+
+func init() {
+	rootCmd.AddCommand(refreshCmd)
+	refreshCmd.Flags().StringVar(&refreshDir, "dir", "", "The documented directory (default: nearest directory above the file whose document has an entry for it)")
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// asciidocRenderer renders the document as AsciiDoc, for documentation sites built with
+// Asciidoctor or Antora. Markers that markdown documents keep in HTML comments are
+// AsciiDoc line comments instead.
+type asciidocRenderer struct{}
+
+// Render implements Renderer.
+func (asciidocRenderer) Render(w io.Writer, baseDir string, grouped map[string][][2]string, sources map[string]string, appendices []docAppendix) error {
+	if _, err := io.WriteString(w, asciidocHeader()+asciidocStats(baseDir, grouped)); err != nil {
+		return err
+	}
+	leading, trailing := splitAppendices(appendices)
+	if err := writeAsciidocAppendices(w, baseDir, leading); err != nil {
+		return err
+	}
+
+	dirs, sorted := sortedDirs(grouped)
+
+	for _, dir := range dirs {
+		if _, err := fmt.Fprintf(w, "\n== link:%s/[%s]\n\n", docFileLink(baseDir, dir), dirHeading(dir)); err != nil {
+			return err
+		}
+		for _, entry := range sorted[dir] {
+			if _, err := fmt.Fprintf(w, "* link:%s[%s]: %s\n", docFileLink(baseDir, dir+"/"+entry[0]), entry[0], entry[1]); err != nil {
+				return err
+			}
+		}
+	}
+	if err := writeAsciidocAppendices(w, baseDir, trailing); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n// yaml-to-readme-inputs sha256:%s\n", groupedChecksum(baseDir, grouped, sources))
+	return err
+}
+
+// writeAsciidocAppendices renders appendices as AsciiDoc sections.
+func writeAsciidocAppendices(w io.Writer, baseDir string, appendices []docAppendix) error {
+	for _, a := range appendices {
+		if len(a.Entries) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "\n== %s\n\n", a.Title); err != nil {
+			return err
+		}
+		if a.Intro != "" {
+			if _, err := fmt.Fprintf(w, "%s\n\n", a.Intro); err != nil {
+				return err
+			}
+		}
+		for _, e := range a.Entries {
+			ref := "`" + e.Path + "`"
+			if a.Link {
+				ref = fmt.Sprintf("link:%s[`%s`]", docFileLink(baseDir, e.Path), e.Path)
+			}
+			if e.Anchor != "" {
+				ref = fmt.Sprintf("[[%s]]%s", e.Anchor, ref)
+			}
+			if _, err := fmt.Fprintf(w, "* %s — %s\n", ref, e.Note); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// asciidocHeader renders the document header in the --lang language, ending with the
+// schema marker.
+func asciidocHeader() string {
+	l := currentLabels()
+	return fmt.Sprintf("= %s\n\n%s\n\n== %s\n\n* %s\n* %s\n\n////\n%s\n////\n\n// yaml-to-readme schema:%d\n",
+		l.Title, l.Intro, l.HowToUse, l.HowToUseLinks, l.HowToUseSummary, l.MaintenanceNote, DocSchemaVersion)
+}
+
+// asciidocStats renders the --stats overview section, or nothing if it is disabled.
+func asciidocStats(baseDir string, grouped map[string][][2]string) string {
+	stats := statsFor(baseDir, grouped)
+	if stats == nil {
+		return ""
+	}
+	l := currentLabels()
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\n== %s\n\n", l.Overview)
+	fmt.Fprintf(&sb, "* *%s:* %d\n", l.StatsFiles, stats.Files)
+	fmt.Fprintf(&sb, "* *%s:* %d\n", l.StatsDirectories, stats.Directories)
+	if len(stats.Kinds) > 0 {
+		fmt.Fprintf(&sb, "* *%s:* %s\n", l.StatsKinds, kindsBreakdown(stats.Kinds))
+	}
+	fmt.Fprintf(&sb, "* *%s:* ~%d min\n", l.StatsReadingTime, stats.ReadingMinutes)
+	return sb.String()
+}
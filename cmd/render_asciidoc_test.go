@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsciidocRenderer(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "render_asciidoc_test_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "apps"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "web.yaml"), []byte("kind: Deployment\n"), 0644))
+
+	origLang := lang
+	origStatsEnabled := statsEnabled
+	defer func() {
+		lang = origLang
+		statsEnabled = origStatsEnabled
+	}()
+	lang = "en"
+	statsEnabled = false
+
+	grouped := map[string][][2]string{
+		"apps": {{"web.yaml", "Runs the web frontend."}},
+		"db":   {{"pg.yaml", "Runs Postgres."}},
+	}
+	appendices := []docAppendix{
+		{Title: "Key Files", Entries: []appendixEntry{{Path: "apps/web.yaml", Note: "Entry point."}}, Link: true, Leading: true},
+		{Title: "Findings", Intro: "Review these.", Entries: []appendixEntry{{Path: "apps/web.yaml", Note: "Runs as root.", Anchor: "finding-web"}}},
+		{Title: "Empty"},
+	}
+
+	var buf strings.Builder
+	assert.NoError(t, asciidocRenderer{}.Render(&buf, tmpDir, grouped, nil, appendices))
+	doc := buf.String()
+
+	// The header ends with the schema marker, which migrate reads back
+	assert.True(t, strings.HasPrefix(doc, asciidocHeader()))
+	assert.Contains(t, asciidocHeader(), "\n////\nTo keep this file up to date")
+	data := []byte(doc)
+	assert.Equal(t, docKindAsciiDoc, detectDocKind(data))
+	version, err := detectDocSchema(docKindAsciiDoc, data)
+	assert.NoError(t, err)
+	assert.Equal(t, DocSchemaVersion, version)
+
+	// Leading appendices come before the directories, the others after them, and
+	// appendices without entries are left out
+	keyFiles := strings.Index(doc, "\n== Key Files\n")
+	apps := strings.Index(doc, "\n== link:apps/[apps/]\n")
+	db := strings.Index(doc, "\n== link:db/[db/]\n")
+	findings := strings.Index(doc, "\n== Findings\n\nReview these.\n")
+	assert.True(t, keyFiles >= 0 && keyFiles < apps && apps < db && db < findings, doc)
+	assert.NotContains(t, doc, "Empty")
+	assert.Contains(t, doc, "* link:apps/web.yaml[web.yaml]: Runs the web frontend.\n")
+	assert.Contains(t, doc, "* link:apps/web.yaml[`apps/web.yaml`] — Entry point.\n")
+	assert.Contains(t, doc, "* [[finding-web]]`apps/web.yaml` — Runs as root.\n")
+
+	// The inputs checksum is the last line, as a line comment
+	assert.True(t, strings.HasSuffix(doc, "\n// yaml-to-readme-inputs sha256:"+groupedChecksum(tmpDir, grouped, nil)+"\n"))
+	docPath := filepath.Join(tmpDir, "yaml_details.adoc")
+	assert.NoError(t, os.WriteFile(docPath, data, 0644))
+	recorded, err := readInputsChecksum(docPath)
+	assert.NoError(t, err)
+	assert.Equal(t, groupedChecksum(tmpDir, grouped, nil), recorded)
+
+	// --stats adds the overview after the header
+	statsEnabled = true
+	buf.Reset()
+	assert.NoError(t, asciidocRenderer{}.Render(&buf, tmpDir, grouped, nil, nil))
+	doc = buf.String()
+	assert.Contains(t, doc, asciidocHeader()+"\n== Overview\n\n* *Files:* 2\n* *Directories:* 2\n")
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+)
+
+const htmlTemplate = `<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<meta name="yaml-to-readme-schema" content="{{.SchemaVersion}}">
+<meta name="yaml-to-readme-inputs" content="sha256:{{.InputsSHA256}}">
+<title>{{.Labels.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 900px; margin: 2rem auto; padding: 0 1rem; color: #333; }
+h1 { border-bottom: 2px solid #eee; padding-bottom: 0.5rem; }
+h2 { color: #555; margin-top: 2rem; }
+ul { list-style: none; padding-left: 0; }
+li { padding: 0.4rem 0; border-bottom: 1px solid #f0f0f0; }
+a { color: #0366d6; text-decoration: none; }
+a:hover { text-decoration: underline; }
+.summary { color: #666; }
+.meta { color: #999; font-size: 0.85rem; margin-top: 2rem; }
+</style>
+</head>
+<body>
+<h1>{{.Labels.Title}}</h1>
+<p>{{.Labels.HTMLIntro}}</p>
+{{with .Stats}}<h2>{{$.Labels.Overview}}</h2>
+<ul>
+<li><strong>{{$.Labels.StatsFiles}}:</strong> {{.Files}}</li>
+<li><strong>{{$.Labels.StatsDirectories}}:</strong> {{.Directories}}</li>
+{{if .Kinds}}<li><strong>{{$.Labels.StatsKinds}}:</strong> {{kindsBreakdown .Kinds}}</li>
+{{end}}<li><strong>{{$.Labels.StatsReadingTime}}:</strong> ~{{.ReadingMinutes}} min</li>
+</ul>
+{{end}}{{template "appendices" .Highlights}}{{range .Dirs}}<h2><a href="{{fileLink .Name}}/">{{.Heading}}</a></h2>
+<ul>
+{{range .Files}}<li><a href="{{fileLink .Path}}">{{.File}}</a>: <span class="summary">{{.Summary}}</span></li>
+{{end}}</ul>
+{{end}}{{template "appendices" .Appendices}}<p class="meta">{{printf .Labels.GeneratedAt .GeneratedAt .Model}}</p>
+</body>
+</html>
+{{define "appendices"}}{{range .}}{{if .Entries}}<h2>{{.Title}}</h2>
+{{if .Intro}}<p>{{.Intro}}</p>
+{{end}}<ul>
+{{$link := .Link}}{{range .Entries}}<li{{if .Anchor}} id="{{.Anchor}}"{{end}}>{{if $link}}<a href="{{fileLink .Path}}"><code>{{.Path}}</code></a>{{else}}<code>{{.Path}}</code>{{end}} — <span class="summary">{{.Note}}</span></li>
+{{end}}</ul>
+{{end}}{{end}}{{end}}`
+
+type htmlData struct {
+	SchemaVersion int
+	Lang          string
+	Labels        docLabels
+	Dirs          []htmlDir
+	Highlights    []docAppendix
+	Appendices    []docAppendix
+	Stats         *docStats
+	InputsSHA256  string
+	GeneratedAt   string
+	Model         string
+}
+
+type htmlDir struct {
+	Name    string
+	Heading string
+	Files   []JSONFileEntry
+}
+
+// htmlRenderer renders the document as a standalone HTML page.
+type htmlRenderer struct{}
+
+// Render implements Renderer.
+func (htmlRenderer) Render(w io.Writer, baseDir string, grouped map[string][][2]string, sources map[string]string, appendices []docAppendix) error {
+	tmpl, err := template.New("html").Funcs(template.FuncMap{
+		"kindsBreakdown": kindsBreakdown,
+		"fileLink":       func(relPath string) string { return docFileLink(baseDir, relPath) },
+	}).Parse(htmlTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse HTML template: %w", err)
+	}
+
+	dirs, sorted := sortedDirs(grouped)
+
+	var data htmlData
+	data.SchemaVersion = DocSchemaVersion
+	data.Lang = lang
+	data.Labels = currentLabels()
+	data.GeneratedAt = generatedAt()
+	data.Model = ModelName
+	data.Highlights, data.Appendices = splitAppendices(appendices)
+	data.Stats = statsFor(baseDir, grouped)
+	data.InputsSHA256 = groupedChecksum(baseDir, grouped, sources)
+
+	for _, dir := range dirs {
+		data.Dirs = append(data.Dirs, htmlDir{Name: dir, Heading: dirHeading(dir), Files: jsonEntries(dir, sorted[dir])})
+	}
+
+	return tmpl.Execute(w, data)
+}
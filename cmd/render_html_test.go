@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTMLRenderer(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "render_html_test_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "apps"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "apps", "web.yaml"), []byte("kind: Deployment\n"), 0644))
+
+	origLang := lang
+	origDeterministic := deterministic
+	defer func() {
+		lang = origLang
+		deterministic = origDeterministic
+	}()
+	lang = "en"
+	deterministic = true
+	t.Setenv("SOURCE_DATE_EPOCH", "0")
+
+	grouped := map[string][][2]string{"apps": {{"web.yaml", "Runs <script>alert(1)</script> & more."}}}
+	appendices := []docAppendix{
+		{Title: "Key Files", Entries: []appendixEntry{{Path: "apps/web.yaml", Note: "Entry point."}}, Link: true, Leading: true},
+		{Title: "Findings", Intro: "Review these.", Entries: []appendixEntry{{Path: "apps/web.yaml", Note: "Runs as root.", Anchor: "finding-web"}}},
+	}
+
+	var buf strings.Builder
+	assert.NoError(t, htmlRenderer{}.Render(&buf, tmpDir, grouped, nil, appendices))
+	doc := buf.String()
+
+	assert.True(t, strings.HasPrefix(doc, "<!DOCTYPE html>\n<html lang=\"en\">"))
+	assert.Contains(t, doc, "<title>YAML File Details</title>")
+	assert.Contains(t, doc, `<meta name="yaml-to-readme-inputs" content="sha256:`+groupedChecksum(tmpDir, grouped, nil)+`">`)
+	// Summaries are escaped
+	assert.Contains(t, doc, `<a href="apps/web.yaml">web.yaml</a>: <span class="summary">Runs &lt;script&gt;alert(1)&lt;/script&gt; &amp; more.</span>`)
+	assert.NotContains(t, doc, "<script>")
+
+	// Leading appendices come before the directories, the others after them
+	keyFiles := strings.Index(doc, "<h2>Key Files</h2>")
+	apps := strings.Index(doc, `<h2><a href="apps/">apps/</a></h2>`)
+	findings := strings.Index(doc, "<h2>Findings</h2>")
+	assert.True(t, keyFiles >= 0 && keyFiles < apps && apps < findings, doc)
+	assert.Contains(t, doc, `<li><a href="apps/web.yaml"><code>apps/web.yaml</code></a> — <span class="summary">Entry point.</span></li>`)
+	assert.Contains(t, doc, `<li id="finding-web"><code>apps/web.yaml</code> — <span class="summary">Runs as root.</span></li>`)
+	assert.Contains(t, doc, `<p class="meta">Generated at 1970-01-01T00:00:00Z using model `+ModelName+`</p>`)
+
+	// Labels follow --lang
+	lang = "de"
+	buf.Reset()
+	assert.NoError(t, htmlRenderer{}.Render(&buf, tmpDir, grouped, nil, nil))
+	doc = buf.String()
+	assert.Contains(t, doc, `<html lang="de">`)
+	assert.Contains(t, doc, "<title>YAML-Dateiübersicht</title>")
+}
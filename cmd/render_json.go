@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// JSONOutput represents the structured JSON output format.
+type JSONOutput struct {
+	SchemaVersion int                        `json:"schema_version"`
+	BaseDirectory string                     `json:"base_directory"`
+	GeneratedAt   string                     `json:"generated_at"`
+	Model         string                     `json:"model"`
+	Directories   map[string][]JSONFileEntry `json:"directories"`
+	// DirectoryNames holds the display names of aliased directories, keyed like
+	// Directories.
+	DirectoryNames map[string]string `json:"directory_names,omitempty"`
+	Appendices     []docAppendix     `json:"appendices,omitempty"`
+	Stats          *docStats         `json:"stats,omitempty"`
+	InputsSHA256   string            `json:"inputs_sha256"`
+}
+
+// JSONFileEntry represents a single file entry in the JSON output.
+type JSONFileEntry struct {
+	File    string `json:"file"`
+	Path    string `json:"path"`
+	Summary string `json:"summary"`
+	// Hash is the SHA-256 of the content the summary was generated from, as
+	// "sha256:<hex>".
+	Hash string `json:"hash,omitempty"`
+	// ModifiedAt is the file's modification time, left out with --deterministic.
+	ModifiedAt string `json:"modified_at,omitempty"`
+}
+
+// jsonRenderer renders the document as structured JSON.
+type jsonRenderer struct{}
+
+// Render implements Renderer.
+func (jsonRenderer) Render(w io.Writer, baseDir string, grouped map[string][][2]string, sources map[string]string, appendices []docAppendix) error {
+	output := JSONOutput{
+		SchemaVersion: DocSchemaVersion,
+		BaseDirectory: baseDir,
+		GeneratedAt:   generatedAt(),
+		Model:         ModelName,
+		Directories:   make(map[string][]JSONFileEntry),
+		Appendices:    appendices,
+		Stats:         statsFor(baseDir, grouped),
+		InputsSHA256:  groupedChecksum(baseDir, grouped, sources),
+	}
+
+	dirs, sorted := sortedDirs(grouped)
+
+	for _, dir := range dirs {
+		entries := jsonEntries(dir, sorted[dir])
+		for i := range entries {
+			addFileMetadata(baseDir, &entries[i], sources)
+		}
+		output.Directories[dir+"/"] = entries
+		if name := dirAliases[filepath.ToSlash(dir)]; name != "" {
+			if output.DirectoryNames == nil {
+				output.DirectoryNames = make(map[string]string)
+			}
+			output.DirectoryNames[dir+"/"] = name
+		}
+	}
+
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// addFileMetadata records the digest of the content an entry's summary was generated
+// from and the modification time of its file, for downstream tools that track changes
+// without reading every file. The modification time is left out with --deterministic,
+// since a checkout resets it.
+func addFileMetadata(baseDir string, entry *JSONFileEntry, sources map[string]string) {
+	path := filepath.Join(baseDir, entry.Path)
+	rel := filepath.ToSlash(entry.Path)
+	if digest := sourceDigests(baseDir, []string{rel}, sources)[rel]; digest != "missing" {
+		entry.Hash = "sha256:" + digest
+	}
+	if deterministic {
+		return
+	}
+	if info, err := os.Stat(path); err == nil {
+		entry.ModifiedAt = info.ModTime().UTC().Format(time.RFC3339)
+	}
+}
+
+// resolveOutputName names the document DefaultJSONFileName for --format json unless
+// --output is given.
+func resolveOutputName(cmd *cobra.Command) {
+	if outputFormat == "json" && !cmd.Flags().Changed("output") {
+		markdownFileName = DefaultJSONFileName
+	}
+}
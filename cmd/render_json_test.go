@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONRenderer(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "render_json_test_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "apps"), 0755))
+	webPath := filepath.Join(tmpDir, "apps", "web.yaml")
+	assert.NoError(t, os.WriteFile(webPath, []byte("kind: Deployment\n"), 0644))
+
+	origDeterministic := deterministic
+	origDirAliases := dirAliases
+	defer func() {
+		deterministic = origDeterministic
+		dirAliases = origDirAliases
+	}()
+	deterministic = false
+	dirAliases = map[string]string{"apps": "Applications"}
+
+	grouped := map[string][][2]string{
+		"apps": {{"web.yaml", "Runs the web frontend."}},
+		"db":   {{"pg.yaml", "Runs Postgres."}},
+	}
+	appendices := []docAppendix{{Title: "Findings", Entries: []appendixEntry{{Path: "apps/web.yaml", Note: "Runs as root."}}}}
+	sources := map[string]string{"db/pg.yaml": strings.Repeat("a", 64)}
+
+	var buf strings.Builder
+	assert.NoError(t, jsonRenderer{}.Render(&buf, tmpDir, grouped, sources, appendices))
+	var output JSONOutput
+	assert.NoError(t, json.Unmarshal([]byte(buf.String()), &output))
+
+	assert.Equal(t, DocSchemaVersion, output.SchemaVersion)
+	assert.Equal(t, tmpDir, output.BaseDirectory)
+	assert.Equal(t, ModelName, output.Model)
+	assert.Equal(t, appendices, output.Appendices)
+	assert.Equal(t, map[string]string{"apps/": "Applications"}, output.DirectoryNames)
+	assert.Equal(t, groupedChecksum(tmpDir, grouped, sources), output.InputsSHA256)
+
+	// Entries record the digest their summary was generated from and their file's
+	// modification time; a missing file has neither
+	web := output.Directories["apps/"][0]
+	assert.Equal(t, "web.yaml", web.File)
+	assert.Equal(t, filepath.Join("apps", "web.yaml"), web.Path)
+	assert.Equal(t, "Runs the web frontend.", web.Summary)
+	assert.Equal(t, "sha256:"+currentDigest(webPath), web.Hash)
+	assert.NotEmpty(t, web.ModifiedAt)
+	pg := output.Directories["db/"][0]
+	assert.Equal(t, "sha256:"+strings.Repeat("a", 64), pg.Hash)
+	assert.Empty(t, pg.ModifiedAt)
+
+	// The recorded digests read back
+	docPath := filepath.Join(tmpDir, DefaultJSONFileName)
+	assert.NoError(t, os.WriteFile(docPath, []byte(buf.String()), 0644))
+	assert.Equal(t, map[string]string{"apps/web.yaml": currentDigest(webPath), "db/pg.yaml": strings.Repeat("a", 64)}, readDocSources(docPath))
+
+	// --deterministic leaves out the modification time and fixes the timestamp
+	deterministic = true
+	t.Setenv("SOURCE_DATE_EPOCH", "0")
+	buf.Reset()
+	assert.NoError(t, jsonRenderer{}.Render(&buf, tmpDir, grouped, sources, nil))
+	output = JSONOutput{}
+	assert.NoError(t, json.Unmarshal([]byte(buf.String()), &output))
+	assert.Equal(t, "1970-01-01T00:00:00Z", output.GeneratedAt)
+	assert.Empty(t, output.Directories["apps/"][0].ModifiedAt)
+	assert.Empty(t, output.Appendices)
+}
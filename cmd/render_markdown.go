@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// markdownRenderer renders the default markdown document, linking every file relative
+// to the document.
+type markdownRenderer struct{}
+
+// Render implements Renderer.
+func (markdownRenderer) Render(w io.Writer, baseDir string, grouped map[string][][2]string, sources map[string]string, appendices []docAppendix) error {
+	if _, err := io.WriteString(w, documentHeader()+docSchemaMarker()+stableMarkdownStats(baseDir, grouped)); err != nil {
+		return err
+	}
+	fileLink := func(path string) string {
+		return fmt.Sprintf("[`%s`](%s)", path, docFileLink(baseDir, path))
+	}
+	leading, trailing := splitAppendices(appendices)
+	if err := writeMarkdownAppendices(w, leading, fileLink); err != nil {
+		return err
+	}
+
+	dirs, sorted := sortedDirs(grouped)
+
+	for _, dir := range dirs {
+		if _, err := fmt.Fprintf(w, "\n## [%s](%s/)\n", dirHeading(dir), docFileLink(baseDir, dir)); err != nil {
+			return err
+		}
+		for _, entry := range sorted[dir] {
+			if _, err := fmt.Fprintf(w, "- [%s](%s): %s%s\n", entry[0], docFileLink(baseDir, dir+"/"+entry[0]), entry[1], entryKey(baseDir, filepath.Join(dir, entry[0]), sources)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := writeMarkdownAppendices(w, trailing, fileLink); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, checksumFooter(baseDir, grouped, sources))
+	return err
+}
+
+// wikiRenderer renders the document as a GitHub wiki page.
+type wikiRenderer struct{}
+
+// Render implements Renderer.
+func (wikiRenderer) Render(w io.Writer, baseDir string, grouped map[string][][2]string, sources map[string]string, appendices []docAppendix) error {
+	if _, err := io.WriteString(w, documentHeader()+docSchemaMarker()+stableMarkdownStats(baseDir, grouped)); err != nil {
+		return err
+	}
+	fileLink := func(path string) string {
+		return wikiFileLink(filepath.Dir(path), filepath.Base(path))
+	}
+	leading, trailing := splitAppendices(appendices)
+	if err := writeMarkdownAppendices(w, leading, fileLink); err != nil {
+		return err
+	}
+
+	dirs, sorted := sortedDirs(grouped)
+
+	for _, dir := range dirs {
+		if _, err := fmt.Fprintf(w, "\n## %s\n", dirHeading(dir)); err != nil {
+			return err
+		}
+		for _, entry := range sorted[dir] {
+			if _, err := fmt.Fprintf(w, "- %s: %s%s\n", wikiFileLink(dir, entry[0]), entry[1], entryKey(baseDir, filepath.Join(dir, entry[0]), sources)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := writeMarkdownAppendices(w, trailing, fileLink); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, checksumFooter(baseDir, grouped, sources))
+	return err
+}
+
+// wikiFileLink renders a file reference for the GitHub wiki renderer, which does not
+// resolve repository-relative paths. When wikiBaseURL is set the file is linked with
+// wiki link syntax to its absolute URL, otherwise the path is rendered as inline code.
+func wikiFileLink(dir, file string) string {
+	relPath := filepath.ToSlash(filepath.Join(dir, file))
+	if wikiBaseURL == "" {
+		return "`" + relPath + "`"
+	}
+	return fmt.Sprintf("[[%s|%s/%s]]", file, strings.TrimSuffix(wikiBaseURL, "/"), relPath)
+}
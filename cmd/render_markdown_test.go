@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdownRenderer(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "render_markdown_test_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "apps"), 0755))
+	webPath := filepath.Join(tmpDir, "apps", "web.yaml")
+	assert.NoError(t, os.WriteFile(webPath, []byte("kind: Deployment\n"), 0644))
+
+	origStableEntries := stableEntries
+	defer func() {
+		stableEntries = origStableEntries
+	}()
+	stableEntries = false
+
+	grouped := map[string][][2]string{
+		"apps": {{"web.yaml", "Runs the web frontend."}},
+		"db":   {{"pg.yaml", "Runs Postgres."}},
+	}
+	appendices := []docAppendix{
+		{Title: "Key Files", Entries: []appendixEntry{{Path: "apps/web.yaml", Note: "Entry point."}}, Link: true, Leading: true},
+		{Title: "Findings", Intro: "Review these.", Entries: []appendixEntry{{Path: "apps/web.yaml", Note: "Runs as root.", Anchor: "finding-web"}}},
+		{Title: "Empty"},
+	}
+	sources := map[string]string{"db/pg.yaml": strings.Repeat("a", 64)}
+
+	var buf strings.Builder
+	assert.NoError(t, markdownRenderer{}.Render(&buf, tmpDir, grouped, sources, appendices))
+	doc := buf.String()
+
+	assert.True(t, strings.HasPrefix(doc, documentHeader()+docSchemaMarker()))
+	// Leading appendices come before the directories, the others after them, and
+	// appendices without entries are left out
+	keyFiles := strings.Index(doc, "Key Files")
+	apps := strings.Index(doc, "## [apps/](apps/)")
+	db := strings.Index(doc, "## [db/](db/)")
+	findings := strings.Index(doc, "Findings")
+	assert.True(t, keyFiles < apps && apps < db && db < findings, doc)
+	assert.NotContains(t, doc, "Empty")
+	assert.Contains(t, doc, "- [web.yaml](apps/web.yaml): Runs the web frontend.\n")
+	assert.Contains(t, doc, "[`apps/web.yaml`](apps/web.yaml) — Entry point.")
+
+	// The footer records the recorded digest, or the current one for entries without
+	assert.Contains(t, doc, "<!-- yaml-to-readme-entry sha256:"+currentDigest(webPath)+" apps/web.yaml -->\n")
+	assert.Contains(t, doc, "<!-- yaml-to-readme-entry sha256:"+strings.Repeat("a", 64)+" db/pg.yaml -->\n")
+	assert.True(t, strings.HasSuffix(doc, "<!-- yaml-to-readme-inputs sha256:"+groupedChecksum(tmpDir, grouped, sources)+" -->\n"))
+
+	// The document reads back
+	lines := strings.Split(doc, "\n")
+	existing := make(map[string]string)
+	parseSummaryLines(lines, existing)
+	assert.Equal(t, "Runs the web frontend.", existing[filepath.Join("apps", "web.yaml")])
+	assert.Equal(t, "Runs Postgres.", existing[filepath.Join("db", "pg.yaml")])
+
+	// With --stable-entries each entry carries its key instead of a footer
+	stableEntries = true
+	buf.Reset()
+	assert.NoError(t, markdownRenderer{}.Render(&buf, tmpDir, grouped, sources, nil))
+	doc = buf.String()
+	assert.Contains(t, doc, "- [web.yaml](apps/web.yaml): Runs the web frontend. <!-- yaml-to-readme-entry sha256:"+currentDigest(webPath)+" apps/web.yaml -->\n")
+	assert.NotContains(t, doc, "yaml-to-readme-inputs")
+	assert.Equal(t, groupedChecksum(tmpDir, grouped, sources), entryKeysChecksum([]byte(doc)))
+}
+
+func TestWikiRenderer(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "render_wiki_test_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	origWikiBaseURL := wikiBaseURL
+	origStableEntries := stableEntries
+	defer func() {
+		wikiBaseURL = origWikiBaseURL
+		stableEntries = origStableEntries
+	}()
+	stableEntries = false
+
+	grouped := map[string][][2]string{"apps": {{"web.yaml", "Runs the web frontend."}}}
+	appendices := []docAppendix{{Title: "Findings", Entries: []appendixEntry{{Path: "apps/web.yaml", Note: "Runs as root."}}, Link: true}}
+
+	// Without a base URL files are rendered as inline code
+	wikiBaseURL = ""
+	var buf strings.Builder
+	assert.NoError(t, wikiRenderer{}.Render(&buf, tmpDir, grouped, nil, appendices))
+	doc := buf.String()
+	assert.Contains(t, doc, "\n## apps/\n- `apps/web.yaml`: Runs the web frontend.\n")
+	assert.Contains(t, doc, "- `apps/web.yaml` — Runs as root.")
+	assert.Contains(t, doc, "<!-- yaml-to-readme-inputs sha256:")
+
+	// With one they are wiki links to the absolute URL
+	wikiBaseURL = "https://github.com/org/repo/blob/main/"
+	buf.Reset()
+	assert.NoError(t, wikiRenderer{}.Render(&buf, tmpDir, grouped, nil, appendices))
+	doc = buf.String()
+	assert.Contains(t, doc, "- [[web.yaml|https://github.com/org/repo/blob/main/apps/web.yaml]]: Runs the web frontend.\n")
+
+	existing := make(map[string]string)
+	parseSummaryLines(strings.Split(doc, "\n"), existing)
+	assert.Equal(t, "Runs the web frontend.", existing[filepath.Join("apps", "web.yaml")])
+}
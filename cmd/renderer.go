@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Renderer writes the summaries document in one output format. Each format selectable
+// with --format is a Renderer registered in renderers.
+type Renderer interface {
+	// Render writes the document for the grouped summaries, keyed by directory relative
+	// to baseDir, followed by any appendices. sources holds the digests of the content
+	// the summaries were generated from, keyed by slash-separated relative path.
+	Render(w io.Writer, baseDir string, grouped map[string][][2]string, sources map[string]string, appendices []docAppendix) error
+}
+
+// renderers maps each --format value to its renderer.
+var renderers = map[string]Renderer{
+	"markdown":    markdownRenderer{},
+	"github-wiki": wikiRenderer{},
+	"json":        jsonRenderer{},
+	"html":        htmlRenderer{},
+	"asciidoc":    asciidocRenderer{},
+}
+
+// supportedFormats returns the --format values in sorted order.
+func supportedFormats() []string {
+	formats := make([]string, 0, len(renderers))
+	for format := range renderers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// validateFormat checks that --format names a registered renderer.
+func validateFormat() error {
+	if _, ok := renderers[outputFormat]; !ok {
+		return fmt.Errorf("unsupported --format %q, expected one of: %s", outputFormat, strings.Join(supportedFormats(), ", "))
+	}
+	return nil
+}
+
+// documentOut receives the document when it is written to stdout with -o -.
+var documentOut io.Writer = os.Stdout
+
+// renderDocument writes the document for baseDir to its output file with r, between
+// the markers of the --inject file, or to stdout with -o -. Missing parent directories
+// of the output file are created.
+func renderDocument(r Renderer, baseDir string, grouped map[string][][2]string, sources map[string]string, appendices []docAppendix) error {
+	if injectPath != "" {
+		var buf bytes.Buffer
+		if err := r.Render(&buf, baseDir, grouped, sources, appendices); err != nil {
+			return err
+		}
+		return writeInjected(docPathFor(baseDir), buf.Bytes())
+	}
+	if writesToStdout() {
+		return r.Render(documentOut, baseDir, grouped, sources, appendices)
+	}
+	docPath := docPathFor(baseDir)
+	if err := os.MkdirAll(filepath.Dir(docPath), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(docPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		cerr := f.Close()
+		if cerr != nil {
+			slog.Warn("error closing file", "file", docPath, "error", cerr)
+		}
+	}()
+	return r.Render(f, baseDir, grouped, sources, appendices)
+}
+
+// writeSummary writes the document with the renderer selected by the outputFormat flag.
+func writeSummary(baseDir string, grouped map[string][][2]string, sources map[string]string, appendices ...docAppendix) error {
+	if err := validateFormat(); err != nil {
+		return err
+	}
+	return renderDocument(renderers[outputFormat], baseDir, grouped, sources, appendices)
+}
+
+// writeMarkdownSummary writes the grouped summaries as a markdown document regardless
+// of --format, as repair-doc does.
+func writeMarkdownSummary(baseDir string, grouped map[string][][2]string, sources map[string]string, appendices ...docAppendix) error {
+	return renderDocument(markdownRenderer{}, baseDir, grouped, sources, appendices)
+}
+
+// jsonEntries returns the entries of one directory with their paths relative to the
+// base directory, for the renderers that list entries as structured data.
+func jsonEntries(dir string, entries [][2]string) []JSONFileEntry {
+	files := make([]JSONFileEntry, 0, len(entries))
+	for _, entry := range entries {
+		files = append(files, JSONFileEntry{
+			File:    entry[0],
+			Path:    filepath.Join(dir, entry[0]),
+			Summary: entry[1],
+		})
+	}
+	return files
+}
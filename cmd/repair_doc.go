@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sebrandon1/yaml-to-readme/summarizer"
+	"github.com/spf13/cobra"
+)
+
+// docHeadingPattern matches a markdown heading of any level, capturing its text.
+var docHeadingPattern = regexp.MustCompile(`^#{2,6}\s+(.+?)\s*$`)
+
+// docEntryPattern matches a bullet entry whose file reference is a link, bold, inline
+// code, or a bare name, followed by a ":", "-" or "—" separator and the summary.
+var docEntryPattern = regexp.MustCompile("^\\s*[-*+]\\s+(?:\\[([^\\]]+)\\]\\([^)]*\\)|\\*\\*([^*]+)\\*\\*|`([^`]+)`|([^\\s:]+))\\s*(?::|\\s-|\\s—)\\s*(.*)$")
+
+// canonicalEntryPattern matches an entry exactly as writeMarkdownSummary renders it.
+var canonicalEntryPattern = regexp.MustCompile(`^- \[[^\]]+\]\([^)]*\): \S`)
+
+// docIssue describes a deviation from the canonical document structure.
+type docIssue struct {
+	Line    int
+	Message string
+}
+
+// lintDocStructure leniently parses a summaries document, recovering entries from
+// common manual edits, and reports every deviation from the canonical structure.
+// Plain headings are treated as directory sections if they end in "/" or name an
+// existing directory under baseDir.
+func lintDocStructure(baseDir string, lines []string) (map[string]string, []docIssue) {
+	recovered := make(map[string]string)
+	var issues []docIssue
+	currentDir := ""
+	inSection := false
+
+	for i, line := range lines {
+		lineNo := i + 1
+		if m := docHeadingPattern.FindStringSubmatch(line); m != nil {
+			dir, canonical, ok := parseDirHeading(baseDir, m[1])
+			if !ok {
+				// Not a directory heading (e.g. "How to Use"); entries after it are orphaned
+				inSection = false
+				continue
+			}
+			if !canonical || !strings.HasPrefix(line, "## ") {
+				issues = append(issues, docIssue{lineNo, fmt.Sprintf("directory heading for %s/ is not in canonical form", dir)})
+			}
+			currentDir = dir
+			inSection = true
+			continue
+		}
+
+		if !inSection && appendixEntryPattern.MatchString(line) {
+			// An appendix entry, kept by repairDoc as is
+			continue
+		}
+		m := docEntryPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		name := firstNonEmpty(m[1], m[2], m[3], m[4])
+		if !isYAMLName(name) {
+			continue
+		}
+		summary := strings.TrimSpace(m[5])
+		key := filepath.Clean(filepath.FromSlash(name))
+		if !strings.Contains(name, "/") {
+			if !inSection {
+				issues = append(issues, docIssue{lineNo, fmt.Sprintf("entry for %s is outside any directory section", name)})
+			}
+			key = filepath.Join(currentDir, name)
+		}
+
+		switch {
+		case summary == "":
+			issues = append(issues, docIssue{lineNo, fmt.Sprintf("entry for %s has an empty summary", key)})
+			continue
+		case !canonicalEntryPattern.MatchString(line) || strings.Contains(name, "/"):
+			issues = append(issues, docIssue{lineNo, fmt.Sprintf("entry for %s is not in canonical form", key)})
+		}
+		if _, dup := recovered[key]; dup {
+			issues = append(issues, docIssue{lineNo, fmt.Sprintf("duplicate entry for %s; keeping the last one", key)})
+		}
+		recovered[key] = summary
+	}
+	return recovered, issues
+}
+
+// parseDirHeading extracts the directory from a heading's text. It reports whether the
+// heading is a directory heading at all and whether it is in the canonical linked form.
+func parseDirHeading(baseDir, text string) (string, bool, bool) {
+	if m := headingLinkPattern.FindStringSubmatch(text); m != nil && strings.HasPrefix(text, "[") {
+		return filepath.Clean(strings.TrimSuffix(m[1], "/")), strings.HasSuffix(m[1], "/"), true
+	}
+	text = strings.Trim(text, "`*")
+	if strings.HasSuffix(text, "/") {
+		return filepath.Clean(strings.TrimSuffix(text, "/")), false, true
+	}
+	if strings.Contains(text, " ") {
+		return "", false, false
+	}
+	if info, err := os.Stat(filepath.Join(baseDir, text)); err == nil && info.IsDir() {
+		return filepath.Clean(text), false, true
+	}
+	return "", false, false
+}
+
+// isYAMLName reports whether a file name has a YAML extension, including any added
+// with --match-extensions.
+func isYAMLName(name string) bool {
+	return summarizer.HasYAMLExtension(name, documentedExtensions()...)
+}
+
+// firstNonEmpty returns the first non-empty string in values.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// groupRecoveredSummaries groups summaries keyed by path relative to baseDir the same
+// way groupSummariesByDir groups freshly generated ones.
+func groupRecoveredSummaries(baseDir string, recovered map[string]string) map[string][][2]string {
+	files := make([]string, 0, len(recovered))
+	summaries := make(map[string]string, len(recovered))
+	for rel, summary := range recovered {
+		file := filepath.Join(baseDir, rel)
+		files = append(files, file)
+		summaries[file] = summary
+	}
+	sort.Strings(files)
+	return groupSummariesByDir(files, summaries, baseDir)
+}
+
+// repairDoc rewrites the document in canonical form from the recovered summaries,
+// keeping the digests it recorded for them and its appendices.
+func repairDoc(baseDir string, recovered map[string]string) error {
+	docPath := docPathFor(baseDir)
+	return writeMarkdownSummary(baseDir, groupRecoveredSummaries(baseDir, recovered), readDocSources(docPath), readDocAppendices(docPath)...)
+}
+
+// runRepairDoc is the main logic for the repair-doc command.
+func runRepairDoc(dir string) error {
+	setupLogging()
+	docPath := docPathFor(dir)
+	lines := readLinesFromFile(docPath)
+	if lines == nil {
+		return fmt.Errorf("failed to read %s", docPath)
+	}
+
+	recovered, issues := lintDocStructure(dir, lines)
+	if len(issues) == 0 {
+		porcelainf("repair", "status", "canonical", "entries", len(recovered))
+		statusf("%s is in canonical form (%d entries)\n", docPath, len(recovered))
+		return nil
+	}
+	statusf("Structural issues in %s:\n", docPath)
+	for _, issue := range issues {
+		porcelainf("issue", "line", issue.Line, "message", issue.Message)
+		statusf("  line %d: %s\n", issue.Line, issue.Message)
+	}
+	if repairCheckOnly {
+		porcelainf("repair", "status", "malformed", "issues", len(issues))
+		return fmt.Errorf("%d structural issue(s) found", len(issues))
+	}
+
+	if err := repairDoc(dir, recovered); err != nil {
+		return fmt.Errorf("failed to repair %s: %w", docPath, err)
+	}
+	porcelainf("repair", "status", "repaired", "issues", len(issues), "entries", len(recovered))
+	statusf("\nRepaired %s (%d entries kept)\n", docPath, len(recovered))
+	return nil
+}
+
+// repairDocCmd detects malformed sections in the document and normalizes it.
+var repairDocCmd = &cobra.Command{
+	Use:   "repair-doc [directory]",
+	Short: "Detect malformed sections in the generated document and normalize it",
+	Long: `Leniently parse the generated markdown document, recovering entries from manual edits
+such as renamed headings, missing links, or different bullet styles, report every
+deviation from the canonical structure, and rewrite the document in canonical form
+without losing any summaries.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRepairDoc(args[0])
+	},
+}
+
+var repairCheckOnly bool
+
+func init() {
+	rootCmd.AddCommand(repairDocCmd)
+	repairDocCmd.Flags().BoolVar(&repairCheckOnly, "check", false, "Only report structural issues and exit non-zero if any are found")
+}
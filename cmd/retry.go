@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// DefaultFailedListFileName is the file, next to the document, that lists the files
+// that failed to summarize in the last run.
+const DefaultFailedListFileName = ".yaml_to_readme_failed"
+
+// failedFiles returns the paths, relative to dir, of files that have no summary after a run.
+func failedFiles(dir string, yamlFiles []string, summaries map[string]string) []string {
+	var failed []string
+	for _, file := range yamlFiles {
+		if summaries[file] == "" {
+			rel, _ := filepath.Rel(dir, file)
+			failed = append(failed, filepath.ToSlash(rel))
+		}
+	}
+	sort.Strings(failed)
+	return failed
+}
+
+// writeFailedList persists the failed files for retry-failed, removing the list when
+// nothing failed.
+func writeFailedList(dir string, failed []string) error {
+	listPath := filepath.Join(outputDir(dir), DefaultFailedListFileName)
+	if len(failed) == 0 {
+		if err := os.Remove(listPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(listPath, []byte(strings.Join(failed, "\n")+"\n"), 0o644)
+}
+
+// readFailedList returns the files recorded as failed in the last run under dir.
+func readFailedList(dir string) []string {
+	var failed []string
+	for _, line := range readLinesFromFile(filepath.Join(outputDir(dir), DefaultFailedListFileName)) {
+		if line = strings.TrimSpace(line); line != "" {
+			failed = append(failed, line)
+		}
+	}
+	return failed
+}
+
+// runRetryFailed is the main logic for the retry-failed command.
+func runRetryFailed(dir string) error {
+	setupLogging()
+	if err := validateRunFlags(dir); err != nil {
+		return err
+	}
+	llm, err := createProvider()
+	if err != nil {
+		return fmt.Errorf("failed to create %s provider: %w", provider, err)
+	}
+	return runRetryFailedWithProvider(dir, llm)
+}
+
+// runRetryFailedWithProvider re-attempts the files that failed in the last run and merges
+// the new summaries into the existing document, keeping its appendices.
+func runRetryFailedWithProvider(dir string, llm LLMProvider) error {
+	failed := readFailedList(dir)
+	if len(failed) == 0 {
+		statusf("No failed files recorded in %s\n", dir)
+		return nil
+	}
+
+	var files []string
+	for _, rel := range failed {
+		file := filepath.Join(dir, filepath.FromSlash(rel))
+		if _, err := os.Stat(file); err != nil {
+			statusf("Skipping %s: no longer exists\n", rel)
+			continue
+		}
+		files = append(files, file)
+	}
+
+	available, err := llm.Available(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to check model availability: %w", err)
+	}
+	if !available {
+		return fmt.Errorf("model %s is not available. Please ensure it is downloaded and available in your %s provider", providerModel(llm), llm.Name())
+	}
+
+	digests := fileDigests(dir, files)
+	var summaries map[string]string
+	var processed int
+	if err := auditRun(dir, llm, func(llm LLMProvider) error {
+		summaries, processed, _, _ = processYAMLFiles(files, dir, make(map[string]string), llm, true)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	docPath := docPathFor(dir)
+	existing := parseExistingSummaries(docPath)
+	sources := readDocSources(docPath)
+	for file, summary := range summaries {
+		rel, _ := filepath.Rel(dir, file)
+		existing[rel] = summary
+		sources[filepath.ToSlash(rel)] = digests[filepath.ToSlash(rel)]
+	}
+	if err := writeSummary(dir, groupRecoveredSummaries(dir, existing), sources, readDocAppendices(docPath)...); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	stillFailed := failedFiles(dir, files, summaries)
+	if err := writeFailedList(dir, stillFailed); err != nil {
+		return fmt.Errorf("failed to record failed files: %w", err)
+	}
+	porcelainf("retried", "files", len(files), "succeeded", processed, "failed", len(stillFailed))
+	statusf("\nRetried %d file(s): %d succeeded, %d still failing\n", len(files), processed, len(stillFailed))
+	return nil
+}
+
+// retryFailedCmd re-attempts only the files that failed in the last run.
+var retryFailedCmd = &cobra.Command{
+	Use:   "retry-failed [directory]",
+	Short: "Re-attempt only the files that failed in the last run",
+	Long: `Re-attempt the files recorded as failed by the last run in the directory and merge
+the new summaries into the existing document without rescanning the whole tree.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRetryFailed(args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(retryFailedCmd)
+}
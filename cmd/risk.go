@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sebrandon1/yaml-to-readme/summarizer"
+	"gopkg.in/yaml.v3"
+)
+
+// Values accepted by --risk-analysis.
+const (
+	RiskAnalysisRules = "rules"
+	RiskAnalysisLLM   = "llm"
+)
+
+// riskNoteMarker separates a summary from the risk note appended to its entry.
+const riskNoteMarker = " ⚠ "
+
+// RiskPrompt asks the LLM to review a file for risky settings the rules do not cover.
+const RiskPrompt = "Review the following YAML for risky security or reliability settings. If there are any, describe the most important one in one short sentence. If there are none, answer exactly NONE. Do not use markdown formatting.\n\n"
+
+// riskFinding is one risky setting found in a file.
+type riskFinding struct {
+	// Rule is a short name shown in the entry's risk note, e.g. "privileged container".
+	Rule string
+	// Detail explains where the setting is, shown in the Findings section.
+	Detail string
+}
+
+// validateRiskAnalysis returns an error if --risk-analysis has an unsupported value.
+func validateRiskAnalysis() error {
+	switch riskAnalysis {
+	case "", RiskAnalysisRules, RiskAnalysisLLM:
+		return nil
+	}
+	return fmt.Errorf("unsupported --risk-analysis %q, expected %s or %s", riskAnalysis, RiskAnalysisRules, RiskAnalysisLLM)
+}
+
+// decodeYAMLDocuments returns the mapping documents of a possibly multi-document YAML stream.
+func decodeYAMLDocuments(data []byte) ([]map[string]any, error) {
+	var docs []map[string]any
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc any
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return docs, nil
+			}
+			return docs, err
+		}
+		if m, ok := doc.(map[string]any); ok {
+			docs = append(docs, m)
+		}
+	}
+}
+
+// isUnpinnedImage reports whether an image reference uses the latest tag or no tag at all.
+func isUnpinnedImage(image string) bool {
+	if image == "" || strings.Contains(image, "{{") || strings.Contains(image, "@") {
+		return false
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	colon := strings.LastIndex(name, ":")
+	return colon < 0 || name[colon+1:] == "latest"
+}
+
+// scanValueRisks walks a document and records settings that are risky wherever they appear.
+func scanValueRisks(path string, value any, findings *[]riskFinding) {
+	switch v := value.(type) {
+	case map[string]any:
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			field := joinFieldPath(path, key)
+			switch child := v[key].(type) {
+			case bool:
+				switch {
+				case key == "privileged" && child:
+					*findings = append(*findings, riskFinding{"privileged container", field})
+				case key == "allowPrivilegeEscalation" && child:
+					*findings = append(*findings, riskFinding{"privilege escalation allowed", field})
+				case (key == "hostNetwork" || key == "hostPID" || key == "hostIPC") && child:
+					*findings = append(*findings, riskFinding{"host namespace shared", field})
+				}
+			case string:
+				if key == "image" && isUnpinnedImage(child) {
+					*findings = append(*findings, riskFinding{"latest image tag", fmt.Sprintf("%s (%s)", field, child)})
+				}
+			case map[string]any:
+				if key == "hostPath" {
+					hostPath, _ := child["path"].(string)
+					*findings = append(*findings, riskFinding{"hostPath mount", fmt.Sprintf("%s (%s)", field, hostPath)})
+					continue
+				}
+			}
+			scanValueRisks(field, v[key], findings)
+		}
+	case []any:
+		for i, item := range v {
+			scanValueRisks(fmt.Sprintf("%s[%d]", path, i), item, findings)
+		}
+	}
+}
+
+// scanRBACWildcards records "*" in the verbs, resources, or apiGroups of Role and
+// ClusterRole rules.
+func scanRBACWildcards(doc map[string]any, findings *[]riskFinding) {
+	if kind, _ := doc["kind"].(string); kind != "Role" && kind != "ClusterRole" {
+		return
+	}
+	rules, _ := doc["rules"].([]any)
+	for i, rule := range rules {
+		r, _ := rule.(map[string]any)
+		for _, field := range []string{"apiGroups", "resources", "verbs"} {
+			values, _ := r[field].([]any)
+			if slices.Contains(values, any("*")) {
+				*findings = append(*findings, riskFinding{"RBAC wildcard", fmt.Sprintf("rules[%d].%s", i, field)})
+			}
+		}
+	}
+}
+
+// scanRisks applies the rule-based checks to every document in a YAML file.
+func scanRisks(file string) ([]riskFinding, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := decodeYAMLDocuments(data)
+	if err != nil {
+		// Templated files often are not valid YAML; check what parsed
+		slog.Debug("risk scan stopped at unparsable document", "file", file, "error", err)
+	}
+	var findings []riskFinding
+	for _, doc := range docs {
+		scanValueRisks("", doc, &findings)
+		scanRBACWildcards(doc, &findings)
+	}
+	return findings, nil
+}
+
+// reviewRisks asks the LLM for a risk the rules may have missed, returning nil when it
+// reports none.
+func reviewRisks(file string, llm LLMProvider) (*riskFinding, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	answer, err := llm.Summarize(withAuditFile(context.Background(), file), promptContent(file, string(content)), localizedPrompt(RiskPrompt))
+	if err != nil {
+		return nil, err
+	}
+	answer = summarizer.CleanSummary(answer)
+	if answer == "" || strings.EqualFold(strings.Trim(answer, ". "), "none") {
+		return nil, nil
+	}
+	return &riskFinding{Rule: "flagged by review", Detail: truncateToSentences(answer, 1)}, nil
+}
+
+// analyzeRisks runs the --risk-analysis pass over files and returns the findings per file.
+func analyzeRisks(files []string, llm LLMProvider) map[string][]riskFinding {
+	risks := make(map[string][]riskFinding)
+	for _, file := range files {
+		findings, err := scanRisks(file)
+		if err != nil {
+			slog.Warn("skipping risk analysis", "file", file, "error", err)
+			continue
+		}
+		if riskAnalysis == RiskAnalysisLLM {
+			review, err := reviewRisks(file, llm)
+			if err != nil {
+				slog.Warn("risk review failed", "file", file, "error", err)
+			} else if review != nil {
+				findings = append(findings, *review)
+			}
+		}
+		if len(findings) > 0 {
+			risks[file] = findings
+		}
+	}
+	return risks
+}
+
+// riskNote renders the short note appended to a file's entry, e.g.
+// "⚠ privileged container, hostPath mount".
+func riskNote(findings []riskFinding) string {
+	var rules []string
+	for _, f := range findings {
+		if !slices.Contains(rules, f.Rule) {
+			rules = append(rules, f.Rule)
+		}
+	}
+	return "⚠ " + strings.Join(rules, ", ")
+}
+
+// withRiskNotes returns a copy of summaries with a risk note appended to every
+// summarized file that has findings.
+func withRiskNotes(summaries map[string]string, risks map[string][]riskFinding) map[string]string {
+	annotated := maps.Clone(summaries)
+	for file, findings := range risks {
+		if summary := annotated[file]; summary != "" {
+			annotated[file] = summary + " " + riskNote(findings)
+		}
+	}
+	return annotated
+}
+
+// mergeFindings adds the findings of from to into.
+func mergeFindings(into, from map[string][]riskFinding) {
+	for file, findings := range from {
+		into[file] = append(into[file], findings...)
+	}
+}
+
+// stripRiskNote removes a risk note, any glossary links before it, and a low-confidence
+// note from a summary read back from a document, so they are recomputed rather than fed
+// back into the next run.
+func stripRiskNote(summary string) string {
+	for _, marker := range []string{glossaryNoteMarker, riskNoteMarker, confidenceNoteMarker} {
+		if i := strings.Index(summary, marker); i >= 0 {
+			summary = summary[:i]
+		}
+	}
+	return summary
+}
+
+// findingsAppendix lists every finding with where it was found.
+func findingsAppendix(dir string, risks map[string][]riskFinding) []docAppendix {
+	if len(risks) == 0 {
+		return nil
+	}
+	var entries []appendixEntry
+	for _, file := range slices.Sorted(maps.Keys(risks)) {
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			rel = file
+		}
+		for _, f := range risks[file] {
+			entries = append(entries, appendixEntry{Path: rel, Note: fmt.Sprintf("⚠ %s: %s", f.Rule, f.Detail)})
+		}
+	}
+	l := currentLabels()
+	return []docAppendix{{Title: l.Findings, Intro: l.FindingsIntro, Entries: entries}}
+}
@@ -3,36 +3,32 @@ package cmd
 import (
 	"bufio"
 	"context"
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"html/template"
 	"log/slog"
+	"maps"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/sebrandon1/yaml-to-readme/summarizer"
 	"github.com/spf13/cobra"
+	"golang.org/x/sync/singleflight"
 )
 
-// setupLogging configures the slog default logger based on the verbose flag.
-func setupLogging() {
-	level := slog.LevelWarn
-	if verbose {
-		level = slog.LevelDebug
-	}
-	handler := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})
-	slog.SetDefault(slog.New(handler))
-}
-
 // Keeping a collection of constants for future use.
 const (
 	DefaultModelName        = "llama3.2:latest"
 	DefaultCacheDirName     = ".yaml_summary_cache"
 	DefaultMarkdownFileName = "yaml_details.md"
+	DefaultJSONFileName     = "yaml_details.json"
 	MarkdownHeader          = `# YAML File Details
 
 This document provides an overview of all YAML files in the repository, organized by directory, with a brief description of what each file does or configures. Use this as a reference for understanding the purpose of each manifest or configuration file.
@@ -50,9 +46,18 @@ This document provides an overview of all YAML files in the repository, organize
 -->
 
 `
-	SummarizePrompt = "Summarize the purpose of this YAML file in no more than two short, high-level sentences. Do not include any lists, breakdowns, explanations, advice, notes, or formatting. Do not use markdown. No newlines. No code sections. Only output a single, concise summary of the file's purpose, and nothing else. Stop after two sentences. If you cannot summarize in two sentences, summarize in one: \n"
+	// DocSchemaVersion is the current version of the generated document format.
+	// Bump it and register a migration in migrate.go when the format changes.
+	DocSchemaVersion = 3
+	SummarizePrompt  = summarizer.DefaultPrompt
 )
 
+// docSchemaMarker returns the comment recording the document schema version, written
+// after the markdown header so migrate can detect which format a document uses.
+func docSchemaMarker() string {
+	return fmt.Sprintf("<!-- yaml-to-readme schema:%d -->\n", DocSchemaVersion)
+}
+
 // ModelName is configurable via the --model flag and defaults to DefaultModelName.
 var ModelName string = DefaultModelName
 
@@ -62,86 +67,142 @@ var markdownFileName string = DefaultMarkdownFileName
 // cacheDirName is configurable via the --cache-dir flag and defaults to DefaultCacheDirName.
 var cacheDirName string = DefaultCacheDirName
 
-// findYAMLFiles recursively finds all YAML files under the given directory path.
+// findYAMLFiles recursively finds all YAML files under the given directory path, leaving
+// out those ignored by git or filtered by --exclude and --include. A file found under
+// several paths through symlinks is listed once.
 func findYAMLFiles(dir string, includeHidden bool) ([]string, error) {
-	var yamlFiles []string
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Skip hidden directories unless includeHidden is true
-		if info.IsDir() && !includeHidden && strings.HasPrefix(info.Name(), ".") {
-			return filepath.SkipDir
-		}
-
-		if !info.IsDir() && (strings.HasSuffix(info.Name(), ".yaml") || strings.HasSuffix(info.Name(), ".yml")) {
-			yamlFiles = append(yamlFiles, path)
-		}
-		return nil
-	})
-	slog.Debug("found YAML files", "count", len(yamlFiles), "dir", dir, "includeHidden", includeHidden)
+	yamlFiles, _, err := findYAMLFilesWithAliases(dir, includeHidden)
 	return yamlFiles, err
 }
 
-// cleanSummary removes markdown, lists, and breakdowns from the summary, keeping only a concise, plain-text summary.
-func cleanSummary(summary string) string {
-	lines := strings.Split(summary, "\n")
-	var cleaned []string
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if trimmed == "" {
+// findYAMLFilesWithAliases is findYAMLFiles, also returning the other paths of files
+// reached through symlinks, keyed by the path listed.
+func findYAMLFilesWithAliases(dir string, includeHidden bool) ([]string, map[string][]string, error) {
+	found, err := findDocumentedFiles(dir, includeHidden)
+	// The tool's own config file is not part of the documented tree, and only .tgz
+	// files under charts/ are packaged charts
+	yamlFiles := found[:0]
+	for _, file := range found {
+		if strings.HasSuffix(strings.ToLower(file), chartArchiveSuffix) && !isChartArchive(file) {
 			continue
 		}
-		if strings.HasPrefix(trimmed, "#") ||
-			strings.HasPrefix(trimmed, "**") ||
-			strings.HasPrefix(trimmed, "-") ||
-			strings.HasPrefix(trimmed, "Here's a breakdown") ||
-			strings.HasPrefix(trimmed, "The following") ||
-			strings.HasPrefix(trimmed, "* ") {
-			continue
+		if filepath.Base(file) != DefaultConfigFileName {
+			yamlFiles = append(yamlFiles, file)
 		}
-		cleaned = append(cleaned, trimmed)
 	}
-	return strings.Join(cleaned, " ")
+	yamlFiles, aliases := dedupeLinkedFiles(yamlFiles)
+	slog.Debug("found YAML files", "count", len(yamlFiles), "dir", dir, "includeHidden", includeHidden, "linked", len(aliases))
+	return yamlFiles, aliases, err
+}
+
+// summarizeGroup coalesces simultaneous provider requests for byte-identical content so
+// concurrent workers share a single call.
+var summarizeGroup singleflight.Group
+
+// summarizeRequestKey identifies a provider request by everything that affects its
+// response: provider, model, prompt, and a hash of the file content.
+func summarizeRequestKey(providerName, model, prompt string, content []byte) string {
+	h := sha256.New()
+	h.Write([]byte(prompt))
+	h.Write([]byte{0})
+	h.Write(content)
+	return providerName + "/" + model + "/" + hex.EncodeToString(h.Sum(nil))
 }
 
-// summarizeYAMLFile uses an LLM provider to generate a short summary for a YAML file.
-func summarizeYAMLFile(ctx context.Context, provider LLMProvider, file string) (string, error) {
-	slog.Debug("summarizing file", "file", file, "model", ModelName, "provider", provider.Name())
-	content, err := os.ReadFile(file)
+// summarizeYAMLFile uses an LLM provider to generate a short summary for a YAML file
+// under the documented directory dir.
+func summarizeYAMLFile(ctx context.Context, provider LLMProvider, dir, file string) (string, error) {
+	slog.Debug("summarizing file", "file", file, "model", providerModel(provider), "provider", provider.Name())
+	content, err := readSummarySource(file)
 	if err != nil {
 		return "", fmt.Errorf("failed to read %s: %w", file, err)
 	}
-
-	summary, err := provider.Summarize(ctx, string(content), SummarizePrompt)
-	if err != nil {
-		return "", fmt.Errorf("%s error for %s: %w", provider.Name(), file, err)
+	content = stripSummaryComment(content)
+	if summary, ok := trivialSummary(content); ok {
+		slog.Debug("summarized trivial file without the LLM", "file", file)
+		return summary, nil
+	}
+	if summary, ok := automationSummary(file, content); ok {
+		slog.Debug("summarized dependency automation config without the LLM", "file", file)
+		return summary, nil
+	}
+	if expandAnchors {
+		if expanded, err := expandYAMLAnchors(content); err != nil {
+			slog.Debug("could not expand anchors, sending the file as is", "file", file, "error", err)
+		} else {
+			content = expanded
+		}
 	}
 
-	// Clean and truncate summary
-	cleaned := cleanSummary(summary)
-	trimmed := truncateToSentences(cleaned, 2)
-	return trimmed, nil
+	prompt := filePrompt(file)
+	body := promptContent(file, string(content))
+	if elideDataBytes > 0 {
+		body = elideData(body, elideDataBytes)
+	}
+	if contextTokens > 0 {
+		tok := tokenizerFor(providerModel(provider))
+		budget := contextTokens - contextResponseTokens - tok.Count(prompt)
+		if compressed, steps := compressToFit(body, budget, tok); len(steps) > 0 {
+			slog.Debug("compressed file to fit the context window", "file", file, "steps", steps,
+				"tokens", tok.Count(body), "compressed_tokens", tok.Count(compressed), "budget", budget)
+			body = compressed
+		}
+	}
+	if promptTemplate != nil {
+		instruction, templatedBody, err := templatedPrompt(dir, file, content, body)
+		if err != nil {
+			return "", err
+		}
+		prompt, body = fileContext(file)+instruction, templatedBody
+	}
+	if promptCache {
+		// Keep the instruction identical for every file so providers can cache it as a
+		// prefix, and send the per-file context along with the content instead
+		fileCtx := fileContext(file)
+		prompt = strings.TrimPrefix(prompt, fileCtx)
+		body = fileCtx + body
+	}
+	ask := func(prompt string) (string, error) {
+		key := summarizeRequestKey(provider.Name(), providerModel(provider), prompt, []byte(body))
+		countProviderCall(ctx)
+		result, err, shared := summarizeGroup.Do(key, func() (any, error) {
+			return provider.Summarize(withAuditFile(ctx, file), body, prompt)
+		})
+		if err != nil {
+			return "", fmt.Errorf("%s error for %s: %w", provider.Name(), file, err)
+		}
+		if shared {
+			slog.Debug("coalesced identical in-flight request", "file", file)
+		}
+		// Clean and truncate summary
+		return truncateSummary(summarizer.CleanSummary(result.(string))), nil
+	}
+	summary, err := ask(prompt)
+	if err != nil || !guardrails {
+		return summary, err
+	}
+	sent := append([]byte(fileContext(file)), content...)
+	problem := guardrailProblem(summary, sent)
+	if problem == "" {
+		return summary, nil
+	}
+	slog.Warn("summary failed the guardrail check; asking again", "file", file, "problem", problem)
+	if summary, err = ask(guardrailPrompt(problem, prompt)); err != nil {
+		return "", err
+	}
+	switch retryProblem := guardrailProblem(summary, sent); {
+	case refusalPattern.MatchString(summary):
+		// A refusal is never worth committing; leave the file for retry-failed
+		return "", fmt.Errorf("%s declined to summarize %s twice", provider.Name(), file)
+	case retryProblem != "":
+		slog.Warn("summary still fails the guardrail check; keeping it", "file", file, "problem", retryProblem)
+	}
+	return summary, nil
 }
 
 // truncateToSentences returns the first n sentences from the input string.
 func truncateToSentences(text string, n int) string {
-	count := 0
-	end := 0
-	for i, r := range text {
-		if r == '.' || r == '!' || r == '?' {
-			count++
-			end = i + 1
-			if count == n {
-				break
-			}
-		}
-	}
-	if end > 0 {
-		return strings.TrimSpace(text[:end])
-	}
-	return strings.TrimSpace(text)
+	return summarizer.TruncateToSentences(text, n)
 }
 
 // groupSummariesByDir organizes file summaries by their relative directory.
@@ -176,177 +237,6 @@ func sortedDirs(grouped map[string][][2]string) ([]string, map[string][][2]strin
 	return dirs, sorted
 }
 
-// writeMarkdownSummary writes the grouped summaries to a markdown file in the base directory.
-func writeMarkdownSummary(baseDir string, grouped map[string][][2]string) error {
-	mdPath := filepath.Join(baseDir, markdownFileName)
-	f, err := os.Create(mdPath)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		cerr := f.Close()
-		if cerr != nil {
-			fmt.Fprintf(os.Stderr, "error closing file: %v\n", cerr)
-		}
-	}()
-
-	if _, err := f.WriteString(MarkdownHeader); err != nil {
-		return err
-	}
-
-	dirs, sorted := sortedDirs(grouped)
-
-	for _, dir := range dirs {
-		if _, err := fmt.Fprintf(f, "\n## [%s/](../%s/)\n", dir, dir); err != nil {
-			return err
-		}
-		for _, entry := range sorted[dir] {
-			if _, err := fmt.Fprintf(f, "- [%s](../%s/%s): %s\n", entry[0], dir, entry[0], entry[1]); err != nil {
-				return err
-			}
-		}
-	}
-	return nil
-}
-
-// JSONOutput represents the structured JSON output format.
-type JSONOutput struct {
-	BaseDirectory string                     `json:"base_directory"`
-	GeneratedAt   string                     `json:"generated_at"`
-	Model         string                     `json:"model"`
-	Directories   map[string][]JSONFileEntry `json:"directories"`
-}
-
-// JSONFileEntry represents a single file entry in the JSON output.
-type JSONFileEntry struct {
-	File    string `json:"file"`
-	Path    string `json:"path"`
-	Summary string `json:"summary"`
-}
-
-// writeJSONSummary writes the grouped summaries as a JSON file.
-func writeJSONSummary(baseDir string, grouped map[string][][2]string) error {
-	output := JSONOutput{
-		BaseDirectory: baseDir,
-		GeneratedAt:   time.Now().UTC().Format(time.RFC3339),
-		Model:         ModelName,
-		Directories:   make(map[string][]JSONFileEntry),
-	}
-
-	dirs, sorted := sortedDirs(grouped)
-
-	for _, dir := range dirs {
-		dirKey := dir + "/"
-		for _, entry := range sorted[dir] {
-			output.Directories[dirKey] = append(output.Directories[dirKey], JSONFileEntry{
-				File:    entry[0],
-				Path:    filepath.Join(dir, entry[0]),
-				Summary: entry[1],
-			})
-		}
-	}
-
-	outPath := filepath.Join(baseDir, markdownFileName)
-	data, err := json.MarshalIndent(output, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal JSON: %w", err)
-	}
-	return os.WriteFile(outPath, data, 0o644)
-}
-
-const htmlTemplate = `<!DOCTYPE html>
-<html lang="en">
-<head>
-<meta charset="UTF-8">
-<meta name="viewport" content="width=device-width, initial-scale=1.0">
-<title>YAML File Details</title>
-<style>
-body { font-family: system-ui, sans-serif; max-width: 900px; margin: 2rem auto; padding: 0 1rem; color: #333; }
-h1 { border-bottom: 2px solid #eee; padding-bottom: 0.5rem; }
-h2 { color: #555; margin-top: 2rem; }
-ul { list-style: none; padding-left: 0; }
-li { padding: 0.4rem 0; border-bottom: 1px solid #f0f0f0; }
-a { color: #0366d6; text-decoration: none; }
-a:hover { text-decoration: underline; }
-.summary { color: #666; }
-.meta { color: #999; font-size: 0.85rem; margin-top: 2rem; }
-</style>
-</head>
-<body>
-<h1>YAML File Details</h1>
-<p>Overview of all YAML files, organized by directory.</p>
-{{range .Dirs}}<h2><a href="../{{.Name}}/">{{.Name}}/</a></h2>
-<ul>
-{{range .Files}}<li><a href="../{{.Path}}">{{.File}}</a>: <span class="summary">{{.Summary}}</span></li>
-{{end}}</ul>
-{{end}}<p class="meta">Generated at {{.GeneratedAt}} using model {{.Model}}</p>
-</body>
-</html>
-`
-
-type htmlData struct {
-	Dirs        []htmlDir
-	GeneratedAt string
-	Model       string
-}
-
-type htmlDir struct {
-	Name  string
-	Files []JSONFileEntry
-}
-
-// writeHTMLSummary writes the grouped summaries as an HTML file.
-func writeHTMLSummary(baseDir string, grouped map[string][][2]string) error {
-	tmpl, err := template.New("html").Parse(htmlTemplate)
-	if err != nil {
-		return fmt.Errorf("failed to parse HTML template: %w", err)
-	}
-
-	dirs, sorted := sortedDirs(grouped)
-
-	var data htmlData
-	data.GeneratedAt = time.Now().UTC().Format(time.RFC3339)
-	data.Model = ModelName
-
-	for _, dir := range dirs {
-		hd := htmlDir{Name: dir}
-		for _, entry := range sorted[dir] {
-			hd.Files = append(hd.Files, JSONFileEntry{
-				File:    entry[0],
-				Path:    filepath.Join(dir, entry[0]),
-				Summary: entry[1],
-			})
-		}
-		data.Dirs = append(data.Dirs, hd)
-	}
-
-	outPath := filepath.Join(baseDir, markdownFileName)
-	f, err := os.Create(outPath)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		cerr := f.Close()
-		if cerr != nil {
-			fmt.Fprintf(os.Stderr, "error closing file: %v\n", cerr)
-		}
-	}()
-
-	return tmpl.Execute(f, data)
-}
-
-// writeSummary dispatches to the appropriate writer based on the outputFormat flag.
-func writeSummary(baseDir string, grouped map[string][][2]string) error {
-	switch outputFormat {
-	case "json":
-		return writeJSONSummary(baseDir, grouped)
-	case "html":
-		return writeHTMLSummary(baseDir, grouped)
-	default:
-		return writeMarkdownSummary(baseDir, grouped)
-	}
-}
-
 var progressMu sync.Mutex
 
 // progressBar displays a simple progress bar in the terminal.
@@ -358,19 +248,31 @@ func progressBar(current, total int) {
 	barLen := 40
 	filledLen := int(float64(barLen) * float64(current) / float64(total))
 	bar := strings.Repeat("=", filledLen) + strings.Repeat(" ", barLen-filledLen)
-	fmt.Printf("\rProcessing YAML files: [%s] %3.0f%% (%d/%d)", bar, percent, current, total)
+	porcelainf("progress", "current", current, "total", total)
+	statusf("\rProcessing YAML files: [%s] %3.0f%% (%d/%d)", bar, percent, current, total)
+	progressLine = current != total && statusEnabled()
 	if current == total {
-		fmt.Println()
+		statusf("\n")
 	}
 }
 
 // parseExistingSummaries parses an existing yaml_details.md and returns a map of file path to summary.
 func parseExistingSummaries(mdPath string) map[string]string {
 	existing := make(map[string]string)
-	parseSummaryLines(readLinesFromFile(mdPath), existing)
+	parseSummaryLines(existingDocumentLines(mdPath), existing)
 	return existing
 }
 
+// existingDocumentLines returns the lines of the existing document at mdPath, or with
+// --inject the lines between its markers.
+func existingDocumentLines(mdPath string) []string {
+	lines := readLinesFromFile(mdPath)
+	if injectPath != "" {
+		lines = injectedLines(lines)
+	}
+	return lines
+}
+
 // readLinesFromFile opens a file and returns its lines as a slice of strings.
 func readLinesFromFile(path string) []string {
 	f, err := os.Open(path)
@@ -393,31 +295,84 @@ func readLinesFromFile(path string) []string {
 func parseSummaryLines(lines []string, existing map[string]string) {
 	var currentDir string
 	for _, line := range lines {
-		if strings.HasPrefix(line, "## [") && strings.Contains(line, "](") {
-			// Extract directory from section header
-			start := strings.Index(line, "[") + 1
-			end := strings.Index(line, "]")
-			if start > 0 && end > start {
-				currentDir = line[start:end]
-				currentDir = strings.TrimSuffix(currentDir, "/")
+		if key, summary, ok := parseSummaryLine(line, &currentDir); ok {
+			existing[key] = summary
+		}
+	}
+}
+
+// parseSummaryLine parses one line of a summaries document. Directory headings update
+// currentDir; entries return the file path relative to the base directory and its summary.
+func parseSummaryLine(line string, currentDir *string) (string, string, bool) {
+	if strings.HasPrefix(line, "## [") && strings.Contains(line, "](") {
+		// Extract directory from section header
+		start := strings.Index(line, "[") + 1
+		end := strings.Index(line, "]")
+		if start > 0 && end > start {
+			heading := line[start:end]
+			if strings.HasSuffix(heading, "/") {
+				*currentDir = strings.TrimSuffix(heading, "/")
+			} else if dir, ok := aliasedDir(heading); ok {
+				*currentDir = dir
+			} else {
+				target, _, _ := strings.Cut(line[end+len("]("):], ")")
+				*currentDir = headingLinkDir(target)
 			}
-		} else if strings.HasPrefix(line, "- [") && strings.Contains(line, "](") {
-			// Extract file and summary
-			start := strings.Index(line, "[") + 1
-			end := strings.Index(line, "]")
-			if start > 0 && end > start {
-				file := line[start:end]
-				if currentDir != "" {
-					key := filepath.Join(currentDir, file)
-					colon := strings.Index(line, ": ")
-					if colon > 0 {
-						summary := strings.TrimSpace(line[colon+2:])
-						existing[key] = summary
-					}
-				}
+		}
+	} else if strings.HasPrefix(line, "## ") && strings.HasSuffix(line, "/") {
+		// GitHub wiki section header without a link
+		*currentDir = strings.TrimSuffix(strings.TrimPrefix(line, "## "), "/")
+	} else if strings.HasPrefix(line, "## ") {
+		// A GitHub wiki section header with a display name; any other section, such as
+		// an appendix, ends the current directory
+		*currentDir, _ = aliasedDir(strings.TrimPrefix(line, "## "))
+	} else if file, summary, ok := parseWikiEntry(line); ok {
+		if *currentDir != "" {
+			summary, _ = cutEntryKey(summary)
+			return filepath.Join(*currentDir, file), stripKindPrefix(stripRiskNote(stripLinkAliases(summary))), true
+		}
+	} else if strings.HasPrefix(line, "- [") && strings.Contains(line, "](") {
+		// Extract file and summary
+		start := strings.Index(line, "[") + 1
+		end := strings.Index(line, "]")
+		if start > 0 && end > start && *currentDir != "" {
+			file := line[start:end]
+			colon := strings.Index(line, ": ")
+			if colon > 0 {
+				summary, _ := cutEntryKey(line[colon+2:])
+				return filepath.Join(*currentDir, file), stripKindPrefix(stripRiskNote(stripLinkAliases(strings.TrimSpace(summary)))), true
 			}
 		}
 	}
+	return "", "", false
+}
+
+// parseWikiEntry extracts the file name and summary from a GitHub wiki entry line,
+// which is either "- [[file|url]]: summary" or "- `dir/file`: summary".
+func parseWikiEntry(line string) (string, string, bool) {
+	var ref, rest string
+	switch {
+	case strings.HasPrefix(line, "- [["):
+		end := strings.Index(line, "]]")
+		if end < 0 {
+			return "", "", false
+		}
+		ref, _, _ = strings.Cut(line[len("- [["):end], "|")
+		rest = line[end+2:]
+	case strings.HasPrefix(line, "- `"):
+		end := strings.Index(line[3:], "`")
+		if end < 0 {
+			return "", "", false
+		}
+		ref = path.Base(line[3 : 3+end])
+		rest = line[3+end+1:]
+	default:
+		return "", "", false
+	}
+	if !strings.HasPrefix(rest, ": ") {
+		return "", "", false
+	}
+	return ref, strings.TrimSpace(rest[2:]), true
 }
 
 // writeIndividualSummary writes the summary for a single YAML file to a hidden cache directory in the given repo root.
@@ -432,8 +387,7 @@ func writeIndividualSummary(repoRoot, baseDir, filePath, summary string) error {
 		// If not under baseDir, fallback to base name only
 		relPath = filepath.Base(filePath)
 	}
-	cacheFile := strings.ReplaceAll(relPath, string(os.PathSeparator), "_")
-	cacheFilePath := filepath.Join(cacheDir, cacheFile+".md")
+	cacheFilePath := cacheEntryPath(repoRoot, relPath)
 	f, err := os.Create(cacheFilePath)
 	if err != nil {
 		return err
@@ -441,27 +395,83 @@ func writeIndividualSummary(repoRoot, baseDir, filePath, summary string) error {
 	defer func() {
 		cerr := f.Close()
 		if cerr != nil {
-			fmt.Fprintf(os.Stderr, "error closing file: %v\n", cerr)
+			slog.Warn("error closing file", "file", cacheFilePath, "error", cerr)
 		}
 	}()
+	// The entry is keyed with the hash of the file, so later runs reuse it only while the
+	// file is unchanged
+	if digest, err := fileSHA256(filePath); err == nil {
+		summary += fmt.Sprintf(" <!-- yaml-to-readme-entry sha256:%s %s -->", digest, filepath.ToSlash(relPath))
+	}
 	_, err = f.WriteString(summary)
 	return err
 }
 
-// processYAMLFiles processes YAML files, generating summaries if needed, and returns the summaries map and counters.
-func processYAMLFiles(yamlFiles []string, dir string, existingSummaries map[string]string, provider LLMProvider, forceRegenerate bool) (map[string]string, int, int) {
+// cachedSummary returns the --localcache entry of file, a path under dir, if its key
+// matches the current content of the file.
+func cachedSummary(repoRoot, dir, file string) (string, bool) {
+	rel, err := filepath.Rel(dir, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	data, err := os.ReadFile(cacheEntryPath(repoRoot, rel))
+	if err != nil {
+		return "", false
+	}
+	m := entryKeyPattern.FindStringSubmatch(string(data))
+	if m == nil {
+		return "", false
+	}
+	if digest, err := fileSHA256(file); err != nil || digest != m[1] {
+		return "", false
+	}
+	summary, _ := cutEntryKey(string(data))
+	return summary, summary != ""
+}
+
+// reusableSummary returns the summary of file in the existing document, unless it is
+// being regenerated.
+func reusableSummary(dir, file string, existingSummaries map[string]string, forceRegenerate bool) (string, bool) {
+	if forceRegenerate || regeneratePath(dir, file) {
+		return "", false
+	}
+	rel, _ := filepath.Rel(dir, file)
+	summary, ok := existingSummaries[filepath.ToSlash(rel)]
+	return summary, ok && summary != ""
+}
+
+// regeneratePath reports whether file, a path under dir, matches --regenerate.
+func regeneratePath(dir, file string) bool {
+	rel, _ := filepath.Rel(dir, file)
+	return matchAnyPathGlob(regeneratePaths, filepath.ToSlash(rel))
+}
+
+// processYAMLFiles processes YAML files, generating summaries if needed, and returns the
+// summaries map, counters, and the directories quarantined after --quarantine-after
+// failures.
+func processYAMLFiles(yamlFiles []string, dir string, existingSummaries map[string]string, provider LLMProvider, forceRegenerate bool) (map[string]string, int, int, []string) {
 	summaries := make(map[string]string)
 	total := len(yamlFiles)
 	skipped := 0
 
+	// Cache repo root for cachedSummary and writeIndividualSummary calls
+	var repoRoot string
+	if localCache {
+		repoRoot = cacheRoot()
+	}
+
 	// First pass: identify which files need processing and collect existing summaries
 	var toProcess []string
 	for _, file := range yamlFiles {
-		rel, _ := filepath.Rel(dir, file)
-		rel = filepath.ToSlash(rel)
-		if !forceRegenerate {
-			if summary, ok := existingSummaries[rel]; ok && summary != "" {
-				slog.Debug("skipping file with existing summary", "file", rel)
+		if summary, ok := reusableSummary(dir, file, existingSummaries, forceRegenerate); ok {
+			slog.Debug("skipping file with existing summary", "file", file)
+			summaries[file] = summary
+			skipped++
+			continue
+		}
+		if localCache && !forceRegenerate && !regeneratePath(dir, file) {
+			if summary, ok := cachedSummary(repoRoot, dir, file); ok {
+				slog.Debug("skipping file with cached summary", "file", file)
 				summaries[file] = summary
 				skipped++
 				continue
@@ -469,43 +479,69 @@ func processYAMLFiles(yamlFiles []string, dir string, existingSummaries map[stri
 		}
 		toProcess = append(toProcess, file)
 	}
+	toProcess = prioritizeFiles(toProcess)
 
-	// Cache repo root for writeIndividualSummary calls
-	var repoRoot string
-	if localCache {
-		repoRoot, _ = os.Getwd()
-	}
-
-	// Determine effective concurrency
-	workers := max(concurrency, 1)
-	if workers > len(toProcess) && len(toProcess) > 0 {
-		workers = len(toProcess)
+	if warmUp && len(toProcess) > 0 {
+		warmUpProvider(provider)
 	}
 
 	// Second pass: process new files concurrently
 	var mu sync.Mutex
-	var completed atomic.Int64
-	completed.Store(int64(skipped))
 	var processed atomic.Int64
 
-	sem := make(chan struct{}, workers)
+	webhook := newProgressWebhook(dir, total)
+	webhook.Start(skipped)
+
+	// advance counts a finished file and reports progress. Counting and reporting share
+	// one lock so the bar never moves backwards when workers finish at the same time.
+	var advanceMu sync.Mutex
+	completed := skipped
+	advance := func(f string, failed bool) {
+		advanceMu.Lock()
+		defer advanceMu.Unlock()
+		completed++
+		progressBar(completed, total)
+		webhook.Progress(completed, f, failed)
+	}
+
+	limiter := newConcurrencyLimiter(len(toProcess))
+	quarantine := newDirQuarantine(quarantineAfter)
 	var wg sync.WaitGroup
 
 	for _, file := range toProcess {
+		limiter.Acquire()
+		// Checked once a slot is free, so failures of files still in flight count
+		if quarantine.skip(file) {
+			limiter.Cancel()
+			slog.Debug("skipping file in quarantined directory", "file", file)
+			advance(file, true)
+			continue
+		}
 		wg.Add(1)
-		sem <- struct{}{} // Acquire semaphore slot
 		go func(f string) {
 			defer wg.Done()
-			defer func() { <-sem }() // Release semaphore slot
 
-			summary, err := summarizeYAMLFile(context.Background(), provider, f)
+			start := time.Now()
+			ctx, calls := withProviderCalls(context.Background())
+			summary, err := summarizeYAMLFile(ctx, provider, dir, f)
+			// Trivial and automation files never reach the provider; their latency
+			// would become the autotune baseline
+			if calls.Load() > 0 {
+				limiter.Release(time.Since(start), err)
+			} else {
+				limiter.Cancel()
+			}
+			if quarantine.record(f, err) {
+				slog.Warn("quarantining directory after repeated failures; skipping its remaining files", "dir", filepath.Dir(f), "failures", quarantineAfter)
+			}
 			if err != nil {
 				slog.Error("failed to summarize file", "file", f, "error", err)
-				completed.Add(1)
-				progressBar(int(completed.Load()), total)
+				advance(f, true)
 				return
 			}
 
+			slog.Debug("summarized file", "file", f, "elapsed", time.Since(start), "provider", provider.Name(), "model", providerModel(provider))
+
 			mu.Lock()
 			summaries[f] = summary
 			mu.Unlock()
@@ -515,14 +551,31 @@ func processYAMLFiles(yamlFiles []string, dir string, existingSummaries map[stri
 			}
 
 			processed.Add(1)
-			completed.Add(1)
-			progressBar(int(completed.Load()), total)
+			advance(f, false)
 		}(file)
 	}
 
 	wg.Wait()
+	webhook.Done(completed)
 
-	return summaries, int(processed.Load()), skipped
+	return summaries, int(processed.Load()), skipped, quarantine.quarantined(dir)
+}
+
+// warmUpProvider preloads the provider's model before the progress bar starts, if the
+// provider supports it. Failures are logged and otherwise ignored.
+func warmUpProvider(provider LLMProvider) {
+	w, ok := provider.(Warmer)
+	if !ok {
+		slog.Debug("provider does not support warm-up", "provider", provider.Name())
+		return
+	}
+	statusf("Warming up %s model %s...\n", provider.Name(), ModelName)
+	start := time.Now()
+	if err := w.WarmUp(context.Background()); err != nil {
+		slog.Warn("model warm-up failed", "provider", provider.Name(), "model", ModelName, "error", err)
+		return
+	}
+	slog.Debug("model warmed up", "elapsed", time.Since(start))
 }
 
 // runDryRun prints which YAML files would be processed without calling the LLM.
@@ -531,13 +584,16 @@ func runDryRun(dir string) error {
 	if err != nil {
 		return err
 	}
-	mdPath := filepath.Join(dir, markdownFileName)
-	existingSummaries := parseExistingSummaries(mdPath)
+	yamlFiles, generated := partitionGenerated(dir, yamlFiles)
+	mdPath := docPathFor(dir)
+	existingSummaries := withoutStale(parseExistingSummaries(mdPath), readDocSources(mdPath), fileDigests(dir, yamlFiles))
 
 	newFiles := 0
 	existingFiles := 0
+	newTokens := 0
+	tok := activeTokenizer()
 	var newList []string
-	for _, file := range yamlFiles {
+	for _, file := range prioritizeFiles(yamlFiles) {
 		rel, _ := filepath.Rel(dir, file)
 		rel = filepath.ToSlash(rel)
 		if summary, ok := existingSummaries[rel]; ok && summary != "" {
@@ -545,12 +601,21 @@ func runDryRun(dir string) error {
 		} else {
 			newFiles++
 			newList = append(newList, rel)
+			if content, err := readSummarySource(file); err == nil {
+				newTokens += tok.Count(filePrompt(file) + string(content))
+			}
 		}
 	}
 
 	fmt.Printf("Dry run: %d YAML files found in %s\n", len(yamlFiles), dir)
 	fmt.Printf("  New (would summarize): %d\n", newFiles)
+	if newFiles > 0 {
+		fmt.Printf("  Prompt tokens to send: %d (%s tokenizer)\n", newTokens, tok.Name())
+	}
 	fmt.Printf("  Existing (would skip): %d\n", existingFiles)
+	if len(generated) > 0 {
+		fmt.Printf("  Generated (would skip): %d\n", len(generated))
+	}
 	if len(newList) > 0 {
 		fmt.Println("\nFiles to summarize:")
 		for _, f := range newList {
@@ -560,19 +625,106 @@ func runDryRun(dir string) error {
 	return nil
 }
 
+// ProviderEnvVar selects the provider when --provider is not given.
+const ProviderEnvVar = "YAML_TO_README_PROVIDER"
+
+// providerNames lists the values accepted by --provider.
+var providerNames = []string{"ollama", "openai", "anthropic"}
+
+// resolveProvider applies YAML_TO_README_PROVIDER unless --provider was given on the
+// command line, and checks that the provider is known.
+func resolveProvider(cmd *cobra.Command) error {
+	if env := os.Getenv(ProviderEnvVar); env != "" && !cmd.Flags().Changed("provider") {
+		provider = env
+	}
+	if !slices.Contains(providerNames, provider) {
+		return fmt.Errorf("unknown provider %q, expected one of %s", provider, strings.Join(providerNames, ", "))
+	}
+	return nil
+}
+
+// ModelEnvVar selects the model when neither --model nor --model-alias is given.
+const ModelEnvVar = "SUMMARIZE_MODEL"
+
 // createProvider creates an LLMProvider based on the --provider flag.
 func createProvider() (LLMProvider, error) {
+	if err := checkProviderEndpoint(provider); err != nil {
+		return nil, err
+	}
 	switch provider {
 	case "openai":
 		return NewOpenAIProvider()
+	case "anthropic":
+		return NewAnthropicProvider()
 	default:
 		return NewOllamaProvider()
 	}
 }
 
+// validateRunFlags checks the flags shared by every command that summarizes dir and
+// writes its document: the language, format, retries, --since, summary length, and
+// where the document is written.
+func validateRunFlags(dir string) error {
+	if err := validateLang(); err != nil {
+		return err
+	}
+	if err := validateFormat(); err != nil {
+		return err
+	}
+	if err := validateRetries(); err != nil {
+		return err
+	}
+	if err := validateSince(); err != nil {
+		return err
+	}
+	if err := validateSummaryLength(); err != nil {
+		return err
+	}
+	if err := validateWriteDir(dir); err != nil {
+		return err
+	}
+	return validateOutput(dir)
+}
+
 // runSummarizeYaml is the main logic for the summarize-yaml command.
 func runSummarizeYaml(dir string) error {
 	setupLogging()
+	if err := validateRunFlags(dir); err != nil {
+		return err
+	}
+	if err := validatePrioritize(); err != nil {
+		return err
+	}
+	if err := validateRefine(); err != nil {
+		return err
+	}
+	if err := validateLargeModel(); err != nil {
+		return err
+	}
+	if err := validateAudiences(); err != nil {
+		return err
+	}
+	if err := validateChangelog(); err != nil {
+		return err
+	}
+	if err := validateKeyFiles(); err != nil {
+		return err
+	}
+	if err := validateInject(dir); err != nil {
+		return err
+	}
+	if err := validateAnnotate(); err != nil {
+		return err
+	}
+	if err := validateJira(); err != nil {
+		return err
+	}
+	if err := validateRiskAnalysis(); err != nil {
+		return err
+	}
+	if isArchive(dir) {
+		return runArchive(dir)
+	}
 	if dryRun {
 		return runDryRun(dir)
 	}
@@ -584,37 +736,318 @@ func runSummarizeYaml(dir string) error {
 	return runSummarizeYamlWithProvider(dir, llm)
 }
 
+// runReport captures the outcome of a single summarization run.
+type runReport struct {
+	Dir        string
+	OutputPath string
+	// Model is the model summaries were generated with.
+	Model     string
+	YAMLFiles []string
+	Summaries map[string]string
+	Processed int
+	// Refined is how many of the processed files were re-summarized with --refine-model.
+	Refined int
+	// Routed is how many of the processed files were summarized with --large-model.
+	Routed int
+	// Annotated is how many files had their summary comment written by --annotate-files.
+	Annotated int
+	Skipped   int
+	Generated int
+	Failed    []string
+	// FailureReasons holds the last provider error of each failed file that has one,
+	// keyed by path relative to Dir.
+	FailureReasons map[string]string
+	// Quarantined lists directories, relative to Dir, whose remaining files were
+	// skipped after --quarantine-after failures.
+	Quarantined []string
+	// Anomalies lists the empty, refusing, or truncated provider responses of the run.
+	Anomalies []providerAnomaly
+	Elapsed   time.Duration
+	// ScanElapsed is how long finding the YAML files took, not included in Elapsed.
+	ScanElapsed time.Duration
+}
+
 // runSummarizeYamlWithProvider contains the core summarization logic, accepting an LLMProvider for testability.
 func runSummarizeYamlWithProvider(dir string, llm LLMProvider) error {
+	return forEachAudience(func() error {
+		report, err := summarizeDirectory(dir, llm)
+		if err != nil {
+			return err
+		}
+		printRunReport(report)
+		if failOnError && len(report.Failed) > 0 {
+			return fmt.Errorf("summarization failed for %d of %d files", len(report.Failed), len(report.YAMLFiles))
+		}
+		return nil
+	})
+}
+
+// printRunReport prints the outcome of a summarization run.
+func printRunReport(report *runReport) {
+	for _, file := range report.Failed {
+		porcelainf("failed", "path", file)
+	}
+	for _, dir := range report.Quarantined {
+		porcelainf("quarantined", "path", dir)
+	}
+	for _, an := range report.Anomalies {
+		porcelainf("anomaly", "path", an.Path, "kind", an.Kind, "severity", anomalySeverity[an.Kind])
+	}
+	porcelainf("result", "dir", report.Dir, "output", report.OutputPath, "format", outputFormat,
+		"processed", report.Processed, "skipped", report.Skipped, "generated", report.Generated,
+		"failed", len(report.Failed), "elapsed_ms", report.Elapsed.Milliseconds(), "scan_ms", report.ScanElapsed.Milliseconds(),
+		"refined", report.Refined, "model", report.Model, "routed", report.Routed)
+
+	if report.OutputPath == stdoutOutput {
+		statusf("\n%s summary written to stdout\n", outputFormat)
+	} else {
+		statusf("\n%s summary written to %s\n", outputFormat, report.OutputPath)
+	}
+	statusf("Model: %s\n", report.Model)
+	statusf("Files processed (new summaries): %d\n", report.Processed)
+	if report.Routed > 0 {
+		statusf("Files summarized with %s: %d\n", largeModel, report.Routed)
+	}
+	if report.Refined > 0 {
+		statusf("Files refined with %s: %d\n", refineModel, report.Refined)
+	}
+	if report.Annotated > 0 {
+		statusf("Files annotated with their summary: %d\n", report.Annotated)
+	}
+	statusf("Files skipped (already summarized): %d\n", report.Skipped)
+	if report.Generated > 0 {
+		statusf("Files skipped as generated: %d\n", report.Generated)
+	}
+	if len(report.Failed) > 0 {
+		statusf("Files failed: %d (run retry-failed to re-attempt them)\n", len(report.Failed))
+		for _, file := range report.Failed {
+			if reason := report.FailureReasons[file]; reason != "" {
+				statusf("  %s: %s\n", file, reason)
+			} else {
+				statusf("  %s\n", file)
+			}
+		}
+	}
+	if len(report.Anomalies) > 0 {
+		statusf("Provider anomalies: %d (%s); check these summaries\n", len(report.Anomalies), anomalyBreakdown(report.Anomalies))
+	}
+	if len(report.Quarantined) > 0 {
+		statusf("Directories quarantined after %d failures: %s\n", quarantineAfter, strings.Join(report.Quarantined, ", "))
+	}
+	statusf("Scan time: %s\n", report.ScanElapsed.Round(time.Millisecond))
+	statusf("Time elapsed: %s\n", report.Elapsed.Round(time.Second))
+}
+
+// summarizeDirectory finds, summarizes, and writes the output document for a single directory.
+func summarizeDirectory(dir string, llm LLMProvider) (*runReport, error) {
+	return summarizeDirectoryChanged(dir, llm, nil)
+}
+
+// summarizeDirectoryChanged is summarizeDirectory that also summarizes the files in
+// changed again, by slash-separated path relative to dir, whatever the document
+// recorded for them.
+func summarizeDirectoryChanged(dir string, llm LLMProvider, changed map[string]bool) (*runReport, error) {
 	slog.Debug("starting summarization", "dir", dir, "model", ModelName, "provider", llm.Name(), "concurrency", concurrency)
-	yamlFiles, err := findYAMLFiles(dir, includeHidden)
+	scanStart := time.Now()
+	yamlFiles, linkAliases, err := findYAMLFilesWithAliases(dir, includeHidden)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	mdPath := filepath.Join(dir, markdownFileName)
+	scanElapsed := time.Since(scanStart)
+	slog.Debug("scanned directory", "dir", dir, "files", len(yamlFiles), "elapsed", scanElapsed)
+	yamlFiles, generated := partitionGenerated(dir, yamlFiles)
+	mdPath := docPathFor(dir)
 	existingSummaries := parseExistingSummaries(mdPath)
+	// Summaries of files edited since they were summarized, or changed since --since,
+	// are not reused, but are still compared against for the change log and delta
+	digests := fileDigests(dir, yamlFiles)
+	reusableSummaries := withoutChanged(withoutStale(existingSummaries, readDocSources(mdPath), digests), changed)
+	if sinceRef != "" {
+		changed, err := gitChangedSince(dir, sinceRef)
+		if err != nil {
+			return nil, err
+		}
+		slog.Debug("found files changed since ref", "ref", sinceRef, "files", len(changed))
+		reusableSummaries = withoutChanged(reusableSummaries, changed)
+	}
 
 	// Check if the model is available
 	modelAvailable, err := llm.Available(context.Background())
 	if err != nil {
-		return fmt.Errorf("failed to check model availability: %w", err)
+		return nil, fmt.Errorf("failed to check model availability: %w", err)
 	}
 	if !modelAvailable {
-		return fmt.Errorf("model %s is not available. Please ensure it is downloaded and available in your %s provider", ModelName, llm.Name())
+		return nil, fmt.Errorf("model %s is not available. Please ensure it is downloaded and available in your %s provider", providerModel(llm), llm.Name())
+	}
+	// Every provider is audited on its own, so each request is recorded with the model
+	// it was sent to
+	trail := newAuditTrail()
+	runProvider := llm
+	var refined LLMProvider
+	if refineModel != "" {
+		if refined, err = newRefineProvider(llm); err != nil {
+			return nil, err
+		}
+		refined = trail.wrap(refined)
+	}
+
+	var routed *routedProvider
+	if largeModel != "" {
+		large, err := switchModel(llm, largeModel, "large-model", "large")
+		if err != nil {
+			return nil, err
+		}
+		routed = newRoutedProvider(trail.wrap(llm), trail.wrap(large))
+		llm = routed
+	} else {
+		llm = trail.wrap(llm)
+	}
+
+	anomalies := newAnomalyProvider(llm)
+	llm = anomalies
+	var refineAnomalies *anomalyProvider
+	if refined != nil {
+		refineAnomalies = newAnomalyProvider(refined)
+		refined = refineAnomalies
+	}
+	retries := newRetryProvider(llm)
+	llm = retries
+	var refineRetries *retryProvider
+	if refined != nil {
+		refineRetries = newRetryProvider(refined)
+		refined = refineRetries
 	}
 
 	start := time.Now()
-	summaries, processed, skipped := processYAMLFiles(yamlFiles, dir, existingSummaries, llm, regenerate)
+	summaries, processed, skipped, quarantined := processYAMLFiles(yamlFiles, dir, reusableSummaries, llm, regenerate)
+	var refinedCount int
+	if refined != nil {
+		refinedCount = refineSummaries(dir, yamlFiles, reusableSummaries, summaries, refined)
+	}
 	elapsed := time.Since(start)
+	// Snapshot the summarization requests before risk analysis and the glossary ask
+	// about the same files
+	answered := retries.answeredFiles()
+	var routedCount int
+	if routed != nil {
+		routedCount = routed.routedFiles()
+	}
+	if refineRetries != nil {
+		// A refined summary replaces the draft, so its requests are the ones that count
+		maps.Copy(answered, refineRetries.answeredFiles())
+	}
+	var annotatedFiles int
+	if annotateFilesEnabled {
+		annotatedFiles = annotateFiles(dir, summaries)
+	}
 	grouped := groupSummariesByDir(yamlFiles, summaries, dir)
-	if err := writeSummary(dir, grouped); err != nil {
-		return fmt.Errorf("failed to write output: %w", err)
+	appendices := append(keyFilesAppendix(dir, yamlFiles, summaries), generatedAppendix(generated)...)
+	notes := make(map[string][]riskFinding)
+	if riskAnalysis != "" {
+		risks := analyzeRisks(yamlFiles, llm)
+		appendices = append(appendices, findingsAppendix(dir, risks)...)
+		mergeFindings(notes, risks)
 	}
-	fmt.Printf("\n%s summary written to %s\n", outputFormat, mdPath)
-	fmt.Printf("Files processed (new summaries): %d\n", processed)
-	fmt.Printf("Files skipped (already summarized): %d\n", skipped)
-	fmt.Printf("Time elapsed: %s\n", elapsed.Round(time.Second))
-	return nil
+	if len(policyPaths) > 0 {
+		violations := checkPolicies(yamlFiles)
+		appendices = append(appendices, policyAppendix(dir, violations)...)
+		mergeFindings(notes, violations)
+	}
+	annotated := summaries
+	aliased := len(linkAliases) > 0 && markdownSummariesSupported()
+	if aliased {
+		annotated = withLinkAliases(dir, annotated, linkAliases)
+	}
+	if glossaryEnabled {
+		var glossary []docAppendix
+		annotated, glossary = documentGlossary(mdPath, yamlFiles, annotated, llm)
+		appendices = append(appendices, glossary...)
+	}
+	if len(notes) > 0 {
+		annotated = withRiskNotes(annotated, notes)
+	}
+	var lowConfidence map[string]string
+	if confidenceNotes {
+		lowConfidence = lowConfidenceNotes(dir, yamlFiles, answered, parseConfidenceNotes(existingDocumentLines(mdPath)))
+		annotated = withConfidenceNotes(annotated, lowConfidence)
+	}
+	prefixed := kindPrefixEnabled && markdownSummariesSupported()
+	if prefixed {
+		annotated = withKindPrefixes(annotated, yamlFiles)
+	}
+	if aliased || glossaryEnabled || len(notes) > 0 || len(lowConfidence) > 0 || prefixed {
+		grouped = groupSummariesByDir(yamlFiles, annotated, dir)
+	}
+	if networkSurfaceEnabled {
+		appendices = append(appendices, networkAppendix(dir, yamlFiles)...)
+	}
+	if labelReport || len(requiredLabels) > 0 {
+		appendices = append(appendices, labelAppendix(dir, yamlFiles)...)
+	}
+	if diffCluster {
+		appendices = append(appendices, driftAppendix(dir, yamlFiles, llm)...)
+	}
+	if changelogRuns > 0 {
+		appendices = append(appendices, changelogAppendix(mdPath, dir, yamlFiles, existingSummaries, summaries)...)
+	}
+	if err := trail.write(dir, runProvider); err != nil {
+		return nil, err
+	}
+	if err := writeSummary(dir, grouped, digests, appendices...); err != nil {
+		return nil, fmt.Errorf("failed to write output: %w", err)
+	}
+	if backstageEnabled {
+		if err := writeBackstage(dir, grouped, appendices); err != nil {
+			return nil, fmt.Errorf("failed to write Backstage TechDocs: %w", err)
+		}
+	}
+	if attestationPath != "" {
+		if err := writeAttestation(attestationPath, dir, mdPath, yamlFiles, llm.Name(), start); err != nil {
+			return nil, fmt.Errorf("failed to write attestation: %w", err)
+		}
+	}
+	if changedOnlyOutput != "" {
+		if err := writeChangedOnly(dir, yamlFiles, existingSummaries, summaries); err != nil {
+			return nil, fmt.Errorf("failed to write changed-only output: %w", err)
+		}
+	}
+	failed := failedFiles(dir, yamlFiles, summaries)
+	failureReasons := make(map[string]string)
+	for _, f := range retries.recorded(dir) {
+		failureReasons[f.Path] = f.Error
+	}
+	if err := writeFailedList(dir, failed); err != nil {
+		return nil, fmt.Errorf("failed to record failed files: %w", err)
+	}
+	recordedAnomalies := anomalies.recorded(dir)
+	if refineAnomalies != nil {
+		recordedAnomalies = append(recordedAnomalies, refineAnomalies.recorded(dir)...)
+	}
+	report := &runReport{
+		Dir:            dir,
+		OutputPath:     mdPath,
+		Model:          providerModel(llm),
+		YAMLFiles:      yamlFiles,
+		Summaries:      summaries,
+		Processed:      processed,
+		Refined:        refinedCount,
+		Routed:         routedCount,
+		Annotated:      annotatedFiles,
+		Skipped:        skipped,
+		Generated:      len(generated),
+		Failed:         failed,
+		FailureReasons: failureReasons,
+		Quarantined:    quarantined,
+		Anomalies:      recordedAnomalies,
+		Elapsed:        elapsed,
+		ScanElapsed:    scanElapsed,
+	}
+	if jiraIssue != "" || jiraProject != "" {
+		if err := publishJira(report, existingSummaries); err != nil {
+			return nil, fmt.Errorf("failed to publish to Jira: %w", err)
+		}
+	}
+	return report, nil
 }
 
 // rootCmd is the main Cobra command for the CLI application.
@@ -622,32 +1055,158 @@ var rootCmd = &cobra.Command{
 	Use:   "summarize-yaml [directory]",
 	Short: "Summarize YAML files in a directory using Ollama",
 	Args:  cobra.ExactArgs(1),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return loadConfig(cmd, args)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runSummarizeYaml(args[0])
 	},
 }
 
 var regenerate bool
+var regeneratePaths []string
+var prioritize []string
+var quarantineAfter int
+var noWrite bool
+var deterministic bool
+var writeDir string
 var localCache bool
 var includeHidden bool
 var dryRun bool
-var concurrency int
+var concurrency int = 1
+var autoConcurrency bool
+var maxAutoConcurrency int
 var verbose bool
 var outputFormat string
 var provider string
+var wikiBaseURL string
+var lang string
+var siblingContextEnabled bool
+var useGitContext bool
+var ollamaKeepAlive string
+var warmUp bool
+var progressWebhookURL string
+var promptCache bool
+var matchExtensions []string
+var configPath string
+var skipGenerated bool
+var maxFileSizeKB int
+var maxFileTokens int
+var contextTokens int
+var elideDataBytes int
+var annotateFilesEnabled bool
+var backstageEnabled bool
+var progressWebhookInterval time.Duration
+var diffCluster bool
+var riskAnalysis string
+var policyPaths []string
+var networkSurfaceEnabled bool
+var labelReport bool
+var changedOnlyOutput string
+var jiraIssue string
+var jiraProject string
+var porcelain bool
+var trivialLines int
+var expandAnchors bool
+var requiredLabels []string
+var policyQuery string
+var diffClusterContext string
+var knowledgeBasePath string
+var glossaryEnabled bool
+var statsEnabled bool
+var auditLogPath string
+var localOnly bool
+var attestationPath string
 
 func init() {
-	rootCmd.Flags().BoolVar(&regenerate, "regenerate", false, "Regenerate all summaries, even if they already exist in yaml_details.md")
-	rootCmd.Flags().BoolVar(&localCache, "localcache", false, "Write individual summaries to .yaml_summary_cache in the repo root. Mostly used for debugging or local development.")
-	rootCmd.Flags().BoolVar(&includeHidden, "include-hidden-directories", false, "Include hidden directories (starting with '.') when searching for YAML files")
-	rootCmd.Flags().StringVar(&ModelName, "model", DefaultModelName, "Ollama model to use (default: "+DefaultModelName+")")
-	rootCmd.Flags().StringVarP(&markdownFileName, "output", "o", DefaultMarkdownFileName, "Output markdown filename (default: "+DefaultMarkdownFileName+")")
-	rootCmd.Flags().StringVar(&cacheDirName, "cache-dir", DefaultCacheDirName, "Cache directory name for --localcache (default: "+DefaultCacheDirName+")")
-	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Preview which YAML files would be processed without calling the LLM")
-	rootCmd.Flags().IntVarP(&concurrency, "concurrency", "j", 1, "Number of concurrent workers for processing YAML files")
-	rootCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose debug logging")
-	rootCmd.Flags().StringVar(&outputFormat, "format", "markdown", "Output format: markdown, json, or html")
-	rootCmd.Flags().StringVar(&provider, "provider", "ollama", "LLM provider: ollama (default) or openai")
+	rootCmd.PersistentFlags().BoolVar(&regenerate, "regenerate", false, "Regenerate all summaries, even if they already exist in yaml_details.md")
+	rootCmd.PersistentFlags().BoolVar(&deterministic, "deterministic", false, "Use temperature 0 and a fixed seed, and stamp documents with SOURCE_DATE_EPOCH, so unchanged input produces identical documents")
+	rootCmd.PersistentFlags().BoolVar(&noWrite, "no-write", false, "Never write inside the source tree, so it can be mounted read-only; requires --write-dir")
+	rootCmd.PersistentFlags().StringVar(&writeDir, "write-dir", "", "Directory to write the document, failed-file list, and --localcache entries to instead of the source tree")
+	rootCmd.PersistentFlags().IntVar(&quarantineAfter, "quarantine-after", DefaultQuarantineAfter, "Skip the rest of a directory after this many of its files fail with none succeeding (0 disables)")
+	rootCmd.PersistentFlags().StringSliceVar(&prioritize, "prioritize", nil, "Summarize files in this order: changed (newest first), small-first, or kind=<Kind>; later values break ties")
+	rootCmd.PersistentFlags().StringArrayVar(&regeneratePaths, "regenerate-path", nil, "Regenerate only summaries whose path matches this glob (e.g. 'networking/**'); can be repeated")
+	rootCmd.PersistentFlags().StringVar(&sinceRef, "since", "", "Also regenerate summaries of files added or modified since this git ref (e.g. 'origin/main'), keeping the other entries")
+	rootCmd.PersistentFlags().BoolVar(&localCache, "localcache", false, "Write individual summaries to .yaml_summary_cache in the repo root. Mostly used for debugging or local development.")
+	rootCmd.PersistentFlags().BoolVar(&includeHidden, "include-hidden-directories", false, "Include hidden directories (starting with '.') when searching for YAML files")
+	rootCmd.PersistentFlags().StringArrayVar(&excludePatterns, "exclude", nil, "Skip files and directories whose path matches this glob (e.g. '**/node_modules'); can be repeated")
+	rootCmd.PersistentFlags().StringArrayVar(&includePatterns, "include", nil, "Only document files whose path matches this glob (e.g. 'deploy/**'); can be repeated")
+	rootCmd.PersistentFlags().BoolVar(&noGitignore, "no-gitignore", false, "Also document files ignored by .gitignore")
+	rootCmd.PersistentFlags().StringVar(&ModelName, "model", DefaultModelName, "Model to use (default: "+DefaultModelName+"), overriding "+ModelEnvVar)
+	rootCmd.PersistentFlags().StringSliceVar(&keyFileRules, "key-files", nil, "Highlight matching files in a \"Key Configuration Files\" section at the top of the document: kind=<Kind>, path=<glob>, or min-size=<KB> (any rule matches; repeatable)")
+	rootCmd.PersistentFlags().StringVar(&refineModel, "refine-model", "", "Higher-quality model that re-summarizes the files matched by --refine after every file is drafted with --model")
+	rootCmd.PersistentFlags().StringVar(&largeModel, "large-model", "", "Model that summarizes large and complex files (see --large-file-size and --large-file-documents, and CustomResourceDefinitions) instead of --model")
+	rootCmd.PersistentFlags().IntVar(&largeFileSizeKB, "large-file-size", DefaultLargeFileSizeKB, "Size in KB from which --large-model summarizes a file (0 disables)")
+	rootCmd.PersistentFlags().IntVar(&largeFileDocuments, "large-file-documents", DefaultLargeFileDocuments, "Number of documents from which --large-model summarizes a file (0 disables)")
+	rootCmd.PersistentFlags().StringSliceVar(&refineRules, "refine", nil, "Files to re-summarize with --refine-model: kind=<Kind>, path=<glob>, or min-size=<KB> (any rule matches; repeatable)")
+	rootCmd.PersistentFlags().StringVar(&modelAliasName, "model-alias", "", "Model alias from the config file's model_aliases, resolved for the selected --provider")
+	rootCmd.PersistentFlags().StringVarP(&markdownFileName, "output", "o", DefaultMarkdownFileName, "Output file, relative to the output directory unless absolute, or - for stdout (default: "+DefaultMarkdownFileName+", or "+DefaultJSONFileName+" with --format json)")
+	rootCmd.PersistentFlags().StringVar(&injectPath, "inject", "", "Write the summaries into this existing markdown file, such as README.md, between <!-- yaml-summaries:start --> and <!-- yaml-summaries:end --> markers instead of a separate document")
+	rootCmd.PersistentFlags().StringVar(&cacheDirName, "cache-dir", DefaultCacheDirName, "Cache directory name for --localcache (default: "+DefaultCacheDirName+")")
+	rootCmd.PersistentFlags().StringSliceVar(&matchExtensions, "match-extensions", nil, "Additional file suffixes to treat as YAML, e.g. .yaml.tpl,.yml.j2,.yaml.gotmpl")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Config file (default: "+DefaultConfigFileName+" in the target directory or the current directory)")
+	rootCmd.PersistentFlags().BoolVar(&guardrails, "guardrails", false, "Ask again once when a summary declines the request or mentions names neither the file nor its prompt context contains; a second refusal fails the file")
+	rootCmd.PersistentFlags().BoolVar(&skipGenerated, "skip-generated", true, "Skip lock files and files with a generated-code header, listing them in an appendix")
+	rootCmd.PersistentFlags().IntVar(&maxFileSizeKB, "max-file-size", 0, "Skip YAML files larger than this many KB, listing them in an appendix (0 disables the limit)")
+	rootCmd.PersistentFlags().IntVar(&maxFileTokens, "max-file-tokens", 0, "Skip YAML files with more than this many tokens, listing them in an appendix (0 disables the limit)")
+	rootCmd.PersistentFlags().BoolVar(&annotateFilesEnabled, "annotate-files", false, "Also write each summary as a \"# Summary:\" comment at the top of its YAML file, updating it in place on later runs")
+	rootCmd.PersistentFlags().IntVar(&elideDataBytes, "elide-data-bytes", DefaultElideDataBytes, "Replace ConfigMap and Secret data and binaryData values larger than this many bytes with a placeholder before prompting (0 disables)")
+	rootCmd.PersistentFlags().BoolVar(&noRedact, "no-redact", false, "Send file content without redacting Secret data, credential values, and high-entropy strings")
+	rootCmd.PersistentFlags().IntVar(&contextTokens, "context-tokens", DefaultContextTokens, "Model context window in tokens; longer files are compressed to fit by dropping comments, eliding base64, and collapsing repeated list items (0 disables)")
+	rootCmd.PersistentFlags().StringVar(&tokenizerName, "tokenizer", DefaultTokenizer, "How tokens are counted: auto picks one for the model, or cl100k_base, o200k_base, llama3, or estimate")
+	rootCmd.PersistentFlags().StringVar(&riskAnalysis, "risk-analysis", "", "Flag risky settings with a note per entry and a Findings section: rules, or llm to add an LLM review (disabled if empty)")
+	rootCmd.PersistentFlags().StringArrayVar(&policyPaths, "policy", nil, "Rego policy file or directory evaluated with opa against each manifest; violations are noted per entry (can be repeated)")
+	rootCmd.PersistentFlags().StringVar(&policyQuery, "policy-query", DefaultPolicyQuery, "Rego query whose result lists policy violations")
+	rootCmd.PersistentFlags().BoolVar(&networkSurfaceEnabled, "network-surface", false, "Add a network surface section listing Service ports, Ingress routes, and Gateway listeners and routes")
+	rootCmd.PersistentFlags().BoolVar(&labelReport, "label-report", false, "Add a governance section listing label and annotation keys with usage counts")
+	rootCmd.PersistentFlags().StringSliceVar(&requiredLabels, "required-labels", nil, "Label keys every resource must set; resources missing any are listed in the governance section")
+	rootCmd.PersistentFlags().BoolVar(&diffCluster, "diff-cluster", false, "Compare declared resources with the live cluster via kubectl and add a declared vs deployed section")
+	rootCmd.PersistentFlags().StringVar(&diffClusterContext, "diff-cluster-context", "", "kubeconfig context for --diff-cluster (default: current context)")
+	rootCmd.PersistentFlags().StringVar(&changedOnlyOutput, "changed-only-output", "", "Also write just the entries added or changed in this run to this markdown file, or POST them to an http(s) URL")
+	rootCmd.PersistentFlags().IntVar(&trivialLines, "trivial-lines", DefaultTrivialLines, "Files with at most this many non-comment lines get a note inlining their content instead of an LLM summary (0 only handles empty files)")
+	rootCmd.PersistentFlags().StringVar(&promptFilePath, "prompt-file", "", "Go text/template replacing the summarization prompt, with {{.Path}}, {{.Dir}}, {{.Kind}}, and {{.Content}} of each file")
+	rootCmd.PersistentFlags().StringVar(&knowledgeBasePath, "knowledge-base", "", "YAML file mapping custom resource kinds to one-line descriptions that are added to the prompt")
+	rootCmd.PersistentFlags().BoolVar(&stableEntries, "stable-entries", false, "Key each markdown and github-wiki entry with its path and content hash instead of writing a checksum footer or --stats overview, so branches touching different files merge cleanly")
+	rootCmd.PersistentFlags().BoolVar(&kindPrefixEnabled, "kind-prefix", false, "Prefix each markdown and github-wiki entry with the kind and name of the resources its file declares, e.g. Deployment/web-app")
+	rootCmd.PersistentFlags().IntVar(&providerRetries, "retries", 2, "Times to retry a failed provider request before giving up on the file")
+	rootCmd.PersistentFlags().DurationVar(&retryBackoff, "retry-backoff", time.Second, "Delay before the first retry of a failed provider request, doubled for every further retry")
+	rootCmd.PersistentFlags().Float64Var(&retryJitter, "retry-jitter", 0.2, "Fraction by which each retry delay is randomly varied, between 0 and 1")
+	rootCmd.PersistentFlags().BoolVar(&failOnError, "fail-on-error", false, "Exit non-zero when any file could not be summarized, after writing the document")
+	rootCmd.PersistentFlags().BoolVar(&followSymlinks, "follow-symlinks", false, "Descend into symlinked directories; files reached under several paths are summarized once and list their other paths")
+	rootCmd.PersistentFlags().IntVar(&maxSentences, "max-sentences", summarizer.DefaultMaxSentences, "Number of sentences summaries are asked for and truncated to")
+	rootCmd.PersistentFlags().IntVar(&maxChars, "max-chars", 0, "Maximum characters per summary, asked for in the prompt and enforced by truncation (0 disables)")
+	rootCmd.PersistentFlags().StringSliceVar(&audiences, "audience", nil, "Summarize for an audience: dev, ops, or exec; each further audience writes its own document named after --output, e.g. yaml_details.exec.md")
+	rootCmd.PersistentFlags().BoolVar(&chartArchives, "chart-archives", false, "Document packaged Helm charts (.tgz) under charts/ directories from their Chart.yaml")
+	rootCmd.PersistentFlags().BoolVar(&statsEnabled, "stats", false, "Add an overview to the document header: file and directory counts, resources by kind, and estimated reading time")
+	rootCmd.PersistentFlags().BoolVar(&confidenceNotes, "confidence-notes", false, "Mark entries whose summary only succeeded after provider retries or a second guardrail attempt as low confidence")
+	rootCmd.PersistentFlags().IntVar(&changelogRuns, "changelog", 0, "Keep a Change Log section at the bottom of the document listing the entries added, updated, and removed by this many recent runs (0 disables)")
+	rootCmd.PersistentFlags().BoolVar(&glossaryEnabled, "glossary", false, "Add a glossary of resource kinds used in more than one file, with one-line definitions linked from entries")
+	rootCmd.PersistentFlags().BoolVar(&expandAnchors, "expand-anchors", false, "Expand YAML anchors, aliases, and <<: merge keys into the effective config before prompting")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Preview which YAML files would be processed without calling the LLM")
+	rootCmd.PersistentFlags().VarP(concurrencyValue{workers: &concurrency, auto: &autoConcurrency}, "concurrency", "j", "Number of concurrent workers for processing YAML files, or \"auto\" to tune it from provider latency")
+	rootCmd.PersistentFlags().IntVar(&maxAutoConcurrency, "max-concurrency", DefaultMaxAutoConcurrency, "Upper bound on concurrent requests with --concurrency auto")
+	rootCmd.PersistentFlags().BoolVar(&localOnly, "local-only", false, "Refuse to run unless the provider endpoint is on localhost or a private network")
+	rootCmd.PersistentFlags().StringVar(&attestationPath, "attestation", "", "Write an in-toto statement with SLSA provenance of the document (input hashes, tool version, model) to this file")
+	rootCmd.PersistentFlags().StringVar(&auditLogPath, "audit-log", "", "Append a JSON line per run to this file recording who ran it, the provider and model, and a hash of every request sent")
+	rootCmd.PersistentFlags().BoolVar(&porcelain, "porcelain", false, "Print stable, tab-separated key=value status lines to stdout instead of human-readable progress")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose debug logging, including per-file timing and provider details")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Print only errors: no progress bar, status messages, or warnings")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "Format of log records on stderr: text or json")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "format", "markdown", "Output format: markdown, json, html, github-wiki, or asciidoc")
+	rootCmd.PersistentFlags().StringVar(&lang, "lang", DefaultLang, "Language for document headings and summaries: "+strings.Join(supportedLangs(), ", "))
+	rootCmd.PersistentFlags().BoolVar(&siblingContextEnabled, "sibling-context", false, "Include the directory name, repository name, and sibling file names in the prompt")
+	rootCmd.PersistentFlags().BoolVar(&useGitContext, "use-git-context", false, "Include the file's most recent git commit subjects in the prompt")
+	rootCmd.PersistentFlags().BoolVar(&backstageEnabled, "backstage", false, "Also write a Backstage TechDocs page, mkdocs.yml, and catalog-info.yaml next to the document")
+	rootCmd.PersistentFlags().StringVar(&wikiBaseURL, "wiki-base-url", "", "Base URL used for file links in github-wiki output and the --backstage TechDocs page (e.g. https://github.com/org/repo/blob/main)")
+	rootCmd.PersistentFlags().StringVar(&ollamaKeepAlive, "ollama-keep-alive", "", "How long Ollama keeps the model loaded between requests, e.g. 30m; negative keeps it loaded indefinitely (default: server setting)")
+	rootCmd.PersistentFlags().BoolVar(&ollamaConcurrentModels, "ollama-concurrent-models", false, "Let Ollama requests for different models (--large-model, --refine-model) run at the same time instead of one model at a time")
+	rootCmd.PersistentFlags().BoolVar(&warmUp, "warm-up", false, "Load the model with a warm-up request before summarizing")
+	rootCmd.PersistentFlags().StringVar(&jiraIssue, "jira-issue", "", "Comment a digest of added and changed summaries on this Jira tracking ticket after the run (needs JIRA_URL)")
+	rootCmd.PersistentFlags().StringVar(&jiraProject, "jira-project", "", "Create or update an issue in this Jira project with a digest of the latest run (needs JIRA_URL)")
+	rootCmd.PersistentFlags().StringVar(&progressWebhookURL, "progress-webhook", "", "URL to POST JSON progress events to during the run")
+	rootCmd.PersistentFlags().DurationVar(&progressWebhookInterval, "progress-webhook-interval", DefaultProgressWebhookInterval, "Minimum time between progress webhook events")
+	rootCmd.PersistentFlags().BoolVar(&promptCache, "prompt-cache", false, "Send the instruction as a stable system prompt and request server-side prompt caching where supported")
+	rootCmd.PersistentFlags().StringVar(&provider, "provider", "ollama", "LLM provider: ollama (default), openai, or anthropic, overriding "+ProviderEnvVar)
 }
 
 // Execute runs the root Cobra command.
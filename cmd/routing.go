@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// DefaultLargeFileSizeKB is the size from which --large-model summarizes a file.
+const DefaultLargeFileSizeKB = 32
+
+// DefaultLargeFileDocuments is the number of documents from which --large-model
+// summarizes a file.
+const DefaultLargeFileDocuments = 5
+
+// largeModel is the model large and complex files are routed to; every other file is
+// summarized with --model.
+var largeModel string
+
+// largeFileSizeKB routes files of at least this many KB to largeModel. 0 disables it.
+var largeFileSizeKB = DefaultLargeFileSizeKB
+
+// largeFileDocuments routes files with at least this many documents to largeModel. 0
+// disables it.
+var largeFileDocuments = DefaultLargeFileDocuments
+
+// validateLargeModel checks the --large-model thresholds.
+func validateLargeModel() error {
+	if largeFileSizeKB < 0 {
+		return fmt.Errorf("--large-file-size must not be negative, got %d", largeFileSizeKB)
+	}
+	if largeFileDocuments < 0 {
+		return fmt.Errorf("--large-file-documents must not be negative, got %d", largeFileDocuments)
+	}
+	return nil
+}
+
+// largeFileReason returns why file should be summarized with the large model: its size,
+// its number of documents, or a CustomResourceDefinition, whose schemas small models
+// describe poorly. It returns an empty string for every other file.
+func largeFileReason(file string) string {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	if largeFileSizeKB > 0 && len(data) >= largeFileSizeKB*1024 {
+		return "size"
+	}
+	docs, _ := decodeYAMLDocuments(data)
+	for _, doc := range docs {
+		if stringField(doc, "kind") == "CustomResourceDefinition" {
+			return "crd"
+		}
+	}
+	if largeFileDocuments > 0 && len(docs) >= largeFileDocuments {
+		return "documents"
+	}
+	return ""
+}
+
+// routedProvider wraps an LLMProvider and sends requests about large and complex files
+// to the --large-model provider instead.
+type routedProvider struct {
+	LLMProvider
+	large LLMProvider
+	mu    sync.Mutex
+	// routed holds the files whose requests went to the large model.
+	routed map[string]bool
+}
+
+// newRoutedProvider wraps llm so requests about large files go to large.
+func newRoutedProvider(llm, large LLMProvider) *routedProvider {
+	return &routedProvider{LLMProvider: llm, large: large, routed: make(map[string]bool)}
+}
+
+// Summarize sends the request to the large provider if the file it is about is large,
+// and to the wrapped provider otherwise.
+func (r *routedProvider) Summarize(ctx context.Context, content string, prompt string) (string, error) {
+	file, _ := ctx.Value(auditFileKey{}).(string)
+	if file != "" {
+		if reason := largeFileReason(file); reason != "" {
+			slog.Debug("routing file to the large model", "file", file, "model", largeModel, "reason", reason)
+			r.mu.Lock()
+			r.routed[file] = true
+			r.mu.Unlock()
+			return r.large.Summarize(ctx, content, prompt)
+		}
+	}
+	return r.LLMProvider.Summarize(ctx, content, prompt)
+}
+
+// WarmUp implements Warmer if the wrapped provider does. The large model is loaded when
+// the first large file needs it.
+func (r *routedProvider) WarmUp(ctx context.Context) error {
+	if w, ok := r.LLMProvider.(Warmer); ok {
+		return w.WarmUp(ctx)
+	}
+	return nil
+}
+
+// Model returns the model of the wrapped provider.
+func (r *routedProvider) Model() string {
+	return providerModel(r.LLMProvider)
+}
+
+// routedFiles returns how many files were sent to the large model.
+func (r *routedProvider) routedFiles() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.routed)
+}
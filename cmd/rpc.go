@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRPCRequestBytes caps the size of a JSON-RPC request body.
+const maxRPCRequestBytes = 1 << 20
+
+// JSON-RPC 2.0 error codes.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcServerError    = -32000
+)
+
+// rpcRequest is a JSON-RPC 2.0 request.
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// rpcResponse is a JSON-RPC 2.0 response.
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError is the error object of a JSON-RPC 2.0 response.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements error.
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// summaryParams are the parameters of the summary method.
+type summaryParams struct {
+	Path string `json:"path"`
+}
+
+// summaryResult is the result of the summary method. Source is "document" when the
+// summary was read from the generated document and "generated" when it was produced on
+// demand because the file is new or changed since the last run.
+type summaryResult struct {
+	Path    string `json:"path"`
+	Summary string `json:"summary"`
+	Source  string `json:"source"`
+}
+
+// generatedSummary is an on-demand summary, valid while the file keeps its stamp.
+type generatedSummary struct {
+	stamp   fileStamp
+	summary string
+}
+
+// rpcServer answers editor requests for a watch daemon.
+type rpcServer struct {
+	d *watchDaemon
+
+	mu        sync.Mutex
+	generated map[string]generatedSummary
+}
+
+// newRPCServer creates a JSON-RPC server for d.
+func newRPCServer(d *watchDaemon) *rpcServer {
+	return &rpcServer{d: d, generated: make(map[string]generatedSummary)}
+}
+
+// ServeHTTP implements http.Handler, answering one JSON-RPC request per POST.
+func (s *rpcServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "JSON-RPC requests must be POSTed", http.StatusMethodNotAllowed)
+		return
+	}
+	resp := rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null")}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRPCRequestBytes))
+	var req rpcRequest
+	switch {
+	case err != nil:
+		resp.Error = &rpcError{Code: rpcParseError, Message: fmt.Sprintf("failed to read request: %v", err)}
+	case json.Unmarshal(body, &req) != nil:
+		resp.Error = &rpcError{Code: rpcParseError, Message: "request is not valid JSON"}
+	default:
+		if len(req.ID) > 0 {
+			resp.ID = req.ID
+		}
+		resp.Result, err = s.call(r.Context(), req)
+		if err != nil {
+			var rerr *rpcError
+			if !errors.As(err, &rerr) {
+				rerr = &rpcError{Code: rpcServerError, Message: err.Error()}
+			}
+			resp.Error = rerr
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// call dispatches a request to its method.
+func (s *rpcServer) call(ctx context.Context, req rpcRequest) (any, error) {
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return nil, &rpcError{Code: rpcInvalidRequest, Message: `expected a "2.0" request with a method`}
+	}
+	switch req.Method {
+	case "summary":
+		var params summaryParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.Path == "" {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: `expected params {"path": "<file>"}`}
+		}
+		return s.summary(ctx, params.Path)
+	case "status":
+		return s.d.status(), nil
+	default:
+		return nil, &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("unknown method %q, expected summary or status", req.Method)}
+	}
+}
+
+// summary returns the summary of path, which is absolute or relative to the watched
+// directory. The document's summary is used while the file is unchanged since the last
+// run; otherwise one is generated on demand and kept until the file changes again.
+func (s *rpcServer) summary(ctx context.Context, path string) (*summaryResult, error) {
+	file := path
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(s.d.dir, file)
+	}
+	if !isWithin(file, s.d.dir) {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("%s is outside %s", path, s.d.dir)}
+	}
+	absDir, err := filepath.Abs(s.d.dir)
+	if err != nil {
+		return nil, err
+	}
+	absFile, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(absDir, absFile)
+	if err != nil {
+		return nil, err
+	}
+	rel = filepath.ToSlash(rel)
+	if !isYAMLName(rel) {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("%s is not a YAML file", path)}
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	stamp := fileStamp{ModTime: info.ModTime(), Size: info.Size()}
+	file = filepath.Join(s.d.dir, filepath.FromSlash(rel))
+
+	s.d.mu.Lock()
+	seen, ok := s.d.stamps[file]
+	s.d.mu.Unlock()
+	if ok && seen.ModTime.Equal(stamp.ModTime) && seen.Size == stamp.Size {
+		if summary := parseExistingSummaries(docPathFor(s.d.dir))[rel]; summary != "" {
+			return &summaryResult{Path: rel, Summary: summary, Source: "document"}, nil
+		}
+	}
+
+	s.mu.Lock()
+	cached, ok := s.generated[file]
+	s.mu.Unlock()
+	if ok && cached.stamp.ModTime.Equal(stamp.ModTime) && cached.stamp.Size == stamp.Size {
+		return &summaryResult{Path: rel, Summary: cached.summary, Source: "generated"}, nil
+	}
+
+	slog.Debug("summarizing file on demand", "file", file)
+	var summary string
+	if err := auditRun(s.d.dir, s.d.llm, func(llm LLMProvider) error {
+		summary, err = summarizeYAMLFile(ctx, llm, s.d.dir, file)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.generated[file] = generatedSummary{stamp: stamp, summary: summary}
+	s.mu.Unlock()
+	return &summaryResult{Path: rel, Summary: summary, Source: "generated"}, nil
+}
+
+// serveRPC serves JSON-RPC over HTTP on addr until ctx is cancelled and returns the
+// address it listens on. An addr starting with "unix:" names a unix socket path.
+func serveRPC(ctx context.Context, addr string, d *watchDaemon) (net.Addr, error) {
+	network, address := "tcp", addr
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		network, address = "unix", path
+		// Remove a stale socket left behind by a previous daemon
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	listener, err := (&net.ListenConfig{}).Listen(ctx, network, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	server := &http.Server{Handler: newRPCServer(d), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("JSON-RPC server stopped", "addr", addr, "error", err)
+		}
+	}()
+	return listener.Addr(), nil
+}
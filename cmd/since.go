@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// sinceRef is the git ref whose changes --since summarizes again.
+var sinceRef string
+
+// validateSince checks --since, which has nothing to narrow down when every summary is
+// regenerated and must not be taken for a git option.
+func validateSince() error {
+	if sinceRef != "" && regenerate {
+		return fmt.Errorf("--since cannot be combined with --regenerate")
+	}
+	if strings.HasPrefix(sinceRef, "-") {
+		return fmt.Errorf("invalid --since ref %q: it must not start with \"-\"", sinceRef)
+	}
+	return nil
+}
+
+// gitChangedSince returns the files under dir that were added or modified since ref,
+// committed or not, relative to dir.
+func gitChangedSince(dir, ref string) (map[string]bool, error) {
+	out, err := exec.Command("git", "-C", dir, "-c", "core.quotepath=off", "diff", "--name-only", "--relative", "--diff-filter=d", "--end-of-options", ref, "--").Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("git diff %s failed: %w: %s", ref, err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("git diff %s failed: %w", ref, err)
+	}
+	changed := make(map[string]bool)
+	for _, line := range strings.Split(string(out), "\n") {
+		if line != "" {
+			changed[line] = true
+		}
+	}
+	return changed, nil
+}
+
+// withoutChanged returns the existing summaries that may be reused: those of files not
+// in changed, the slash-separated relative paths of files known to have changed, such
+// as since --since.
+func withoutChanged(existingSummaries map[string]string, changed map[string]bool) map[string]string {
+	reusable := make(map[string]string, len(existingSummaries))
+	for rel, summary := range existingSummaries {
+		if !changed[rel] {
+			reusable[rel] = summary
+		}
+	}
+	return reusable
+}
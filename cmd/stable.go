@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// stableEntries keys every markdown and GitHub wiki entry with its path and content
+// hash, in place of the inputs checksum footer and the --stats overview, so branches
+// that regenerate the document for different files do not conflict.
+var stableEntries bool
+
+// entryKeyPattern matches the key at the end of an entry line written with
+// --stable-entries.
+var entryKeyPattern = regexp.MustCompile(` ?<!-- yaml-to-readme-entry sha256:(\S+) (.+?) -->$`)
+
+// entryKey returns the key appended to the entry of rel, a path relative to baseDir,
+// with --stable-entries, or an empty string without it. The key records the digest of
+// the content the summary was generated from, by sources.
+func entryKey(baseDir, rel string, sources map[string]string) string {
+	if !stableEntries {
+		return ""
+	}
+	rel = filepath.ToSlash(rel)
+	return fmt.Sprintf(" <!-- yaml-to-readme-entry sha256:%s %s -->", sourceDigests(baseDir, []string{rel}, sources)[rel], rel)
+}
+
+// cutEntryKey splits an entry line into the line without its key and the key, which
+// is empty if the line has none.
+func cutEntryKey(line string) (string, string) {
+	loc := entryKeyPattern.FindStringIndex(line)
+	if loc == nil {
+		return line, ""
+	}
+	return line[:loc[0]], line[loc[0]:]
+}
+
+// entryKeysChecksum rebuilds the inputs checksum of a document written with
+// --stable-entries from the keys of its entries. It returns an empty string if the
+// document has no keyed entries.
+func entryKeysChecksum(data []byte) string {
+	digests := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		if m := entryKeyPattern.FindStringSubmatch(strings.TrimRight(line, "\r")); m != nil {
+			digests[m[2]] = m[1]
+		}
+	}
+	if len(digests) == 0 {
+		return ""
+	}
+	// The same hash inputsChecksum computes from the files
+	return digestsChecksum(digests)
+}
+
+// stableMarkdownStats returns the --stats overview of a markdown document, which is
+// left out with --stable-entries since its counts change with every added file.
+func stableMarkdownStats(baseDir string, grouped map[string][][2]string) string {
+	if stableEntries {
+		return ""
+	}
+	return markdownStats(baseDir, grouped)
+}
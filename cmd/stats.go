@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// readingWordsPerMinute is the reading speed used to estimate a document's reading time.
+const readingWordsPerMinute = 200
+
+// maxStatsKinds caps how many kinds are listed in the overview; the rest are counted.
+const maxStatsKinds = 10
+
+// kindCount is how many resources of a kind the documented files declare.
+type kindCount struct {
+	Kind  string `json:"kind"`
+	Count int    `json:"count"`
+}
+
+// docStats is the at-a-glance overview rendered in the document header with --stats.
+type docStats struct {
+	Files          int         `json:"files"`
+	Directories    int         `json:"directories"`
+	Kinds          []kindCount `json:"kinds,omitempty"`
+	ReadingMinutes int         `json:"reading_minutes"`
+}
+
+// computeDocStats counts the documented files, their directories, and the resources
+// they declare by kind, and estimates the reading time of their summaries.
+func computeDocStats(baseDir string, grouped map[string][][2]string) docStats {
+	stats := docStats{Directories: len(grouped)}
+	counts := make(map[string]int)
+	words := 0
+	for dir, entries := range grouped {
+		for _, entry := range entries {
+			stats.Files++
+			words += len(strings.Fields(entry[1]))
+			data, err := os.ReadFile(filepath.Join(baseDir, dir, entry[0]))
+			if err != nil {
+				continue
+			}
+			docs, _ := decodeYAMLDocuments(data)
+			for _, doc := range docs {
+				if kind := stringField(doc, "kind"); kind != "" {
+					counts[kind]++
+				}
+			}
+		}
+	}
+	for kind, n := range counts {
+		stats.Kinds = append(stats.Kinds, kindCount{Kind: kind, Count: n})
+	}
+	sort.Slice(stats.Kinds, func(i, j int) bool {
+		if stats.Kinds[i].Count != stats.Kinds[j].Count {
+			return stats.Kinds[i].Count > stats.Kinds[j].Count
+		}
+		return stats.Kinds[i].Kind < stats.Kinds[j].Kind
+	})
+	stats.ReadingMinutes = max(1, (words+readingWordsPerMinute-1)/readingWordsPerMinute)
+	return stats
+}
+
+// kindsBreakdown lists the most common kinds with their counts, e.g.
+// "Deployment (4), Service (3), +2".
+func kindsBreakdown(kinds []kindCount) string {
+	var parts []string
+	for i, k := range kinds {
+		if i == maxStatsKinds {
+			parts = append(parts, fmt.Sprintf("+%d", len(kinds)-maxStatsKinds))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%s (%d)", k.Kind, k.Count))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// statsFor returns the --stats overview for the JSON and HTML renderers, or nil when
+// --stats is not set.
+func statsFor(baseDir string, grouped map[string][][2]string) *docStats {
+	if !statsEnabled {
+		return nil
+	}
+	stats := computeDocStats(baseDir, grouped)
+	return &stats
+}
+
+// markdownStats renders the --stats overview section, or an empty string when --stats
+// is not set.
+func markdownStats(baseDir string, grouped map[string][][2]string) string {
+	if !statsEnabled {
+		return ""
+	}
+	stats := computeDocStats(baseDir, grouped)
+	l := currentLabels()
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\n## %s\n", l.Overview)
+	fmt.Fprintf(&sb, "- **%s:** %d\n", l.StatsFiles, stats.Files)
+	fmt.Fprintf(&sb, "- **%s:** %d\n", l.StatsDirectories, stats.Directories)
+	if len(stats.Kinds) > 0 {
+		fmt.Fprintf(&sb, "- **%s:** %s\n", l.StatsKinds, kindsBreakdown(stats.Kinds))
+	}
+	fmt.Fprintf(&sb, "- **%s:** ~%d min\n", l.StatsReadingTime, stats.ReadingMinutes)
+	return sb.String()
+}
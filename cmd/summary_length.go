@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sebrandon1/yaml-to-readme/summarizer"
+)
+
+// maxSentences is the number of sentences summaries are asked for and truncated to.
+var maxSentences = summarizer.DefaultMaxSentences
+
+// maxChars is the number of characters summaries are asked for and truncated to. 0
+// leaves their length to maxSentences.
+var maxChars int
+
+// sentenceLimitPattern matches the length request at the start of the built-in prompts,
+// such as "no more than two short, high-level sentences".
+var sentenceLimitPattern = regexp.MustCompile(`no more than two short(, [a-z-]+)? sentences`)
+
+// stopRule is the closing length rule of the built-in prompts.
+const stopRule = "Stop after two sentences. If you cannot summarize in two sentences, summarize in one"
+
+// validateSummaryLength checks --max-sentences and --max-chars.
+func validateSummaryLength() error {
+	if maxSentences < 1 {
+		return fmt.Errorf("--max-sentences must be at least 1, got %d", maxSentences)
+	}
+	if maxChars < 0 {
+		return fmt.Errorf("--max-chars must not be negative, got %d", maxChars)
+	}
+	return nil
+}
+
+// countWord spells out small sentence counts, as the prompts do.
+func countWord(n int) string {
+	words := []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten"}
+	if n < len(words) {
+		return words[n]
+	}
+	return strconv.Itoa(n)
+}
+
+// lengthPrompt rewrites the length rules of a built-in prompt for --max-sentences and
+// --max-chars. Prompts without them, such as --prompt-file templates, are returned
+// unchanged, and so is every prompt with the default length.
+func lengthPrompt(prompt string) string {
+	if maxSentences == summarizer.DefaultMaxSentences && maxChars == 0 {
+		return prompt
+	}
+	limit := "no more than " + countWord(maxSentences) + " short$1 sentences"
+	stop := "Stop after " + countWord(maxSentences) + " sentences"
+	if maxSentences == 1 {
+		limit = "one short$1 sentence"
+		stop = "Stop after one sentence"
+	}
+	if maxChars > 0 {
+		stop += fmt.Sprintf(", and keep the summary under %d characters", maxChars)
+	}
+	prompt = sentenceLimitPattern.ReplaceAllString(prompt, limit)
+	return strings.Replace(prompt, stopRule, stop, 1)
+}
+
+// truncateSummary cuts a cleaned summary to --max-sentences and then --max-chars. A
+// summary over the character limit keeps as many whole sentences as fit, or else is cut
+// at a word boundary and ends with an ellipsis.
+func truncateSummary(summary string) string {
+	summary = truncateToSentences(summary, maxSentences)
+	if maxChars <= 0 || utf8.RuneCountInString(summary) <= maxChars {
+		return summary
+	}
+	for n := maxSentences - 1; n > 0; n-- {
+		if shorter := truncateToSentences(summary, n); utf8.RuneCountInString(shorter) <= maxChars {
+			return shorter
+		}
+	}
+	cut := string([]rune(summary)[:maxChars-1])
+	if space := strings.LastIndex(cut, " "); space > 0 {
+		cut = cut[:space]
+	}
+	return strings.TrimRight(cut, " ,;:") + "…"
+}
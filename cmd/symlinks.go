@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"maps"
+	"path/filepath"
+	"strings"
+
+	"github.com/sebrandon1/yaml-to-readme/summarizer"
+)
+
+// followSymlinks descends into symlinked directories, as in monorepos that link shared
+// manifest directories into each workspace.
+var followSymlinks bool
+
+// linkAliasMarker starts the note listing the other paths of a file reached through
+// symlinks.
+const linkAliasMarker = " 🔗 "
+
+// findDocumentedFiles finds the files documented under dir, following symlinked
+// directories with --follow-symlinks.
+func findDocumentedFiles(dir string, includeHidden bool) ([]string, error) {
+	if followSymlinks {
+		return summarizer.FindYAMLFilesFollowingLinks(dir, includeHidden, pathFilter(dir), documentedExtensions()...)
+	}
+	return summarizer.FindYAMLFilesFunc(dir, includeHidden, pathFilter(dir), documentedExtensions()...)
+}
+
+// dedupeLinkedFiles keeps one path for each real file found under several paths through
+// symlinks, and returns the other paths keyed by the one kept. The kept path is the
+// first one that does not pass through a symlink, or the first one found if every path
+// does.
+func dedupeLinkedFiles(files []string) ([]string, map[string][]string) {
+	realPaths := make(map[string]string, len(files))
+	groups := make(map[string][]string)
+	for _, file := range files {
+		real, err := filepath.EvalSymlinks(file)
+		if err != nil {
+			real = file
+		}
+		if abs, err := filepath.Abs(real); err == nil {
+			real = abs
+		}
+		realPaths[file] = real
+		groups[real] = append(groups[real], file)
+	}
+
+	kept := files[:0:0]
+	aliases := make(map[string][]string)
+	for _, file := range files {
+		group := groups[realPaths[file]]
+		if len(group) == 1 {
+			kept = append(kept, file)
+			continue
+		}
+		canonical := group[0]
+		for _, candidate := range group {
+			if abs, err := filepath.Abs(candidate); err == nil && abs == realPaths[file] {
+				canonical = candidate
+				break
+			}
+		}
+		if file != canonical {
+			continue
+		}
+		kept = append(kept, file)
+		for _, other := range group {
+			if other != canonical {
+				aliases[canonical] = append(aliases[canonical], other)
+			}
+		}
+	}
+	return kept, aliases
+}
+
+// linkAliasNote renders the other paths of a file, relative to dir, such as
+// " 🔗 also at `workspaces/web/deploy.yaml`".
+func linkAliasNote(dir string, others []string) string {
+	refs := make([]string, 0, len(others))
+	for _, other := range others {
+		rel, _ := filepath.Rel(dir, other)
+		refs = append(refs, "`"+filepath.ToSlash(rel)+"`")
+	}
+	return linkAliasMarker + "also at " + strings.Join(refs, ", ")
+}
+
+// withLinkAliases returns a copy of summaries with every summarized file that is also
+// reachable through symlinks followed by its other paths.
+func withLinkAliases(dir string, summaries map[string]string, aliases map[string][]string) map[string]string {
+	annotated := maps.Clone(summaries)
+	for file, others := range aliases {
+		if summary := annotated[file]; summary != "" {
+			annotated[file] = summary + linkAliasNote(dir, others)
+		}
+	}
+	return annotated
+}
+
+// stripLinkAliases removes the note listing a file's other paths, and anything after
+// it, from a summary read back from a document, so it is recomputed on the next run.
+func stripLinkAliases(summary string) string {
+	if i := strings.Index(summary, linkAliasMarker); i >= 0 {
+		return summary[:i]
+	}
+	return summary
+}
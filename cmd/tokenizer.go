@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktokenloader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+// DefaultTokenizer picks a tokenizer from the provider and model.
+const DefaultTokenizer = "auto"
+
+// estimateBytesPerToken is the rough byte count of one token used when no real
+// tokenizer is known for a model.
+const estimateBytesPerToken = 4
+
+// Tokenizer counts and truncates text in a model's tokens.
+type Tokenizer interface {
+	// Name identifies the tokenizer, as accepted by --tokenizer.
+	Name() string
+	// Count returns the number of tokens in text.
+	Count(text string) int
+	// Truncate returns the longest prefix of text that fits in n tokens.
+	Truncate(text string, n int) string
+}
+
+// tiktokenTokenizer counts with one of OpenAI's BPE encodings.
+type tiktokenTokenizer struct {
+	name string
+	enc  *tiktoken.Tiktoken
+}
+
+// Name implements Tokenizer.
+func (t *tiktokenTokenizer) Name() string {
+	return t.name
+}
+
+// Count implements Tokenizer.
+func (t *tiktokenTokenizer) Count(text string) int {
+	return len(t.enc.EncodeOrdinary(text))
+}
+
+// Truncate implements Tokenizer.
+func (t *tiktokenTokenizer) Truncate(text string, n int) string {
+	tokens := t.enc.EncodeOrdinary(text)
+	if len(tokens) <= n {
+		return text
+	}
+	// A token can end inside a multi-byte character; drop the partial character
+	return strings.ToValidUTF8(t.enc.Decode(tokens[:n]), "")
+}
+
+// estimateTokenizer approximates tokens from the byte count, for models whose
+// tokenizer is unknown.
+type estimateTokenizer struct{}
+
+// Name implements Tokenizer.
+func (estimateTokenizer) Name() string {
+	return "estimate"
+}
+
+// Count implements Tokenizer.
+func (estimateTokenizer) Count(text string) int {
+	return (len(text) + estimateBytesPerToken - 1) / estimateBytesPerToken
+}
+
+// Truncate implements Tokenizer.
+func (estimateTokenizer) Truncate(text string, n int) string {
+	limit := n * estimateBytesPerToken
+	if len(text) <= limit {
+		return text
+	}
+	for limit > 0 && !utf8.RuneStart(text[limit]) {
+		limit--
+	}
+	return text[:limit]
+}
+
+// tokenizerRegistry maps --tokenizer names to their constructors.
+var tokenizerRegistry = map[string]func() (Tokenizer, error){
+	tiktoken.MODEL_CL100K_BASE: func() (Tokenizer, error) {
+		return newTiktokenTokenizer(tiktoken.MODEL_CL100K_BASE, tiktoken.MODEL_CL100K_BASE)
+	},
+	tiktoken.MODEL_O200K_BASE: func() (Tokenizer, error) {
+		return newTiktokenTokenizer(tiktoken.MODEL_O200K_BASE, tiktoken.MODEL_O200K_BASE)
+	},
+	// Llama 3 extends cl100k_base with 28K extra tokens and the same pre-tokenizer, so
+	// cl100k_base counts are a close upper bound: never fewer tokens than the model sees.
+	"llama3": func() (Tokenizer, error) {
+		return newTiktokenTokenizer("llama3", tiktoken.MODEL_CL100K_BASE)
+	},
+	"estimate": func() (Tokenizer, error) {
+		return estimateTokenizer{}, nil
+	},
+}
+
+// tokenizerModelPrefixes picks a tokenizer for the model names that start with a prefix.
+// The first match wins, so more specific prefixes come first.
+var tokenizerModelPrefixes = []struct {
+	prefix    string
+	tokenizer string
+}{
+	{"gpt-3.5", tiktoken.MODEL_CL100K_BASE},
+	{"gpt-4o", tiktoken.MODEL_O200K_BASE},
+	{"gpt-4.1", tiktoken.MODEL_O200K_BASE},
+	{"gpt-4.5", tiktoken.MODEL_O200K_BASE},
+	{"gpt-4", tiktoken.MODEL_CL100K_BASE},
+	{"gpt-5", tiktoken.MODEL_O200K_BASE},
+	{"o1", tiktoken.MODEL_O200K_BASE},
+	{"o3", tiktoken.MODEL_O200K_BASE},
+	{"o4", tiktoken.MODEL_O200K_BASE},
+	{"text-embedding-", tiktoken.MODEL_CL100K_BASE},
+	{"llama3", "llama3"},
+	{"llama-3", "llama3"},
+}
+
+var tokenizerName = DefaultTokenizer
+
+var tokenizerCache sync.Map
+
+func init() {
+	tiktoken.SetBpeLoader(tiktokenloader.NewOfflineLoader())
+}
+
+// newTiktokenTokenizer loads a bundled BPE encoding.
+func newTiktokenTokenizer(name, encoding string) (Tokenizer, error) {
+	enc, err := tiktoken.GetEncoding(encoding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s encoding: %w", encoding, err)
+	}
+	return &tiktokenTokenizer{name: name, enc: enc}, nil
+}
+
+// tokenizerNames returns the registered tokenizer names, sorted.
+func tokenizerNames() []string {
+	names := make([]string, 0, len(tokenizerRegistry))
+	for name := range tokenizerRegistry {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// validateTokenizer checks the --tokenizer flag.
+func validateTokenizer() error {
+	if tokenizerName == DefaultTokenizer {
+		return nil
+	}
+	if _, ok := tokenizerRegistry[tokenizerName]; !ok {
+		return fmt.Errorf("unknown --tokenizer %q, expected %s or one of %s", tokenizerName, DefaultTokenizer, strings.Join(tokenizerNames(), ", "))
+	}
+	return nil
+}
+
+// tokenizerNameFor returns the tokenizer for a model, falling back to the byte estimate
+// for models without a known tokenizer. Ollama tags such as "llama3.1:8b" match by family.
+func tokenizerNameFor(model string) string {
+	model = strings.ToLower(model)
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		// Namespaced models such as "meta-llama/llama-3.1-8b" or "openai/gpt-4o"
+		model = model[i+1:]
+	}
+	for _, p := range tokenizerModelPrefixes {
+		if strings.HasPrefix(model, p.prefix) {
+			return p.tokenizer
+		}
+	}
+	return "estimate"
+}
+
+// tokenizerFor returns the tokenizer selected by --tokenizer for model. Tokenizers are
+// loaded once per process. If a tokenizer fails to load, the byte estimate is used.
+func tokenizerFor(model string) Tokenizer {
+	name := tokenizerName
+	if name == DefaultTokenizer {
+		name = tokenizerNameFor(model)
+	}
+	if cached, ok := tokenizerCache.Load(name); ok {
+		return cached.(Tokenizer)
+	}
+	newTokenizer, ok := tokenizerRegistry[name]
+	if !ok {
+		return estimateTokenizer{}
+	}
+	tok, err := newTokenizer()
+	if err != nil {
+		slog.Warn("falling back to estimated token counts", "tokenizer", name, "error", err)
+		tok = estimateTokenizer{}
+	}
+	cached, _ := tokenizerCache.LoadOrStore(name, tok)
+	return cached.(Tokenizer)
+}
+
+// activeTokenizer returns the tokenizer for the model summaries are generated with.
+func activeTokenizer() Tokenizer {
+	return tokenizerFor(ModelName)
+}
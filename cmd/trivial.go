@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// DefaultTrivialLines is the default --trivial-lines threshold: only empty files are
+// handled without the LLM unless it is raised.
+const DefaultTrivialLines = 0
+
+// maxInlineChars caps how much content a trivial file's note inlines.
+const maxInlineChars = 160
+
+// significantLines returns the lines of content that are not blank, comments, or
+// document markers.
+func significantLines(content []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || line == "---" || line == "..." {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// trivialSummary returns a deterministic summary for an empty file, or for a file with at
+// most --trivial-lines significant lines, which it inlines. Such files get nonsense
+// summaries from the LLM, so they are never sent to it.
+func trivialSummary(content []byte) (string, bool) {
+	lines := significantLines(content)
+	l := currentLabels()
+	if len(lines) == 0 {
+		return l.EmptyFile, true
+	}
+	if len(lines) > trivialLines {
+		return "", false
+	}
+	inline := strings.Join(lines, "; ")
+	if len(inline) > maxInlineChars {
+		return "", false
+	}
+	return fmt.Sprintf(l.TrivialFile, "`"+inline+"`"), true
+}
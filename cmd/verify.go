@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// inputsChecksumPattern matches the inputs checksum of a markdown or AsciiDoc document
+// (a comment in the footer) or an HTML document (a meta tag).
+var inputsChecksumPattern = regexp.MustCompile(`yaml-to-readme-inputs(?:" content="| )sha256:([0-9a-f]{64})`)
+
+// inputsChecksum hashes the paths and contents of the documented files, given relative
+// to baseDir, so any added, removed, renamed, or edited file changes it.
+func inputsChecksum(baseDir string, relPaths []string) string {
+	return digestsChecksum(sourceDigests(baseDir, relPaths, nil))
+}
+
+// digestsChecksum hashes paths and content digests, keyed by slash-separated path, in
+// sorted order.
+func digestsChecksum(digests map[string]string) string {
+	rels := make([]string, 0, len(digests))
+	for rel := range digests {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+	h := sha256.New()
+	for _, rel := range rels {
+		fmt.Fprintf(h, "%s\x00%s\n", rel, digests[rel])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// currentDigest returns the hex SHA-256 digest of a file's content, or "missing" if it
+// cannot be read.
+func currentDigest(path string) string {
+	digest, err := fileSHA256(path)
+	if err != nil {
+		return "missing"
+	}
+	return digest
+}
+
+// sourceDigests returns the digest of the content each entry's summary was generated
+// from, keyed by slash-separated path: the one recorded in sources, or for entries
+// without one, such as those of files that failed, the file's current content.
+func sourceDigests(baseDir string, relPaths []string, sources map[string]string) map[string]string {
+	digests := make(map[string]string, len(relPaths))
+	for _, rel := range relPaths {
+		rel = filepath.ToSlash(rel)
+		if digest := sources[rel]; digest != "" {
+			digests[rel] = digest
+			continue
+		}
+		digests[rel] = currentDigest(filepath.Join(baseDir, filepath.FromSlash(rel)))
+	}
+	return digests
+}
+
+// fileDigests returns the current content digests of files under baseDir, keyed by
+// slash-separated relative path. A run takes them before summarizing, so a file edited
+// while it runs is recorded with its old digest and summarized again next time.
+func fileDigests(baseDir string, files []string) map[string]string {
+	digests := make(map[string]string, len(files))
+	for _, file := range files {
+		rel, err := filepath.Rel(baseDir, file)
+		if err != nil {
+			continue
+		}
+		digests[filepath.ToSlash(rel)] = currentDigest(file)
+	}
+	return digests
+}
+
+// groupedRels returns the paths of the entries in grouped, relative to the base
+// directory.
+func groupedRels(grouped map[string][][2]string) []string {
+	var rels []string
+	for dir, entries := range grouped {
+		for _, entry := range entries {
+			rels = append(rels, filepath.Join(dir, entry[0]))
+		}
+	}
+	return rels
+}
+
+// groupedChecksum returns the inputs checksum of the entries in grouped, from the
+// digests of the content their summaries were generated from.
+func groupedChecksum(baseDir string, grouped map[string][][2]string, sources map[string]string) string {
+	return digestsChecksum(sourceDigests(baseDir, groupedRels(grouped), sources))
+}
+
+// checksumFooter renders the source key of every entry and the inputs checksum as the
+// last lines of a markdown document. With --stable-entries both are carried by the
+// entry keys instead.
+func checksumFooter(baseDir string, grouped map[string][][2]string, sources map[string]string) string {
+	if stableEntries {
+		return ""
+	}
+	return formatChecksumFooter(sourceDigests(baseDir, groupedRels(grouped), sources))
+}
+
+// formatChecksumFooter renders the given source keys, keyed by slash-separated path,
+// and their inputs checksum as a markdown footer.
+func formatChecksumFooter(digests map[string]string) string {
+	rels := make([]string, 0, len(digests))
+	for rel := range digests {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+	var sb strings.Builder
+	sb.WriteString("\n")
+	for _, rel := range rels {
+		fmt.Fprintf(&sb, "<!-- yaml-to-readme-entry sha256:%s %s -->\n", digests[rel], rel)
+	}
+	fmt.Fprintf(&sb, "<!-- yaml-to-readme-inputs sha256:%s -->\n", digestsChecksum(digests))
+	return sb.String()
+}
+
+// readDocSources returns the digests of the content the entries of a document were
+// generated from, keyed by slash-separated path: the JSON entry hashes, or the entry
+// keys of a markdown or GitHub wiki document. Documents written before digests were
+// recorded, and HTML and AsciiDoc documents, have none.
+func readDocSources(docPath string) map[string]string {
+	sources := make(map[string]string)
+	data, err := os.ReadFile(docPath)
+	if err != nil {
+		return sources
+	}
+	switch detectDocKind(data) {
+	case docKindJSON:
+		var output JSONOutput
+		if json.Unmarshal(data, &output) != nil {
+			return sources
+		}
+		for _, entries := range output.Directories {
+			for _, entry := range entries {
+				if digest, ok := strings.CutPrefix(entry.Hash, "sha256:"); ok {
+					sources[filepath.ToSlash(filepath.Clean(entry.Path))] = digest
+				}
+			}
+		}
+	case docKindMarkdown:
+		for _, line := range strings.Split(string(data), "\n") {
+			if m := entryKeyPattern.FindStringSubmatch(strings.TrimRight(line, "\r")); m != nil {
+				sources[m[2]] = m[1]
+			}
+		}
+	}
+	return sources
+}
+
+// withoutStale returns the existing summaries that may be reused: those of files whose
+// content is the one their summary was generated from, by the digests recorded in the
+// document. Summaries without a recorded digest are kept.
+func withoutStale(existingSummaries, sources, digests map[string]string) map[string]string {
+	reusable := make(map[string]string, len(existingSummaries))
+	for rel, summary := range existingSummaries {
+		if recorded := sources[filepath.ToSlash(rel)]; recorded != "" && recorded != digests[filepath.ToSlash(rel)] {
+			slog.Debug("file changed since it was summarized", "file", rel)
+			continue
+		}
+		reusable[rel] = summary
+	}
+	return reusable
+}
+
+// readInputsChecksum returns the inputs checksum recorded in a document, or an empty
+// string if it has none.
+func readInputsChecksum(docPath string) (string, error) {
+	data, err := os.ReadFile(docPath)
+	if err != nil {
+		return "", err
+	}
+	if detectDocKind(data) == docKindJSON {
+		var output JSONOutput
+		if err := json.Unmarshal(data, &output); err != nil {
+			return "", fmt.Errorf("failed to parse JSON document: %w", err)
+		}
+		return output.InputsSHA256, nil
+	}
+	if m := inputsChecksumPattern.FindSubmatch(data); m != nil {
+		return string(m[1]), nil
+	}
+	return entryKeysChecksum(data), nil
+}
+
+// runVerify recomputes the inputs checksum of dir and compares it with the one in the
+// document, without calling the LLM.
+func runVerify(dir string) error {
+	setupLogging()
+	docPath := docPathFor(dir)
+	recorded, err := readInputsChecksum(docPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", docPath, err)
+	}
+	if recorded == "" {
+		return fmt.Errorf("%s has no inputs checksum; run migrate or regenerate it to add one", docPath)
+	}
+	yamlFiles, err := findYAMLFiles(dir, includeHidden)
+	if err != nil {
+		return err
+	}
+	yamlFiles, _ = partitionGenerated(dir, yamlFiles)
+	rels := make([]string, 0, len(yamlFiles))
+	for _, file := range yamlFiles {
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		rels = append(rels, rel)
+	}
+	if inputsChecksum(dir, rels) != recorded {
+		porcelainf("verify", "status", "stale", "files", len(rels))
+		return fmt.Errorf("%s is out of date: YAML files were added, removed, or changed since it was generated", docPath)
+	}
+	porcelainf("verify", "status", "up-to-date", "files", len(rels))
+	statusf("%s is up to date with %d YAML files\n", docPath, len(rels))
+	return nil
+}
+
+// verifyCmd checks whether the generated document still matches the YAML files.
+var verifyCmd = &cobra.Command{
+	Use:   "verify [directory]",
+	Short: "Check that the generated document matches the current YAML files",
+	Long: `Recompute the checksum of the YAML files that would be documented and compare it
+with the one recorded when the document was written. No LLM calls are made, so this is
+a cheap CI check for out-of-date documents.`,
+	Args: cobra.ExactArgs(1),
+	// An out-of-date document is reported by runVerify; the usage text adds nothing.
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runVerify(args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+}
@@ -0,0 +1,419 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const DefaultWatchInterval = 10 * time.Second
+
+// DefaultWatchSettle is how long the tree must stay unchanged after a change before it
+// is re-summarized.
+const DefaultWatchSettle = 2 * time.Second
+
+// watchSettleRounds caps how many settle periods a run is postponed while changes keep
+// arriving, so a tree that never settles is still summarized.
+const watchSettleRounds = 10
+
+// fileStamp records the modification time and size of a watched file.
+type fileStamp struct {
+	ModTime time.Time
+	Size    int64
+}
+
+// watchStatus is the JSON response returned by the control socket.
+type watchStatus struct {
+	OK        bool   `json:"ok"`
+	Error     string `json:"error,omitempty"`
+	State     string `json:"state"`
+	Dir       string `json:"dir"`
+	Runs      int    `json:"runs"`
+	LastRun   string `json:"last_run,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+	Files     int    `json:"files"`
+}
+
+// watchDaemon re-summarizes a directory whenever its YAML files change and can be
+// operated at runtime through a control socket.
+type watchDaemon struct {
+	dir     string
+	llm     LLMProvider
+	refresh chan struct{}
+	// settle is how long changes must stop before a run starts; zero runs immediately.
+	settle time.Duration
+
+	mu       sync.Mutex
+	paused   bool
+	settling bool
+	running  bool
+	runs     int
+	lastRun  time.Time
+	lastErr  error
+	stamps   map[string]fileStamp
+}
+
+// newWatchDaemon creates a daemon watching dir.
+func newWatchDaemon(dir string, llm LLMProvider) *watchDaemon {
+	return &watchDaemon{dir: dir, llm: llm, refresh: make(chan struct{}, 1), settle: watchSettle}
+}
+
+// snapshotYAMLFiles returns the current stamps of every YAML file under dir.
+func snapshotYAMLFiles(dir string) (map[string]fileStamp, error) {
+	files, err := findYAMLFiles(dir, includeHidden)
+	if err != nil {
+		return nil, err
+	}
+	stamps := make(map[string]fileStamp, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		stamps[file] = fileStamp{ModTime: info.ModTime(), Size: info.Size()}
+	}
+	return stamps, nil
+}
+
+// stampsEqual reports whether two snapshots describe the same set of unchanged files.
+func stampsEqual(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for file, stamp := range a {
+		if other, ok := b[file]; !ok || !other.ModTime.Equal(stamp.ModTime) || other.Size != stamp.Size {
+			return false
+		}
+	}
+	return true
+}
+
+// changedStamps returns the files of stamps that were in previous with another stamp, by
+// slash-separated path relative to dir.
+func changedStamps(dir string, previous, stamps map[string]fileStamp) map[string]bool {
+	changed := make(map[string]bool)
+	for file, stamp := range stamps {
+		if old, ok := previous[file]; ok && (!old.ModTime.Equal(stamp.ModTime) || old.Size != stamp.Size) {
+			if rel, err := filepath.Rel(dir, file); err == nil {
+				changed[filepath.ToSlash(rel)] = true
+			}
+		}
+	}
+	return changed
+}
+
+// waitForSettle polls until the tree stops changing for d.settle, starting from stamps,
+// and returns the settled snapshot. A burst of edits such as a git checkout thereby
+// costs one run after it ends instead of runs for files that are about to change again.
+// It gives up waiting after watchSettleRounds periods or when ctx is cancelled.
+func (d *watchDaemon) waitForSettle(ctx context.Context, stamps map[string]fileStamp) (map[string]fileStamp, error) {
+	d.mu.Lock()
+	d.settling = true
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		d.settling = false
+		d.mu.Unlock()
+	}()
+
+	for round := 0; round < watchSettleRounds; round++ {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(d.settle):
+		}
+		next, err := snapshotYAMLFiles(d.dir)
+		if err != nil {
+			return nil, err
+		}
+		if stampsEqual(stamps, next) {
+			return next, nil
+		}
+		slog.Debug("changes still arriving, postponing run", "dir", d.dir, "files", len(next))
+		stamps = next
+	}
+	slog.Warn("changes did not settle, running anyway", "dir", d.dir, "waited", d.settle*watchSettleRounds)
+	return stamps, nil
+}
+
+// tick checks for changes and re-summarizes if any are found, or unconditionally when
+// force is set. Changes are only acted on once they settle. It returns whether a run
+// happened.
+func (d *watchDaemon) tick(ctx context.Context, force bool) bool {
+	d.mu.Lock()
+	if d.paused && !force {
+		d.mu.Unlock()
+		return false
+	}
+	previous := d.stamps
+	d.mu.Unlock()
+
+	stamps, err := snapshotYAMLFiles(d.dir)
+	if err != nil {
+		slog.Error("failed to scan directory", "dir", d.dir, "error", err)
+		return false
+	}
+	if !force && previous != nil && stampsEqual(previous, stamps) {
+		return false
+	}
+	if !force && previous != nil && d.settle > 0 {
+		if stamps, err = d.waitForSettle(ctx, stamps); err != nil {
+			if ctx.Err() == nil {
+				slog.Error("failed to scan directory", "dir", d.dir, "error", err)
+			}
+			return false
+		}
+	}
+
+	d.mu.Lock()
+	d.running = true
+	d.mu.Unlock()
+
+	// Files modified since the last run are summarized again, not served from the document
+	_, err = summarizeDirectoryChanged(d.dir, d.llm, changedStamps(d.dir, previous, stamps))
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.running = false
+	d.runs++
+	d.lastRun = time.Now()
+	d.lastErr = err
+	d.stamps = stamps
+	if err != nil {
+		slog.Error("summarization run failed", "dir", d.dir, "error", err)
+	}
+	return true
+}
+
+// run polls for changes until ctx is cancelled, also running on force-refresh requests.
+// Runs never overlap: events that arrive during a run or while settling are coalesced
+// into the next poll rather than queued.
+func (d *watchDaemon) run(ctx context.Context, interval time.Duration) {
+	d.tick(ctx, false)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.tick(ctx, false)
+		case <-d.refresh:
+			d.tick(ctx, true)
+		}
+	}
+}
+
+// status returns the daemon's current state.
+func (d *watchDaemon) status() watchStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	st := watchStatus{OK: true, State: "idle", Dir: d.dir, Runs: d.runs, Files: len(d.stamps)}
+	switch {
+	case d.running:
+		st.State = "running"
+	case d.settling:
+		st.State = "settling"
+	case d.paused:
+		st.State = "paused"
+	}
+	if !d.lastRun.IsZero() {
+		st.LastRun = d.lastRun.UTC().Format(time.RFC3339)
+	}
+	if d.lastErr != nil {
+		st.LastError = d.lastErr.Error()
+	}
+	return st
+}
+
+// handleCommand applies a control command and returns the resulting status.
+func (d *watchDaemon) handleCommand(command string) watchStatus {
+	switch command {
+	case "status":
+	case "pause":
+		d.mu.Lock()
+		d.paused = true
+		d.mu.Unlock()
+	case "resume":
+		d.mu.Lock()
+		d.paused = false
+		d.mu.Unlock()
+	case "refresh":
+		select {
+		case d.refresh <- struct{}{}:
+		default:
+			// A refresh is already queued
+		}
+	default:
+		st := d.status()
+		st.OK = false
+		st.Error = fmt.Sprintf("unknown command %q, expected status, pause, resume, or refresh", command)
+		return st
+	}
+	return d.status()
+}
+
+// serveControlSocket accepts one command per connection on a unix socket until ctx is
+// cancelled, replying with the daemon status as a JSON line.
+func serveControlSocket(ctx context.Context, socketPath string, d *watchDaemon) error {
+	// Remove a stale socket left behind by a previous daemon
+	if err := os.Remove(socketPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	listener, err := (&net.ListenConfig{}).Listen(ctx, "unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", socketPath, err)
+	}
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer func() {
+					_ = conn.Close()
+				}()
+				line, err := bufio.NewReader(conn).ReadString('\n')
+				if err != nil && line == "" {
+					return
+				}
+				st := d.handleCommand(strings.TrimSpace(line))
+				_ = json.NewEncoder(conn).Encode(st)
+			}(conn)
+		}
+	}()
+	return nil
+}
+
+// sendControlCommand sends a command to a watch daemon's control socket and returns
+// its status reply.
+func sendControlCommand(socketPath, command string) (*watchStatus, error) {
+	conn, err := net.DialTimeout("unix", socketPath, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", socketPath, err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	if _, err := fmt.Fprintln(conn, command); err != nil {
+		return nil, err
+	}
+	var st watchStatus
+	if err := json.NewDecoder(conn).Decode(&st); err != nil {
+		return nil, fmt.Errorf("failed to read reply: %w", err)
+	}
+	if !st.OK {
+		return &st, errors.New(st.Error)
+	}
+	return &st, nil
+}
+
+// runWatch is the main logic for the watch command.
+func runWatch(dir string) error {
+	setupLogging()
+	if err := validateRunFlags(dir); err != nil {
+		return err
+	}
+	llm, err := createProvider()
+	if err != nil {
+		return fmt.Errorf("failed to create %s provider: %w", provider, err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	d := newWatchDaemon(dir, llm)
+	if controlSocket != "" {
+		if err := serveControlSocket(ctx, controlSocket, d); err != nil {
+			return err
+		}
+		defer func() {
+			_ = os.Remove(controlSocket)
+		}()
+		statusf("Control socket listening on %s\n", controlSocket)
+	}
+	if rpcListen != "" {
+		addr, err := serveRPC(ctx, rpcListen, d)
+		if err != nil {
+			return err
+		}
+		statusf("JSON-RPC endpoint listening on %s\n", addr)
+	}
+	statusf("Watching %s for YAML changes every %s, running once they settle for %s\n", dir, watchInterval, watchSettle)
+	d.run(ctx, watchInterval)
+	return nil
+}
+
+// watchCmd runs as a long-lived daemon, re-summarizing when YAML files change.
+var watchCmd = &cobra.Command{
+	Use:   "watch [directory]",
+	Short: "Re-summarize a directory whenever its YAML files change",
+	Long: `Run as a long-lived daemon that polls the directory for added, removed, or modified
+YAML files and re-runs summarization when anything changes. Bursts of changes, such as a
+git checkout, are coalesced into one run once the tree has been unchanged for --settle.
+With --control-socket the daemon can be operated at runtime using the ctl subcommand.
+With --rpc-listen it also answers JSON-RPC 2.0 requests over HTTP POST, so editor plugins
+can show a file's summary on hover: the "summary" method takes {"path": "<file>"} and
+returns the documented summary, generating one on demand for new or changed files.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWatch(args[0])
+	},
+}
+
+// ctlCmd sends a control command to a running watch daemon.
+var ctlCmd = &cobra.Command{
+	Use:       "ctl [status|pause|resume|refresh]",
+	Short:     "Send a control command to a running watch daemon",
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{"status", "pause", "resume", "refresh"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if controlSocket == "" {
+			return fmt.Errorf("--control-socket is required")
+		}
+		st, err := sendControlCommand(controlSocket, args[0])
+		if err != nil {
+			return err
+		}
+		out, err := json.MarshalIndent(st, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	},
+}
+
+var watchInterval time.Duration
+var watchSettle time.Duration
+var controlSocket string
+var rpcListen string
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(ctlCmd)
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", DefaultWatchInterval, "How often to poll for YAML changes")
+	watchCmd.Flags().DurationVar(&watchSettle, "settle", DefaultWatchSettle, "How long YAML files must stay unchanged before a run starts (0 runs immediately)")
+	watchCmd.Flags().StringVar(&controlSocket, "control-socket", "", "Unix socket path for the ctl subcommand (disabled if empty)")
+	watchCmd.Flags().StringVar(&rpcListen, "rpc-listen", "", "Address for the JSON-RPC summary endpoint, host:port or unix:/path (disabled if empty)")
+	ctlCmd.Flags().StringVar(&controlSocket, "control-socket", "", "Unix socket path of the watch daemon")
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const DefaultProgressWebhookInterval = 5 * time.Second
+
+// progressEvent is the JSON payload POSTed to --progress-webhook.
+type progressEvent struct {
+	Event       string  `json:"event"`
+	Dir         string  `json:"dir"`
+	Completed   int     `json:"completed"`
+	Total       int     `json:"total"`
+	Percent     float64 `json:"percent"`
+	CurrentFile string  `json:"current_file,omitempty"`
+	Failures    int     `json:"failures"`
+	Timestamp   string  `json:"timestamp"`
+}
+
+// progressWebhook POSTs progress events for one run, throttled to one event per interval
+// plus start and done events. Delivery failures are logged and never abort the run.
+type progressWebhook struct {
+	url      string
+	dir      string
+	total    int
+	interval time.Duration
+	client   *http.Client
+
+	mu       sync.Mutex
+	lastSent time.Time
+	failures int
+	inFlight sync.WaitGroup
+}
+
+// newProgressWebhook returns a webhook for the --progress-webhook flag, or nil if it is unset.
+func newProgressWebhook(dir string, total int) *progressWebhook {
+	if progressWebhookURL == "" {
+		return nil
+	}
+	return &progressWebhook{
+		url:      progressWebhookURL,
+		dir:      dir,
+		total:    total,
+		interval: progressWebhookInterval,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// event builds a progress event. The caller must hold w.mu.
+func (w *progressWebhook) event(name string, completed int, file string) progressEvent {
+	percent := 100.0
+	if w.total > 0 {
+		percent = float64(completed) * 100 / float64(w.total)
+	}
+	return progressEvent{
+		Event:       name,
+		Dir:         w.dir,
+		Completed:   completed,
+		Total:       w.total,
+		Percent:     percent,
+		CurrentFile: file,
+		Failures:    w.failures,
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// Start sends the start event.
+func (w *progressWebhook) Start(completed int) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	ev := w.event("start", completed, "")
+	w.lastSent = time.Now()
+	w.mu.Unlock()
+	w.post(ev)
+}
+
+// Progress records a finished file and sends a progress event if the interval has elapsed.
+// Events are sent in the background so workers are not held up by a slow endpoint.
+func (w *progressWebhook) Progress(completed int, file string, failed bool) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	if failed {
+		w.failures++
+	}
+	if time.Since(w.lastSent) < w.interval {
+		w.mu.Unlock()
+		return
+	}
+	ev := w.event("progress", completed, file)
+	w.lastSent = time.Now()
+	w.mu.Unlock()
+
+	w.inFlight.Add(1)
+	go func() {
+		defer w.inFlight.Done()
+		w.post(ev)
+	}()
+}
+
+// Done waits for background events and sends the done event.
+func (w *progressWebhook) Done(completed int) {
+	if w == nil {
+		return
+	}
+	w.inFlight.Wait()
+	w.mu.Lock()
+	ev := w.event("done", completed, "")
+	w.mu.Unlock()
+	w.post(ev)
+}
+
+// post delivers one event.
+func (w *progressWebhook) post(ev progressEvent) {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		slog.Warn("progress webhook failed", "url", w.url, "error", err)
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		slog.Warn("progress webhook rejected event", "url", w.url, "status", resp.Status)
+	}
+}
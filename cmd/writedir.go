@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// stdoutOutput is the --output value that writes the document to stdout.
+const stdoutOutput = "-"
+
+// outputDir returns the directory the document and run state (the failed-file list)
+// for dir are kept in: --write-dir if set, otherwise dir itself.
+func outputDir(dir string) string {
+	if writeDir != "" {
+		return writeDir
+	}
+	return dir
+}
+
+// docPathFor returns the path of the summaries document for dir, which is the --inject
+// file when set. A relative --output is resolved against the output directory, and
+// "-" is returned as is for a document written to stdout.
+func docPathFor(dir string) string {
+	if injectPath != "" {
+		return injectTarget(dir)
+	}
+	if markdownFileName == stdoutOutput || filepath.IsAbs(markdownFileName) {
+		return markdownFileName
+	}
+	return filepath.Join(outputDir(dir), markdownFileName)
+}
+
+// sharedDocPath reports whether the document path is the same for every documented
+// directory: with --write-dir, or an absolute --output or --inject.
+func sharedDocPath() bool {
+	if injectPath != "" {
+		return writeDir != "" || filepath.IsAbs(injectPath)
+	}
+	return writeDir != "" || filepath.IsAbs(markdownFileName)
+}
+
+// writesToStdout reports whether the document is written to stdout with -o -.
+func writesToStdout() bool {
+	return injectPath == "" && markdownFileName == stdoutOutput
+}
+
+// validateOutput checks that -o - is not combined with options that need the document
+// on disk.
+func validateOutput(dir string) error {
+	if !writesToStdout() {
+		return nil
+	}
+	if isArchive(dir) {
+		return fmt.Errorf("-o - cannot be used with archives, which are documented next to the archive")
+	}
+	if attestationPath != "" {
+		return fmt.Errorf("-o - cannot be used with --attestation, which hashes the written document")
+	}
+	return nil
+}
+
+// docLinkBase returns the path from the directory the document for baseDir is written
+// to back to baseDir, so file links resolve wherever the document lives. A document
+// written to stdout links as if it were written to the output directory.
+func docLinkBase(baseDir string) string {
+	docDir := outputDir(baseDir)
+	if docPath := docPathFor(baseDir); docPath != stdoutOutput {
+		docDir = filepath.Dir(docPath)
+	}
+	absDocDir, err := filepath.Abs(docDir)
+	if err != nil {
+		return filepath.ToSlash(baseDir)
+	}
+	absBase, err := filepath.Abs(baseDir)
+	if err != nil {
+		return filepath.ToSlash(baseDir)
+	}
+	rel, err := filepath.Rel(absDocDir, absBase)
+	if err != nil {
+		return filepath.ToSlash(absBase)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// docFileLink returns the link target of a path relative to baseDir, relative to the
+// document for baseDir.
+func docFileLink(baseDir, relPath string) string {
+	return path.Join(docLinkBase(baseDir), filepath.ToSlash(relPath))
+}
+
+// cacheRoot returns the directory --localcache entries are written under: --write-dir
+// if set, otherwise the current directory.
+func cacheRoot() string {
+	if writeDir != "" {
+		return writeDir
+	}
+	root, _ := os.Getwd()
+	return root
+}
+
+// isWithin reports whether path is dir or inside it.
+func isWithin(path, dir string) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// validateWriteDir prepares --write-dir and, with --no-write, checks that nothing will
+// be written inside the source tree dir, so it can be a read-only mount.
+func validateWriteDir(dir string) error {
+	if noWrite {
+		if writeDir == "" {
+			return fmt.Errorf("--no-write requires --write-dir for the document and cache")
+		}
+		if isWithin(writeDir, dir) {
+			return fmt.Errorf("--no-write: --write-dir %s is inside %s", writeDir, dir)
+		}
+	}
+	if writeDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(writeDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create --write-dir: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package summarizer
+
+import "context"
+
+// Call is one file on its way through the pipeline. Hooks receive a pointer to it and
+// may change what is sent to the provider.
+type Call struct {
+	// Path is the file path, as in Result.Path.
+	Path string
+	// Content is sent to the provider. BeforeSummarize may rewrite it, for example to
+	// redact secrets; Result.Hash and Result.Kind are always computed from the file.
+	Content []byte
+	// Prompt is sent to the provider with the content.
+	Prompt string
+	// Summary, if set by BeforeSummarize, is used as the summary without calling the
+	// provider, for example when it is found in a cache.
+	Summary string
+}
+
+// Hooks are called around each file SummarizeFile, SummarizeDir, and SummarizeScan
+// summarize, so embedders can add redaction, metrics, or caching without changing the
+// pipeline. Any field may be nil. With Concurrency above 1, hooks are called from
+// several goroutines at once.
+type Hooks struct {
+	// BeforeSummarize is called after the file is read and before the provider is. A
+	// returned error fails the file as Result.Err.
+	BeforeSummarize func(ctx context.Context, call *Call) error
+	// AfterSummarize is called with each successful result and may modify it.
+	AfterSummarize func(ctx context.Context, call *Call, result *Result)
+	// OnError is called when a file fails, with the error also set in Result.Err. call
+	// is nil if the file could not be read or the context was done before it was.
+	OnError func(ctx context.Context, call *Call, err error)
+}
+
+// before runs every BeforeSummarize hook in order, stopping at the first error.
+func (o Options) before(ctx context.Context, call *Call) error {
+	for _, h := range o.Hooks {
+		if h.BeforeSummarize == nil {
+			continue
+		}
+		if err := h.BeforeSummarize(ctx, call); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// after runs every AfterSummarize hook in order.
+func (o Options) after(ctx context.Context, call *Call, result *Result) {
+	for _, h := range o.Hooks {
+		if h.AfterSummarize != nil {
+			h.AfterSummarize(ctx, call, result)
+		}
+	}
+}
+
+// failed runs every OnError hook in order and returns result with err set.
+func (o Options) failed(ctx context.Context, call *Call, result Result, err error) Result {
+	result.Err = err
+	for _, h := range o.Hooks {
+		if h.OnError != nil {
+			h.OnError(ctx, call, err)
+		}
+	}
+	return result
+}
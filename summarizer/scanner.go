@@ -0,0 +1,288 @@
+package summarizer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is a YAML file found by a Scanner.
+type File struct {
+	// Path identifies the file. DirScanner returns paths on disk; other scanners return
+	// slash-separated paths within their source.
+	Path string
+	// Read returns the file content.
+	Read func() ([]byte, error)
+}
+
+// Scanner finds the YAML files of one input source, such as a directory, a git tree, an
+// archive, or a live cluster. New sources only need to implement Scan to be summarized
+// with SummarizeScan.
+type Scanner interface {
+	// Scan returns the files found, sorted by path.
+	Scan(ctx context.Context) ([]File, error)
+}
+
+// DirScanner finds YAML files in a directory tree with FindYAMLFiles.
+type DirScanner struct {
+	Dir           string
+	IncludeHidden bool
+	// Extensions lists additional file name suffixes treated as YAML.
+	Extensions []string
+	// Skip, if set, leaves out files and directories, such as ignored ones.
+	Skip SkipFunc
+}
+
+// Scan implements Scanner.
+func (s DirScanner) Scan(ctx context.Context) ([]File, error) {
+	paths, err := FindYAMLFilesFunc(s.Dir, s.IncludeHidden, s.Skip, s.Extensions...)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	files := make([]File, len(paths))
+	for i, p := range paths {
+		files[i] = File{Path: p, Read: func() ([]byte, error) { return os.ReadFile(p) }}
+	}
+	return files, nil
+}
+
+// isHiddenPath reports whether any directory of a slash-separated path starts with a dot.
+func isHiddenPath(p string) bool {
+	dirs := strings.Split(path.Dir(p), "/")
+	for _, d := range dirs {
+		if strings.HasPrefix(d, ".") && d != "." {
+			return true
+		}
+	}
+	return false
+}
+
+// GitTreeScanner finds YAML files in a commit of a git repository without checking it
+// out, using the git binary.
+type GitTreeScanner struct {
+	// Repo is a directory inside the repository.
+	Repo string
+	// Rev is the commit, branch, or tag to read (default HEAD).
+	Rev           string
+	IncludeHidden bool
+	Extensions    []string
+}
+
+func (s GitTreeScanner) rev() string {
+	if s.Rev != "" {
+		return s.Rev
+	}
+	return "HEAD"
+}
+
+// git runs git in the repository and returns its stdout.
+func (s GitTreeScanner) git(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", s.Repo}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s failed: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// Scan implements Scanner.
+func (s GitTreeScanner) Scan(ctx context.Context) ([]File, error) {
+	out, err := s.git(ctx, "ls-tree", "-r", "-z", "--name-only", s.rev())
+	if err != nil {
+		return nil, err
+	}
+	var files []File
+	for _, p := range strings.Split(string(out), "\x00") {
+		if p == "" || !HasYAMLExtension(path.Base(p), s.Extensions...) || (!s.IncludeHidden && isHiddenPath(p)) {
+			continue
+		}
+		object := s.rev() + ":" + p
+		files = append(files, File{Path: p, Read: func() ([]byte, error) {
+			return s.git(context.Background(), "cat-file", "blob", object)
+		}})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// TarScanner finds YAML files in a tar archive, optionally gzip-compressed, without
+// extracting it. The archive is read once and its YAML files are kept in memory.
+type TarScanner struct {
+	Path          string
+	IncludeHidden bool
+	Extensions    []string
+}
+
+// Scan implements Scanner.
+func (s TarScanner) Scan(ctx context.Context) ([]File, error) {
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	var r io.Reader = f
+	if strings.HasSuffix(s.Path, ".gz") || strings.HasSuffix(s.Path, ".tgz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", s.Path, err)
+		}
+		defer func() {
+			_ = gz.Close()
+		}()
+		r = gz
+	}
+	tr := tar.NewReader(r)
+	var files []File
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", s.Path, err)
+		}
+		p := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		if hdr.Typeflag != tar.TypeReg || !HasYAMLExtension(path.Base(p), s.Extensions...) || (!s.IncludeHidden && isHiddenPath(p)) {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from %s: %w", p, s.Path, err)
+		}
+		files = append(files, File{Path: p, Read: func() ([]byte, error) { return data, nil }})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// KubernetesScanner reads live resources from a cluster with kubectl. Each resource is
+// a file named <namespace>/<kind>/<name>.yaml (_cluster for cluster-scoped resources)
+// with server-populated fields and secret values removed by SanitizeResource.
+type KubernetesScanner struct {
+	// Context is the kubeconfig context (default: current context).
+	Context string
+	// Namespaces to read (default: all namespaces).
+	Namespaces []string
+	// Kinds are the resource kinds to read, as accepted by kubectl get.
+	Kinds []string
+	// Command is the kubectl binary (default "kubectl").
+	Command string
+}
+
+// kubectl runs kubectl against the scanner's context and returns its stdout.
+func (s KubernetesScanner) kubectl(ctx context.Context, args ...string) ([]byte, error) {
+	command := s.Command
+	if command == "" {
+		command = "kubectl"
+	}
+	cmdArgs := args
+	if s.Context != "" {
+		cmdArgs = append([]string{"--context", s.Context}, args...)
+	}
+	cmd := exec.CommandContext(ctx, command, cmdArgs...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("kubectl %s failed: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// Scan implements Scanner.
+func (s KubernetesScanner) Scan(ctx context.Context) ([]File, error) {
+	namespaces := s.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+	var files []File
+	for _, kind := range s.Kinds {
+		for _, namespace := range namespaces {
+			args := []string{"get", kind, "-o", "yaml"}
+			if namespace == "" {
+				args = append(args, "--all-namespaces")
+			} else {
+				args = append(args, "--namespace", namespace)
+			}
+			out, err := s.kubectl(ctx, args...)
+			if err != nil {
+				return nil, err
+			}
+			var list struct {
+				Items []map[string]any `yaml:"items"`
+			}
+			if err := yaml.Unmarshal(out, &list); err != nil {
+				return nil, fmt.Errorf("failed to parse kubectl output for %s: %w", kind, err)
+			}
+			for _, obj := range list.Items {
+				SanitizeResource(obj)
+				p, ok := resourcePath(obj)
+				if !ok {
+					continue
+				}
+				data, err := yaml.Marshal(obj)
+				if err != nil {
+					return nil, err
+				}
+				files = append(files, File{Path: p, Read: func() ([]byte, error) { return data, nil }})
+			}
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// ClusterScopedDir is the directory KubernetesScanner puts cluster-scoped resources in.
+const ClusterScopedDir = "_cluster"
+
+// resourcePath returns <namespace>/<kind>/<name>.yaml for a resource, or false if it
+// has no kind or name.
+func resourcePath(obj map[string]any) (string, bool) {
+	kind, _ := obj["kind"].(string)
+	meta, _ := obj["metadata"].(map[string]any)
+	namespace, _ := meta["namespace"].(string)
+	name, _ := meta["name"].(string)
+	if kind == "" || name == "" {
+		return "", false
+	}
+	if namespace == "" {
+		namespace = ClusterScopedDir
+	}
+	return path.Join(namespace, strings.ToLower(kind), name+".yaml"), true
+}
+
+// SanitizeResource removes server-populated noise (status, managed fields, and similar)
+// and secret values from a live resource so only its declared intent is summarized.
+func SanitizeResource(obj map[string]any) {
+	delete(obj, "status")
+	if meta, ok := obj["metadata"].(map[string]any); ok {
+		for _, field := range []string{"managedFields", "uid", "resourceVersion", "creationTimestamp", "generation", "selfLink"} {
+			delete(meta, field)
+		}
+		if annotations, ok := meta["annotations"].(map[string]any); ok {
+			delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+			if len(annotations) == 0 {
+				delete(meta, "annotations")
+			}
+		}
+	}
+	if obj["kind"] == "Secret" {
+		delete(obj, "data")
+		delete(obj, "stringData")
+	}
+}
@@ -0,0 +1,420 @@
+// Package summarizer exposes yaml-to-readme's YAML summarization as a library, so
+// other programs can summarize files directly instead of parsing CLI output.
+package summarizer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPrompt is the instruction sent to the LLM along with the file content.
+const DefaultPrompt = "Summarize the purpose of this YAML file in no more than two short, high-level sentences. Do not include any lists, breakdowns, explanations, advice, notes, or formatting. Do not use markdown. No newlines. No code sections. Only output a single, concise summary of the file's purpose, and nothing else. Stop after two sentences. If you cannot summarize in two sentences, summarize in one: \n"
+
+// DefaultMaxSentences is the number of sentences a summary is truncated to.
+const DefaultMaxSentences = 2
+
+// DefaultExtensions are the file name suffixes always treated as YAML.
+var DefaultExtensions = []string{".yaml", ".yml"}
+
+// Provider is the subset of an LLM backend the summarizer needs. The providers in the
+// cmd package satisfy it.
+type Provider interface {
+	// Summarize sends content with a prompt to the LLM and returns the generated summary.
+	Summarize(ctx context.Context, content string, prompt string) (string, error)
+	// Name returns the provider name for display purposes.
+	Name() string
+}
+
+// Options controls how files are summarized. The zero value uses the defaults.
+type Options struct {
+	// Prompt overrides DefaultPrompt.
+	Prompt string
+	// MaxSentences overrides DefaultMaxSentences.
+	MaxSentences int
+	// IncludeHidden makes SummarizeDir descend into hidden directories.
+	IncludeHidden bool
+	// Extensions lists additional file name suffixes SummarizeDir treats as YAML, such
+	// as ".yaml.tpl" or ".yml.j2".
+	Extensions []string
+	// Concurrency is the number of files SummarizeDir summarizes at once (default 1).
+	Concurrency int
+	// Hooks are called around each file, in order. See Hooks.
+	Hooks []Hooks
+}
+
+// Result is the outcome of summarizing one file.
+type Result struct {
+	// Path is the file path as passed in or found by SummarizeDir.
+	Path string
+	// Summary is the cleaned, truncated summary. It is empty if Err is set.
+	Summary string
+	// Kind is the top-level "kind" field of the first YAML document, if any.
+	Kind string
+	// Hash is the hex-encoded SHA-256 of the file content.
+	Hash string
+	// Err is set if the file could not be read or summarized.
+	Err error
+}
+
+func (o Options) prompt() string {
+	if o.Prompt != "" {
+		return o.Prompt
+	}
+	return DefaultPrompt
+}
+
+func (o Options) maxSentences() int {
+	if o.MaxSentences > 0 {
+		return o.MaxSentences
+	}
+	return DefaultMaxSentences
+}
+
+// SummarizeFile summarizes a single YAML file. Errors are reported in Result.Err.
+func SummarizeFile(ctx context.Context, provider Provider, path string, opts Options) Result {
+	return summarizeFile(ctx, provider, File{Path: path, Read: func() ([]byte, error) { return os.ReadFile(path) }}, opts)
+}
+
+// summarizeFile summarizes a file found by a Scanner.
+func summarizeFile(ctx context.Context, provider Provider, file File, opts Options) Result {
+	result := Result{Path: file.Path}
+	content, err := file.Read()
+	if err != nil {
+		return opts.failed(ctx, nil, result, fmt.Errorf("failed to read %s: %w", file.Path, err))
+	}
+	sum := sha256.Sum256(content)
+	result.Hash = hex.EncodeToString(sum[:])
+	result.Kind = DetectKind(content)
+
+	call := &Call{Path: file.Path, Content: content, Prompt: opts.prompt()}
+	if err := opts.before(ctx, call); err != nil {
+		return opts.failed(ctx, call, result, err)
+	}
+	if call.Summary != "" {
+		result.Summary = call.Summary
+	} else {
+		summary, err := provider.Summarize(ctx, string(call.Content), call.Prompt)
+		if err != nil {
+			return opts.failed(ctx, call, result, fmt.Errorf("%s error for %s: %w", provider.Name(), file.Path, err))
+		}
+		result.Summary = TruncateToSentences(CleanSummary(summary), opts.maxSentences())
+	}
+	opts.after(ctx, call, &result)
+	return result
+}
+
+// SummarizeDir summarizes every YAML file under dir, returning results sorted by path.
+// The returned error is only set if the directory could not be walked; per-file
+// failures are reported in each Result.Err.
+func SummarizeDir(ctx context.Context, provider Provider, dir string, opts Options) ([]Result, error) {
+	return SummarizeScan(ctx, provider, DirScanner{Dir: dir, IncludeHidden: opts.IncludeHidden, Extensions: opts.Extensions}, opts)
+}
+
+// SummarizeScan summarizes every file found by scanner, returning results in the
+// scanner's order. The returned error is only set if the scan failed; per-file failures
+// are reported in each Result.Err. Options.IncludeHidden and Options.Extensions are
+// ignored; configure them on the scanner instead.
+func SummarizeScan(ctx context.Context, provider Provider, scanner Scanner, opts Options) ([]Result, error) {
+	files, err := scanner.Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, len(files))
+	sem := make(chan struct{}, max(opts.Concurrency, 1))
+	var wg sync.WaitGroup
+	for i, file := range files {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, file File) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := ctx.Err(); err != nil {
+				results[i] = opts.failed(ctx, nil, Result{Path: file.Path}, err)
+				return
+			}
+			results[i] = summarizeFile(ctx, provider, file, opts)
+		}(i, file)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// DefaultWalkWorkers is how many directories FindYAMLFiles reads at once. Listing
+// directories in parallel hides the per-call latency of network file systems.
+const DefaultWalkWorkers = 16
+
+// FindYAMLFiles recursively finds all YAML files under dir, skipping hidden directories
+// unless includeHidden is set. dir itself is always searched. Files ending in any of
+// extraExtensions are matched in addition to DefaultExtensions. Directories are read by
+// DefaultWalkWorkers goroutines; the result is in the order filepath.Walk would return.
+func FindYAMLFiles(dir string, includeHidden bool, extraExtensions ...string) ([]string, error) {
+	return FindYAMLFilesFunc(dir, includeHidden, nil, extraExtensions...)
+}
+
+// SkipFunc reports whether a file or directory found by FindYAMLFilesFunc is left out.
+// A skipped directory is not read at all. It is called from several goroutines at once.
+type SkipFunc func(path string, isDir bool) bool
+
+// FindYAMLFilesFunc is FindYAMLFiles, also leaving out the files and directories for
+// which skip, if not nil, returns true.
+func FindYAMLFilesFunc(dir string, includeHidden bool, skip SkipFunc, extraExtensions ...string) ([]string, error) {
+	return findYAMLFiles(dir, includeHidden, false, skip, extraExtensions)
+}
+
+// FindYAMLFilesFollowingLinks is FindYAMLFilesFunc, also descending into symlinked
+// directories. Files are listed under the path they were found at, so a directory linked
+// into the tree twice lists its files twice. A link back to a directory that is already
+// being walked is not followed, so link cycles end.
+func FindYAMLFilesFollowingLinks(dir string, includeHidden bool, skip SkipFunc, extraExtensions ...string) ([]string, error) {
+	return findYAMLFiles(dir, includeHidden, true, skip, extraExtensions)
+}
+
+func findYAMLFiles(dir string, includeHidden, followLinks bool, skip SkipFunc, extraExtensions []string) ([]string, error) {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		if HasYAMLExtension(info.Name(), extraExtensions...) {
+			return []string{dir}, nil
+		}
+		return nil, nil
+	}
+
+	root := walkDir{path: dir}
+	if followLinks {
+		real, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			return nil, err
+		}
+		if root.real, err = filepath.Abs(real); err != nil {
+			return nil, err
+		}
+	}
+	w := &walker{pending: []walkDir{root}, includeHidden: includeHidden, followLinks: followLinks, extensions: extraExtensions, skip: skip}
+	w.cond = sync.NewCond(&w.mu)
+	var wg sync.WaitGroup
+	for range DefaultWalkWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.work()
+		}()
+	}
+	wg.Wait()
+	if w.err != nil {
+		return nil, w.err
+	}
+	sort.Slice(w.files, func(i, j int) bool {
+		return walkOrderLess(w.files[i], w.files[j])
+	})
+	return w.files, nil
+}
+
+// walkDir is a directory queued for reading. When links are followed it also carries
+// its real path and the real paths of the directories it was reached through.
+type walkDir struct {
+	path      string
+	real      string
+	ancestors []string
+}
+
+// walker is the shared state of FindYAMLFiles' workers: a queue of directories to read
+// and the files found so far.
+type walker struct {
+	mu            sync.Mutex
+	cond          *sync.Cond
+	pending       []walkDir
+	active        int
+	files         []string
+	err           error
+	includeHidden bool
+	followLinks   bool
+	extensions    []string
+	skip          SkipFunc
+}
+
+// work reads directories from the queue until it is empty and no other worker can add
+// to it, or until a directory cannot be read.
+func (w *walker) work() {
+	for {
+		w.mu.Lock()
+		for len(w.pending) == 0 && w.active > 0 && w.err == nil {
+			w.cond.Wait()
+		}
+		if len(w.pending) == 0 || w.err != nil {
+			w.cond.Broadcast()
+			w.mu.Unlock()
+			return
+		}
+		dir := w.pending[len(w.pending)-1]
+		w.pending = w.pending[:len(w.pending)-1]
+		w.active++
+		w.mu.Unlock()
+
+		subdirs, files, err := w.readDir(dir)
+
+		w.mu.Lock()
+		w.active--
+		if err != nil && w.err == nil {
+			w.err = err
+		}
+		w.pending = append(w.pending, subdirs...)
+		w.files = append(w.files, files...)
+		w.cond.Broadcast()
+		w.mu.Unlock()
+	}
+}
+
+// readDir lists one directory, returning the subdirectories to search and the YAML files.
+func (w *walker) readDir(dir walkDir) ([]walkDir, []string, error) {
+	entries, err := os.ReadDir(dir.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var subdirs []walkDir
+	var files []string
+	for _, e := range entries {
+		path := filepath.Join(dir.path, e.Name())
+		isDir := e.IsDir()
+		var sub walkDir
+		if w.followLinks && e.Type()&fs.ModeSymlink != 0 {
+			sub, isDir = w.linkedDir(dir, path)
+		} else if isDir && w.followLinks {
+			sub = walkDir{path: path, real: filepath.Join(dir.real, e.Name()), ancestors: dir.chain()}
+		} else {
+			sub = walkDir{path: path}
+		}
+		switch {
+		case isDir:
+			if (w.includeHidden || !strings.HasPrefix(e.Name(), ".")) && (w.skip == nil || !w.skip(path, true)) {
+				subdirs = append(subdirs, sub)
+			}
+		case HasYAMLExtension(e.Name(), w.extensions...):
+			if w.skip == nil || !w.skip(path, false) {
+				files = append(files, path)
+			}
+		}
+	}
+	return subdirs, files, nil
+}
+
+// chain returns the real paths of dir and the directories it was reached through.
+func (dir walkDir) chain() []string {
+	return append(slices.Clone(dir.ancestors), dir.real)
+}
+
+// linkedDir resolves a symlink found in dir. It reports whether the link should be
+// walked as a directory: the target is a directory and not one dir was reached through.
+func (w *walker) linkedDir(dir walkDir, path string) (walkDir, bool) {
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return walkDir{}, false
+	}
+	if info, err := os.Stat(real); err != nil || !info.IsDir() {
+		return walkDir{}, false
+	}
+	if real, err = filepath.Abs(real); err != nil {
+		return walkDir{}, false
+	}
+	chain := dir.chain()
+	if slices.Contains(chain, real) {
+		return walkDir{}, false
+	}
+	return walkDir{path: path, real: real, ancestors: chain}, true
+}
+
+// walkOrderLess orders paths the way filepath.Walk visits them: component by component,
+// so "a/b.yaml" sorts before "a.yaml".
+func walkOrderLess(a, b string) bool {
+	ap := strings.Split(a, string(filepath.Separator))
+	bp := strings.Split(b, string(filepath.Separator))
+	for i := 0; i < len(ap) && i < len(bp); i++ {
+		if ap[i] != bp[i] {
+			return ap[i] < bp[i]
+		}
+	}
+	return len(ap) < len(bp)
+}
+
+// HasYAMLExtension reports whether name ends in one of DefaultExtensions or extraExtensions.
+func HasYAMLExtension(name string, extraExtensions ...string) bool {
+	for _, ext := range DefaultExtensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	for _, ext := range extraExtensions {
+		if ext != "" && strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectKind returns the top-level "kind" field of the first YAML document in content,
+// or an empty string if there is none or the content does not parse.
+func DetectKind(content []byte) string {
+	var doc struct {
+		Kind string `yaml:"kind"`
+	}
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return ""
+	}
+	return doc.Kind
+}
+
+// CleanSummary removes markdown, lists, and breakdowns from the summary, keeping only a concise, plain-text summary.
+func CleanSummary(summary string) string {
+	lines := strings.Split(summary, "\n")
+	var cleaned []string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#") ||
+			strings.HasPrefix(trimmed, "**") ||
+			strings.HasPrefix(trimmed, "-") ||
+			strings.HasPrefix(trimmed, "Here's a breakdown") ||
+			strings.HasPrefix(trimmed, "The following") ||
+			strings.HasPrefix(trimmed, "* ") {
+			continue
+		}
+		cleaned = append(cleaned, trimmed)
+	}
+	return strings.Join(cleaned, " ")
+}
+
+// TruncateToSentences returns the first n sentences from the input string.
+func TruncateToSentences(text string, n int) string {
+	count := 0
+	end := 0
+	for i, r := range text {
+		if r == '.' || r == '!' || r == '?' {
+			count++
+			end = i + 1
+			if count == n {
+				break
+			}
+		}
+	}
+	if end > 0 {
+		return strings.TrimSpace(text[:end])
+	}
+	return strings.TrimSpace(text)
+}
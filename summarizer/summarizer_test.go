@@ -0,0 +1,343 @@
+package summarizer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeProvider returns a fixed response, or an error for content containing "fail".
+type fakeProvider struct{}
+
+func (fakeProvider) Summarize(ctx context.Context, content string, prompt string) (string, error) {
+	if strings.Contains(content, "fail") {
+		return "", errors.New("boom")
+	}
+	return "# Heading\nFirst sentence. Second sentence. Third sentence.", nil
+}
+
+func (fakeProvider) Name() string {
+	return "fake"
+}
+
+func TestSummarizeFile(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "summarizer_test_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	path := filepath.Join(tmpDir, "svc.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("kind: Service\nmetadata:\n  name: web\n"), 0644))
+
+	result := SummarizeFile(context.Background(), fakeProvider{}, path, Options{})
+	assert.NoError(t, result.Err)
+	assert.Equal(t, path, result.Path)
+	assert.Equal(t, "First sentence. Second sentence.", result.Summary)
+	assert.Equal(t, "Service", result.Kind)
+	assert.Len(t, result.Hash, 64)
+
+	result = SummarizeFile(context.Background(), fakeProvider{}, path, Options{MaxSentences: 1})
+	assert.Equal(t, "First sentence.", result.Summary)
+
+	result = SummarizeFile(context.Background(), fakeProvider{}, filepath.Join(tmpDir, "missing.yaml"), Options{})
+	assert.Error(t, result.Err)
+}
+
+func TestSummarizeDir(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "summarizer_test_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "sub"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, ".hidden"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "a.yaml"), []byte("kind: ConfigMap"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "sub", "b.yml"), []byte("fail: true"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".hidden", "c.yaml"), []byte("x: 1"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "readme.txt"), []byte("not yaml"), 0644))
+
+	results, err := SummarizeDir(context.Background(), fakeProvider{}, tmpDir, Options{Concurrency: 2})
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, filepath.Join(tmpDir, "a.yaml"), results[0].Path)
+	assert.Equal(t, "ConfigMap", results[0].Kind)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, filepath.Join(tmpDir, "sub", "b.yml"), results[1].Path)
+	assert.Error(t, results[1].Err)
+
+	results, err = SummarizeDir(context.Background(), fakeProvider{}, tmpDir, Options{IncludeHidden: true})
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "d.yaml.tpl"), []byte("x: {{ .Values.x }}"), 0644))
+	results, err = SummarizeDir(context.Background(), fakeProvider{}, tmpDir, Options{Extensions: []string{".yaml.tpl"}})
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+}
+
+func TestFindYAMLFilesWalkOrder(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "summarizer_walk_test_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	var want []string
+	for _, rel := range []string{"a/b.yaml", "a/c/d.yml", "a.yaml", "b/x.yaml", "b-c/y.yaml", "z.yaml"} {
+		path := filepath.Join(tmpDir, filepath.FromSlash(rel))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte("a: b"), 0644))
+	}
+	// The order matches filepath.Walk, which the walker replaced
+	assert.NoError(t, filepath.Walk(tmpDir, func(path string, info os.FileInfo, err error) error {
+		if !info.IsDir() {
+			want = append(want, path)
+		}
+		return err
+	}))
+	files, err := FindYAMLFiles(tmpDir, false)
+	assert.NoError(t, err)
+	assert.Equal(t, want, files)
+
+	// A dot-named root is searched even though hidden directories are skipped
+	t.Chdir(tmpDir)
+	files, err = FindYAMLFiles(".", false)
+	assert.NoError(t, err)
+	assert.Len(t, files, 6)
+	assert.Equal(t, filepath.Join("a", "b.yaml"), files[0])
+
+	_, err = FindYAMLFiles(filepath.Join(tmpDir, "missing"), false)
+	assert.Error(t, err)
+}
+
+func TestFindYAMLFilesFunc(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "summarizer_skip_test_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	for _, rel := range []string{"a.yaml", "b.yaml", "vendor/dep.yaml", "vendor/nested/deep.yaml"} {
+		path := filepath.Join(tmpDir, filepath.FromSlash(rel))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte("a: b"), 0644))
+	}
+
+	var mu sync.Mutex
+	var seen []string
+	skip := func(path string, isDir bool) bool {
+		mu.Lock()
+		seen = append(seen, filepath.Base(path))
+		mu.Unlock()
+		return filepath.Base(path) == "vendor" || filepath.Base(path) == "b.yaml"
+	}
+	files, err := FindYAMLFilesFunc(tmpDir, false, skip)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(tmpDir, "a.yaml")}, files)
+	// A skipped directory is never read
+	assert.NotContains(t, seen, "dep.yaml")
+	assert.NotContains(t, seen, "nested")
+}
+
+func TestFindYAMLFilesFollowingLinks(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "summarizer_links_test_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "manifests", "web"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "workspaces"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "manifests", "web", "deploy.yaml"), []byte("a: b"), 0644))
+	assert.NoError(t, os.Symlink(filepath.Join(tmpDir, "manifests", "web"), filepath.Join(tmpDir, "workspaces", "web")))
+	// A link back to an ancestor would loop forever if followed
+	assert.NoError(t, os.Symlink(tmpDir, filepath.Join(tmpDir, "manifests", "web", "root")))
+
+	files, err := FindYAMLFilesFunc(tmpDir, false, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(tmpDir, "manifests", "web", "deploy.yaml")}, files)
+
+	files, err = FindYAMLFilesFollowingLinks(tmpDir, false, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(tmpDir, "manifests", "web", "deploy.yaml"),
+		filepath.Join(tmpDir, "workspaces", "web", "deploy.yaml"),
+	}, files)
+}
+
+func TestGitTreeScanner(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	tmpDir, err := os.MkdirTemp("", "summarizer_git_test_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", tmpDir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+	}
+	git("init", "-q")
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "sub"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, ".github"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "a.yaml"), []byte("kind: ConfigMap"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "sub", "b.yml"), []byte("kind: Service"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".github", "ci.yaml"), []byte("on: push"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "readme.txt"), []byte("not yaml"), 0644))
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+	// Uncommitted changes are not part of the tree
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "a.yaml"), []byte("kind: Secret"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "c.yaml"), []byte("kind: Pod"), 0644))
+
+	results, err := SummarizeScan(context.Background(), fakeProvider{}, GitTreeScanner{Repo: tmpDir}, Options{})
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, "a.yaml", results[0].Path)
+	assert.Equal(t, "ConfigMap", results[0].Kind)
+	assert.Equal(t, "sub/b.yml", results[1].Path)
+	assert.Equal(t, "Service", results[1].Kind)
+
+	files, err := GitTreeScanner{Repo: tmpDir, IncludeHidden: true}.Scan(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, files, 3)
+
+	_, err = GitTreeScanner{Repo: tmpDir, Rev: "missing"}.Scan(context.Background())
+	assert.Error(t, err)
+}
+
+func TestTarScanner(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "summarizer_tar_test_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string]string{
+		"./chart/values.yaml":    "replicas: 1",
+		"chart/templates/a.yaml": "kind: Deployment",
+		"chart/.hidden/b.yaml":   "kind: Secret",
+		"chart/README.md":        "# Chart",
+	} {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+	archive := filepath.Join(tmpDir, "chart.tgz")
+	assert.NoError(t, os.WriteFile(archive, buf.Bytes(), 0644))
+
+	results, err := SummarizeScan(context.Background(), fakeProvider{}, TarScanner{Path: archive}, Options{})
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, "chart/templates/a.yaml", results[0].Path)
+	assert.Equal(t, "Deployment", results[0].Kind)
+	assert.Equal(t, "chart/values.yaml", results[1].Path)
+	assert.NoError(t, results[1].Err)
+
+	_, err = TarScanner{Path: filepath.Join(tmpDir, "missing.tar")}.Scan(context.Background())
+	assert.Error(t, err)
+}
+
+// recordingProvider records the content it is sent.
+type recordingProvider struct {
+	fakeProvider
+	mu   sync.Mutex
+	sent []string
+}
+
+func (p *recordingProvider) Summarize(ctx context.Context, content string, prompt string) (string, error) {
+	p.mu.Lock()
+	p.sent = append(p.sent, content)
+	p.mu.Unlock()
+	return p.fakeProvider.Summarize(ctx, content, prompt)
+}
+
+func TestHooks(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "summarizer_hooks_test_*")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "a.yaml"), []byte("kind: Secret\npassword: hunter2"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "b.yaml"), []byte("kind: ConfigMap"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "c.yaml"), []byte("fail: true"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "d.yaml"), []byte("kind: Pod"), 0644))
+
+	var mu sync.Mutex
+	cache := map[string]string{filepath.Join(tmpDir, "b.yaml"): "Cached summary."}
+	var after, failed []string
+	redact := Hooks{
+		BeforeSummarize: func(ctx context.Context, call *Call) error {
+			call.Content = []byte(strings.ReplaceAll(string(call.Content), "hunter2", "REDACTED"))
+			return nil
+		},
+	}
+	caching := Hooks{
+		BeforeSummarize: func(ctx context.Context, call *Call) error {
+			if strings.HasSuffix(call.Path, "d.yaml") {
+				return errors.New("blocked")
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			call.Summary = cache[call.Path]
+			return nil
+		},
+		AfterSummarize: func(ctx context.Context, call *Call, result *Result) {
+			mu.Lock()
+			defer mu.Unlock()
+			after = append(after, filepath.Base(result.Path))
+			result.Summary = strings.ToUpper(result.Summary)
+		},
+		OnError: func(ctx context.Context, call *Call, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			failed = append(failed, filepath.Base(call.Path)+": "+err.Error())
+		},
+	}
+
+	provider := &recordingProvider{}
+	results, err := SummarizeDir(context.Background(), provider, tmpDir, Options{Concurrency: 2, Hooks: []Hooks{redact, caching}})
+	assert.NoError(t, err)
+	assert.Len(t, results, 4)
+
+	// Redacted content is sent, but the hash is of the file
+	assert.ElementsMatch(t, []string{"kind: Secret\npassword: REDACTED", "fail: true"}, provider.sent)
+	assert.Equal(t, "FIRST SENTENCE. SECOND SENTENCE.", results[0].Summary)
+	assert.Equal(t, "Secret", results[0].Kind)
+	// A cached summary skips the provider
+	assert.Equal(t, "CACHED SUMMARY.", results[1].Summary)
+	assert.ElementsMatch(t, []string{"a.yaml", "b.yaml"}, after)
+	// Provider and hook errors both fail the file and reach OnError
+	assert.Error(t, results[2].Err)
+	assert.EqualError(t, results[3].Err, "blocked")
+	assert.ElementsMatch(t, []string{"c.yaml: fake error for " + filepath.Join(tmpDir, "c.yaml") + ": boom", "d.yaml: blocked"}, failed)
+
+	// OnError gets a nil call for files that cannot be read
+	var unread error
+	result := SummarizeFile(context.Background(), provider, filepath.Join(tmpDir, "missing.yaml"), Options{Hooks: []Hooks{{
+		OnError: func(ctx context.Context, call *Call, err error) {
+			assert.Nil(t, call)
+			unread = err
+		},
+	}}})
+	assert.Error(t, result.Err)
+	assert.Equal(t, result.Err, unread)
+}